@@ -14,18 +14,44 @@ import (
 	beegoContext "github.com/beego/beego/v2/server/web/context"
 	"github.com/beego/beego/v2/server/web/filter/cors"
 	"github.com/beego/i18n"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/docs"
 	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/client"
 	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
 	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/middlewares"
 	readerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_reader"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_writer"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/cache"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/health"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/interceptors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/jwt"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/openapi"
+	redisClient "github.com/radyatamaa/go-cqrs-microservices/pkg/redis"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 
+	articleGraphql "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/article/delivery/graphql"
 	articleHandler "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/article/delivery/http/v1"
+	articleHandlerV2 "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/article/delivery/http/v2"
 	articleRepository "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/article/repository"
 	articleUsecase "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/article/usecase"
+	commentHandler "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/comment/delivery/http/v1"
+	commentRepository "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/comment/repository"
+	commentUsecase "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/comment/usecase"
+	webhookHandler "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/webhook/delivery/http/v1"
+	webhookRepository "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/webhook/repository"
+	webhookUsecase "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/webhook/usecase"
+)
+
+const (
+	readerPolicyName = "gateway_reader_service"
+	writerPolicyName = "gateway_writer_service"
+	kafkaPolicyName  = "gateway_kafka"
 )
 
 // @title Api Gateway V1
@@ -53,16 +79,97 @@ func main() {
 	logPath := beego.AppConfig.DefaultString("logPath", "./logs/api_gateway_service.log")
 	// grpc Reader Service Port
 	grpcReaderServiceHost := beego.AppConfig.DefaultString("grpcReaderServiceHost", "localhost:5003")
+	// grpc Writer Service Port, only called by the synchronous creations
+	grpcWriterServiceHost := beego.AppConfig.DefaultString("grpcWriterServiceHost", "localhost:5004")
 	// brokers
 	brokers := beego.AppConfig.DefaultStrings("brokers", []string{"localhost:9092"})
 	// article create topic
 	createArticleTopic := beego.AppConfig.DefaultString("createArticleTopic", "article_create")
+	// article update topic
+	updateArticleTopic := beego.AppConfig.DefaultString("updateArticleTopic", "article_update")
+	transitionArticleTopic := beego.AppConfig.DefaultString("transitionArticleTopic", "article_transition")
+	tagArticleTopic := beego.AppConfig.DefaultString("tagArticleTopic", "article_tag")
+	// comment command topics
+	createCommentTopic := beego.AppConfig.DefaultString("createCommentTopic", "comment_create")
+	editCommentTopic := beego.AppConfig.DefaultString("editCommentTopic", "comment_edit")
+	deleteCommentTopic := beego.AppConfig.DefaultString("deleteCommentTopic", "comment_delete")
+	moderateCommentTopic := beego.AppConfig.DefaultString("moderateCommentTopic", "comment_moderate")
+	// encoding of the produced kafka messages
+	kafkaContentType := beego.AppConfig.DefaultString("kafkaContentType", kafka.ContentTypeJSON)
+	// circuit breaker, bulkhead and timeout of the reader service, writer service and kafka calls
+	readerResilience := resilienceConfig("reader")
+	writerResilience := resilienceConfig("writer")
+	kafkaResilience := resilienceConfig("kafka")
+	// copies of the search responses served while the reader service is unavailable
+	staleSearchCacheSize := beego.AppConfig.DefaultInt("staleSearchCacheSize", 1000)
+	staleSearchTTL := time.Duration(beego.AppConfig.DefaultInt("staleSearchTTLSeconds", 300)) * time.Second
+	// article imports, the items sent to the writer at a time and how long the job reports are kept in redis
+	batchImportMaxItems := beego.AppConfig.DefaultInt("batchImportMaxItems", 5000)
+	batchImportPublishSize := beego.AppConfig.DefaultInt("batchImportPublishSize", 100)
+	batchJobTTL := time.Duration(beego.AppConfig.DefaultInt("batchJobTTLSeconds", 86400)) * time.Second
+	// article change feed, the event topics and how many changes are replayed to a resuming client
+	articleCreatedTopic := beego.AppConfig.DefaultString("articleCreatedTopic", "article_created")
+	articleUpdatedTopic := beego.AppConfig.DefaultString("articleUpdatedTopic", "article_updated")
+	articleStatusChangedTopic := beego.AppConfig.DefaultString("articleStatusChangedTopic", "article_status_changed")
+	articlePublishedTopic := beego.AppConfig.DefaultString("articlePublishedTopic", "article_published")
+	articleTagsChangedTopic := beego.AppConfig.DefaultString("articleTagsChangedTopic", "article_tags_changed")
+	streamReplaySize := beego.AppConfig.DefaultInt("streamReplaySize", 1000)
+	streamClientBuffer := beego.AppConfig.DefaultInt("streamClientBuffer", 64)
+	// a client resuming from a change no longer held is replayed from kafka, up to this many messages
+	streamResumeMaxMessages := beego.AppConfig.DefaultInt("streamResumeMaxMessages", 10000)
+	// the event streams end before the server write timeout, the clients reconnect with Last-Event-ID
+	streamConfig := articleHandler.StreamConfig{
+		Heartbeat:    time.Duration(beego.AppConfig.DefaultInt("streamHeartbeatSeconds", 15)) * time.Second,
+		MaxDuration:  time.Duration(beego.AppConfig.DefaultInt("streamMaxSeconds", 110)) * time.Second,
+		WriteTimeout: time.Duration(beego.AppConfig.DefaultInt("streamWriteTimeoutSeconds", 10)) * time.Second,
+		// ";" separated origins allowed to open the websocket stream, only the gateway host when empty
+		AllowedOrigins: beego.AppConfig.DefaultStrings("streamAllowedOrigins", nil),
+	}
+	// upper bounds of the graphql operations, 0 is no limit
+	graphqlLimits := articleGraphql.Limits{
+		MaxDepth:      beego.AppConfig.DefaultInt("graphqlMaxDepth", 8),
+		MaxComplexity: beego.AppConfig.DefaultInt("graphqlMaxComplexity", 2000),
+	}
+	// responses checked against the openapi document outside prod, the mismatches are logged
+	openapiValidateResponses := beego.AppConfig.DefaultBool("openapiValidateResponses", true)
+	// tenant of the requests, taken only from the token claim when the secret key is set, else from the X-Tenant-ID header
+	tenantRequired := beego.AppConfig.DefaultBool("tenantRequired", false)
+	tenantJwtSecretKey := beego.AppConfig.DefaultString("tenantJwtSecretKey", "")
+	tenantJwtSignMethod := beego.AppConfig.DefaultString("tenantJwtSignMethod", jwt.HS256)
+	tenantJwtClaim := beego.AppConfig.DefaultString("tenantJwtClaim", "tenant_id")
+	// requests per second and burst granted to each tenant, 0 is no limit; overrides as "tenant=rate:burst,..."
+	tenantRateLimit := beego.AppConfig.DefaultFloat("tenantRateLimit", 0)
+	tenantRateBurst := beego.AppConfig.DefaultInt("tenantRateBurst", 0)
+	tenantQuotas := beego.AppConfig.DefaultString("tenantQuotas", "")
+	// redis shared by the gateway instances, keeps the article import reports
+	redisConfig := &redisClient.Config{
+		Addr:     beego.AppConfig.DefaultString("redisAddr", "localhost:6379"),
+		Password: beego.AppConfig.DefaultString("redisPassword", ""),
+		DB:       beego.AppConfig.DefaultInt("redisDB", 0),
+		PoolSize: beego.AppConfig.DefaultInt("redisPoolSize", 10),
+	}
+	// readiness checks interval
+	checkInterval := time.Duration(beego.AppConfig.DefaultInt("checkIntervalSeconds", 10)) * time.Second
+	// time between reporting not ready on shutdown and draining, lets the load balancers stop routing
+	drainDelay := time.Duration(beego.AppConfig.DefaultInt("drainDelaySeconds", 5)) * time.Second
+	// upper bound of the wait for the in-flight requests
+	shutdownTimeout := time.Duration(beego.AppConfig.DefaultInt("shutdownTimeoutSeconds", 30)) * time.Second
 
 	grpcReaderService := os.Getenv("READER_SERVICE")
 	if grpcReaderService != "" {
 		grpcReaderServiceHost = grpcReaderService
 	}
 
+	grpcWriterService := os.Getenv("WRITER_SERVICE")
+	if grpcWriterService != "" {
+		grpcWriterServiceHost = grpcWriterService
+	}
+
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr != "" {
+		redisConfig.Addr = redisAddr
+	}
+
 	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
 	if kafkaBrokers != "" {
 		brokers = []string{kafkaBrokers}
@@ -94,53 +201,165 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 
-	readerServiceConn, err := client.NewReaderServiceConn(ctx, grpcReaderServiceHost, im)
+	readerPolicy := resilience.NewPolicy(readerPolicyName, readerResilience)
+	readerServiceConn, err := client.NewReaderServiceConn(ctx, grpcReaderServiceHost, im, readerPolicy)
 	if err != nil {
 		panic(err)
 	}
 	defer readerServiceConn.Close() // nolint: errcheck
 	rsClient := readerService.NewReaderServiceClient(readerServiceConn)
+	crsClient := readerService.NewCommentReaderServiceClient(readerServiceConn)
+	writerServiceConn, err := client.NewWriterServiceConn(ctx, grpcWriterServiceHost, im, resilience.NewPolicy(writerPolicyName, writerResilience))
+	if err != nil {
+		panic(err)
+	}
+	defer writerServiceConn.Close() // nolint: errcheck
+	wsClient := writerService.NewWriterServiceClient(writerServiceConn)
+	whClient := writerService.NewWebhookServiceClient(writerServiceConn)
+	staleSearchCache, err := cache.NewLocal(staleSearchCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	redisConn := redisClient.NewUniversalRedisClient(redisConfig)
+	defer redisConn.Close() // nolint: errcheck
 
 	// init kafka
-	kafkaProducer := kafka.NewProducer(zapLog, brokers)
+	kafkaProducer := resilience.NewProducer(kafka.NewProducer(zapLog, brokers), resilience.NewPolicy(kafkaPolicyName, kafkaResilience))
 	defer kafkaProducer.Close() // nolint: errcheck
+	kafkaCodec, err := kafka.CodecFor(kafkaContentType)
+	if err != nil {
+		panic(err)
+	}
+	// every gateway instance tails the events on its own, the group is never committed
+	articleChangeFeed := articleRepository.NewArticleChangeFeed(
+		kafka.NewTailReader(brokers, []string{articleCreatedTopic, articleUpdatedTopic, articleStatusChangedTopic, articlePublishedTopic, articleTagsChangedTopic}, "api_gateway_stream_"+uuid.New().String(), nil),
+		map[string]string{
+			articleCreatedTopic:       events.ArticleCreatedType,
+			articleUpdatedTopic:       events.ArticleUpdatedType,
+			articleStatusChangedTopic: events.ArticleStatusChangedType,
+			articlePublishedTopic:     events.ArticlePublishedType,
+			articleTagsChangedTopic:   events.ArticleTagsChangedType,
+		},
+		streamReplaySize, streamClientBuffer, streamResumeMaxMessages, zapLog)
 	confKafka := domain.ConfKafkaTopics{
-		CreateArticle: createArticleTopic,
+		CreateArticle:     createArticleTopic,
+		UpdateArticle:     updateArticleTopic,
+		TransitionArticle: transitionArticleTopic,
+		TagArticle:        tagArticleTopic,
+		CreateComment:     createCommentTopic,
+		EditComment:       editCommentTopic,
+		DeleteComment:     deleteCommentTopic,
+		ModerateComment:   moderateCommentTopic,
+	}
+
+	// tenant resolution and quotas
+	tenantOverrides, err := tenant.ParseQuotas(tenantQuotas)
+	if err != nil {
+		panic(err)
+	}
+	tenantConfig := middlewares.TenantConfig{
+		Claim:     tenantJwtClaim,
+		Required:  tenantRequired,
+		Limiter:   tenant.NewLimiter(tenant.Quota{Rate: tenantRateLimit, Burst: tenantRateBurst}, tenantOverrides),
+		ZapLogger: zapLog,
+	}
+	if tenantJwtSecretKey != "" {
+		tenantConfig.JWT, err = jwt.NewJwt(&jwt.Options{
+			Locations:  "header:Authorization",
+			SignMethod: tenantJwtSignMethod,
+			SecretKey:  tenantJwtSecretKey,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	openapiDocument, err := openapi.NewDocument(docs.OpenAPI)
+	if err != nil {
+		panic(err)
+	}
+	openapiConfig := middlewares.OpenAPIConfig{
+		Document:  openapiDocument,
+		ZapLogger: zapLog,
 	}
 
 	if beego.BConfig.RunMode != "prod" {
 		// static files swagger
 		beego.BConfig.WebConfig.DirectoryIndex = true
 		beego.BConfig.WebConfig.StaticDir["/swagger"] = "swagger"
+		beego.Get("/openapi.json", func(ctx *beegoContext.Context) {
+			ctx.Output.Header("Content-Type", "application/json")
+			ctx.Output.Body(docs.OpenAPI) // nolint: errcheck
+		})
 	}
 
 	// middleware init
 	beego.InsertFilter("*", beego.BeforeRouter, cors.Allow(&cors.Options{
-		AllowMethods:    []string{http.MethodGet, http.MethodPost},
+		AllowMethods:    []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
 		AllowAllOrigins: true,
 	}))
 
 	beego.InsertFilterChain("*", middlewares.RequestID())
 	beego.InsertFilterChain("/api/*", middlewares.BodyDumpWithConfig(middlewares.NewAccessLogMiddleware(zapLog, appVersion).Logger()))
+	beego.InsertFilterChain("/api/*", middlewares.Tenant(tenantConfig))
+	if beego.BConfig.RunMode != "prod" && openapiValidateResponses {
+		beego.InsertFilterChain("/api/*", middlewares.OpenAPIResponseValidator(openapiConfig))
+	}
+	beego.InsertFilterChain("/api/*", middlewares.OpenAPIRequestValidator(openapiConfig))
 
 	// health check
 	beego.Get("/health", func(ctx *beegoContext.Context) {
 		ctx.Output.SetStatus(http.StatusOK)
-		ctx.Output.JSON(beego.M{"status": "alive"}, beego.BConfig.RunMode != "prod", false)
+		ctx.Output.JSON(beego.M{"status": "alive", "circuitBreakers": resilience.States()}, beego.BConfig.RunMode != "prod", false)
+	})
+
+	// kubernetes probes, the gateway is ready only when the reader service is serving
+	gatewayHealth := health.New(checkInterval)
+	gatewayHealth.AddLivenessCheck("api_gateway_service", func() error {
+		return nil
 	})
+	gatewayHealth.AddReadinessCheck("reader_service", health.GrpcCheck(readerServiceConn, readerService.ReaderService_ServiceDesc.ServiceName))
+	gatewayHealth.AddReadinessCheck("redis", func(ctx context.Context) error {
+		return redisConn.Ping(ctx).Err()
+	})
+	gatewayHealth.AddReadinessCheck("circuit_breakers", func(ctx context.Context) error {
+		return resilience.HealthCheck()()
+	})
+	go gatewayHealth.Run(ctx)
+	go articleChangeFeed.Run(ctx)
+	beego.Handler("/live", gatewayHealth.Handler())
+	beego.Handler("/ready", gatewayHealth.Handler())
+
+	// prometheus metrics
+	beego.Handler("/metrics", promhttp.Handler())
 
 	// default error handler
 	beego.ErrorController(&response.ErrorController{})
 
 	// init repository
-	articleQueriesRepository := articleRepository.NewQueriesArticleRepository(rsClient, zapLog)
-	articleCommandRepository := articleRepository.NewCommandArticleRepository(kafkaProducer, confKafka, zapLog)
+	articleQueriesRepository := articleRepository.NewQueriesArticleRepository(rsClient, cache.NewInstrumented("gateway_stale_search", "local", staleSearchCache), staleSearchTTL, zapLog)
+	articleCommandRepository := articleRepository.NewCommandArticleRepository(kafkaProducer, kafkaCodec, confKafka, zapLog)
+	articleWriterRepository := articleRepository.NewWriterArticleRepository(wsClient, zapLog)
+	articleBatchJobRepository := articleRepository.NewBatchJobArticleRepository(cache.NewInstrumented("gateway_article_batch", "redis", cache.NewRedis(redisConn)), batchJobTTL, zapLog)
+	commentQueriesRepository := commentRepository.NewQueriesCommentRepository(crsClient, zapLog)
+	commentCommandRepository := commentRepository.NewCommandCommentRepository(kafkaProducer, kafkaCodec, confKafka, zapLog)
+	webhookWriterRepository := webhookRepository.NewWriterWebhookRepository(whClient, zapLog)
 
 	// init usecase
-	articleUcase := articleUsecase.NewArticleUseCase(timeoutContext, zapLog, articleCommandRepository, articleQueriesRepository)
+	articleUcase := articleUsecase.NewArticleUseCase(timeoutContext, zapLog, articleCommandRepository, articleQueriesRepository, articleWriterRepository, articleBatchJobRepository, articleChangeFeed, batchImportPublishSize)
+	commentUcase := commentUsecase.NewCommentUseCase(timeoutContext, zapLog, commentCommandRepository, commentQueriesRepository)
+	webhookUcase := webhookUsecase.NewWebhookUseCase(timeoutContext, zapLog, webhookWriterRepository)
 
 	// init handler
-	articleHandler.NewArticleHandler(articleUcase, zapLog)
+	articleHandler.NewArticleHandler(articleUcase, batchImportMaxItems, streamConfig, zapLog)
+	commentHandler.NewCommentHandler(commentUcase, zapLog)
+	webhookHandler.NewWebhookHandler(webhookUcase, zapLog)
+	if err := articleGraphql.NewGraphqlHandler(timeoutContext, articleQueriesRepository, articleCommandRepository, graphqlLimits, zapLog); err != nil {
+		panic(err)
+	}
+	if err := articleHandlerV2.NewReaderProxyHandler(ctx, timeoutContext, rsClient, crsClient, zapLog); err != nil {
+		panic(err)
+	}
 
 	// Initializing the server in a goroutine so that
 	// it won't block the graceful shutdown handling below
@@ -160,17 +379,23 @@ func main() {
 
 	pid := syscall.Getpid()
 
+	// stop receiving new requests before draining the in-flight ones
+	gatewayHealth.Drain()
+	time.Sleep(drainDelay)
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+
 	switch sig {
 	case syscall.SIGINT:
 		log.Println(pid, "Received SIGINT.")
 		log.Println(pid, "Waiting for connections to finish...")
-		if err := beego.BeeApp.Server.Shutdown(ctx); err != nil {
+		if err := beego.BeeApp.Server.Shutdown(shutdownCtx); err != nil {
 			log.Fatal("failed shutdown server:", err)
 		}
 	case syscall.SIGTERM:
 		log.Println(pid, "Received SIGTERM.")
 		log.Println(pid, "Waiting for connections to finish...")
-		if err := beego.BeeApp.Server.Shutdown(ctx); err != nil {
+		if err := beego.BeeApp.Server.Shutdown(shutdownCtx); err != nil {
 			log.Fatal("failed shutdown server:", err)
 		}
 	default:
@@ -184,3 +409,17 @@ func main() {
 	}
 	log.Println("server exiting")
 }
+
+// resilienceConfig read the circuit breaker, bulkhead and timeout settings of a dependency prefixed by name
+func resilienceConfig(name string) resilience.Config {
+	return resilience.Config{
+		Timeout:       time.Duration(beego.AppConfig.DefaultInt(name+"TimeoutMillis", 5000)) * time.Millisecond,
+		MaxConcurrent: beego.AppConfig.DefaultInt(name+"MaxConcurrent", 100),
+		MaxWait:       time.Duration(beego.AppConfig.DefaultInt(name+"MaxWaitMillis", 100)) * time.Millisecond,
+		Breaker: resilience.BreakerConfig{
+			FailureThreshold:    beego.AppConfig.DefaultInt(name+"BreakerFailureThreshold", 5),
+			OpenTimeout:         time.Duration(beego.AppConfig.DefaultInt(name+"BreakerOpenSeconds", 30)) * time.Second,
+			HalfOpenMaxRequests: beego.AppConfig.DefaultInt(name+"BreakerHalfOpenMaxRequests", 1),
+		},
+	}
+}
@@ -0,0 +1,8 @@
+package docs
+
+import _ "embed"
+
+// OpenAPI the OpenAPI 3.1 document of the gateway, the requests of its operations are validated against it
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -0,0 +1,148 @@
+package docs_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/docs"
+	articleGraphql "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/article/delivery/graphql"
+	articleHandler "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/article/delivery/http/v1"
+	articleHandlerV2 "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/article/delivery/http/v2"
+	commentHandler "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/comment/delivery/http/v1"
+	webhookHandler "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/webhook/delivery/http/v1"
+	readerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_reader"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/openapi"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestOpenAPIDescribesRoutes fails when a route registered by the handlers has no operation in the document,
+// the /api/v2 reverse proxy is expanded into the google.api.http rules of article_reader.proto
+func TestOpenAPIDescribesRoutes(t *testing.T) {
+	articleHandler.NewArticleHandler(nil, 0, articleHandler.StreamConfig{}, nil)
+	commentHandler.NewCommentHandler(nil, nil)
+	webhookHandler.NewWebhookHandler(nil, nil)
+	if err := articleGraphql.NewGraphqlHandler(time.Second, nil, nil, articleGraphql.Limits{}, nil); err != nil {
+		t.Fatalf("NewGraphqlHandler: %v", err)
+	}
+	if err := articleHandlerV2.NewReaderProxyHandler(context.Background(), time.Second, nil, nil, nil); err != nil {
+		t.Fatalf("NewReaderProxyHandler: %v", err)
+	}
+
+	operations := documentOperations(t)
+	rules := readerRules()
+	routes := 0
+	for _, info := range beego.BeeApp.Handlers.GetAllControllerInfo() {
+		pattern := strings.ReplaceAll(info.GetPattern(), "\\:", ":")
+		if !strings.HasPrefix(pattern, "/api/") {
+			continue
+		}
+		for method := range info.GetMethod() {
+			paths := []string{pattern}
+			if strings.HasSuffix(pattern, "/*") {
+				paths = rules[method+" "+strings.TrimSuffix(pattern, "*")]
+				if len(paths) == 0 {
+					t.Errorf("%s %s proxies no google.api.http rule", method, pattern)
+				}
+			}
+			for _, path := range paths {
+				routes++
+				if !operations[method+" "+template(path)] {
+					t.Errorf("%s %s is registered but has no operation in openapi.json", method, path)
+				}
+			}
+		}
+	}
+	if routes == 0 {
+		t.Fatal("no route is registered")
+	}
+}
+
+// documentOperations the method and path template of every operation, the paths include the base path of the first server
+func documentOperations(t *testing.T) map[string]bool {
+	var document struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if _, err := openapi.NewDocument(docs.OpenAPI); err != nil {
+		t.Fatalf("NewDocument: %v", err)
+	}
+	if err := json.Unmarshal(docs.OpenAPI, &document); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	basePath := ""
+	if len(document.Servers) > 0 {
+		basePath = strings.TrimSuffix(document.Servers[0].URL, "/")
+	}
+
+	operations := map[string]bool{}
+	for path, item := range document.Paths {
+		for method := range item {
+			if method != "parameters" {
+				operations[strings.ToUpper(method)+" "+template(basePath+path)] = true
+			}
+		}
+	}
+	return operations
+}
+
+// readerRules the paths of the google.api.http rules of the reader services by method and path prefix
+func readerRules() map[string][]string {
+	rules := map[string][]string{}
+	services := readerService.File_article_reader_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		methods := services.Get(i).Methods()
+		for j := 0; j < methods.Len(); j++ {
+			rule, ok := proto.GetExtension(methods.Get(j).Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+			for _, binding := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				method, path := httpRule(binding)
+				if path == "" {
+					continue
+				}
+				for k := 1; k < len(path); k++ {
+					if path[k] == '/' {
+						rules[method+" "+path[:k+1]] = append(rules[method+" "+path[:k+1]], path)
+					}
+				}
+			}
+		}
+	}
+	return rules
+}
+
+func httpRule(rule *annotations.HttpRule) (string, string) {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	}
+	return "", ""
+}
+
+// template the path with its parameters unnamed, /articles/:id and /articles/{ID} are both /articles/{}
+func template(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "{") {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
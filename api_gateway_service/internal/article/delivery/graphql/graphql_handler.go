@@ -0,0 +1,230 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/beego/i18n"
+	validatorGo "github.com/go-playground/validator/v10"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/validator"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+// loaderMaxConcurrent articles fetched at once by the loader of a request
+const loaderMaxConcurrent = 10
+
+type GraphqlHandler struct {
+	ZapLogger zaplogger.Logger
+	internal.BaseController
+	Schema            graphql.Schema
+	Limits            Limits
+	ContextTimeout    time.Duration
+	QueriesRepository domain.QueriesArticleRepository
+}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphqlResponse errors carry the pkg/response code and the localized message of the matching REST error
+type graphqlResponse struct {
+	Data       interface{}                `json:"data,omitempty"`
+	Errors     []gqlerrors.FormattedError `json:"errors,omitempty"`
+	Extensions map[string]interface{}     `json:"extensions,omitempty"`
+}
+
+func NewGraphqlHandler(timeout time.Duration,
+	queriesRepository domain.QueriesArticleRepository,
+	commandRepository domain.CommandArticleRepository,
+	limits Limits,
+	zapLogger zaplogger.Logger) error {
+	schema, err := newSchema(resolver{
+		zapLogger:         zapLogger,
+		contextTimeout:    timeout,
+		queriesRepository: queriesRepository,
+		commandRepository: commandRepository,
+	})
+	if err != nil {
+		return err
+	}
+
+	pHandler := &GraphqlHandler{
+		ZapLogger:         zapLogger,
+		Schema:            schema,
+		Limits:            limits,
+		ContextTimeout:    timeout,
+		QueriesRepository: queriesRepository,
+	}
+	beego.Router("/api/graphql", pHandler, "post:Query")
+	return nil
+}
+
+func (h *GraphqlHandler) Prepare() {
+	// check user access when needed
+	h.SetLangVersion()
+}
+
+// Query
+// @Title GraphQL
+// @Tags GraphQL
+// @Summary Query And Change The Articles With GraphQL, the errors carry the code of the matching REST error in their extensions
+// @Accept json
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param body body swagger.GraphqlRequest true "query, operationName and variables"
+// @Success 200 {object} swagger.GraphqlResponse
+// @Failure 400 {object} swagger.GraphqlResponse
+// @Router /graphql [post]
+func (h *GraphqlHandler) Query() {
+	var request graphqlRequest
+	if err := json.Unmarshal(h.Ctx.Input.RequestBody, &request); err != nil || request.Query == "" {
+		if err == nil {
+			err = errors.New("query is missing")
+		}
+		h.responseRequestError(err)
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(request.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		h.responseRequestError(err)
+		return
+	}
+	validation := graphql.ValidateDocument(&h.Schema, doc, nil)
+	if !validation.IsValid {
+		h.responseRequestErrors(validation.Errors)
+		return
+	}
+	if err := checkLimits(doc, request.OperationName, request.Variables, h.Limits); err != nil {
+		h.responseRequestError(err)
+		return
+	}
+
+	ctx := withArticleLoader(h.Ctx.Request.Context(), newArticleLoader(h.QueriesRepository, h.ContextTimeout, loaderMaxConcurrent))
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.Schema,
+		AST:           doc,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       ctx,
+	})
+
+	errs := make([]gqlerrors.FormattedError, 0, len(result.Errors))
+	for _, formatted := range result.Errors {
+		errs = append(errs, h.mapError(formatted))
+	}
+	h.response(http.StatusOK, result.Data, errs)
+}
+
+// responseRequestError the document is not executed, its error is a validation error
+func (h *GraphqlHandler) responseRequestError(err error) {
+	h.responseRequestErrors(gqlerrors.FormatErrors(err))
+}
+
+func (h *GraphqlHandler) responseRequestErrors(formatted []gqlerrors.FormattedError) {
+	errs := make([]gqlerrors.FormattedError, 0, len(formatted))
+	for _, err := range formatted {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		err.Extensions = map[string]interface{}{
+			"code":   response.ApiValidationCodeError,
+			"reason": err.Message,
+		}
+		err.Message = response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang)
+		errs = append(errs, err)
+	}
+	h.response(http.StatusBadRequest, nil, errs)
+}
+
+// mapError the same code as the REST endpoints answer for the error of the resolver
+func (h *GraphqlHandler) mapError(formatted gqlerrors.FormattedError) gqlerrors.FormattedError {
+	err := originalError(formatted)
+	h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+
+	code := response.ServerErrorCode
+	extensions := map[string]interface{}{}
+	var fields validatorGo.ValidationErrors
+	switch {
+	case errors.As(err, &fields):
+		code = response.ApiValidationCodeError
+		extensions["errors"] = h.fieldErrors(fields)
+	case errors.Is(err, errInvalidArgument), errors.Is(err, events.ErrSchemaValidation):
+		code = response.ApiValidationCodeError
+		extensions["reason"] = err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		code = response.RequestTimeoutCodeError
+	case errors.Is(err, domain.ErrArticleNotFound):
+		code = response.DataNotFoundCodeError
+	case errors.Is(err, domain.ErrServiceUnavailable):
+		code = response.ServiceCommunicationErrorCode
+	}
+	extensions["code"] = code
+
+	formatted.Message = response.ErrorCodeText(code, h.Locale.Lang)
+	formatted.Extensions = extensions
+	return formatted
+}
+
+// fieldErrors the invalid fields of an input with the message in the language of the request
+func (h *GraphqlHandler) fieldErrors(fields validatorGo.ValidationErrors) []response.Errors {
+	lang := "id"
+	if i18n.IsExist(h.Locale.Lang) {
+		lang = h.Locale.Lang
+	}
+	list := make([]response.Errors, 0, len(fields))
+	trans, found := validator.Validate.GetTranslator(lang)
+	for _, field := range fields {
+		description := field.Error()
+		if found {
+			description = field.Translate(trans)
+		}
+		list = append(list, response.Errors{Field: field.Field(), Description: description})
+	}
+	return list
+}
+
+func (h *GraphqlHandler) response(status int, data interface{}, errs []gqlerrors.FormattedError) {
+	res := graphqlResponse{Data: data, Errors: errs}
+	if requestID := h.Ctx.ResponseWriter.ResponseWriter.Header().Get("X-REQUEST-ID"); requestID != "" {
+		res.Extensions = map[string]interface{}{"request_id": requestID}
+	}
+	h.Ctx.Output.SetStatus(status)
+	h.Ctx.Output.JSON(res, beego.BConfig.RunMode != "prod", false) // nolint: errcheck
+}
+
+// originalError the error returned by the resolver, the executor wraps it once more when it comes from a thunk
+func originalError(err error) error {
+	for {
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			if e.OriginalError() == nil {
+				return e
+			}
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			if e.OriginalError == nil {
+				return e
+			}
+			err = e.OriginalError
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+)
+
+// Limits upper bounds of the operations executed, 0 is no limit
+type Limits struct {
+	// MaxDepth nesting of the fields, `{ article(id: 1) { title } }` is 2 deep
+	MaxDepth int
+	// MaxComplexity every field costs 1, the fields of a list are counted once per item asked by its size or ids argument
+	MaxComplexity int
+}
+
+// queryCost depth and complexity of the operation, the introspection fields are free
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// checkLimits doc is already validated so its fragments are known and do not cycle
+func checkLimits(doc *ast.Document, operationName string, variables map[string]interface{}, limits Limits) error {
+	cost := queryCost{fragments: make(map[string]*ast.FragmentDefinition), variables: variables}
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			cost.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	depth, complexity := cost.selectionSet(operation.SelectionSet)
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the limit of %d", depth, limits.MaxDepth)
+	}
+	if limits.MaxComplexity > 0 && complexity > limits.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, limits.MaxComplexity)
+	}
+	return nil
+}
+
+func (q queryCost) selectionSet(set *ast.SelectionSet) (depth int, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		var d, c int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			d, c = q.selectionSet(selection.SelectionSet)
+			d++
+			c = 1 + c*q.listSize(selection)
+		case *ast.InlineFragment:
+			d, c = q.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if fragment, ok := q.fragments[selection.Name.Value]; ok {
+				d, c = q.selectionSet(fragment.SelectionSet)
+			}
+		}
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+	return depth, complexity
+}
+
+// listSize items the field asks for, 1 when it is not a list
+func (q queryCost) listSize(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		switch argument.Name.Value {
+		case "size":
+			size := domain.DEFAULT_PAGESIZE
+			if n, ok := q.intValue(argument.Value); ok && n > 0 {
+				size = n
+			}
+			if size > domain.MAX_PAGESIZE {
+				size = domain.MAX_PAGESIZE
+			}
+			return size
+		case "ids":
+			if n := q.listLength(argument.Value); n > 0 {
+				return n
+			}
+		}
+	}
+	return 1
+}
+
+func (q queryCost) intValue(value ast.Value) (int, bool) {
+	switch value := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(value.Value)
+		return n, err == nil
+	case *ast.Variable:
+		// json numbers are decoded as float64
+		if n, ok := q.variables[value.Name.Value].(float64); ok {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+func (q queryCost) listLength(value ast.Value) int {
+	switch value := value.(type) {
+	case *ast.ListValue:
+		return len(value.Values)
+	case *ast.Variable:
+		if list, ok := q.variables[value.Name.Value].([]interface{}); ok {
+			return len(list)
+		}
+	}
+	return 0
+}
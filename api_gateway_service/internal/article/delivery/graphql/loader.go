@@ -0,0 +1,147 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+)
+
+type loaderContextKey struct{}
+
+// articleResult outcome of the lookup of an id, kept for the rest of the request
+type articleResult struct {
+	article *domain.ArticleResponse
+	err     error
+}
+
+// articleLoader batches the by-id lookups of a request: the resolvers register their ids and return thunks,
+// the first thunk called fetches every id registered so far so the ids asked at the same level of the query
+// are fetched together and each id at most once
+type articleLoader struct {
+	repository    domain.QueriesArticleRepository
+	timeout       time.Duration
+	maxConcurrent int
+
+	mu      sync.Mutex
+	pending map[int]struct{}
+	results map[int]articleResult
+}
+
+func newArticleLoader(repository domain.QueriesArticleRepository, timeout time.Duration, maxConcurrent int) *articleLoader {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &articleLoader{
+		repository:    repository,
+		timeout:       timeout,
+		maxConcurrent: maxConcurrent,
+		pending:       make(map[int]struct{}),
+		results:       make(map[int]articleResult),
+	}
+}
+
+func withArticleLoader(ctx context.Context, loader *articleLoader) context.Context {
+	return context.WithValue(ctx, loaderContextKey{}, loader)
+}
+
+func articleLoaderFrom(ctx context.Context) *articleLoader {
+	loader, _ := ctx.Value(loaderContextKey{}).(*articleLoader)
+	return loader
+}
+
+// Load thunk of the article, domain.ErrArticleNotFound when it does not exist
+func (l *articleLoader) Load(ctx context.Context, id int) func() (interface{}, error) {
+	l.register(id)
+	return func() (interface{}, error) {
+		result := l.result(ctx, id)
+		if result.err != nil {
+			return nil, result.err
+		}
+		return result.article, nil
+	}
+}
+
+// LoadMany thunk of the articles in the order of ids, the ones which do not exist are null
+func (l *articleLoader) LoadMany(ctx context.Context, ids []int) func() (interface{}, error) {
+	for _, id := range ids {
+		l.register(id)
+	}
+	return func() (interface{}, error) {
+		articles := make([]*domain.ArticleResponse, 0, len(ids))
+		for _, id := range ids {
+			result := l.result(ctx, id)
+			if result.err != nil && !errors.Is(result.err, domain.ErrArticleNotFound) {
+				return nil, result.err
+			}
+			articles = append(articles, result.article)
+		}
+		return articles, nil
+	}
+}
+
+func (l *articleLoader) register(id int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.results[id]; !ok {
+		l.pending[id] = struct{}{}
+	}
+}
+
+func (l *articleLoader) result(ctx context.Context, id int) articleResult {
+	l.mu.Lock()
+	result, ok := l.results[id]
+	l.mu.Unlock()
+	if ok {
+		return result
+	}
+
+	l.dispatch(ctx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.results[id]
+}
+
+// dispatch fetch the pending ids, maxConcurrent at a time since the reader has no multi-get
+func (l *articleLoader) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	ids := make([]int, 0, len(l.pending))
+	for id := range l.pending {
+		ids = append(ids, id)
+	}
+	l.pending = make(map[int]struct{})
+	l.mu.Unlock()
+
+	var (
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, l.maxConcurrent)
+	)
+	for _, id := range ids {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(id int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			c, cancel := context.WithTimeout(ctx, l.timeout)
+			defer cancel()
+
+			var result articleResult
+			article, err := l.repository.GetById(c, id)
+			if err != nil {
+				result.err = err
+			} else {
+				result.article = domain.ToArticleResponse(article)
+			}
+
+			l.mu.Lock()
+			l.results[id] = result
+			l.mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,176 @@
+package graphql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/validator"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+// errInvalidArgument an argument is well typed but its value is refused, e.g. a negative id
+var errInvalidArgument = errors.New("invalid argument")
+
+// commandResult the command is published, the article is changed once the writer consumed it
+type commandResult struct {
+	Accepted bool `json:"accepted"`
+}
+
+type resolver struct {
+	zapLogger         zaplogger.Logger
+	contextTimeout    time.Duration
+	queriesRepository domain.QueriesArticleRepository
+	commandRepository domain.CommandArticleRepository
+}
+
+func (r resolver) article(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	return articleLoaderFrom(p.Context).Load(p.Context, id), nil
+}
+
+func (r resolver) articles(p graphql.ResolveParams) (interface{}, error) {
+	list, _ := p.Args["ids"].([]interface{})
+	if len(list) > domain.MAX_PAGESIZE {
+		return nil, errors.Wrapf(errInvalidArgument, "ids holds more than %d ids", domain.MAX_PAGESIZE)
+	}
+	ids := make([]int, 0, len(list))
+	for _, value := range list {
+		id, err := idArg(value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return articleLoaderFrom(p.Context).LoadMany(p.Context, ids), nil
+}
+
+// searchArticles the last known page is served with stale true while the reader is unavailable
+func (r resolver) searchArticles(p graphql.ResolveParams) (interface{}, error) {
+	size, _ := p.Args["size"].(int)
+	page, _ := p.Args["page"].(int)
+	size, page, err := domain.PaginationQueryParamValidation(strconv.Itoa(size), strconv.Itoa(page))
+	if err != nil {
+		return nil, errors.Wrap(errInvalidArgument, err.Error())
+	}
+	search, _ := p.Args["search"].(string)
+	author, _ := p.Args["author"].(string)
+	category, _ := p.Args["category"].(string)
+	filter := domain.SearchArticleFilter{
+		Search:   search,
+		Author:   author,
+		Statuses: stringsArg(p.Args["status"]),
+		Tags:     domain.TagsQueryParam(strings.Join(stringsArg(p.Args["tags"]), ",")),
+		Category: events.NormalizeCategory(category),
+	}
+
+	c, cancel := context.WithTimeout(p.Context, r.contextTimeout)
+	defer cancel()
+
+	stale := false
+	var cachedAt time.Time
+	list, err := r.queriesRepository.Search(c, page, size, filter)
+	if err != nil {
+		var staleErr error
+		list, cachedAt, staleErr = r.queriesRepository.SearchStale(p.Context, page, size, filter)
+		if staleErr != nil {
+			return nil, err
+		}
+		r.zapLogger.Warnf("searchArticles serving stale response cached at %s: %v", cachedAt, err)
+		stale = true
+	}
+
+	result := domain.ArticlePaginationResponse{}.ToArticlePaginationResponse(list)
+	result.Stale = stale
+	result.CachedAt = cachedAt
+	result.Articles = make([]*domain.ArticleResponse, 0, len(list.Articles))
+	for i := range list.Articles {
+		result.Articles = append(result.Articles, domain.ToArticleResponse(list.Articles[i]))
+	}
+	return result, nil
+}
+
+func (r resolver) createArticle(p graphql.ResolveParams) (interface{}, error) {
+	input, _ := p.Args["input"].(map[string]interface{})
+	author, _ := input["author"].(string)
+	title, _ := input["title"].(string)
+	body, _ := input["body"].(string)
+	category, _ := input["category"].(string)
+	request := domain.CreateArticleRequest{
+		Author:   author,
+		Title:    title,
+		Body:     body,
+		Tags:     stringsArg(input["tags"]),
+		Category: category,
+	}
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(p.Context, r.contextTimeout)
+	defer cancel()
+
+	if err := r.commandRepository.Create(c, request.ToCreateArticleCommand()); err != nil {
+		return nil, err
+	}
+	return commandResult{Accepted: true}, nil
+}
+
+func (r resolver) updateArticle(p graphql.ResolveParams) (interface{}, error) {
+	id, err := idArg(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	input, _ := p.Args["input"].(map[string]interface{})
+	changedBy, _ := input["changedBy"].(string)
+	title, _ := input["title"].(string)
+	body, _ := input["body"].(string)
+	category, _ := input["category"].(string)
+	request := domain.UpdateArticleRequest{
+		ChangedBy: changedBy,
+		Title:     title,
+		Body:      body,
+		Category:  category,
+	}
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(p.Context, r.contextTimeout)
+	defer cancel()
+
+	// the mutation carries no expected version, the update applies to any version of the article
+	if err := r.commandRepository.Update(c, request.ToUpdateArticleCommand(id, 0)); err != nil {
+		return nil, err
+	}
+	return commandResult{Accepted: true}, nil
+}
+
+// idArg the ID scalar is parsed as a string
+func idArg(value interface{}) (int, error) {
+	str, _ := value.(string)
+	id, err := strconv.Atoi(str)
+	if err != nil || id < 1 {
+		return 0, errors.Wrapf(errInvalidArgument, "id %q is not a positive integer", str)
+	}
+	return id, nil
+}
+
+func stringsArg(value interface{}) []string {
+	list, _ := value.([]interface{})
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
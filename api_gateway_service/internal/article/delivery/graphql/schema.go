@@ -0,0 +1,140 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+)
+
+// newSchema the fields of Article and ArticlePage are resolved from domain.ArticleResponse and
+// domain.ArticlePaginationResponse by the default resolver
+func newSchema(r resolver) (graphql.Schema, error) {
+	articleStatus := graphql.NewEnum(graphql.EnumConfig{
+		Name: "ArticleStatus",
+		Values: graphql.EnumValueConfigMap{
+			"DRAFT":     &graphql.EnumValueConfig{Value: events.ArticleStatusDraft},
+			"IN_REVIEW": &graphql.EnumValueConfig{Value: events.ArticleStatusInReview},
+			"PUBLISHED": &graphql.EnumValueConfig{Value: events.ArticleStatusPublished},
+			"ARCHIVED":  &graphql.EnumValueConfig{Value: events.ArticleStatusArchived},
+		},
+	})
+
+	article := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Article",
+		Fields: graphql.Fields{
+			"id":           &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"status":       &graphql.Field{Type: graphql.NewNonNull(articleStatus)},
+			"publishAt":    &graphql.Field{Type: graphql.DateTime, Description: "scheduled publication"},
+			"publishedAt":  &graphql.Field{Type: graphql.DateTime},
+			"tags":         &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+			"category":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"commentCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"author":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"title":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"body":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"createdAt":    &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"updatedAt":    &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		},
+	})
+
+	articlePage := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ArticlePage",
+		Fields: graphql.Fields{
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"totalPages": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"page":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"size":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"hasMore":    &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"stale":      &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Description: "the reader is unavailable, the page is a copy cached at cachedAt"},
+			"cachedAt":   &graphql.Field{Type: graphql.DateTime},
+			"articles":   &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(article)))},
+		},
+	})
+
+	commandPayload := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "CommandResult",
+		Description: "the command is published, the article is changed once the writer consumed it",
+		Fields: graphql.Fields{
+			"accepted": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+
+	createArticleInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateArticleInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"author":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"title":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"body":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"tags":     &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"category": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "slash separated path, e.g. tech/golang"},
+		},
+	})
+
+	updateArticleInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "UpdateArticleInput",
+		Description: "only the given fields are changed",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"changedBy": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"title":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"body":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"category":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"article": &graphql.Field{
+				Type: article,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.article,
+			},
+			"articles": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(article)),
+				Description: "articles in the order of ids, null for the ones which do not exist",
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+				},
+				Resolve: r.articles,
+			},
+			"searchArticles": &graphql.Field{
+				Type:        graphql.NewNonNull(articlePage),
+				Description: "only the published articles are searched when status is empty",
+				Args: graphql.FieldConfigArgument{
+					"page":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+					"size":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+					"search":   &graphql.ArgumentConfig{Type: graphql.String},
+					"author":   &graphql.ArgumentConfig{Type: graphql.String},
+					"status":   &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(articleStatus))},
+					"tags":     &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Description: "the articles carry every tag"},
+					"category": &graphql.ArgumentConfig{Type: graphql.String, Description: "the articles of its subcategories are returned as well"},
+				},
+				Resolve: r.searchArticles,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createArticle": &graphql.Field{
+				Type: graphql.NewNonNull(commandPayload),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createArticleInput)},
+				},
+				Resolve: r.createArticle,
+			},
+			"updateArticle": &graphql.Field{
+				Type: graphql.NewNonNull(commandPayload),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateArticleInput)},
+				},
+				Resolve: r.updateArticle,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+)
+
+// articleETag strong validator of an article, every change of the article bumps its version
+// and the comment count is the only field changing without it
+func articleETag(article *domain.ArticleResponse) string {
+	return fmt.Sprintf(`"%d-%d-%d"`, article.ID, article.Version, article.CommentCount)
+}
+
+// pageETag weak validator of a search page, the hash of the page as it is answered
+func pageETag(page *domain.ArticlePaginationResponse) (string, error) {
+	content, err := json.Marshal(page)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// notModified set the validators of the answer and report whether the copy of the client is still current,
+// in which case a 304 is answered. If-Modified-Since is only used without If-None-Match
+func (h *ArticleHandler) notModified(etag string, lastModified time.Time) bool {
+	h.Ctx.Output.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		h.Ctx.Output.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := h.Ctx.Input.Header("If-None-Match"); ifNoneMatch != "" {
+		if !matchETag(ifNoneMatch, etag, true) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(h.Ctx.Input.Header("If-Modified-Since"))
+		if err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+	h.Ctx.ResponseWriter.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// checkIfMatch answer 412 when the If-Match header of a command does not match the current article.
+// Only the id and version of the etags are compared, the commands do not depend on the comment count.
+// The article is the one of the reader, a change not projected yet is not seen: this is only a fast pre-check,
+// the writer checks the version of ifMatchVersion against the article itself
+func (h *ArticleHandler) checkIfMatch(id int) bool {
+	ifMatch := h.Ctx.Input.Header("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+
+	article, err := h.ArticleUsecase.GetArticleById(h.Ctx, id)
+	switch {
+	case err == nil:
+		if matchArticleVersion(ifMatch, article) {
+			return true
+		}
+	case !errors.Is(err, domain.ErrArticleNotFound):
+		h.responseQueryError(err)
+		return false
+	}
+
+	err = response.ErrPreconditionFailed
+	h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+	h.ResponseError(h.Ctx, http.StatusPreconditionFailed, response.PreconditionFailedCode, response.ErrorCodeText(response.PreconditionFailedCode, h.Locale.Lang), err)
+	return false
+}
+
+// ifMatchVersion version of the article named by the If-Match header, the writer rejects the command once
+// the article moved past it. 0 when the header is not a single strong etag of the article
+func (h *ArticleHandler) ifMatchVersion(id int) int {
+	etagID, version, ok := parseArticleETag(h.Ctx.Input.Header("If-Match"))
+	if !ok || etagID != id {
+		return 0
+	}
+	return version
+}
+
+// matchArticleVersion whether one of the comma separated etags of the header names the id and version
+// of article, * matches any article
+func matchArticleVersion(header string, article *domain.ArticleResponse) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimSpace(candidate) == "*" {
+			return true
+		}
+		id, version, ok := parseArticleETag(candidate)
+		if ok && id == article.ID && version == article.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// parseArticleETag id and version of a strong etag of articleETag
+func parseArticleETag(etag string) (id int, version int, ok bool) {
+	etag = strings.TrimSpace(etag)
+	if len(etag) < 2 || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		return 0, 0, false
+	}
+	fields := strings.Split(etag[1:len(etag)-1], "-")
+	if len(fields) != 3 {
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	version, err = strconv.Atoi(fields[1])
+	if err != nil || version < 1 {
+		return 0, 0, false
+	}
+	return id, version, true
+}
+
+// matchETag whether etag is one of the comma separated list of the header, * matches any etag.
+// The weak comparison ignores the W/ prefix, the strong comparison never matches a weak etag
+func matchETag(header string, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+		if candidate == etag && !strings.HasPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	beego "github.com/beego/beego/v2/server/web"
 	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal"
 	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/validator"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
 )
 
@@ -19,15 +25,35 @@ type ArticleHandler struct {
 	internal.BaseController
 	response.ApiResponse
 	ArticleUsecase domain.ArticleUseCase
+	BatchMaxItems  int
+	Stream         StreamConfig
 }
 
-func NewArticleHandler(articleUsecase domain.ArticleUseCase, zapLogger zaplogger.Logger) {
+func NewArticleHandler(articleUsecase domain.ArticleUseCase, batchMaxItems int, stream StreamConfig, zapLogger zaplogger.Logger) {
 	pHandler := &ArticleHandler{
 		ZapLogger:      zapLogger,
 		ArticleUsecase: articleUsecase,
+		BatchMaxItems:  batchMaxItems,
+		Stream:         stream,
 	}
 	beego.Router("/api/v1/articles", pHandler, "post:CreateArticle")
+	beego.Router("/api/v1/articles\\:batch", pHandler, "post:ImportArticles")
+	beego.Router("/api/v1/articles\\:batch/:id", pHandler, "get:GetArticleBatchJob")
 	beego.Router("/api/v1/articles", pHandler, "get:GetArticles")
+	beego.Router("/api/v1/articles/stream", pHandler, "get:StreamArticles")
+	beego.Router("/api/v1/articles/stream/ws", pHandler, "get:StreamArticlesWebSocket")
+	beego.Router("/api/v1/articles/:id", pHandler, "get:GetArticleById")
+	beego.Router("/api/v1/articles/:id", pHandler, "put:UpdateArticle")
+	beego.Router("/api/v1/articles/:id/submit", pHandler, "post:SubmitArticle")
+	beego.Router("/api/v1/articles/:id/publish", pHandler, "post:PublishArticle")
+	beego.Router("/api/v1/articles/:id/unpublish", pHandler, "post:UnpublishArticle")
+	beego.Router("/api/v1/articles/:id/archive", pHandler, "post:ArchiveArticle")
+	beego.Router("/api/v1/articles/:id/tags", pHandler, "post:AddArticleTags")
+	beego.Router("/api/v1/articles/:id/tags", pHandler, "delete:RemoveArticleTags")
+	beego.Router("/api/v1/tags", pHandler, "get:ListTags")
+	beego.Router("/api/v1/articles/:id/revisions", pHandler, "get:GetArticleRevisions")
+	beego.Router("/api/v1/articles/:id/revisions/:rev", pHandler, "get:GetArticleRevision")
+	beego.Router("/api/v1/articles/:id/diff", pHandler, "get:DiffArticleRevisions")
 }
 
 func (h *ArticleHandler) Prepare() {
@@ -41,10 +67,14 @@ func (h *ArticleHandler) Prepare() {
 // @Summary Create Data Article
 // @Produce json
 // @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param Prefer header string false "wait creates the article right away like mode=sync"
+// @Param mode query string false "async (default), sync creates the article right away and answers its id, validate only checks the command"
 // @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.CreateArticleRequest}
 // @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
 // @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
 // @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
 // @Param body body domain.CreateArticleRequest true "request payload"
 // @Router /v1/articles [post]
 func (h *ArticleHandler) CreateArticle() {
@@ -61,16 +91,51 @@ func (h *ArticleHandler) CreateArticle() {
 		return
 	}
 
-	err := h.ArticleUsecase.CreateArticle(h.Ctx, request)
+	mode, preferred, err := h.createMode()
+	if err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.QueryParamInvalidCode, response.ErrorCodeText(response.QueryParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+	switch mode {
+	case domain.CreateModeSync:
+		created, err := h.ArticleUsecase.CreateArticleSync(h.Ctx, request)
+		if err != nil {
+			h.responseWriterError(err)
+			return
+		}
+		if preferred {
+			h.Ctx.Output.Header("Preference-Applied", "wait")
+		}
+		h.Ok(h.Ctx, h.Tr("message.success"), created)
+		return
+	case domain.CreateModeValidate:
+		if err := h.ArticleUsecase.ValidateCreateArticle(h.Ctx, request); err != nil {
+			h.responseWriterError(err)
+			return
+		}
+		h.Ok(h.Ctx, h.Tr("message.success"), domain.ValidateCreateArticleResponse{Valid: true})
+		return
+	}
+
+	err = h.ArticleUsecase.CreateArticle(h.Ctx, request)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
 			return
 		}
+		if errors.Is(err, events.ErrSchemaValidation) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+			return
+		}
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			h.ResponseError(h.Ctx, http.StatusBadRequest, response.DataNotFoundCodeError, response.ErrorCodeText(response.DataNotFoundCodeError, h.Locale.Lang), err)
 			return
 		}
+		if errors.Is(err, domain.ErrServiceUnavailable) {
+			h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+			return
+		}
 		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
 		return
 	}
@@ -78,19 +143,98 @@ func (h *ArticleHandler) CreateArticle() {
 	return
 }
 
+// ImportArticles
+// @Title Import Articles
+// @Tags Article
+// @Summary Import Articles in batches, the job id answered reports the result of each item
+// @Accept json
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param Content-Type header string false "application/json for a JSON array, application/x-ndjson for one article per line"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.ArticleBatchJob}
+// @Header 200 {string} Location "url of the job report"
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Param body body []domain.CreateArticleRequest true "request payload"
+// @Router /v1/articles:batch [post]
+func (h *ArticleHandler) ImportArticles() {
+	items, err := domain.ArticleBatchItemsFromRequest(h.Ctx.Input.RequestBody, isNDJSON(h.Ctx.Input.Header("Content-Type")), h.Locale.Lang, h.BatchMaxItems)
+	if err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return
+	}
+
+	job, err := h.ArticleUsecase.ImportArticles(h.Ctx, items, h.Locale.Lang)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+			return
+		}
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.Ctx.Output.Header("Location", "/api/v1/articles:batch/"+job.ID)
+	h.Ok(h.Ctx, h.Tr("message.success"), job)
+	return
+}
+
+// GetArticleBatchJob
+// @Title Get Article Import
+// @Tags Article
+// @Summary Get the progress of an Article import and the result of each item
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path string true "job id"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.ArticleBatchJob}
+// @Failure 404 {object} swagger.NotFoundResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Router /v1/articles:batch/{id} [get]
+func (h *ArticleHandler) GetArticleBatchJob() {
+	job, err := h.ArticleUsecase.GetArticleBatchJob(h.Ctx, h.Ctx.Input.Param(":id"))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, domain.ErrBatchJobNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, response.DataNotFoundCodeError, response.ErrorCodeText(response.DataNotFoundCodeError, h.Locale.Lang), err)
+			return
+		}
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), job)
+	return
+}
+
 // GetArticles
 // @Title Get All Articles
 // @Tags Article
 // @Summary Get All Articles
 // @Produce json
 // @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
 // @Param size query int false "size"
 // @Param page query int false "page"
 // @Param search query string false "search by body or title"
 // @Param author query string false "filter by author"
+// @Param status query string false "comma separated statuses among draft, in_review, published and archived, published when empty"
+// @Param tags query string false "comma separated tags, the articles carry every tag"
+// @Param category query string false "category path, e.g. tech/golang, the articles of its subcategories are included"
+// @Param If-None-Match header string false "etag of the page already held, answered with 304 when it is unchanged"
 // @Success 200 {object} swagger.BaseResponse{data=[]domain.ArticlePaginationResponse,errors=[]object}
+// @Header 200 {string} ETag "weak etag of the page"
+// @Success 304 "the page is unchanged"
+// @Header 200 {string} X-Cache "stale when the reader service is unavailable and a cached copy is served"
+// @Header 200 {string} Warning "110 Response is Stale, sent along with X-Cache stale"
 // @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
 // @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
 // @router /v1/articles [get]
 func (h *ArticleHandler) GetArticles() {
 	pageSize, page, err := domain.PaginationQueryParamValidation(h.Ctx.Input.Query("size"), h.Ctx.Input.Query("page"))
@@ -100,15 +244,595 @@ func (h *ArticleHandler) GetArticles() {
 		return
 	}
 
-	result, err := h.ArticleUsecase.GetArticles(h.Ctx, page, pageSize, h.Ctx.Input.Query("search"), h.Ctx.Input.Query("author"))
+	statuses, err := domain.StatusQueryParamValidation(h.Ctx.Input.Query("status"))
+	if err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.QueryParamInvalidCode, response.ErrorCodeText(response.QueryParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	filter := domain.SearchArticleFilter{
+		Search:   h.Ctx.Input.Query("search"),
+		Author:   h.Ctx.Input.Query("author"),
+		Statuses: statuses,
+		Tags:     domain.TagsQueryParam(h.Ctx.Input.Query("tags")),
+		Category: events.NormalizeCategory(h.Ctx.Input.Query("category")),
+	}
+
+	result, err := h.ArticleUsecase.GetArticles(h.Ctx, page, pageSize, filter)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, domain.ErrServiceUnavailable) {
+			h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+			return
+		}
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+	if result.Stale {
+		h.Ctx.Output.Header("Warning", `110 - "Response is Stale"`)
+		h.Ctx.Output.Header("X-Cache", "stale")
+		h.Ctx.Output.Header("Age", strconv.Itoa(int(time.Since(result.CachedAt).Seconds())))
+	}
+	if etag, err := pageETag(result); err == nil && h.notModified(etag, time.Time{}) {
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// GetArticleById
+// @Title Get Article By Id
+// @Tags Article
+// @Summary Get Article By Id
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param If-None-Match header string false "etag of the article already held, answered with 304 when it is unchanged"
+// @Param If-Modified-Since header string false "answered with 304 when the article is not updated since, ignored with If-None-Match"
+// @Success 200 {object} swagger.BaseResponse{data=domain.ArticleResponse,errors=[]object}
+// @Header 200 {string} ETag "strong etag of the article"
+// @Header 200 {string} Last-Modified "last update of the article"
+// @Success 304 "the article is unchanged"
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 404 {object} swagger.NotFoundResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @router /v1/articles/{id} [get]
+func (h *ArticleHandler) GetArticleById() {
+	id, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil || id < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	result, err := h.ArticleUsecase.GetArticleById(h.Ctx, id)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, domain.ErrArticleNotFound) {
+			h.ResponseError(h.Ctx, http.StatusNotFound, response.DataNotFoundCodeError, response.ErrorCodeText(response.DataNotFoundCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, domain.ErrServiceUnavailable) {
+			h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+			return
+		}
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+	if h.notModified(articleETag(result), result.UpdatedAt) {
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// UpdateArticle
+// @Title Update Article
+// @Tags Article
+// @Summary Update Data Article, every change is recorded as a new revision
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param If-Match header string false "etag of the article the command is based on, answered with 412 when the article changed since"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.UpdateArticleRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 412 {object} swagger.PreconditionFailedResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.UpdateArticleRequest true "request payload, title and body are changed only when given"
+// @Router /v1/articles/{id} [put]
+func (h *ArticleHandler) UpdateArticle() {
+	id, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil || id < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	var request domain.UpdateArticleRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return
+	}
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return
+	}
+	if !h.checkIfMatch(id) {
+		return
+	}
+	expectedVersion := h.ifMatchVersion(id)
+
+	err = h.ArticleUsecase.UpdateArticle(h.Ctx, id, expectedVersion, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, events.ErrSchemaValidation) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, domain.ErrServiceUnavailable) {
+			h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+			return
+		}
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), request)
+	return
+}
+
+// SubmitArticle
+// @Title Submit Article
+// @Tags Article
+// @Summary Submit An Article For Review
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param If-Match header string false "etag of the article the command is based on, answered with 412 when the article changed since"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.TransitionArticleRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 412 {object} swagger.PreconditionFailedResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.TransitionArticleRequest true "request payload"
+// @Router /v1/articles/{id}/submit [post]
+func (h *ArticleHandler) SubmitArticle() {
+	h.transitionArticle(events.ArticleActionSubmitForReview)
+}
+
+// PublishArticle
+// @Title Publish Article
+// @Tags Article
+// @Summary Publish An Article, a publish_at in the future schedules the publication
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param If-Match header string false "etag of the article the command is based on, answered with 412 when the article changed since"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.TransitionArticleRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 412 {object} swagger.PreconditionFailedResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.TransitionArticleRequest true "request payload"
+// @Router /v1/articles/{id}/publish [post]
+func (h *ArticleHandler) PublishArticle() {
+	h.transitionArticle(events.ArticleActionPublish)
+}
+
+// UnpublishArticle
+// @Title Unpublish Article
+// @Tags Article
+// @Summary Move A Published Article Back To Draft
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param If-Match header string false "etag of the article the command is based on, answered with 412 when the article changed since"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.TransitionArticleRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 412 {object} swagger.PreconditionFailedResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.TransitionArticleRequest true "request payload"
+// @Router /v1/articles/{id}/unpublish [post]
+func (h *ArticleHandler) UnpublishArticle() {
+	h.transitionArticle(events.ArticleActionUnpublish)
+}
+
+// ArchiveArticle
+// @Title Archive Article
+// @Tags Article
+// @Summary Archive An Article
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param If-Match header string false "etag of the article the command is based on, answered with 412 when the article changed since"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.TransitionArticleRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 412 {object} swagger.PreconditionFailedResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.TransitionArticleRequest true "request payload"
+// @Router /v1/articles/{id}/archive [post]
+func (h *ArticleHandler) ArchiveArticle() {
+	h.transitionArticle(events.ArticleActionArchive)
+}
+
+// transitionArticle send the workflow command, the transition is validated by the writer service
+func (h *ArticleHandler) transitionArticle(action string) {
+	id, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil || id < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	var request domain.TransitionArticleRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return
+	}
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return
+	}
+	if !h.checkIfMatch(id) {
+		return
+	}
+	expectedVersion := h.ifMatchVersion(id)
+
+	err = h.ArticleUsecase.TransitionArticle(h.Ctx, id, action, expectedVersion, request)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, events.ErrSchemaValidation) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, domain.ErrServiceUnavailable) {
+			h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+			return
+		}
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), request)
+	return
+}
+
+// AddArticleTags
+// @Title Add Article Tags
+// @Tags Article
+// @Summary Add Tags To An Article
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param If-Match header string false "etag of the article the command is based on, answered with 412 when the article changed since"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.TagArticleRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 412 {object} swagger.PreconditionFailedResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.TagArticleRequest true "request payload"
+// @Router /v1/articles/{id}/tags [post]
+func (h *ArticleHandler) AddArticleTags() {
+	h.tagArticle(events.ArticleTagActionAdd)
+}
+
+// RemoveArticleTags
+// @Title Remove Article Tags
+// @Tags Article
+// @Summary Remove Tags From An Article
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param If-Match header string false "etag of the article the command is based on, answered with 412 when the article changed since"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.TagArticleRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 412 {object} swagger.PreconditionFailedResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.TagArticleRequest true "request payload"
+// @Router /v1/articles/{id}/tags [delete]
+func (h *ArticleHandler) RemoveArticleTags() {
+	h.tagArticle(events.ArticleTagActionRemove)
+}
+
+// tagArticle send the tag command, tags already added or already removed are ignored by the writer service
+func (h *ArticleHandler) tagArticle(action string) {
+	id, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil || id < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	var request domain.TagArticleRequest
+	if err := h.BindJSON(&request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return
+	}
+	if err := validator.Validate.ValidateStruct(&request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return
+	}
+	if !h.checkIfMatch(id) {
+		return
+	}
+	expectedVersion := h.ifMatchVersion(id)
+
+	err = h.ArticleUsecase.TagArticle(h.Ctx, id, action, expectedVersion, request)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
 			return
 		}
+		if errors.Is(err, events.ErrSchemaValidation) {
+			h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, domain.ErrServiceUnavailable) {
+			h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+			return
+		}
 		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
 		return
 	}
+	h.Ok(h.Ctx, h.Tr("message.success"), request)
+	return
+}
+
+// ListTags
+// @Title List Tags
+// @Tags Article
+// @Summary List The Tags With Their Article Counts, the most used first
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param prefix query string false "only the tags starting with prefix"
+// @Param size query int false "number of tags, 50 by default and at most 100"
+// @Success 200 {object} swagger.BaseResponse{data=[]domain.TagCountResponse,errors=[]object}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @router /v1/tags [get]
+func (h *ArticleHandler) ListTags() {
+	size := 0
+	if sizeStr := h.Ctx.Input.Query("size"); sizeStr != "" {
+		var err error
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil || size < 1 {
+			err = response.ErrQueryParamInvalid
+			h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+			h.ResponseError(h.Ctx, http.StatusBadRequest, response.QueryParamInvalidCode, response.ErrorCodeText(response.QueryParamInvalidCode, h.Locale.Lang), err)
+			return
+		}
+	}
+
+	result, err := h.ArticleUsecase.ListTags(h.Ctx, h.Ctx.Input.Query("prefix"), size)
+	if err != nil {
+		h.responseQueryError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// GetArticleRevisions
+// @Title Get Article Revisions
+// @Tags Article
+// @Summary Get Revisions Of An Article, oldest first
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Success 200 {object} swagger.BaseResponse{data=[]domain.ArticleRevisionResponse,errors=[]object}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 404 {object} swagger.NotFoundResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @router /v1/articles/{id}/revisions [get]
+func (h *ArticleHandler) GetArticleRevisions() {
+	id, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil || id < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	result, err := h.ArticleUsecase.GetArticleRevisions(h.Ctx, id)
+	if err != nil {
+		h.responseQueryError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// GetArticleRevision
+// @Title Get Article Revision
+// @Tags Article
+// @Summary Get One Revision Of An Article
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param rev path int true "revision number"
+// @Success 200 {object} swagger.BaseResponse{data=domain.ArticleRevisionResponse,errors=[]object}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 404 {object} swagger.NotFoundResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @router /v1/articles/{id}/revisions/{rev} [get]
+func (h *ArticleHandler) GetArticleRevision() {
+	id, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil || id < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+	revision, err := strconv.Atoi(h.Ctx.Input.Param(":rev"))
+	if err != nil || revision < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	result, err := h.ArticleUsecase.GetArticleRevision(h.Ctx, id, revision)
+	if err != nil {
+		h.responseQueryError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// DiffArticleRevisions
+// @Title Diff Article Revisions
+// @Tags Article
+// @Summary Get The Fields Changed Between Two Revisions Of An Article
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param from query int true "revision compared from"
+// @Param to query int true "revision compared to"
+// @Success 200 {object} swagger.BaseResponse{data=domain.ArticleRevisionDiffResponse,errors=[]object}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 404 {object} swagger.NotFoundResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @router /v1/articles/{id}/diff [get]
+func (h *ArticleHandler) DiffArticleRevisions() {
+	id, err := strconv.Atoi(h.Ctx.Input.Param(":id"))
+	if err != nil || id < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+	from, fromErr := strconv.Atoi(h.Ctx.Input.Query("from"))
+	to, toErr := strconv.Atoi(h.Ctx.Input.Query("to"))
+	if fromErr != nil || toErr != nil || from < 1 || to < 1 {
+		err = response.ErrQueryParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.QueryParamInvalidCode, response.ErrorCodeText(response.QueryParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	result, err := h.ArticleUsecase.DiffArticleRevisions(h.Ctx, id, from, to)
+	if err != nil {
+		h.responseQueryError(err)
+		return
+	}
 	h.Ok(h.Ctx, h.Tr("message.success"), result)
 	return
 }
+
+// responseQueryError respond the error of a query on the reader service
+func (h *ArticleHandler) responseQueryError(err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+		return
+	}
+	if errors.Is(err, domain.ErrArticleNotFound) || errors.Is(err, domain.ErrRevisionNotFound) {
+		h.ResponseError(h.Ctx, http.StatusNotFound, response.DataNotFoundCodeError, response.ErrorCodeText(response.DataNotFoundCodeError, h.Locale.Lang), err)
+		return
+	}
+	if errors.Is(err, domain.ErrServiceUnavailable) {
+		h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+}
+
+// createMode mode of the creation asked by the mode query parameter, sync when the Prefer header asks to wait.
+// preferred reports the mode comes from the Prefer header
+func (h *ArticleHandler) createMode() (mode string, preferred bool, err error) {
+	mode = strings.ToLower(h.Ctx.Input.Query("mode"))
+	switch mode {
+	case domain.CreateModeAsync, domain.CreateModeSync, domain.CreateModeValidate:
+		return mode, false, nil
+	case "":
+	default:
+		return "", false, response.ErrQueryParamInvalid
+	}
+
+	for _, preference := range strings.Split(h.Ctx.Request.Header.Get("Prefer"), ",") {
+		name := strings.TrimSpace(strings.SplitN(preference, "=", 2)[0])
+		if strings.EqualFold(name, "wait") {
+			return domain.CreateModeSync, true, nil
+		}
+	}
+	return domain.CreateModeAsync, false, nil
+}
+
+// responseWriterError respond the error of a synchronous call on the writer service,
+// the rules the command breaks are listed in the errors of the response
+func (h *ArticleHandler) responseWriterError(err error) {
+	var rejected *domain.CommandRejectedError
+	if errors.As(err, &rejected) {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), rejected)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
+		h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+		return
+	}
+	if errors.Is(err, domain.ErrServiceUnavailable) {
+		h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+}
+
+// isNDJSON the import body holds one article per line
+func isNDJSON(contentType string) bool {
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	return mediaType == "application/x-ndjson" || mediaType == "application/ndjson"
+}
@@ -0,0 +1,209 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"golang.org/x/net/websocket"
+)
+
+// streamRetryMillis reconnection delay advised to the event source clients
+const streamRetryMillis = 3000
+
+// StreamConfig keep-alive and lifetime of the change feed connections
+type StreamConfig struct {
+	// Heartbeat interval of the comments keeping the idle event streams open
+	Heartbeat time.Duration
+	// MaxDuration lifetime of an event stream, below the server write timeout so the stream ends cleanly and
+	// the client resumes it with Last-Event-ID
+	MaxDuration time.Duration
+	// WriteTimeout upper bound of a websocket write, a client not reading is disconnected
+	WriteTimeout time.Duration
+	// AllowedOrigins origins of the pages allowed to open a websocket, "*" allows any origin.
+	// Only the pages served by the gateway host are allowed when empty
+	AllowedOrigins []string
+}
+
+// checkOrigin refuse the websocket handshakes of the pages of other origins, a browser sends the cookies
+// of the gateway whatever page opens the socket. The clients sending no Origin are not browsers and are accepted
+func (c StreamConfig) checkOrigin(config *websocket.Config, req *http.Request) error {
+	if config.Origin == nil {
+		return nil
+	}
+	if len(c.AllowedOrigins) == 0 && strings.EqualFold(config.Origin.Host, req.Host) {
+		return nil
+	}
+	origin := config.Origin.Scheme + "://" + config.Origin.Host
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return nil
+		}
+	}
+	return fmt.Errorf("websocket origin %s not allowed", origin)
+}
+
+// StreamArticles
+// @Title Stream Article Changes
+// @Tags Article
+// @Summary Stream the changes of the Articles as server-sent events, the id of each event resumes the stream after it
+// @Produce text/event-stream
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param Last-Event-ID header string false "id of the last event received, the stream resumes after it"
+// @Param last_event_id query string false "Last-Event-ID for the clients unable to set the header"
+// @Param author query string false "filter by author"
+// @Param tags query string false "comma separated tags, the articles carry every tag"
+// @Success 200 {object} domain.ArticleChange
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Router /v1/articles/stream [get]
+func (h *ArticleHandler) StreamArticles() {
+	lastEventID := h.Ctx.Input.Header("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = h.Ctx.Input.Query("last_event_id")
+	}
+	filter := domain.NewArticleStreamFilter(h.Ctx.Input.Query("author"), h.Ctx.Input.Query("tags"))
+
+	subscription, err := h.ArticleUsecase.SubscribeArticleChanges(h.Ctx, filter, lastEventID)
+	if err != nil {
+		h.responseStreamError(err)
+		return
+	}
+	defer subscription.Close()
+
+	w := h.Ctx.ResponseWriter
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// proxies must not buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMillis)
+	if !subscription.Resumed() {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", domain.ArticleStreamReset)
+	}
+	w.Flush()
+
+	heartbeat := time.NewTicker(h.Stream.Heartbeat)
+	defer heartbeat.Stop()
+	lifetime := time.NewTimer(h.Stream.MaxDuration)
+	defer lifetime.Stop()
+
+	for {
+		select {
+		case <-h.Ctx.Request.Context().Done():
+			return
+		case <-lifetime.C:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case change, ok := <-subscription.Changes():
+			if !ok {
+				if subscription.Lagging() {
+					fmt.Fprintf(w, "event: %s\ndata: {}\n\n", domain.ArticleStreamLagging)
+					w.Flush()
+				}
+				return
+			}
+			data, err := json.Marshal(change)
+			if err != nil {
+				h.ZapLogger.WarnMsg("json.Marshal", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", change.ID, change.Type, data); err != nil {
+				return
+			}
+		}
+		w.Flush()
+	}
+}
+
+// StreamArticlesWebSocket
+// @Title Stream Article Changes over WebSocket
+// @Tags Article
+// @Summary Stream the changes of the Articles as websocket messages, the id of each change resumes the stream after it
+// @Param X-Tenant-ID header string false "tenant"
+// @Param last_event_id query string false "id of the last change received, the stream resumes after it"
+// @Param author query string false "filter by author"
+// @Param tags query string false "comma separated tags, the articles carry every tag"
+// @Success 101 {object} domain.ArticleChange
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Router /v1/articles/stream/ws [get]
+func (h *ArticleHandler) StreamArticlesWebSocket() {
+	filter := domain.NewArticleStreamFilter(h.Ctx.Input.Query("author"), h.Ctx.Input.Query("tags"))
+
+	subscription, err := h.ArticleUsecase.SubscribeArticleChanges(h.Ctx, filter, h.Ctx.Input.Query("last_event_id"))
+	if err != nil {
+		h.responseStreamError(err)
+		return
+	}
+	defer subscription.Close()
+
+	websocket.Server{
+		// the cors filter does not apply to websockets, the browsers do not enforce it on them
+		Handshake: h.Stream.checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			h.pushChanges(ws, subscription)
+		},
+	}.ServeHTTP(h.Ctx.ResponseWriter, h.Ctx.Request)
+}
+
+func (h *ArticleHandler) pushChanges(ws *websocket.Conn, subscription domain.ArticleSubscription) {
+	// the deadlines of the http server do not apply to the socket
+	if err := ws.SetDeadline(time.Time{}); err != nil {
+		h.ZapLogger.WarnMsg("ws.SetDeadline", err)
+		return
+	}
+
+	// the messages of the client are discarded, the read fails once it goes away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	send := func(change domain.ArticleChange) bool {
+		if err := ws.SetWriteDeadline(time.Now().Add(h.Stream.WriteTimeout)); err != nil {
+			return false
+		}
+		return websocket.JSON.Send(ws, change) == nil
+	}
+
+	if !subscription.Resumed() && !send(domain.ArticleChange{Type: domain.ArticleStreamReset}) {
+		return
+	}
+	for {
+		select {
+		case <-gone:
+			return
+		case change, ok := <-subscription.Changes():
+			if !ok {
+				if subscription.Lagging() {
+					send(domain.ArticleChange{Type: domain.ArticleStreamLagging})
+				}
+				return
+			}
+			if !send(change) {
+				return
+			}
+		}
+	}
+}
+
+func (h *ArticleHandler) responseStreamError(err error) {
+	if errors.Is(err, domain.ErrStreamUnavailable) {
+		h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+}
@@ -0,0 +1,152 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal"
+	readerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_reader"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// errRouteNotFound the path is not bound to any rpc by the google.api.http rules of the reader proto
+var errRouteNotFound = errors.New("route is not bound to a reader rpc")
+
+// ReaderProxyHandler serves the REST transcoding of the reader service generated from article_reader.proto,
+// the answers of the reverse proxy are wrapped in the response.ApiResponse envelope
+type ReaderProxyHandler struct {
+	ZapLogger zaplogger.Logger
+	internal.BaseController
+	response.ApiResponse
+	Mux            *runtime.ServeMux
+	ContextTimeout time.Duration
+}
+
+// proxyResult the error of the reverse proxy, answered by the handler instead of the runtime
+type proxyResult struct {
+	err error
+}
+
+type proxyResultKey struct{}
+
+func NewReaderProxyHandler(ctx context.Context,
+	timeout time.Duration,
+	rsClient readerService.ReaderServiceClient,
+	crsClient readerService.CommentReaderServiceClient,
+	zapLogger zaplogger.Logger) error {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		// the tenant resolved by the middleware is the only metadata sent to the reader
+		runtime.WithIncomingHeaderMatcher(func(string) (string, bool) { return "", false }),
+		runtime.WithErrorHandler(recordError),
+		runtime.WithRoutingErrorHandler(recordRoutingError),
+	)
+	if err := readerService.RegisterReaderServiceHandlerClient(ctx, mux, rsClient); err != nil {
+		return err
+	}
+	if err := readerService.RegisterCommentReaderServiceHandlerClient(ctx, mux, crsClient); err != nil {
+		return err
+	}
+
+	pHandler := &ReaderProxyHandler{
+		ZapLogger:      zapLogger,
+		Mux:            mux,
+		ContextTimeout: timeout,
+	}
+	beego.Router("/api/v2/*", pHandler, "get:Proxy")
+	return nil
+}
+
+func (h *ReaderProxyHandler) Prepare() {
+	// check user access when needed
+	h.SetLangVersion()
+}
+
+// Proxy the routes and their parameters are the google.api.http rules of article_reader.proto
+func (h *ReaderProxyHandler) Proxy() {
+	c, cancel := context.WithTimeout(h.Ctx.Request.Context(), h.ContextTimeout)
+	defer cancel()
+
+	result := &proxyResult{}
+	writer := newBufferedWriter()
+	h.Mux.ServeHTTP(writer, h.Ctx.Request.WithContext(context.WithValue(c, proxyResultKey{}, result)))
+	if result.err != nil {
+		h.responseError(result.err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), json.RawMessage(writer.body.Bytes()))
+}
+
+// responseError the same codes as the v1 endpoints answer for the status of the reader
+func (h *ReaderProxyHandler) responseError(err error) {
+	h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+	if errors.Is(err, errRouteNotFound) {
+		h.ResponseError(h.Ctx, http.StatusNotFound, response.ResourceNotFoundCodeError, response.ErrorCodeText(response.ResourceNotFoundCodeError, h.Locale.Lang), err)
+		return
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.OutOfRange:
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+	case codes.NotFound:
+		h.ResponseError(h.Ctx, http.StatusNotFound, response.DataNotFoundCodeError, response.ErrorCodeText(response.DataNotFoundCodeError, h.Locale.Lang), err)
+	case codes.DeadlineExceeded:
+		h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+	case codes.Unavailable:
+		h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+	default:
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+	}
+}
+
+func recordError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, _ http.ResponseWriter, r *http.Request, err error) {
+	if result, ok := r.Context().Value(proxyResultKey{}).(*proxyResult); ok {
+		result.err = err
+	}
+}
+
+func recordRoutingError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, _ http.ResponseWriter, r *http.Request, httpStatus int) {
+	result, ok := r.Context().Value(proxyResultKey{}).(*proxyResult)
+	if !ok {
+		return
+	}
+	switch httpStatus {
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		result.err = errRouteNotFound
+	default:
+		result.err = status.Error(codes.InvalidArgument, http.StatusText(httpStatus))
+	}
+}
+
+// bufferedWriter keeps the answer of the reverse proxy so the handler writes it inside the envelope
+type bufferedWriter struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func newBufferedWriter() *bufferedWriter {
+	return &bufferedWriter{header: http.Header{}}
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// WriteHeader the status of a failed call is answered from the recorded error
+func (w *bufferedWriter) WriteHeader(int) {}
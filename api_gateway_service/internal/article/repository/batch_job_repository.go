@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/cache"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+type batchJobArticleRepository struct {
+	zapLogger zaplogger.Logger
+	jobs      cache.Cache
+	ttl       time.Duration
+}
+
+// NewBatchJobArticleRepository the jobs are kept in jobs for ttl after their last change
+func NewBatchJobArticleRepository(jobs cache.Cache, ttl time.Duration, zapLogger zaplogger.Logger) domain.BatchJobArticleRepository {
+	return &batchJobArticleRepository{
+		jobs:      jobs,
+		ttl:       ttl,
+		zapLogger: zapLogger,
+	}
+}
+
+func (b batchJobArticleRepository) Save(ctx context.Context, job domain.ArticleBatchJob) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+	return b.jobs.Set(ctx, batchJobKey(ctx, job.ID), value, b.ttl)
+}
+
+func (b batchJobArticleRepository) Get(ctx context.Context, id string) (*domain.ArticleBatchJob, error) {
+	value, err := b.jobs.Get(ctx, batchJobKey(ctx, id))
+	if err != nil {
+		if err == cache.ErrMiss {
+			return nil, domain.ErrBatchJobNotFound
+		}
+		return nil, errors.Wrap(err, "jobs.Get")
+	}
+
+	job := new(domain.ArticleBatchJob)
+	if err := json.Unmarshal(value, job); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	return job, nil
+}
+
+// batchJobKey jobs are only visible to the tenant which started them
+func batchJobKey(ctx context.Context, id string) string {
+	return fmt.Sprintf("article_batch:%s:%s", tenant.FromContext(ctx), id)
+}
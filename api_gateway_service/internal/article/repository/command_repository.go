@@ -2,40 +2,86 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
-	"time"
 
+	"github.com/pkg/errors"
 	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
 	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
-	"github.com/segmentio/kafka-go"
 )
 
 type commandArticleRepository struct {
 	zapLogger       zaplogger.Logger
 	producer        kafkaClient.Producer
+	codec           kafkaClient.Codec
 	confKafkaTopics domain.ConfKafkaTopics
 }
 
-func NewCommandArticleRepository(producer kafkaClient.Producer, confKafkaTopics domain.ConfKafkaTopics, zapLogger zaplogger.Logger) domain.CommandArticleRepository {
+func NewCommandArticleRepository(producer kafkaClient.Producer, codec kafkaClient.Codec, confKafkaTopics domain.ConfKafkaTopics, zapLogger zaplogger.Logger) domain.CommandArticleRepository {
 	return &commandArticleRepository{
 		producer:        producer,
+		codec:           codec,
 		confKafkaTopics: confKafkaTopics,
 		zapLogger:       zapLogger,
 	}
 }
 
 func (m commandArticleRepository) Create(ctx context.Context, command domain.CreateArticleCommand) error {
-	msg, err := json.Marshal(command)
+	msg, err := events.Default.EncodeMessage(m.codec, m.confKafkaTopics.CreateArticle, events.ArticleCreateType, command)
 	if err != nil {
 		return err
 	}
-	err = m.producer.PublishMessage(ctx, kafka.Message{
-		Topic: m.confKafkaTopics.CreateArticle,
-		Value: msg,
-		Time:  time.Now().UTC(),
-	})
+	err = m.producer.PublishMessage(ctx, msg)
 	if err != nil {
+		if resilience.IsRejected(err) {
+			return errors.Wrap(domain.ErrServiceUnavailable, err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func (m commandArticleRepository) Update(ctx context.Context, command domain.UpdateArticleCommand) error {
+	msg, err := events.Default.EncodeMessage(m.codec, m.confKafkaTopics.UpdateArticle, events.ArticleUpdateType, command)
+	if err != nil {
+		return err
+	}
+	err = m.producer.PublishMessage(ctx, msg)
+	if err != nil {
+		if resilience.IsRejected(err) {
+			return errors.Wrap(domain.ErrServiceUnavailable, err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func (m commandArticleRepository) Transition(ctx context.Context, command domain.TransitionArticleCommand) error {
+	msg, err := events.Default.EncodeMessage(m.codec, m.confKafkaTopics.TransitionArticle, events.ArticleTransitionType, command)
+	if err != nil {
+		return err
+	}
+	err = m.producer.PublishMessage(ctx, msg)
+	if err != nil {
+		if resilience.IsRejected(err) {
+			return errors.Wrap(domain.ErrServiceUnavailable, err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func (m commandArticleRepository) Tag(ctx context.Context, command domain.TagArticleCommand) error {
+	msg, err := events.Default.EncodeMessage(m.codec, m.confKafkaTopics.TagArticle, events.ArticleTagType, command)
+	if err != nil {
+		return err
+	}
+	err = m.producer.PublishMessage(ctx, msg)
+	if err != nil {
+		if resilience.IsRejected(err) {
+			return errors.Wrap(domain.ErrServiceUnavailable, err.Error())
+		}
 		return err
 	}
 	return nil
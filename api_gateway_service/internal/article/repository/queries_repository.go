@@ -2,34 +2,171 @@ package repository
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
 	readerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_reader"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/cache"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 type queriesArticleRepository struct {
 	zapLogger zaplogger.Logger
 	rsClient  readerService.ReaderServiceClient
+	// staleCache copies of the last search responses served while the reader is unavailable
+	staleCache cache.Cache
+	staleTTL   time.Duration
 }
 
-func NewQueriesArticleRepository(rsClient readerService.ReaderServiceClient, zapLogger zaplogger.Logger) domain.QueriesArticleRepository {
+// staleSearch search response kept for the fallback with the time it was received
+type staleSearch struct {
+	CachedAt time.Time `json:"cachedAt"`
+	Response []byte    `json:"response"`
+}
+
+func NewQueriesArticleRepository(rsClient readerService.ReaderServiceClient, staleCache cache.Cache, staleTTL time.Duration, zapLogger zaplogger.Logger) domain.QueriesArticleRepository {
 	return &queriesArticleRepository{
-		rsClient:  rsClient,
-		zapLogger: zapLogger,
+		rsClient:   rsClient,
+		staleCache: staleCache,
+		staleTTL:   staleTTL,
+		zapLogger:  zapLogger,
 	}
 }
 
-func (q queriesArticleRepository) Search(ctx context.Context, page int, size int, search string, author string) (*readerService.SearchRes, error) {
+func (q queriesArticleRepository) Search(ctx context.Context, page int, size int, filter domain.SearchArticleFilter) (*readerService.SearchRes, error) {
 	res, err := q.rsClient.SearchArticle(ctx, &readerService.SearchReq{
-		Author: author,
-		Search: search,
-		Page:   int64(page),
-		Size:   int64(size),
+		Author:   filter.Author,
+		Search:   filter.Search,
+		Page:     int64(page),
+		Size:     int64(size),
+		Statuses: filter.Statuses,
+		Tags:     filter.Tags,
+		Category: filter.Category,
 	})
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
+	}
+
+	q.putStaleSearch(ctx, staleSearchKey(ctx, page, size, filter), res)
+	return res, nil
+}
+
+// SearchStale returns domain.ErrNoStaleData when no copy of the search response is left
+func (q queriesArticleRepository) SearchStale(ctx context.Context, page int, size int, filter domain.SearchArticleFilter) (*readerService.SearchRes, time.Time, error) {
+	value, err := q.staleCache.Get(ctx, staleSearchKey(ctx, page, size, filter))
+	if err != nil {
+		if err == cache.ErrMiss {
+			return nil, time.Time{}, domain.ErrNoStaleData
+		}
+		return nil, time.Time{}, errors.Wrap(err, "staleCache.Get")
+	}
+
+	var stale staleSearch
+	if err := json.Unmarshal(value, &stale); err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "json.Unmarshal")
+	}
+	res := new(readerService.SearchRes)
+	if err := proto.Unmarshal(stale.Response, res); err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "proto.Unmarshal")
+	}
+
+	return res, stale.CachedAt, nil
+}
+
+func (q queriesArticleRepository) putStaleSearch(ctx context.Context, key string, res *readerService.SearchRes) {
+	response, err := proto.Marshal(res)
+	if err != nil {
+		q.zapLogger.WarnMsg("proto.Marshal", err)
+		return
+	}
+	value, err := json.Marshal(staleSearch{CachedAt: time.Now().UTC(), Response: response})
+	if err != nil {
+		q.zapLogger.WarnMsg("json.Marshal", err)
+		return
+	}
+	if err := q.staleCache.Set(ctx, key, value, q.staleTTL); err != nil {
+		q.zapLogger.WarnMsg("staleCache.Set", err)
+	}
+}
+
+// staleSearchKey the copies are kept per tenant
+func staleSearchKey(ctx context.Context, page int, size int, filter domain.SearchArticleFilter) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d|%s|%s|%s|%s|%s", tenant.FromContext(ctx), page, size, filter.Search, filter.Author,
+		strings.Join(filter.Statuses, ","), strings.Join(filter.Tags, ","), filter.Category)))
+	return "search:" + hex.EncodeToString(sum[:])
+}
+
+func (q queriesArticleRepository) ListTags(ctx context.Context, prefix string, size int) ([]*readerService.TagCount, error) {
+	res, err := q.rsClient.ListTags(ctx, &readerService.ListTagsReq{Prefix: prefix, Size: int64(size)})
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return res.GetTags(), nil
+}
+
+func (q queriesArticleRepository) GetById(ctx context.Context, id int) (*readerService.Article, error) {
+	res, err := q.rsClient.GetArticleById(ctx, &readerService.GetArticleByIdReq{ID: int32(id)})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrArticleNotFound
+		}
+		return nil, mapError(err)
+	}
+
+	return res.GetArticle(), nil
+}
+
+func (q queriesArticleRepository) GetRevisions(ctx context.Context, id int) ([]*readerService.ArticleRevision, error) {
+	res, err := q.rsClient.GetArticleRevisions(ctx, &readerService.GetArticleRevisionsReq{ID: int32(id)})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrArticleNotFound
+		}
+		return nil, mapError(err)
+	}
+
+	return res.GetRevisions(), nil
+}
+
+func (q queriesArticleRepository) GetRevision(ctx context.Context, id int, revision int) (*readerService.ArticleRevision, error) {
+	res, err := q.rsClient.GetArticleRevision(ctx, &readerService.GetArticleRevisionReq{ID: int32(id), Revision: int32(revision)})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrRevisionNotFound
+		}
+		return nil, mapError(err)
+	}
+
+	return res.GetRevision(), nil
+}
+
+func (q queriesArticleRepository) DiffRevisions(ctx context.Context, id int, from int, to int) (*readerService.DiffArticleRevisionsRes, error) {
+	res, err := q.rsClient.DiffArticleRevisions(ctx, &readerService.DiffArticleRevisionsReq{ID: int32(id), From: int32(from), To: int32(to)})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, domain.ErrRevisionNotFound
+		}
+		return nil, mapError(err)
 	}
 
 	return res, nil
 }
+
+// mapError the reader being unreachable, overloaded or behind an open circuit breaker is reported as domain.ErrServiceUnavailable
+func mapError(err error) error {
+	if status.Code(err) == codes.Unavailable {
+		return errors.Wrap(domain.ErrServiceUnavailable, err.Error())
+	}
+	return err
+}
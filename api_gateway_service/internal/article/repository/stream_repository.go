@@ -0,0 +1,532 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/segmentio/kafka-go"
+)
+
+// resumeTimeout how long a resumption reads kafka before the subscriber is reset
+const resumeTimeout = 10 * time.Second
+
+var (
+	streamSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_article_stream_subscribers",
+		Help: "Number of clients subscribed to the article change feed.",
+	})
+	streamLagging = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_article_stream_lagging_total",
+		Help: "Number of subscribers dropped for falling behind the article change feed.",
+	})
+)
+
+// articleChangePayload fields shared by the article events, each of them carries the whole article
+type articleChangePayload struct {
+	ID          int        `json:"id"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at"`
+	PublishedAt *time.Time `json:"published_at"`
+	Tags        []string   `json:"tags"`
+	Category    string     `json:"category"`
+	Author      string     `json:"author"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type articleSubscription struct {
+	feed     *ArticleChangeFeed
+	tenantID string
+	filter   domain.ArticleStreamFilter
+	changes  chan domain.ArticleChange
+	resumed  bool
+	// lagging, replaying, held and replayed guarded by feed.mu
+	lagging bool
+	// replaying the changes following the last event id are read from kafka, the broadcast changes are held meanwhile
+	replaying bool
+	held      []domain.ArticleChange
+	// replayed last offset per topic:partition replayed from kafka, the broadcast changes up to it are skipped
+	replayed map[string]int64
+}
+
+func (s *articleSubscription) Changes() <-chan domain.ArticleChange {
+	return s.changes
+}
+
+func (s *articleSubscription) Lagging() bool {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	return s.lagging
+}
+
+func (s *articleSubscription) Resumed() bool {
+	return s.resumed
+}
+
+func (s *articleSubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	if _, ok := s.feed.subscribers[s]; ok {
+		s.feed.drop(s)
+	}
+}
+
+// ArticleChangeFeed fan out the article events of kafka to the subscribers of this instance, a subscriber whose
+// buffer is full is dropped rather than slowing the others down. The last changes are held so the subscribers
+// reconnecting with the id of the last change they received resume without a gap, from kafka when it is no longer held
+type ArticleChangeFeed struct {
+	zapLogger   zaplogger.Logger
+	reader      *kafka.Reader
+	eventTypes  map[string]string
+	bufferSize  int
+	resumeLimit int
+
+	mu          sync.Mutex
+	stopped     bool
+	replay      []domain.ArticleChange
+	next        int
+	subscribers map[*articleSubscription]struct{}
+}
+
+// NewArticleChangeFeed eventTypes maps the topics read by reader to the type of their events,
+// replaySize changes are held for the resumptions and bufferSize changes are queued per subscriber.
+// A resumption from an id no longer held reads up to resumeLimit messages from kafka, 0 never reads them
+func NewArticleChangeFeed(reader *kafka.Reader, eventTypes map[string]string, replaySize, bufferSize, resumeLimit int, zapLogger zaplogger.Logger) *ArticleChangeFeed {
+	return &ArticleChangeFeed{
+		zapLogger:   zapLogger,
+		reader:      reader,
+		eventTypes:  eventTypes,
+		bufferSize:  bufferSize,
+		resumeLimit: resumeLimit,
+		replay:      make([]domain.ArticleChange, 0, replaySize),
+		subscribers: make(map[*articleSubscription]struct{}),
+	}
+}
+
+// Run fan out the events until ctx is done, the subscriptions are closed once it returns
+func (f *ArticleChangeFeed) Run(ctx context.Context) {
+	defer func() {
+		f.mu.Lock()
+		f.stopped = true
+		for sub := range f.subscribers {
+			f.drop(sub)
+		}
+		f.mu.Unlock()
+		if err := f.reader.Close(); err != nil {
+			f.zapLogger.Warnf("ArticleChangeFeed.reader.Close: %v", err)
+		}
+	}()
+
+	for {
+		m, err := f.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			f.zapLogger.Warnf("ArticleChangeFeed.FetchMessage: %v", err)
+			continue
+		}
+
+		change, err := f.decode(m)
+		if err != nil {
+			f.zapLogger.WarnMsg("ArticleChangeFeed.decode", err)
+			continue
+		}
+		f.broadcast(change)
+	}
+}
+
+func (f *ArticleChangeFeed) Subscribe(ctx context.Context, filter domain.ArticleStreamFilter, lastEventID string) (domain.ArticleSubscription, error) {
+	f.mu.Lock()
+
+	if f.stopped {
+		f.mu.Unlock()
+		return nil, domain.ErrStreamUnavailable
+	}
+
+	sub := &articleSubscription{feed: f, tenantID: tenant.FromContext(ctx), filter: filter, resumed: true}
+	var missed []domain.ArticleChange
+	if lastEventID != "" {
+		missed, sub.resumed = f.since(lastEventID)
+	}
+	if position, ok := f.parsePosition(lastEventID); !sub.resumed && ok && f.resumeLimit > 0 {
+		// the subscriber is registered first so no change broadcast while kafka is read is missed
+		sub.replaying = true
+		sub.changes = make(chan domain.ArticleChange, f.bufferSize)
+		f.subscribers[sub] = struct{}{}
+		streamSubscribers.Inc()
+		f.mu.Unlock()
+		return f.resume(ctx, sub, position)
+	}
+	defer f.mu.Unlock()
+
+	pending := make([]domain.ArticleChange, 0, len(missed))
+	for _, change := range missed {
+		if sub.matches(change) {
+			pending = append(pending, change)
+		}
+	}
+	sub.changes = make(chan domain.ArticleChange, f.bufferSize+len(pending))
+	for _, change := range pending {
+		sub.changes <- change
+	}
+
+	f.subscribers[sub] = struct{}{}
+	streamSubscribers.Inc()
+	return sub, nil
+}
+
+func (f *ArticleChangeFeed) broadcast(change domain.ArticleChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.replay) < cap(f.replay) {
+		f.replay = append(f.replay, change)
+	} else if cap(f.replay) > 0 {
+		f.replay[f.next] = change
+		f.next = (f.next + 1) % cap(f.replay)
+	}
+
+	for sub := range f.subscribers {
+		if !sub.matches(change) || sub.isReplayed(change) {
+			continue
+		}
+		if sub.replaying {
+			if len(sub.held) < f.bufferSize {
+				sub.held = append(sub.held, change)
+				continue
+			}
+			sub.lagging = true
+			streamLagging.Inc()
+			f.drop(sub)
+			continue
+		}
+		select {
+		case sub.changes <- change:
+		default:
+			// the subscriber resumes from the replay once it reconnects
+			sub.lagging = true
+			streamLagging.Inc()
+			f.drop(sub)
+		}
+	}
+}
+
+// since changes following the change id in the replay, false when id is no longer held
+func (f *ArticleChangeFeed) since(id string) ([]domain.ArticleChange, bool) {
+	ordered := append(append(make([]domain.ArticleChange, 0, len(f.replay)), f.replay[f.next:]...), f.replay[:f.next]...)
+	for i := range ordered {
+		if ordered[i].ID == id {
+			return ordered[i+1:], true
+		}
+	}
+	return nil, false
+}
+
+// drop must be called with mu held
+func (f *ArticleChangeFeed) drop(sub *articleSubscription) {
+	delete(f.subscribers, sub)
+	close(sub.changes)
+	streamSubscribers.Dec()
+}
+
+func (f *ArticleChangeFeed) decode(m kafka.Message) (domain.ArticleChange, error) {
+	eventType, ok := f.eventTypes[m.Topic]
+	if !ok {
+		return domain.ArticleChange{}, errors.Errorf("unexpected topic %s", m.Topic)
+	}
+
+	envelope, err := events.Default.DecodeMessage(eventType, m)
+	if err != nil {
+		return domain.ArticleChange{}, errors.Wrap(err, "events.DecodeMessage")
+	}
+	var payload articleChangePayload
+	if err := envelope.Decode(&payload); err != nil {
+		return domain.ArticleChange{}, errors.Wrap(err, "envelope.Decode")
+	}
+
+	return domain.ArticleChange{
+		ID:       fmt.Sprintf("%s:%d:%d", m.Topic, m.Partition, m.Offset),
+		Type:     eventType,
+		TenantID: tenant.FromContext(tenant.ContextFromMessage(context.Background(), m)),
+		Article: &domain.ArticleResponse{
+			ID:          payload.ID,
+			Status:      payload.Status,
+			PublishAt:   payload.PublishAt,
+			PublishedAt: payload.PublishedAt,
+			Tags:        payload.Tags,
+			Category:    payload.Category,
+			Author:      payload.Author,
+			Title:       payload.Title,
+			Body:        payload.Body,
+			CreatedAt:   payload.CreatedAt,
+			UpdatedAt:   payload.UpdatedAt,
+		},
+	}, nil
+}
+
+// matches the change belongs to the tenant of the subscription and passes its filter
+func (s *articleSubscription) matches(change domain.ArticleChange) bool {
+	return change.TenantID == s.tenantID && s.filter.Match(change)
+}
+
+// isReplayed the change was already replayed from kafka to the subscriber, must be called with feed.mu held
+func (s *articleSubscription) isReplayed(change domain.ArticleChange) bool {
+	if s.replayed == nil {
+		return false
+	}
+	position, ok := s.feed.parsePosition(change.ID)
+	if !ok {
+		return false
+	}
+	last, ok := s.replayed[position.partitionKey()]
+	return ok && position.offset <= last
+}
+
+// changePosition kafka position of a change, its id is topic:partition:offset
+type changePosition struct {
+	topic     string
+	partition int
+	offset    int64
+}
+
+func (p changePosition) partitionKey() string {
+	return p.topic + ":" + strconv.Itoa(p.partition)
+}
+
+// parsePosition the position of the change id, false when it is not one of the topics of the feed
+func (f *ArticleChangeFeed) parsePosition(id string) (changePosition, bool) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 3 {
+		return changePosition{}, false
+	}
+	if _, ok := f.eventTypes[parts[0]]; !ok {
+		return changePosition{}, false
+	}
+	partition, err := strconv.Atoi(parts[1])
+	if err != nil || partition < 0 {
+		return changePosition{}, false
+	}
+	offset, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || offset < 0 {
+		return changePosition{}, false
+	}
+	return changePosition{topic: parts[0], partition: partition, offset: offset}, true
+}
+
+// resume queue the changes read from kafka since position to sub, registered replaying, then the changes broadcast
+// meanwhile. When kafka can not replay them sub is not resumed, it only gets the changes broadcast meanwhile
+func (f *ArticleChangeFeed) resume(ctx context.Context, sub *articleSubscription, position changePosition) (domain.ArticleSubscription, error) {
+	replay, replayed, err := f.readSince(ctx, position)
+	if err != nil {
+		f.zapLogger.WarnMsg("ArticleChangeFeed.readSince", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subscribers[sub]; !ok {
+		// the feed stopped or sub fell behind while kafka was read, its changes are closed
+		if f.stopped {
+			return nil, domain.ErrStreamUnavailable
+		}
+		return sub, nil
+	}
+
+	var pending []domain.ArticleChange
+	if err == nil {
+		sub.resumed = true
+		sub.replayed = replayed
+		for _, change := range replay {
+			if sub.matches(change) {
+				pending = append(pending, change)
+			}
+		}
+	}
+	for _, change := range sub.held {
+		if !sub.isReplayed(change) {
+			pending = append(pending, change)
+		}
+	}
+
+	// nothing was sent on the channel of the registration yet, nobody reads it before Subscribe returns
+	sub.changes = make(chan domain.ArticleChange, f.bufferSize+len(pending))
+	for _, change := range pending {
+		sub.changes <- change
+	}
+	sub.replaying = false
+	sub.held = nil
+	return sub, nil
+}
+
+// readSince read the changes following position from kafka, ordered by time. The partition of position is read
+// after its offset, every other partition of the topics of the feed from the time of its message; each partition
+// up to its last offset when it is looked up, which is returned per topic:partition
+func (f *ArticleChangeFeed) readSince(ctx context.Context, position changePosition) ([]domain.ArticleChange, map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, resumeTimeout)
+	defer cancel()
+
+	config := f.reader.Config()
+	dialer := config.Dialer
+	if dialer == nil {
+		dialer = kafka.DefaultDialer
+	}
+
+	first, end, err := f.partitionOffsets(ctx, dialer, config.Brokers, position.topic, position.partition, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if position.offset < first || position.offset >= end {
+		return nil, nil, errors.Errorf("offset %d of %s is not retained, the partition holds %d to %d", position.offset, position.partitionKey(), first, end-1)
+	}
+
+	var messages []kafka.Message
+	read := func(topic string, partition int, start, end int64) error {
+		if end-start+int64(len(messages)) > int64(f.resumeLimit)+1 {
+			return errors.Errorf("more than %d changes to replay", f.resumeLimit)
+		}
+		read, err := f.readPartition(ctx, config, topic, partition, start, end)
+		messages = append(messages, read...)
+		return err
+	}
+
+	// the message of position itself gives the time the other partitions are read from
+	if err := read(position.topic, position.partition, position.offset, end); err != nil {
+		return nil, nil, err
+	}
+	since := messages[0].Time
+	messages = messages[1:]
+	replayed := map[string]int64{position.partitionKey(): end - 1}
+
+	topics := make([]string, 0, len(f.eventTypes))
+	for topic := range f.eventTypes {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		partitions, err := f.lookupPartitions(ctx, dialer, config.Brokers, topic)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, partition := range partitions {
+			key := changePosition{topic: topic, partition: partition}.partitionKey()
+			if key == position.partitionKey() {
+				continue
+			}
+			start, end, err := f.partitionOffsets(ctx, dialer, config.Brokers, topic, partition, &since)
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := read(topic, partition, start, end); err != nil {
+				return nil, nil, err
+			}
+			replayed[key] = end - 1
+		}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Time.Before(messages[j].Time)
+	})
+	changes := make([]domain.ArticleChange, 0, len(messages))
+	for _, m := range messages {
+		change, err := f.decode(m)
+		if err != nil {
+			f.zapLogger.WarnMsg("ArticleChangeFeed.decode", err)
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, replayed, nil
+}
+
+// readPartition the messages of the partition from offset start to end excluded, with a partition reader
+func (f *ArticleChangeFeed) readPartition(ctx context.Context, config kafka.ReaderConfig, topic string, partition int, start, end int64) ([]kafka.Message, error) {
+	if start >= end {
+		return nil, nil
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   config.Brokers,
+		Topic:     topic,
+		Partition: partition,
+		Dialer:    config.Dialer,
+		MinBytes:  1,
+		MaxBytes:  config.MaxBytes,
+		MaxWait:   config.MaxWait,
+	})
+	defer reader.Close() // nolint: errcheck
+
+	if err := reader.SetOffset(start); err != nil {
+		return nil, errors.Wrap(err, "reader.SetOffset")
+	}
+	messages := make([]kafka.Message, 0, end-start)
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return messages, errors.Wrapf(err, "%s:%d", topic, partition)
+		}
+		messages = append(messages, m)
+		if m.Offset >= end-1 {
+			return messages, nil
+		}
+	}
+}
+
+// partitionOffsets the first offset of the partition, or the first one at since or later when it is given,
+// and the offset the next message will be written at
+func (f *ArticleChangeFeed) partitionOffsets(ctx context.Context, dialer *kafka.Dialer, brokers []string, topic string, partition int, since *time.Time) (int64, int64, error) {
+	var err error
+	for _, broker := range brokers {
+		var conn *kafka.Conn
+		conn, err = dialer.DialLeader(ctx, "tcp", broker, topic, partition)
+		if err != nil {
+			continue
+		}
+		defer conn.Close() // nolint: errcheck
+
+		first, end, err := conn.ReadOffsets()
+		if err != nil || since == nil {
+			return first, end, err
+		}
+		start, err := conn.ReadOffset(*since)
+		if err != nil {
+			return 0, 0, err
+		}
+		// no message since then is answered as an offset out of the partition
+		if start < first || start > end {
+			start = end
+		}
+		return start, end, nil
+	}
+	return 0, 0, errors.Wrapf(err, "DialLeader %s:%d", topic, partition)
+}
+
+func (f *ArticleChangeFeed) lookupPartitions(ctx context.Context, dialer *kafka.Dialer, brokers []string, topic string) ([]int, error) {
+	var err error
+	for _, broker := range brokers {
+		var partitions []kafka.Partition
+		partitions, err = dialer.LookupPartitions(ctx, "tcp", broker, topic)
+		if err != nil {
+			continue
+		}
+		ids := make([]int, 0, len(partitions))
+		for _, partition := range partitions {
+			ids = append(ids, partition.ID)
+		}
+		return ids, nil
+	}
+	return nil, errors.Wrapf(err, "LookupPartitions %s", topic)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_writer"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+type writerArticleRepository struct {
+	zapLogger zaplogger.Logger
+	wsClient  writerService.WriterServiceClient
+}
+
+func NewWriterArticleRepository(wsClient writerService.WriterServiceClient, zapLogger zaplogger.Logger) domain.WriterArticleRepository {
+	return &writerArticleRepository{
+		wsClient:  wsClient,
+		zapLogger: zapLogger,
+	}
+}
+
+func (w writerArticleRepository) ValidateCreate(ctx context.Context, command domain.CreateArticleCommand) error {
+	res, err := w.wsClient.ValidateCreateArticle(ctx, createArticleReq(command))
+	if err != nil {
+		return mapError(err)
+	}
+	if !res.GetValid() {
+		return &domain.CommandRejectedError{Reasons: res.GetReasons()}
+	}
+	return nil
+}
+
+func (w writerArticleRepository) CreateSync(ctx context.Context, command domain.CreateArticleCommand) (*writerService.CreateArticleSyncRes, error) {
+	res, err := w.wsClient.CreateArticleSync(ctx, createArticleReq(command))
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if len(res.GetReasons()) > 0 {
+		return nil, &domain.CommandRejectedError{Reasons: res.GetReasons()}
+	}
+	return res, nil
+}
+
+func createArticleReq(command domain.CreateArticleCommand) *writerService.CreateArticleReq {
+	return &writerService.CreateArticleReq{
+		Author:   command.Author,
+		Title:    command.Title,
+		Body:     command.Body,
+		Tags:     command.Tags,
+		Category: command.Category,
+	}
+}
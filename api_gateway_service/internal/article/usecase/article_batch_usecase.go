@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/google/uuid"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+)
+
+// batchJobStallTimeouts context timeouts after which a job in progress that is not saved is reported interrupted
+const batchJobStallTimeouts = 3
+
+func (a articleUseCase) ImportArticles(beegoCtx *beegoContext.Context, items []domain.ArticleBatchItem, lang string) (*domain.ArticleBatchJob, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	job := domain.ArticleBatchJob{
+		ID:        uuid.New().String(),
+		Status:    domain.BatchJobStatusProcessing,
+		Total:     len(items),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Items:     make([]domain.ArticleBatchJobResult, len(items)),
+	}
+
+	valid := make([]domain.ArticleBatchItem, 0, len(items))
+	for i, item := range items {
+		job.Items[i] = domain.ArticleBatchJobResult{
+			Index:  item.Index,
+			Status: domain.BatchItemStatusPending,
+			Title:  item.Request.Title,
+		}
+		if len(item.Errors) > 0 {
+			job.Items[i].Status = domain.BatchItemStatusInvalid
+			job.Items[i].Errors = item.Errors
+			job.Invalid++
+			job.Processed++
+			continue
+		}
+		valid = append(valid, item)
+	}
+	if len(valid) == 0 {
+		job.Status = domain.BatchJobStatusDone
+		job.FinishedAt = &now
+	}
+
+	if err := a.articleBatchRepository.Save(c, job); err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	if len(valid) > 0 {
+		// the import outlives the request, only its tenant is carried over
+		ctx := tenant.WithID(context.Background(), tenant.FromContext(beegoCtx.Request.Context()))
+		go a.createArticleBatch(ctx, job, valid, lang)
+	}
+
+	return &job, nil
+}
+
+// createArticleBatch create the valid items on the writer batchPublishSize at a time and save the progress of job
+// after each chunk, every item reports what the writer answered for it
+func (a articleUseCase) createArticleBatch(ctx context.Context, job domain.ArticleBatchJob, valid []domain.ArticleBatchItem, lang string) {
+	// items are kept in the order of the import, valid[i].Index points to job.Items
+	position := make(map[int]int, len(job.Items))
+	for i := range job.Items {
+		position[job.Items[i].Index] = i
+	}
+
+	size := a.batchPublishSize
+	if size <= 0 {
+		size = len(valid)
+	}
+
+	for start := 0; start < len(valid); start += size {
+		end := start + size
+		if end > len(valid) {
+			end = len(valid)
+		}
+
+		// the items of a chunk are sent concurrently, each one writes its own result
+		var wg sync.WaitGroup
+		for _, item := range valid[start:end] {
+			wg.Add(1)
+			go func(item domain.ArticleBatchItem, result *domain.ArticleBatchJobResult) {
+				defer wg.Done()
+				a.createArticleBatchItem(ctx, job.ID, item, result, lang)
+			}(item, &job.Items[position[item.Index]])
+		}
+		wg.Wait()
+
+		for _, item := range valid[start:end] {
+			switch job.Items[position[item.Index]].Status {
+			case domain.BatchItemStatusCreated:
+				job.Created++
+			case domain.BatchItemStatusRejected:
+				job.Rejected++
+			default:
+				job.Failed++
+			}
+		}
+		job.Processed += end - start
+		job.UpdatedAt = time.Now().UTC()
+		if job.Processed == job.Total {
+			job.Status = domain.BatchJobStatusDone
+			job.FinishedAt = &job.UpdatedAt
+		}
+
+		c, cancel := context.WithTimeout(ctx, a.contextTimeout)
+		if err := a.articleBatchRepository.Save(c, job); err != nil {
+			a.zapLogger.Errorf("createArticleBatch save job %s: %v", job.ID, err)
+		}
+		cancel()
+	}
+}
+
+// createArticleBatchItem create the item with the sync create of the writer and record its outcome in result
+func (a articleUseCase) createArticleBatchItem(ctx context.Context, jobID string, item domain.ArticleBatchItem, result *domain.ArticleBatchJobResult, lang string) {
+	c, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	created, err := a.articleWriterRepository.CreateSync(c, item.Request.ToCreateArticleCommand())
+	if err != nil {
+		var rejected *domain.CommandRejectedError
+		if errors.As(err, &rejected) {
+			result.Status = domain.BatchItemStatusRejected
+			result.Errors = rejected.FieldErrors(lang)
+			return
+		}
+		a.zapLogger.Errorf("createArticleBatch job %s item %d: %v", jobID, item.Index, err)
+		result.Status = domain.BatchItemStatusFailed
+		return
+	}
+
+	result.Status = domain.BatchItemStatusCreated
+	result.ArticleID = int(created.GetID())
+}
+
+func (a articleUseCase) GetArticleBatchJob(beegoCtx *beegoContext.Context, id string) (*domain.ArticleBatchJob, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	job, err := a.articleBatchRepository.Get(c, id)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	// every chunk is saved within two timeouts, a job left unsaved longer lost the gateway sending it
+	if job.Status == domain.BatchJobStatusProcessing && time.Since(job.UpdatedAt) > batchJobStallTimeouts*a.contextTimeout {
+		interruptArticleBatch(job)
+	}
+
+	return job, nil
+}
+
+// interruptArticleBatch fail the items the job did not send, they can be imported again
+func interruptArticleBatch(job *domain.ArticleBatchJob) {
+	for i := range job.Items {
+		if job.Items[i].Status == domain.BatchItemStatusPending {
+			job.Items[i].Status = domain.BatchItemStatusFailed
+			job.Failed++
+			job.Processed++
+		}
+	}
+	job.Status = domain.BatchJobStatusInterrupted
+	job.FinishedAt = &job.UpdatedAt
+}
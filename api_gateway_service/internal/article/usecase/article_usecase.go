@@ -14,15 +14,27 @@ type articleUseCase struct {
 	contextTimeout           time.Duration
 	articleCommandRepository domain.CommandArticleRepository
 	articleQueriesRepository domain.QueriesArticleRepository
+	articleWriterRepository  domain.WriterArticleRepository
+	articleBatchRepository   domain.BatchJobArticleRepository
+	articleStreamRepository  domain.StreamArticleRepository
+	batchPublishSize         int
 }
 
 func NewArticleUseCase(timeout time.Duration,
 	zapLogger zaplogger.Logger,
 	articleCommandRepository domain.CommandArticleRepository,
-	articleQueriesRepository domain.QueriesArticleRepository) domain.ArticleUseCase {
+	articleQueriesRepository domain.QueriesArticleRepository,
+	articleWriterRepository domain.WriterArticleRepository,
+	articleBatchRepository domain.BatchJobArticleRepository,
+	articleStreamRepository domain.StreamArticleRepository,
+	batchPublishSize int) domain.ArticleUseCase {
 	return &articleUseCase{
 		articleCommandRepository: articleCommandRepository,
 		articleQueriesRepository: articleQueriesRepository,
+		articleWriterRepository:  articleWriterRepository,
+		articleBatchRepository:   articleBatchRepository,
+		articleStreamRepository:  articleStreamRepository,
+		batchPublishSize:         batchPublishSize,
 		contextTimeout:           timeout,
 		zapLogger:                zapLogger,
 	}
@@ -41,29 +53,182 @@ func (a articleUseCase) CreateArticle(beegoCtx *beegoContext.Context, body domai
 	return nil
 }
 
-func (a articleUseCase) GetArticles(beegoCtx *beegoContext.Context, page int, size int, search string, author string) (*domain.ArticlePaginationResponse, error) {
+func (a articleUseCase) CreateArticleSync(beegoCtx *beegoContext.Context, body domain.CreateArticleRequest) (*domain.CreateArticleSyncResponse, error) {
 	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
 	defer cancel()
 
-	result := new(domain.ArticlePaginationResponse)
+	created, err := a.articleWriterRepository.CreateSync(c, body.ToCreateArticleCommand())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToCreateArticleSyncResponse(created), nil
+}
+
+func (a articleUseCase) ValidateCreateArticle(beegoCtx *beegoContext.Context, body domain.CreateArticleRequest) error {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	err := a.articleWriterRepository.ValidateCreate(c, body.ToCreateArticleCommand())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return err
+	}
 
-	list, err := a.articleQueriesRepository.Search(c, page, size, search, author)
+	return nil
+}
+
+// SubscribeArticleChanges the subscription lasts as long as the request, it is not bound to the context timeout
+func (a articleUseCase) SubscribeArticleChanges(beegoCtx *beegoContext.Context, filter domain.ArticleStreamFilter, lastEventID string) (domain.ArticleSubscription, error) {
+	subscription, err := a.articleStreamRepository.Subscribe(beegoCtx.Request.Context(), filter, lastEventID)
 	if err != nil {
 		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
 		return nil, err
 	}
 
+	return subscription, nil
+}
+
+func (a articleUseCase) GetArticles(beegoCtx *beegoContext.Context, page int, size int, filter domain.SearchArticleFilter) (*domain.ArticlePaginationResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	result := new(domain.ArticlePaginationResponse)
+
+	list, err := a.articleQueriesRepository.Search(c, page, size, filter)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+
+		// serve the last known response while the reader is unavailable
+		stale, cachedAt, staleErr := a.articleQueriesRepository.SearchStale(beegoCtx.Request.Context(), page, size, filter)
+		if staleErr != nil {
+			return nil, err
+		}
+		a.zapLogger.Warnf("GetArticles serving stale response cached at %s: %v", cachedAt, err)
+
+		result = result.ToArticlePaginationResponse(stale)
+		result.Stale = true
+		result.CachedAt = cachedAt
+		for i := range stale.Articles {
+			result.Articles = append(result.Articles, domain.ToArticleResponse(stale.Articles[i]))
+		}
+		return result, nil
+	}
+
 	result = result.ToArticlePaginationResponse(list)
 	for i := range list.Articles {
-		result.Articles = append(result.Articles, &domain.ArticleResponse{
-			ID:        int(list.Articles[i].ID),
-			Author:    list.Articles[i].Author,
-			Title:     list.Articles[i].Title,
-			Body:      list.Articles[i].Body,
-			CreatedAt: list.Articles[i].CreatedAt.AsTime(),
-			UpdatedAt: list.Articles[i].UpdatedAt.AsTime(),
-		})
+		result.Articles = append(result.Articles, domain.ToArticleResponse(list.Articles[i]))
 	}
 
 	return result, nil
 }
+
+func (a articleUseCase) GetArticleById(beegoCtx *beegoContext.Context, id int) (*domain.ArticleResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	article, err := a.articleQueriesRepository.GetById(c, id)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToArticleResponse(article), nil
+}
+
+func (a articleUseCase) UpdateArticle(beegoCtx *beegoContext.Context, id int, expectedVersion int, body domain.UpdateArticleRequest) error {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	err := a.articleCommandRepository.Update(c, body.ToUpdateArticleCommand(id, expectedVersion))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a articleUseCase) TransitionArticle(beegoCtx *beegoContext.Context, id int, action string, expectedVersion int, body domain.TransitionArticleRequest) error {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	err := a.articleCommandRepository.Transition(c, body.ToTransitionArticleCommand(id, action, expectedVersion))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a articleUseCase) TagArticle(beegoCtx *beegoContext.Context, id int, action string, expectedVersion int, body domain.TagArticleRequest) error {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	err := a.articleCommandRepository.Tag(c, body.ToTagArticleCommand(id, action, expectedVersion))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a articleUseCase) ListTags(beegoCtx *beegoContext.Context, prefix string, size int) ([]*domain.TagCountResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	tags, err := a.articleQueriesRepository.ListTags(c, prefix, size)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToTagCountResponses(tags), nil
+}
+
+func (a articleUseCase) GetArticleRevisions(beegoCtx *beegoContext.Context, id int) ([]*domain.ArticleRevisionResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	revisions, err := a.articleQueriesRepository.GetRevisions(c, id)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	result := make([]*domain.ArticleRevisionResponse, 0, len(revisions))
+	for i := range revisions {
+		result = append(result, domain.ToArticleRevisionResponse(revisions[i]))
+	}
+
+	return result, nil
+}
+
+func (a articleUseCase) GetArticleRevision(beegoCtx *beegoContext.Context, id int, revision int) (*domain.ArticleRevisionResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	result, err := a.articleQueriesRepository.GetRevision(c, id, revision)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToArticleRevisionResponse(result), nil
+}
+
+func (a articleUseCase) DiffArticleRevisions(beegoCtx *beegoContext.Context, id int, from int, to int) (*domain.ArticleRevisionDiffResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	diff, err := a.articleQueriesRepository.DiffRevisions(c, id, from, to)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToArticleRevisionDiffResponse(diff), nil
+}
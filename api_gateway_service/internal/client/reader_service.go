@@ -7,6 +7,8 @@ import (
 	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
 	"github.com/pkg/errors"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/interceptors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 )
@@ -16,19 +18,25 @@ const (
 	backoffRetries = 3
 )
 
-func NewReaderServiceConn(ctx context.Context, grpcHost string, im interceptors.InterceptorManager) (*grpc.ClientConn, error) {
+// NewReaderServiceConn the retries run inside the policy so an open circuit breaker is not retried
+// and the policy timeout bounds every attempt of a call
+func NewReaderServiceConn(ctx context.Context, grpcHost string, im interceptors.InterceptorManager, policy *resilience.Policy) (*grpc.ClientConn, error) {
 	opts := []grpc_retry.CallOption{
 		grpc_retry.WithBackoff(grpc_retry.BackoffLinear(backoffLinear)),
-		grpc_retry.WithCodes(codes.NotFound, codes.Aborted),
+		grpc_retry.WithCodes(codes.Unavailable, codes.Aborted),
 		grpc_retry.WithMax(backoffRetries),
 	}
 
 	readerServiceConn, err := grpc.DialContext(
 		ctx,
 		grpcHost,
-		grpc.WithUnaryInterceptor(im.ClientRequestLoggerInterceptor()),
 		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(grpc_retry.UnaryClientInterceptor(opts...)),
+		grpc.WithChainUnaryInterceptor(
+			im.ClientRequestLoggerInterceptor(),
+			tenant.UnaryClientInterceptor(),
+			resilience.UnaryClientInterceptor(policy),
+			grpc_retry.UnaryClientInterceptor(opts...),
+		),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "grpc.DialContext")
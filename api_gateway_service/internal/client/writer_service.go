@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/interceptors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"google.golang.org/grpc"
+)
+
+// NewWriterServiceConn the calls are not retried, a creation retried after a timeout could create the article twice
+func NewWriterServiceConn(ctx context.Context, grpcHost string, im interceptors.InterceptorManager, policy *resilience.Policy) (*grpc.ClientConn, error) {
+	writerServiceConn, err := grpc.DialContext(
+		ctx,
+		grpcHost,
+		grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(
+			im.ClientRequestLoggerInterceptor(),
+			tenant.UnaryClientInterceptor(),
+			resilience.UnaryClientInterceptor(policy),
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "grpc.DialContext")
+	}
+
+	return writerServiceConn, nil
+}
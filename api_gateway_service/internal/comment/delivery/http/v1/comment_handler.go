@@ -0,0 +1,281 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/validator"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+type CommentHandler struct {
+	ZapLogger zaplogger.Logger
+	internal.BaseController
+	response.ApiResponse
+	CommentUsecase domain.CommentUseCase
+}
+
+func NewCommentHandler(commentUsecase domain.CommentUseCase, zapLogger zaplogger.Logger) {
+	pHandler := &CommentHandler{
+		ZapLogger:      zapLogger,
+		CommentUsecase: commentUsecase,
+	}
+	beego.Router("/api/v1/articles/:id/comments", pHandler, "post:CreateComment")
+	beego.Router("/api/v1/articles/:id/comments", pHandler, "get:ListComments")
+	beego.Router("/api/v1/articles/:id/comments/:commentId", pHandler, "put:EditComment")
+	beego.Router("/api/v1/articles/:id/comments/:commentId", pHandler, "delete:DeleteComment")
+	beego.Router("/api/v1/articles/:id/comments/:commentId/moderate", pHandler, "post:ModerateComment")
+}
+
+func (h *CommentHandler) Prepare() {
+	// check user access when needed
+	h.SetLangVersion()
+}
+
+// CreateComment
+// @Title Create Comment
+// @Tags Comment
+// @Summary Comment On An Article
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.CreateCommentRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.CreateCommentRequest true "request payload"
+// @Router /v1/articles/{id}/comments [post]
+func (h *CommentHandler) CreateComment() {
+	articleID, ok := h.pathID(":id")
+	if !ok {
+		return
+	}
+
+	var request domain.CreateCommentRequest
+	if !h.bindRequest(&request) {
+		return
+	}
+
+	if err := h.CommentUsecase.CreateComment(h.Ctx, articleID, request); err != nil {
+		h.responseCommandError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), request)
+	return
+}
+
+// ListComments
+// @Title List Comments
+// @Tags Comment
+// @Summary List The Visible Comments Of An Article, the oldest first
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param size query int false "size"
+// @Param page query int false "page"
+// @Success 200 {object} swagger.BaseResponse{data=domain.CommentPaginationResponse,errors=[]object}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @router /v1/articles/{id}/comments [get]
+func (h *CommentHandler) ListComments() {
+	articleID, ok := h.pathID(":id")
+	if !ok {
+		return
+	}
+
+	pageSize, page, err := domain.PaginationQueryParamValidation(h.Ctx.Input.Query("size"), h.Ctx.Input.Query("page"))
+	if err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.QueryParamInvalidCode, response.ErrorCodeText(response.QueryParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	result, err := h.CommentUsecase.ListComments(h.Ctx, articleID, page, pageSize)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+			return
+		}
+		if errors.Is(err, domain.ErrServiceUnavailable) {
+			h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+			return
+		}
+		h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// EditComment
+// @Title Edit Comment
+// @Tags Comment
+// @Summary Replace The Body Of A Comment
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param commentId path int true "comment id"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.EditCommentRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.EditCommentRequest true "request payload"
+// @Router /v1/articles/{id}/comments/{commentId} [put]
+func (h *CommentHandler) EditComment() {
+	articleID, ok := h.pathID(":id")
+	if !ok {
+		return
+	}
+	id, ok := h.pathID(":commentId")
+	if !ok {
+		return
+	}
+
+	var request domain.EditCommentRequest
+	if !h.bindRequest(&request) {
+		return
+	}
+
+	if err := h.CommentUsecase.EditComment(h.Ctx, articleID, id, request); err != nil {
+		h.responseCommandError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), request)
+	return
+}
+
+// DeleteComment
+// @Title Delete Comment
+// @Tags Comment
+// @Summary Delete A Comment, its body is erased
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param commentId path int true "comment id"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.DeleteCommentRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.DeleteCommentRequest true "request payload"
+// @Router /v1/articles/{id}/comments/{commentId} [delete]
+func (h *CommentHandler) DeleteComment() {
+	articleID, ok := h.pathID(":id")
+	if !ok {
+		return
+	}
+	id, ok := h.pathID(":commentId")
+	if !ok {
+		return
+	}
+
+	var request domain.DeleteCommentRequest
+	if !h.bindRequest(&request) {
+		return
+	}
+
+	if err := h.CommentUsecase.DeleteComment(h.Ctx, articleID, id, request); err != nil {
+		h.responseCommandError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), request)
+	return
+}
+
+// ModerateComment
+// @Title Moderate Comment
+// @Tags Comment
+// @Summary Hide A Comment Or Make It Visible Again
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "article id"
+// @Param commentId path int true "comment id"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.ModerateCommentRequest}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.ModerateCommentRequest true "request payload"
+// @Router /v1/articles/{id}/comments/{commentId}/moderate [post]
+func (h *CommentHandler) ModerateComment() {
+	articleID, ok := h.pathID(":id")
+	if !ok {
+		return
+	}
+	id, ok := h.pathID(":commentId")
+	if !ok {
+		return
+	}
+
+	var request domain.ModerateCommentRequest
+	if !h.bindRequest(&request) {
+		return
+	}
+
+	if err := h.CommentUsecase.ModerateComment(h.Ctx, articleID, id, request); err != nil {
+		h.responseCommandError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), request)
+	return
+}
+
+// pathID positive id of the path param, the bad request is responded when it is not
+func (h *CommentHandler) pathID(param string) (int, bool) {
+	id, err := strconv.Atoi(h.Ctx.Input.Param(param))
+	if err != nil || id < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return 0, false
+	}
+	return id, true
+}
+
+// bindRequest decode and validate the request payload, the bad request is responded when it is invalid
+func (h *CommentHandler) bindRequest(request interface{}) bool {
+	if err := h.BindJSON(request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return false
+	}
+	if err := validator.Validate.ValidateStruct(request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return false
+	}
+	return true
+}
+
+// responseCommandError respond the error of a command sent to the writer service
+func (h *CommentHandler) responseCommandError(err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+		return
+	}
+	if errors.Is(err, events.ErrSchemaValidation) {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return
+	}
+	if errors.Is(err, domain.ErrServiceUnavailable) {
+		h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+type commandCommentRepository struct {
+	zapLogger       zaplogger.Logger
+	producer        kafkaClient.Producer
+	codec           kafkaClient.Codec
+	confKafkaTopics domain.ConfKafkaTopics
+}
+
+func NewCommandCommentRepository(producer kafkaClient.Producer, codec kafkaClient.Codec, confKafkaTopics domain.ConfKafkaTopics, zapLogger zaplogger.Logger) domain.CommandCommentRepository {
+	return &commandCommentRepository{
+		producer:        producer,
+		codec:           codec,
+		confKafkaTopics: confKafkaTopics,
+		zapLogger:       zapLogger,
+	}
+}
+
+func (m commandCommentRepository) Create(ctx context.Context, command domain.CreateCommentCommand) error {
+	return m.publish(ctx, m.confKafkaTopics.CreateComment, events.CommentCreateType, command)
+}
+
+func (m commandCommentRepository) Edit(ctx context.Context, command domain.EditCommentCommand) error {
+	return m.publish(ctx, m.confKafkaTopics.EditComment, events.CommentEditType, command)
+}
+
+func (m commandCommentRepository) Delete(ctx context.Context, command domain.DeleteCommentCommand) error {
+	return m.publish(ctx, m.confKafkaTopics.DeleteComment, events.CommentDeleteType, command)
+}
+
+func (m commandCommentRepository) Moderate(ctx context.Context, command domain.ModerateCommentCommand) error {
+	return m.publish(ctx, m.confKafkaTopics.ModerateComment, events.CommentModerateType, command)
+}
+
+func (m commandCommentRepository) publish(ctx context.Context, topic string, eventType string, command interface{}) error {
+	msg, err := events.Default.EncodeMessage(m.codec, topic, eventType, command)
+	if err != nil {
+		return err
+	}
+	err = m.producer.PublishMessage(ctx, msg)
+	if err != nil {
+		if resilience.IsRejected(err) {
+			return errors.Wrap(domain.ErrServiceUnavailable, err.Error())
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	readerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_reader"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type queriesCommentRepository struct {
+	zapLogger zaplogger.Logger
+	crsClient readerService.CommentReaderServiceClient
+}
+
+func NewQueriesCommentRepository(crsClient readerService.CommentReaderServiceClient, zapLogger zaplogger.Logger) domain.QueriesCommentRepository {
+	return &queriesCommentRepository{
+		crsClient: crsClient,
+		zapLogger: zapLogger,
+	}
+}
+
+func (q queriesCommentRepository) List(ctx context.Context, articleID int, page int, size int) (*readerService.ListCommentsRes, error) {
+	res, err := q.crsClient.ListComments(ctx, &readerService.ListCommentsReq{
+		ArticleID: int32(articleID),
+		Page:      int64(page),
+		Size:      int64(size),
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return res, nil
+}
+
+// mapError the reader being unreachable, overloaded or behind an open circuit breaker is reported as domain.ErrServiceUnavailable
+func mapError(err error) error {
+	if status.Code(err) == codes.Unavailable {
+		return errors.Wrap(domain.ErrServiceUnavailable, err.Error())
+	}
+	return err
+}
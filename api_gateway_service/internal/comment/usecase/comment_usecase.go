@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+type commentUseCase struct {
+	zapLogger                zaplogger.Logger
+	contextTimeout           time.Duration
+	commentCommandRepository domain.CommandCommentRepository
+	commentQueriesRepository domain.QueriesCommentRepository
+}
+
+func NewCommentUseCase(timeout time.Duration,
+	zapLogger zaplogger.Logger,
+	commentCommandRepository domain.CommandCommentRepository,
+	commentQueriesRepository domain.QueriesCommentRepository) domain.CommentUseCase {
+	return &commentUseCase{
+		commentCommandRepository: commentCommandRepository,
+		commentQueriesRepository: commentQueriesRepository,
+		contextTimeout:           timeout,
+		zapLogger:                zapLogger,
+	}
+}
+
+func (a commentUseCase) CreateComment(beegoCtx *beegoContext.Context, articleID int, body domain.CreateCommentRequest) error {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	err := a.commentCommandRepository.Create(c, body.ToCreateCommentCommand(articleID))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a commentUseCase) ListComments(beegoCtx *beegoContext.Context, articleID int, page int, size int) (*domain.CommentPaginationResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	list, err := a.commentQueriesRepository.List(c, articleID, page, size)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToCommentPaginationResponse(list), nil
+}
+
+func (a commentUseCase) EditComment(beegoCtx *beegoContext.Context, articleID int, id int, body domain.EditCommentRequest) error {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	err := a.commentCommandRepository.Edit(c, body.ToEditCommentCommand(articleID, id))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a commentUseCase) DeleteComment(beegoCtx *beegoContext.Context, articleID int, id int, body domain.DeleteCommentRequest) error {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	err := a.commentCommandRepository.Delete(c, body.ToDeleteCommentCommand(articleID, id))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	return nil
+}
+
+func (a commentUseCase) ModerateComment(beegoCtx *beegoContext.Context, articleID int, id int, body domain.ModerateCommentRequest) error {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	err := a.commentCommandRepository.Moderate(c, body.ToModerateCommentCommand(articleID, id))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return err
+	}
+
+	return nil
+}
@@ -2,36 +2,167 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	beegoContext "github.com/beego/beego/v2/server/web/context"
 	readerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_reader"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_writer"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
 )
 
+var (
+	ErrArticleNotFound  = errors.New("article not found")
+	ErrRevisionNotFound = errors.New("article revision not found")
+	// ErrServiceUnavailable the downstream service is down or its circuit breaker is open
+	ErrServiceUnavailable = errors.New("service unavailable")
+	ErrNoStaleData        = errors.New("no stale data")
+)
+
+// modes of the article creation, the command goes through kafka unless the request asks otherwise
+const (
+	CreateModeAsync = "async"
+	// CreateModeSync the writer creates the article right away and answers its id
+	CreateModeSync = "sync"
+	// CreateModeValidate the writer checks the command against its business rules without creating anything
+	CreateModeValidate = "validate"
+)
+
+// CommandRejectedError rules of the writer the command breaks
+type CommandRejectedError struct {
+	Reasons []*writerService.RejectionReason
+}
+
+func (e *CommandRejectedError) Error() string {
+	return "command rejected by the writer"
+}
+
+// FieldErrors the reasons in lang, english when the writer gave none in lang
+func (e *CommandRejectedError) FieldErrors(lang string) []response.Errors {
+	list := make([]response.Errors, 0, len(e.Reasons))
+	for _, reason := range e.Reasons {
+		message, ok := reason.GetMessages()[lang]
+		if !ok {
+			message = reason.GetMessages()["en"]
+		}
+		list = append(list, response.Errors{Field: reason.GetField(), Description: message})
+	}
+	return list
+}
+
 type CreateArticleCommand struct {
-	ID     int    `json:"id"`
-	Author string `json:"author"`
-	Title  string `json:"title"`
-	Body   string `json:"body"`
+	ID       int      `json:"id"`
+	Author   string   `json:"author"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Tags     []string `json:"tags"`
+	Category string   `json:"category,omitempty"`
+}
+
+// UpdateArticleCommand title, body and category are left empty when they do not change,
+// ExpectedVersion is the version of the article the command is based on, 0 updates any version
+type UpdateArticleCommand struct {
+	ID              int    `json:"id"`
+	ChangedBy       string `json:"changed_by"`
+	ExpectedVersion int    `json:"expected_version,omitempty"`
+	Title           string `json:"title,omitempty"`
+	Body            string `json:"body,omitempty"`
+	Category        string `json:"category,omitempty"`
+}
+
+// TransitionArticleCommand action is one of the events.ArticleAction, a publish with PublishAt in the future is scheduled,
+// ExpectedVersion as in UpdateArticleCommand
+type TransitionArticleCommand struct {
+	ID              int        `json:"id"`
+	Action          string     `json:"action"`
+	ChangedBy       string     `json:"changed_by"`
+	ExpectedVersion int        `json:"expected_version,omitempty"`
+	PublishAt       *time.Time `json:"publish_at,omitempty"`
+}
+
+// TagArticleCommand action is events.ArticleTagActionAdd or events.ArticleTagActionRemove,
+// ExpectedVersion as in UpdateArticleCommand
+type TagArticleCommand struct {
+	ID              int      `json:"id"`
+	Action          string   `json:"action"`
+	ChangedBy       string   `json:"changed_by"`
+	ExpectedVersion int      `json:"expected_version,omitempty"`
+	Tags            []string `json:"tags"`
+}
+
+// SearchArticleFilter filters of an article search, only the published articles are returned when Statuses is empty
+type SearchArticleFilter struct {
+	Search   string
+	Author   string
+	Statuses []string
+	// Tags the returned articles carry every tag
+	Tags []string
+	// Category the articles of its subcategories are returned as well
+	Category string
 }
 
 type ConfKafkaTopics struct {
-	CreateArticle string
+	CreateArticle     string
+	UpdateArticle     string
+	TransitionArticle string
+	TagArticle        string
+	CreateComment     string
+	EditComment       string
+	DeleteComment     string
+	ModerateComment   string
 }
 
 // ArticleUseCase UseCase Interface
 type ArticleUseCase interface {
 	CreateArticle(beegoCtx *beegoContext.Context, body CreateArticleRequest) error
-	GetArticles(beegoCtx *beegoContext.Context, page int, size int, search string, author string) (*ArticlePaginationResponse, error)
+	// ImportArticles start the job creating the valid items on the writer in batches, the job is answered before it is done.
+	// The rules the writer rejects an item for are reported in lang
+	ImportArticles(beegoCtx *beegoContext.Context, items []ArticleBatchItem, lang string) (*ArticleBatchJob, error)
+	GetArticleBatchJob(beegoCtx *beegoContext.Context, id string) (*ArticleBatchJob, error)
+	// SubscribeArticleChanges changes of the articles of the tenant of the request, the caller closes the subscription
+	SubscribeArticleChanges(beegoCtx *beegoContext.Context, filter ArticleStreamFilter, lastEventID string) (ArticleSubscription, error)
+	// CreateArticleSync create the article on the writer right away, a CommandRejectedError holds the broken rules
+	CreateArticleSync(beegoCtx *beegoContext.Context, body CreateArticleRequest) (*CreateArticleSyncResponse, error)
+	// ValidateCreateArticle dry run of the creation on the writer, a CommandRejectedError holds the broken rules
+	ValidateCreateArticle(beegoCtx *beegoContext.Context, body CreateArticleRequest) error
+	GetArticles(beegoCtx *beegoContext.Context, page int, size int, filter SearchArticleFilter) (*ArticlePaginationResponse, error)
+	GetArticleById(beegoCtx *beegoContext.Context, id int) (*ArticleResponse, error)
+	// UpdateArticle expectedVersion is the version of the article the command is based on, 0 updates any version
+	UpdateArticle(beegoCtx *beegoContext.Context, id int, expectedVersion int, body UpdateArticleRequest) error
+	TransitionArticle(beegoCtx *beegoContext.Context, id int, action string, expectedVersion int, body TransitionArticleRequest) error
+	TagArticle(beegoCtx *beegoContext.Context, id int, action string, expectedVersion int, body TagArticleRequest) error
+	ListTags(beegoCtx *beegoContext.Context, prefix string, size int) ([]*TagCountResponse, error)
+	GetArticleRevisions(beegoCtx *beegoContext.Context, id int) ([]*ArticleRevisionResponse, error)
+	GetArticleRevision(beegoCtx *beegoContext.Context, id int, revision int) (*ArticleRevisionResponse, error)
+	DiffArticleRevisions(beegoCtx *beegoContext.Context, id int, from int, to int) (*ArticleRevisionDiffResponse, error)
 }
 
 // CommandArticleRepository Repository Interface
 type CommandArticleRepository interface {
 	Create(ctx context.Context, command CreateArticleCommand) error
+	Update(ctx context.Context, command UpdateArticleCommand) error
+	Transition(ctx context.Context, command TransitionArticleCommand) error
+	Tag(ctx context.Context, command TagArticleCommand) error
+}
+
+// WriterArticleRepository Repository Interface
+type WriterArticleRepository interface {
+	// ValidateCreate a CommandRejectedError holds the rules command breaks
+	ValidateCreate(ctx context.Context, command CreateArticleCommand) error
+	// CreateSync a CommandRejectedError holds the rules command breaks
+	CreateSync(ctx context.Context, command CreateArticleCommand) (*writerService.CreateArticleSyncRes, error)
 }
 
 // QueriesArticleRepository Repository Interface
 type QueriesArticleRepository interface {
-	Search(ctx context.Context, page int, size int, search string, author string) (*readerService.SearchRes, error)
+	Search(ctx context.Context, page int, size int, filter SearchArticleFilter) (*readerService.SearchRes, error)
+	// SearchStale last response of the search received from the reader and the time it was received
+	SearchStale(ctx context.Context, page int, size int, filter SearchArticleFilter) (*readerService.SearchRes, time.Time, error)
+	ListTags(ctx context.Context, prefix string, size int) ([]*readerService.TagCount, error)
+	GetById(ctx context.Context, id int) (*readerService.Article, error)
+	GetRevisions(ctx context.Context, id int) ([]*readerService.ArticleRevision, error)
+	GetRevision(ctx context.Context, id int, revision int) (*readerService.ArticleRevision, error)
+	DiffRevisions(ctx context.Context, id int, from int, to int) (*readerService.DiffArticleRevisionsRes, error)
 }
 
 // Mapper
@@ -45,3 +176,73 @@ func (a ArticlePaginationResponse) ToArticlePaginationResponse(r *readerService.
 	}
 	return result
 }
+
+func ToArticleResponse(r *readerService.Article) *ArticleResponse {
+	result := &ArticleResponse{
+		ID:           int(r.ID),
+		Status:       r.Status,
+		Tags:         r.Tags,
+		Category:     r.Category,
+		CommentCount: r.CommentCount,
+		Version:      int(r.Version),
+		Author:       r.Author,
+		Title:        r.Title,
+		Body:         r.Body,
+		CreatedAt:    r.CreatedAt.AsTime(),
+		UpdatedAt:    r.UpdatedAt.AsTime(),
+	}
+	if r.PublishAt != nil {
+		publishAt := r.PublishAt.AsTime()
+		result.PublishAt = &publishAt
+	}
+	if r.PublishedAt != nil {
+		publishedAt := r.PublishedAt.AsTime()
+		result.PublishedAt = &publishedAt
+	}
+	return result
+}
+
+func ToArticleRevisionResponse(r *readerService.ArticleRevision) *ArticleRevisionResponse {
+	return &ArticleRevisionResponse{
+		Revision:      int(r.Revision),
+		ChangedBy:     r.ChangedBy,
+		ChangedFields: r.ChangedFields,
+		Status:        r.Status,
+		Tags:          r.Tags,
+		Category:      r.Category,
+		Author:        r.Author,
+		Title:         r.Title,
+		Body:          r.Body,
+		CreatedAt:     r.CreatedAt.AsTime(),
+	}
+}
+
+func ToTagCountResponses(tags []*readerService.TagCount) []*TagCountResponse {
+	result := make([]*TagCountResponse, 0, len(tags))
+	for _, tag := range tags {
+		result = append(result, &TagCountResponse{Name: tag.Name, Count: tag.Count})
+	}
+	return result
+}
+
+func ToArticleRevisionDiffResponse(r *readerService.DiffArticleRevisionsRes) *ArticleRevisionDiffResponse {
+	result := &ArticleRevisionDiffResponse{
+		ID:      int(r.ID),
+		From:    int(r.From),
+		To:      int(r.To),
+		Changes: make([]FieldChangeResponse, 0, len(r.Changes)),
+	}
+	for _, change := range r.Changes {
+		result.Changes = append(result.Changes, FieldChangeResponse{Field: change.Field, From: change.From, To: change.To})
+	}
+	return result
+}
+
+func ToCreateArticleSyncResponse(r *writerService.CreateArticleSyncRes) *CreateArticleSyncResponse {
+	return &CreateArticleSyncResponse{
+		ID:        int(r.GetID()),
+		Version:   int(r.GetVersion()),
+		Status:    r.GetStatus(),
+		CreatedAt: r.GetCreatedAt().AsTime(),
+	}
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	validatorGo "github.com/go-playground/validator/v10"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/validator"
+)
+
+var (
+	ErrBatchJobNotFound = errors.New("article batch job not found")
+	ErrBatchEmpty       = errors.New("article batch is empty")
+	ErrBatchTooLarge    = errors.New("article batch has too many items")
+)
+
+// statuses of an article batch job, the job is done once the writer answered every valid item or it failed.
+// A job is interrupted when the gateway sending it stopped, e.g. on a restart, its pending items are then failed
+const (
+	BatchJobStatusProcessing  = "processing"
+	BatchJobStatusDone        = "done"
+	BatchJobStatusInterrupted = "interrupted"
+)
+
+// statuses of an item of an article batch job, the writer created the created items
+// and rejected the rejected ones, failed items did not reach the writer or got no answer
+const (
+	BatchItemStatusPending  = "pending"
+	BatchItemStatusCreated  = "created"
+	BatchItemStatusRejected = "rejected"
+	BatchItemStatusInvalid  = "invalid"
+	BatchItemStatusFailed   = "failed"
+)
+
+// ArticleBatchItem item of an import, Errors holds the broken rules of an invalid item
+type ArticleBatchItem struct {
+	Index   int
+	Request CreateArticleRequest
+	Errors  []response.Errors
+}
+
+// ArticleBatchJob progress of an article import and the result of each of its items
+type ArticleBatchJob struct {
+	ID         string                  `json:"id"`
+	Status     string                  `json:"status"`
+	Total      int                     `json:"total"`
+	Processed  int                     `json:"processed"`
+	Created    int                     `json:"created"`
+	Rejected   int                     `json:"rejected"`
+	Invalid    int                     `json:"invalid"`
+	Failed     int                     `json:"failed"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	Items      []ArticleBatchJobResult `json:"items"`
+}
+
+// ArticleBatchJobResult result of the item at Index of the import, ArticleID is the id of the created article
+// and Errors the broken rules of an invalid or rejected item
+type ArticleBatchJobResult struct {
+	Index     int               `json:"index"`
+	Status    string            `json:"status"`
+	Title     string            `json:"title"`
+	ArticleID int               `json:"article_id,omitempty"`
+	Errors    []response.Errors `json:"errors,omitempty"`
+}
+
+// BatchJobArticleRepository Repository Interface
+type BatchJobArticleRepository interface {
+	// Save store the job within the tenant of ctx
+	Save(ctx context.Context, job ArticleBatchJob) error
+	// Get ErrBatchJobNotFound when the job is unknown to the tenant of ctx or expired
+	Get(ctx context.Context, id string) (*ArticleBatchJob, error)
+}
+
+// ArticleBatchItemsFromRequest decode the body, a JSON array or one JSON object per line when ndjson,
+// and validate each item, the broken rules are translated to lang and kept on the item
+func ArticleBatchItemsFromRequest(body []byte, ndjson bool, lang string, maxItems int) ([]ArticleBatchItem, error) {
+	var raws []json.RawMessage
+	if ndjson {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			raws = append(raws, append(json.RawMessage(nil), line...))
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, err
+	}
+
+	if len(raws) == 0 {
+		return nil, ErrBatchEmpty
+	}
+	if maxItems > 0 && len(raws) > maxItems {
+		return nil, fmt.Errorf("%w: %d items, at most %d", ErrBatchTooLarge, len(raws), maxItems)
+	}
+
+	items := make([]ArticleBatchItem, len(raws))
+	for i, raw := range raws {
+		items[i].Index = i
+		if err := json.Unmarshal(raw, &items[i].Request); err != nil {
+			items[i].Errors = []response.Errors{{Field: "json", Description: err.Error()}}
+			continue
+		}
+		if err := validator.Validate.ValidateStruct(&items[i].Request); err != nil {
+			items[i].Errors = batchItemErrors(err, lang)
+		}
+	}
+	return items, nil
+}
+
+func batchItemErrors(err error, lang string) []response.Errors {
+	fields, ok := err.(validatorGo.ValidationErrors)
+	if !ok {
+		return []response.Errors{{Description: err.Error()}}
+	}
+	trans, found := validator.Validate.GetTranslator(lang)
+	result := make([]response.Errors, 0, len(fields))
+	for _, v := range fields {
+		description := v.Error()
+		if found {
+			description = v.Translate(trans)
+		}
+		result = append(result, response.Errors{Field: v.Field(), Description: description})
+	}
+	return result
+}
@@ -1,16 +1,111 @@
 package domain
 
+import (
+	"strings"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+)
+
 type CreateArticleRequest struct {
-	Author    string `json:"author"`
-	Title     string `json:"title"`
-	Body      string `json:"body"`
+	Author    string `json:"author" validate:"required,min=3,max=250"`
+	Title     string `json:"title" validate:"required,min=3,max=250"`
+	Body      string `json:"body" validate:"required,min=3,max=250"`
+	// Tags case insensitive, duplicates are dropped
+	Tags     []string `json:"tags"`
+	// Category slash separated path of the category, e.g. tech/golang
+	Category string   `json:"category"`
 }
 
 func (r CreateArticleRequest)ToCreateArticleCommand() CreateArticleCommand {
 	return CreateArticleCommand{
-		ID:     0,
-		Author: r.Author,
-		Title:  r.Title,
-		Body:   r.Body,
+		ID:       0,
+		Author:   r.Author,
+		Title:    r.Title,
+		Body:     r.Body,
+		Tags:     events.NormalizeTags(r.Tags),
+		Category: events.NormalizeCategory(r.Category),
+	}
+}
+
+// UpdateArticleRequest only the given fields are changed
+type UpdateArticleRequest struct {
+	ChangedBy string `json:"changed_by"`
+	Title     string `json:"title" validate:"omitempty,min=3,max=250"`
+	Body      string `json:"body" validate:"omitempty,min=3,max=250"`
+	Category  string `json:"category"`
+}
+
+func (r UpdateArticleRequest) ToUpdateArticleCommand(id int, expectedVersion int) UpdateArticleCommand {
+	return UpdateArticleCommand{
+		ID:              id,
+		ChangedBy:       r.ChangedBy,
+		ExpectedVersion: expectedVersion,
+		Title:           r.Title,
+		Body:            r.Body,
+		Category:        events.NormalizeCategory(r.Category),
+	}
+}
+
+// TransitionArticleRequest publish_at is only read by publish, a publish_at in the future schedules the publication
+type TransitionArticleRequest struct {
+	ChangedBy string     `json:"changed_by"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+func (r TransitionArticleRequest) ToTransitionArticleCommand(id int, action string, expectedVersion int) TransitionArticleCommand {
+	command := TransitionArticleCommand{
+		ID:              id,
+		Action:          action,
+		ChangedBy:       r.ChangedBy,
+		ExpectedVersion: expectedVersion,
+	}
+	if action == events.ArticleActionPublish {
+		command.PublishAt = r.PublishAt
+	}
+	return command
+}
+
+// TagArticleRequest tags added to or removed from the article
+type TagArticleRequest struct {
+	ChangedBy string   `json:"changed_by"`
+	Tags      []string `json:"tags"`
+}
+
+func (r TagArticleRequest) ToTagArticleCommand(id int, action string, expectedVersion int) TagArticleCommand {
+	return TagArticleCommand{
+		ID:              id,
+		Action:          action,
+		ChangedBy:       r.ChangedBy,
+		ExpectedVersion: expectedVersion,
+		Tags:            events.NormalizeTags(r.Tags),
+	}
+}
+
+// TagsQueryParam comma separated tags the searched articles all carry
+func TagsQueryParam(tagsStr string) []string {
+	if strings.TrimSpace(tagsStr) == "" {
+		return nil
+	}
+	return events.NormalizeTags(strings.Split(tagsStr, ","))
+}
+
+// StatusQueryParamValidation comma separated statuses of the searched articles, empty searches the published articles
+func StatusQueryParamValidation(statusStr string) ([]string, error) {
+	if strings.TrimSpace(statusStr) == "" {
+		return nil, nil
+	}
+
+	statuses := make([]string, 0)
+	for _, status := range strings.Split(statusStr, ",") {
+		status = strings.TrimSpace(status)
+		switch status {
+		case events.ArticleStatusDraft, events.ArticleStatusInReview, events.ArticleStatusPublished, events.ArticleStatusArchived:
+			statuses = append(statuses, status)
+		default:
+			return nil, response.ErrQueryParamInvalid
+		}
 	}
+	return statuses, nil
 }
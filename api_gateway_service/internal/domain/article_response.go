@@ -4,6 +4,13 @@ import "time"
 
 type ArticleResponse struct {
 	ID        int `json:"id"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Tags        []string   `json:"tags"`
+	Category    string     `json:"category"`
+	CommentCount int64     `json:"comment_count"`
+	Version      int       `json:"version"`
 	Author    string `json:"author"`
 	Title     string `json:"title"`
 	Body      string `json:"body"`
@@ -18,6 +25,51 @@ type ArticlePaginationResponse struct {
 	Size       int64 `json:"size"`
 	HasMore    bool `json:"has_more"`
 	Articles   []*ArticleResponse `json:"articles"`
+	// Stale the reader is unavailable, the result is a copy cached at CachedAt
+	Stale    bool      `json:"-"`
+	CachedAt time.Time `json:"-"`
 }
 
+type ArticleRevisionResponse struct {
+	Revision      int       `json:"revision"`
+	ChangedBy     string    `json:"changed_by"`
+	ChangedFields []string  `json:"changed_fields"`
+	Status        string    `json:"status"`
+	Tags          []string  `json:"tags"`
+	Category      string    `json:"category"`
+	Author        string    `json:"author"`
+	Title         string    `json:"title"`
+	Body          string    `json:"body"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type TagCountResponse struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type FieldChangeResponse struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
 
+type ArticleRevisionDiffResponse struct {
+	ID      int                   `json:"id"`
+	From    int                   `json:"from"`
+	To      int                   `json:"to"`
+	Changes []FieldChangeResponse `json:"changes"`
+}
+
+// CreateArticleSyncResponse article created right away by the writer
+type CreateArticleSyncResponse struct {
+	ID        int       `json:"id"`
+	Version   int       `json:"version"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ValidateCreateArticleResponse answer of a dry run, invalid commands are answered with the broken rules instead
+type ValidateCreateArticleResponse struct {
+	Valid bool `json:"valid"`
+}
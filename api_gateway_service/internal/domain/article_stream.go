@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var ErrStreamUnavailable = errors.New("article change feed is not running")
+
+// control events of the change feed, sent along the article changes
+const (
+	// ArticleStreamReset the changes following the last event id are lost, the client reloads the articles
+	ArticleStreamReset = "reset"
+	// ArticleStreamLagging the client did not keep up and is disconnected, it resumes by reconnecting
+	ArticleStreamLagging = "lagging"
+)
+
+// ArticleChange event of the change feed, ID is the kafka position of the event and resumes the feed after it
+type ArticleChange struct {
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type"`
+	TenantID string           `json:"-"`
+	Article  *ArticleResponse `json:"article,omitempty"`
+}
+
+// ArticleStreamFilter changes of the articles of Author carrying every tag of Tags, empty matches every change
+type ArticleStreamFilter struct {
+	Author string
+	Tags   []string
+}
+
+// NewArticleStreamFilter filter of the author and comma separated tags query params
+func NewArticleStreamFilter(author, tags string) ArticleStreamFilter {
+	return ArticleStreamFilter{
+		Author: strings.TrimSpace(author),
+		Tags:   TagsQueryParam(tags),
+	}
+}
+
+func (f ArticleStreamFilter) Match(change ArticleChange) bool {
+	if f.Author != "" && change.Article.Author != f.Author {
+		return false
+	}
+	for _, tag := range f.Tags {
+		found := false
+		for _, articleTag := range change.Article.Tags {
+			if articleTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ArticleSubscription changes of the tenant matching the filter of the subscription
+type ArticleSubscription interface {
+	// Changes closed once the subscriber falls behind its buffer, the subscription is closed or the feed stops
+	Changes() <-chan ArticleChange
+	// Lagging the changes were closed because the subscriber did not keep up
+	Lagging() bool
+	// Resumed false when the changes following the last event id asked for are neither held nor retained by kafka
+	Resumed() bool
+	Close()
+}
+
+// StreamArticleRepository Repository Interface
+type StreamArticleRepository interface {
+	// Subscribe the changes of the tenant of ctx, replaying the ones following lastEventID when it is given
+	Subscribe(ctx context.Context, filter ArticleStreamFilter, lastEventID string) (ArticleSubscription, error)
+}
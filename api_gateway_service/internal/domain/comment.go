@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	readerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_reader"
+)
+
+type CreateCommentCommand struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+}
+
+type EditCommentCommand struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	ChangedBy string `json:"changed_by"`
+	Body      string `json:"body"`
+}
+
+type DeleteCommentCommand struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	ChangedBy string `json:"changed_by"`
+}
+
+// ModerateCommentCommand status is events.CommentStatusVisible or events.CommentStatusHidden
+type ModerateCommentCommand struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	ChangedBy string `json:"changed_by"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// CommentUseCase UseCase Interface
+type CommentUseCase interface {
+	CreateComment(beegoCtx *beegoContext.Context, articleID int, body CreateCommentRequest) error
+	ListComments(beegoCtx *beegoContext.Context, articleID int, page int, size int) (*CommentPaginationResponse, error)
+	EditComment(beegoCtx *beegoContext.Context, articleID int, id int, body EditCommentRequest) error
+	DeleteComment(beegoCtx *beegoContext.Context, articleID int, id int, body DeleteCommentRequest) error
+	ModerateComment(beegoCtx *beegoContext.Context, articleID int, id int, body ModerateCommentRequest) error
+}
+
+// CommandCommentRepository Repository Interface
+type CommandCommentRepository interface {
+	Create(ctx context.Context, command CreateCommentCommand) error
+	Edit(ctx context.Context, command EditCommentCommand) error
+	Delete(ctx context.Context, command DeleteCommentCommand) error
+	Moderate(ctx context.Context, command ModerateCommentCommand) error
+}
+
+// QueriesCommentRepository Repository Interface
+type QueriesCommentRepository interface {
+	List(ctx context.Context, articleID int, page int, size int) (*readerService.ListCommentsRes, error)
+}
+
+// Mapper
+func ToCommentPaginationResponse(r *readerService.ListCommentsRes) *CommentPaginationResponse {
+	result := &CommentPaginationResponse{
+		TotalCount: r.TotalCount,
+		TotalPages: r.TotalPages,
+		Page:       r.Page,
+		Size:       r.Size,
+		HasMore:    r.HasMore,
+		Comments:   make([]*CommentResponse, 0, len(r.Comments)),
+	}
+	for _, comment := range r.Comments {
+		result.Comments = append(result.Comments, ToCommentResponse(comment))
+	}
+	return result
+}
+
+func ToCommentResponse(r *readerService.Comment) *CommentResponse {
+	return &CommentResponse{
+		ID:        int(r.ID),
+		ArticleID: int(r.ArticleID),
+		Author:    r.Author,
+		Body:      r.Body,
+		CreatedAt: r.CreatedAt.AsTime(),
+		UpdatedAt: r.UpdatedAt.AsTime(),
+	}
+}
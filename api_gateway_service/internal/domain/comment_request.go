@@ -0,0 +1,60 @@
+package domain
+
+import "strings"
+
+type CreateCommentRequest struct {
+	Author string `json:"author" validate:"required,min=3,max=250"`
+	Body   string `json:"body" validate:"required,max=1000"`
+}
+
+func (r CreateCommentRequest) ToCreateCommentCommand(articleID int) CreateCommentCommand {
+	return CreateCommentCommand{
+		ID:        0,
+		ArticleID: articleID,
+		Author:    r.Author,
+		Body:      r.Body,
+	}
+}
+
+type EditCommentRequest struct {
+	ChangedBy string `json:"changed_by"`
+	Body      string `json:"body" validate:"required,max=1000"`
+}
+
+func (r EditCommentRequest) ToEditCommentCommand(articleID int, id int) EditCommentCommand {
+	return EditCommentCommand{
+		ID:        id,
+		ArticleID: articleID,
+		ChangedBy: r.ChangedBy,
+		Body:      r.Body,
+	}
+}
+
+type DeleteCommentRequest struct {
+	ChangedBy string `json:"changed_by"`
+}
+
+func (r DeleteCommentRequest) ToDeleteCommentCommand(articleID int, id int) DeleteCommentCommand {
+	return DeleteCommentCommand{
+		ID:        id,
+		ArticleID: articleID,
+		ChangedBy: r.ChangedBy,
+	}
+}
+
+// ModerateCommentRequest status is visible or hidden, hidden comments are left out of the listings and counts
+type ModerateCommentRequest struct {
+	ChangedBy string `json:"changed_by"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+}
+
+func (r ModerateCommentRequest) ToModerateCommentCommand(articleID int, id int) ModerateCommentCommand {
+	return ModerateCommentCommand{
+		ID:        id,
+		ArticleID: articleID,
+		ChangedBy: r.ChangedBy,
+		Status:    strings.ToLower(strings.TrimSpace(r.Status)),
+		Reason:    r.Reason,
+	}
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+type CommentResponse struct {
+	ID        int       `json:"id"`
+	ArticleID int       `json:"article_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CommentPaginationResponse struct {
+	TotalCount int64              `json:"total_count"`
+	TotalPages int64              `json:"total_pages"`
+	Page       int64              `json:"page"`
+	Size       int64              `json:"size"`
+	HasMore    bool               `json:"has_more"`
+	Comments   []*CommentResponse `json:"comments"`
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_writer"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrWebhookInvalid the writer service refused the url or the events of the webhook
+	ErrWebhookInvalid = errors.New("webhook invalid")
+	// ErrWebhookDeliveryStatusInvalid the status filter of the deliveries is not a delivery status
+	ErrWebhookDeliveryStatusInvalid = errors.New("webhook delivery status must be pending, delivered or failed")
+)
+
+// status of the webhook deliveries
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDeliveryStatusQueryParam status filter of the deliveries, empty is every delivery
+func WebhookDeliveryStatusQueryParam(status string) (string, error) {
+	switch status {
+	case "", WebhookDeliveryPending, WebhookDeliveryDelivered, WebhookDeliveryFailed:
+		return status, nil
+	}
+	return "", ErrWebhookDeliveryStatusInvalid
+}
+
+// WebhookUseCase UseCase Interface
+type WebhookUseCase interface {
+	CreateWebhook(beegoCtx *beegoContext.Context, body CreateWebhookRequest) (*CreateWebhookResponse, error)
+	UpdateWebhook(beegoCtx *beegoContext.Context, id int, body UpdateWebhookRequest) (*WebhookResponse, error)
+	GetWebhook(beegoCtx *beegoContext.Context, id int) (*WebhookResponse, error)
+	ListWebhooks(beegoCtx *beegoContext.Context) ([]*WebhookResponse, error)
+	DeleteWebhook(beegoCtx *beegoContext.Context, id int) (*WebhookResponse, error)
+	ListWebhookDeliveries(beegoCtx *beegoContext.Context, id int, status string, page int, size int) (*WebhookDeliveryPaginationResponse, error)
+}
+
+// WriterWebhookRepository Repository Interface
+type WriterWebhookRepository interface {
+	Create(ctx context.Context, req *writerService.CreateWebhookReq) (*writerService.CreateWebhookRes, error)
+	Update(ctx context.Context, req *writerService.UpdateWebhookReq) (*writerService.Webhook, error)
+	GetById(ctx context.Context, id int) (*writerService.Webhook, error)
+	List(ctx context.Context) ([]*writerService.Webhook, error)
+	Delete(ctx context.Context, id int) (*writerService.Webhook, error)
+	ListDeliveries(ctx context.Context, id int, status string, page int, size int) (*writerService.ListWebhookDeliveriesRes, error)
+}
+
+// Mapper
+func ToWebhookResponse(r *writerService.Webhook) *WebhookResponse {
+	res := &WebhookResponse{
+		ID:                  int(r.ID),
+		URL:                 r.URL,
+		Events:              r.Events,
+		Active:              r.Active,
+		ConsecutiveFailures: int(r.ConsecutiveFailures),
+		DisabledReason:      r.DisabledReason,
+		CreatedAt:           r.CreatedAt.AsTime(),
+		UpdatedAt:           r.UpdatedAt.AsTime(),
+	}
+	if res.Events == nil {
+		res.Events = []string{}
+	}
+	if r.DisabledAt != nil {
+		disabledAt := r.DisabledAt.AsTime()
+		res.DisabledAt = &disabledAt
+	}
+	return res
+}
+
+func ToWebhookDeliveryPaginationResponse(r *writerService.ListWebhookDeliveriesRes) *WebhookDeliveryPaginationResponse {
+	result := &WebhookDeliveryPaginationResponse{
+		TotalCount: r.TotalCount,
+		TotalPages: r.TotalPages,
+		Page:       r.Page,
+		Size:       r.Size,
+		HasMore:    r.HasMore,
+		Deliveries: make([]*WebhookDeliveryResponse, 0, len(r.Deliveries)),
+	}
+	for _, delivery := range r.Deliveries {
+		result.Deliveries = append(result.Deliveries, toWebhookDeliveryResponse(delivery))
+	}
+	return result
+}
+
+func toWebhookDeliveryResponse(r *writerService.WebhookDelivery) *WebhookDeliveryResponse {
+	res := &WebhookDeliveryResponse{
+		ID:         int(r.ID),
+		WebhookID:  int(r.WebhookID),
+		EventID:    r.EventID,
+		EventType:  r.EventType,
+		Status:     r.Status,
+		Attempts:   int(r.Attempts),
+		CreatedAt:  r.CreatedAt.AsTime(),
+		AttemptLog: make([]WebhookDeliveryAttemptResponse, 0, len(r.AttemptLog)),
+	}
+	if r.NextAttemptAt != nil {
+		nextAttemptAt := r.NextAttemptAt.AsTime()
+		res.NextAttemptAt = &nextAttemptAt
+	}
+	if r.DeliveredAt != nil {
+		deliveredAt := r.DeliveredAt.AsTime()
+		res.DeliveredAt = &deliveredAt
+	}
+	for _, attempt := range r.AttemptLog {
+		res.AttemptLog = append(res.AttemptLog, WebhookDeliveryAttemptResponse{
+			Attempt:    int(attempt.Attempt),
+			StatusCode: int(attempt.StatusCode),
+			Error:      attempt.Error,
+			DurationMs: attempt.DurationMs,
+			CreatedAt:  attempt.CreatedAt.AsTime(),
+		})
+	}
+	return res
+}
@@ -0,0 +1,36 @@
+package domain
+
+import writerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_writer"
+
+// CreateWebhookRequest events empty is every article event, the secret signing the payloads is generated when empty
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events" validate:"omitempty,dive,oneof=article.created article.updated article.status_changed article.published article.tags_changed"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=256"`
+}
+
+func (r CreateWebhookRequest) ToCreateWebhookReq() *writerService.CreateWebhookReq {
+	return &writerService.CreateWebhookReq{
+		URL:    r.URL,
+		Events: r.Events,
+		Secret: r.Secret,
+	}
+}
+
+// UpdateWebhookRequest replace the webhook, secret empty keeps the current one and active true enables a disabled webhook again
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events" validate:"omitempty,dive,oneof=article.created article.updated article.status_changed article.published article.tags_changed"`
+	Active *bool    `json:"active" validate:"required"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=256"`
+}
+
+func (r UpdateWebhookRequest) ToUpdateWebhookReq(id int) *writerService.UpdateWebhookReq {
+	return &writerService.UpdateWebhookReq{
+		ID:     int32(id),
+		URL:    r.URL,
+		Events: r.Events,
+		Active: r.Active != nil && *r.Active,
+		Secret: r.Secret,
+	}
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+type WebhookResponse struct {
+	ID                  int        `json:"id"`
+	URL                 string     `json:"url"`
+	Events              []string   `json:"events"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// CreateWebhookResponse the secret is only answered on creation
+type CreateWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryAttemptResponse struct {
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID            int                              `json:"id"`
+	WebhookID     int                              `json:"webhook_id"`
+	EventID       string                           `json:"event_id"`
+	EventType     string                           `json:"event_type"`
+	Status        string                           `json:"status"`
+	Attempts      int                              `json:"attempts"`
+	NextAttemptAt *time.Time                       `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time                       `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time                        `json:"created_at"`
+	AttemptLog    []WebhookDeliveryAttemptResponse `json:"attempt_log"`
+}
+
+type WebhookDeliveryPaginationResponse struct {
+	TotalCount int64                      `json:"total_count"`
+	TotalPages int64                      `json:"total_pages"`
+	Page       int64                      `json:"page"`
+	Size       int64                      `json:"size"`
+	HasMore    bool                       `json:"has_more"`
+	Deliveries []*WebhookDeliveryResponse `json:"deliveries"`
+}
@@ -97,10 +97,14 @@ func (w *bodyDumpResponseWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
+// Flush streams the response, the dump stops capturing so a long lived stream does not grow it
 func (w *bodyDumpResponseWriter) Flush() {
+	w.Writer = w.ResponseWriter
 	w.ResponseWriter.(http.Flusher).Flush()
 }
 
+// Hijack hands the connection over, nothing written afterwards goes through the dump
 func (w *bodyDumpResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.Writer = w.ResponseWriter
 	return w.ResponseWriter.(http.Hijacker).Hijack()
 }
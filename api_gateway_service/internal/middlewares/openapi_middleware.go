@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	beego "github.com/beego/beego/v2/server/web"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/helper"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/openapi"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+// OpenAPIConfig the document the requests are validated against
+type OpenAPIConfig struct {
+	Document  *openapi.Document
+	ZapLogger zaplogger.Logger
+}
+
+// OpenAPIRequestValidator answers a 400 listing the invalid fields when the request does not match its operation,
+// the requests of the operations missing from the document are passed as is
+func OpenAPIRequestValidator(config OpenAPIConfig) beego.FilterChain {
+	return func(next beego.FilterFunc) beego.FilterFunc {
+		return func(ctx *beegoContext.Context) {
+			operation, pathValues, ok := config.Document.Find(ctx.Request.Method, ctx.Request.URL.Path)
+			if !ok {
+				next(ctx)
+				return
+			}
+
+			body := []byte{}
+			if ctx.Request.Body != nil {
+				body, _ = ioutil.ReadAll(ctx.Request.Body)
+			}
+			ctx.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+			// ResponseError renders the field errors only when the request body is set
+			ctx.Input.RequestBody = body
+
+			if err := operation.ValidateRequest(ctx.Request, pathValues, body); err != nil {
+				code := response.ApiValidationCodeError
+				var requestErr *openapi.RequestError
+				if errors.As(err, &requestErr) {
+					switch requestErr.In {
+					case openapi.InPath:
+						code = response.PathParamInvalidCode
+					case openapi.InQuery:
+						code = response.QueryParamInvalidCode
+					}
+				}
+				if config.ZapLogger != nil {
+					ctx.Input.SetData("stackTrace", config.ZapLogger.SetMessageLog(err))
+				}
+				response.ApiResponse{}.ResponseError(ctx, http.StatusBadRequest, code, response.ErrorCodeText(code, helper.GetLangVersion(ctx)), err)
+				return
+			}
+			next(ctx)
+		}
+	}
+}
+
+// OpenAPIResponseValidator logs the responses not matching the document, they are still answered as is
+func OpenAPIResponseValidator(config OpenAPIConfig) beego.FilterChain {
+	return BodyDumpWithConfig(BodyDumpConfig{
+		Skipper: func(ctx *beegoContext.Context) bool {
+			_, _, ok := config.Document.Find(ctx.Request.Method, ctx.Request.URL.Path)
+			return !ok
+		},
+		Handler: func(ctx *beegoContext.Context, _ []byte, resBody []byte) {
+			operation, _, ok := config.Document.Find(ctx.Request.Method, ctx.Request.URL.Path)
+			if !ok {
+				return
+			}
+			status := ctx.ResponseWriter.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if err := operation.ValidateResponse(status, ctx.ResponseWriter.Header().Get("Content-Type"), resBody); err != nil {
+				config.ZapLogger.Warnf("%s %s (%s): %v", ctx.Request.Method, ctx.Request.URL.Path, operation.ID, err)
+			}
+		},
+	})
+}
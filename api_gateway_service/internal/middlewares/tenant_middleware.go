@@ -0,0 +1,139 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+
+	beego "github.com/beego/beego/v2/server/web"
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/helper"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/jwt"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+type (
+	// TenantConfig defines the config for Tenant middleware.
+	TenantConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// JWT parses the bearer token carrying the tenant claim, every request must then carry a token with the claim.
+		// Optional. The tenant is only taken from the X-Tenant-ID header when nil.
+		JWT jwt.JWT
+
+		// Claim of the token payload carrying the tenant id.
+		// Optional. Default value "tenant_id".
+		Claim string
+
+		// Required rejects the requests without tenant instead of serving them as the default tenant.
+		Required bool
+
+		// Limiter enforces the request quota of each tenant.
+		// Optional. The tenants are not limited when nil.
+		Limiter *tenant.Limiter
+
+		ZapLogger zaplogger.Logger
+	}
+)
+
+const defaultTenantClaim = "tenant_id"
+
+// Tenant returns a middleware resolving the tenant of the request from the token claim or the X-Tenant-ID header,
+// the tenant is carried by the request context down to the repositories
+func Tenant(config TenantConfig) beego.FilterChain {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultSkipper
+	}
+	if config.Claim == "" {
+		config.Claim = defaultTenantClaim
+	}
+
+	return func(next beego.FilterFunc) beego.FilterFunc {
+		return func(ctx *beegoContext.Context) {
+			if config.Skipper(ctx) {
+				next(ctx)
+				return
+			}
+
+			id, ok := resolveTenant(ctx, config)
+			if !ok {
+				return
+			}
+
+			if config.Limiter != nil {
+				if allowed, retryAfter := config.Limiter.Allow(id); !allowed {
+					ctx.ResponseWriter.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+					responseTenantError(ctx, config, http.StatusTooManyRequests, response.TenantQuotaExceededCode, response.ErrTenantQuotaExceeded)
+					return
+				}
+			}
+
+			ctx.ResponseWriter.Header().Set(tenant.HeaderName, id)
+			ctx.Request = ctx.Request.WithContext(tenant.WithID(ctx.Request.Context(), id))
+			next(ctx)
+		}
+	}
+}
+
+// resolveTenant tenant of the token claim when the JWT is configured, the header must then name the same tenant
+// and the requests without a token or without the claim are rejected. The header is only trusted without JWT.
+// The error is responded when the tenant can not be resolved
+func resolveTenant(ctx *beegoContext.Context, config TenantConfig) (string, bool) {
+	header := ctx.Request.Header.Get(tenant.HeaderName)
+	if config.JWT == nil {
+		if header == "" {
+			if config.Required {
+				responseTenantError(ctx, config, http.StatusBadRequest, response.TenantInvalidCode, response.ErrTenantMissing)
+				return "", false
+			}
+			return tenant.DefaultID, true
+		}
+		id, err := tenant.Parse(header)
+		if err != nil {
+			responseTenantError(ctx, config, http.StatusBadRequest, response.TenantInvalidCode, err)
+			return "", false
+		}
+		return id, true
+	}
+
+	payload, err := config.JWT.GetPayload(ctx.Request)
+	switch {
+	case jwt.IsMissingToken(err):
+		responseTenantError(ctx, config, http.StatusUnauthorized, response.MissingTokenCodeError, err)
+		return "", false
+	case jwt.IsExpiredToken(err):
+		responseTenantError(ctx, config, http.StatusUnauthorized, response.ExpiredTokenCodeError, err)
+		return "", false
+	case err != nil:
+		responseTenantError(ctx, config, http.StatusUnauthorized, response.InvalidTokenCodeError, err)
+		return "", false
+	}
+
+	claimed, _ := payload[config.Claim].(string)
+	if claimed == "" {
+		responseTenantError(ctx, config, http.StatusUnauthorized, response.InvalidTokenCodeError, response.ErrTenantClaimMissing)
+		return "", false
+	}
+	id, err := tenant.Parse(claimed)
+	if err != nil {
+		responseTenantError(ctx, config, http.StatusBadRequest, response.TenantInvalidCode, err)
+		return "", false
+	}
+	if header != "" {
+		if headerID, err := tenant.Parse(header); err != nil || headerID != id {
+			responseTenantError(ctx, config, http.StatusForbidden, response.RequestForbiddenCodeError, response.ErrTenantMismatch)
+			return "", false
+		}
+	}
+	return id, true
+}
+
+func responseTenantError(ctx *beegoContext.Context, config TenantConfig, httpStatus int, code string, err error) {
+	if config.ZapLogger != nil {
+		ctx.Input.SetData("stackTrace", config.ZapLogger.SetMessageLog(err))
+	}
+	response.ApiResponse{}.ResponseError(ctx, httpStatus, code, response.ErrorCodeText(code, helper.GetLangVersion(ctx)), err)
+}
@@ -0,0 +1,281 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	beego "github.com/beego/beego/v2/server/web"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/validator"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+type WebhookHandler struct {
+	ZapLogger zaplogger.Logger
+	internal.BaseController
+	response.ApiResponse
+	WebhookUsecase domain.WebhookUseCase
+}
+
+func NewWebhookHandler(webhookUsecase domain.WebhookUseCase, zapLogger zaplogger.Logger) {
+	pHandler := &WebhookHandler{
+		ZapLogger:      zapLogger,
+		WebhookUsecase: webhookUsecase,
+	}
+	beego.Router("/api/v1/webhooks", pHandler, "post:CreateWebhook")
+	beego.Router("/api/v1/webhooks", pHandler, "get:ListWebhooks")
+	beego.Router("/api/v1/webhooks/:id", pHandler, "get:GetWebhook")
+	beego.Router("/api/v1/webhooks/:id", pHandler, "put:UpdateWebhook")
+	beego.Router("/api/v1/webhooks/:id", pHandler, "delete:DeleteWebhook")
+	beego.Router("/api/v1/webhooks/:id/deliveries", pHandler, "get:ListWebhookDeliveries")
+}
+
+func (h *WebhookHandler) Prepare() {
+	// check user access when needed
+	h.SetLangVersion()
+}
+
+// CreateWebhook
+// @Title Create Webhook
+// @Tags Webhook
+// @Summary Subscribe An Endpoint To The Article Events, the secret signing the payloads is only answered here
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.CreateWebhookResponse}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.CreateWebhookRequest true "request payload"
+// @Router /v1/webhooks [post]
+func (h *WebhookHandler) CreateWebhook() {
+	var request domain.CreateWebhookRequest
+	if !h.bindRequest(&request) {
+		return
+	}
+
+	result, err := h.WebhookUsecase.CreateWebhook(h.Ctx, request)
+	if err != nil {
+		h.responseError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// ListWebhooks
+// @Title List Webhooks
+// @Tags Webhook
+// @Summary List The Webhooks Of The Tenant
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=[]domain.WebhookResponse}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Router /v1/webhooks [get]
+func (h *WebhookHandler) ListWebhooks() {
+	result, err := h.WebhookUsecase.ListWebhooks(h.Ctx)
+	if err != nil {
+		h.responseError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// GetWebhook
+// @Title Get Webhook
+// @Tags Webhook
+// @Summary Get A Webhook, a webhook disabled after failing deliveries carries the reason
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "webhook id"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.WebhookResponse}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 404 {object} swagger.NotFoundResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Router /v1/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook() {
+	id, ok := h.pathID(":id")
+	if !ok {
+		return
+	}
+
+	result, err := h.WebhookUsecase.GetWebhook(h.Ctx, id)
+	if err != nil {
+		h.responseError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// UpdateWebhook
+// @Title Update Webhook
+// @Tags Webhook
+// @Summary Replace A Webhook, active true enables a disabled webhook again and its pending deliveries resume
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "webhook id"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.WebhookResponse}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 404 {object} swagger.NotFoundResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Param body body domain.UpdateWebhookRequest true "request payload"
+// @Router /v1/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook() {
+	id, ok := h.pathID(":id")
+	if !ok {
+		return
+	}
+
+	var request domain.UpdateWebhookRequest
+	if !h.bindRequest(&request) {
+		return
+	}
+
+	result, err := h.WebhookUsecase.UpdateWebhook(h.Ctx, id, request)
+	if err != nil {
+		h.responseError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// DeleteWebhook
+// @Title Delete Webhook
+// @Tags Webhook
+// @Summary Delete A Webhook Along With Its Deliveries
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "webhook id"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.WebhookResponse}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 404 {object} swagger.NotFoundResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Router /v1/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook() {
+	id, ok := h.pathID(":id")
+	if !ok {
+		return
+	}
+
+	result, err := h.WebhookUsecase.DeleteWebhook(h.Ctx, id)
+	if err != nil {
+		h.responseError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// ListWebhookDeliveries
+// @Title List Webhook Deliveries
+// @Tags Webhook
+// @Summary List The Deliveries Of A Webhook With Their Attempts, the latest first
+// @Produce json
+// @Param Accept-Language header string false "lang"
+// @Param X-Tenant-ID header string false "tenant"
+// @Param id path int true "webhook id"
+// @Param status query string false "pending, delivered or failed"
+// @Param size query int false "size"
+// @Param page query int false "page"
+// @Success 200 {object} swagger.BaseResponse{errors=[]object,data=domain.WebhookDeliveryPaginationResponse}
+// @Failure 400 {object} swagger.BadRequestErrorValidationResponse{errors=[]swagger.ValidationErrors,data=object}
+// @Failure 404 {object} swagger.NotFoundResponse{errors=[]object,data=object}
+// @Failure 408 {object} swagger.RequestTimeoutResponse{errors=[]object,data=object}
+// @Failure 500 {object} swagger.InternalServerErrorResponse{errors=[]object,data=object}
+// @Failure 503 {object} swagger.ServiceUnavailableResponse{errors=[]object,data=object}
+// @Router /v1/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries() {
+	id, ok := h.pathID(":id")
+	if !ok {
+		return
+	}
+
+	pageSize, page, err := domain.PaginationQueryParamValidation(h.Ctx.Input.Query("size"), h.Ctx.Input.Query("page"))
+	if err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.QueryParamInvalidCode, response.ErrorCodeText(response.QueryParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+	status, err := domain.WebhookDeliveryStatusQueryParam(h.Ctx.Input.Query("status"))
+	if err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.QueryParamInvalidCode, response.ErrorCodeText(response.QueryParamInvalidCode, h.Locale.Lang), err)
+		return
+	}
+
+	result, err := h.WebhookUsecase.ListWebhookDeliveries(h.Ctx, id, status, page, pageSize)
+	if err != nil {
+		h.responseError(err)
+		return
+	}
+	h.Ok(h.Ctx, h.Tr("message.success"), result)
+	return
+}
+
+// pathID positive id of the path param, the bad request is responded when it is not
+func (h *WebhookHandler) pathID(param string) (int, bool) {
+	id, err := strconv.Atoi(h.Ctx.Input.Param(param))
+	if err != nil || id < 1 {
+		err = response.ErrPathParamInvalid
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.PathParamInvalidCode, response.ErrorCodeText(response.PathParamInvalidCode, h.Locale.Lang), err)
+		return 0, false
+	}
+	return id, true
+}
+
+// bindRequest decode and validate the request payload, the bad request is responded when it is invalid
+func (h *WebhookHandler) bindRequest(request interface{}) bool {
+	if err := h.BindJSON(request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return false
+	}
+	if err := validator.Validate.ValidateStruct(request); err != nil {
+		h.Ctx.Input.SetData("stackTrace", h.ZapLogger.SetMessageLog(err))
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return false
+	}
+	return true
+}
+
+// responseError respond the error of a call to the writer service
+func (h *WebhookHandler) responseError(err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		h.ResponseError(h.Ctx, http.StatusRequestTimeout, response.RequestTimeoutCodeError, response.ErrorCodeText(response.RequestTimeoutCodeError, h.Locale.Lang), err)
+		return
+	}
+	if errors.Is(err, domain.ErrWebhookNotFound) {
+		h.ResponseError(h.Ctx, http.StatusNotFound, response.DataNotFoundCodeError, response.ErrorCodeText(response.DataNotFoundCodeError, h.Locale.Lang), err)
+		return
+	}
+	if errors.Is(err, domain.ErrWebhookInvalid) {
+		h.ResponseError(h.Ctx, http.StatusBadRequest, response.ApiValidationCodeError, response.ErrorCodeText(response.ApiValidationCodeError, h.Locale.Lang), err)
+		return
+	}
+	if errors.Is(err, domain.ErrServiceUnavailable) {
+		h.ResponseError(h.Ctx, http.StatusServiceUnavailable, response.ServiceCommunicationErrorCode, response.ErrorCodeText(response.ServiceCommunicationErrorCode, h.Locale.Lang), err)
+		return
+	}
+	h.ResponseError(h.Ctx, http.StatusInternalServerError, response.ServerErrorCode, response.ErrorCodeText(response.ServerErrorCode, h.Locale.Lang), err)
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/proto/article_writer"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type writerWebhookRepository struct {
+	zapLogger zaplogger.Logger
+	whClient  writerService.WebhookServiceClient
+}
+
+func NewWriterWebhookRepository(whClient writerService.WebhookServiceClient, zapLogger zaplogger.Logger) domain.WriterWebhookRepository {
+	return &writerWebhookRepository{
+		whClient:  whClient,
+		zapLogger: zapLogger,
+	}
+}
+
+func (w writerWebhookRepository) Create(ctx context.Context, req *writerService.CreateWebhookReq) (*writerService.CreateWebhookRes, error) {
+	res, err := w.whClient.CreateWebhook(ctx, req)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return res, nil
+}
+
+func (w writerWebhookRepository) Update(ctx context.Context, req *writerService.UpdateWebhookReq) (*writerService.Webhook, error) {
+	res, err := w.whClient.UpdateWebhook(ctx, req)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return res.GetWebhook(), nil
+}
+
+func (w writerWebhookRepository) GetById(ctx context.Context, id int) (*writerService.Webhook, error) {
+	res, err := w.whClient.GetWebhook(ctx, &writerService.GetWebhookReq{ID: int32(id)})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return res.GetWebhook(), nil
+}
+
+func (w writerWebhookRepository) List(ctx context.Context) ([]*writerService.Webhook, error) {
+	res, err := w.whClient.ListWebhooks(ctx, &writerService.ListWebhooksReq{})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return res.GetWebhooks(), nil
+}
+
+func (w writerWebhookRepository) Delete(ctx context.Context, id int) (*writerService.Webhook, error) {
+	res, err := w.whClient.DeleteWebhook(ctx, &writerService.DeleteWebhookReq{ID: int32(id)})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return res.GetWebhook(), nil
+}
+
+func (w writerWebhookRepository) ListDeliveries(ctx context.Context, id int, status string, page int, size int) (*writerService.ListWebhookDeliveriesRes, error) {
+	res, err := w.whClient.ListWebhookDeliveries(ctx, &writerService.ListWebhookDeliveriesReq{
+		WebhookID: int32(id),
+		Status:    status,
+		Page:      int64(page),
+		Size:      int64(size),
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return res, nil
+}
+
+// mapError the writer being unreachable, overloaded or behind an open circuit breaker is reported as domain.ErrServiceUnavailable
+func mapError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return domain.ErrWebhookNotFound
+	case codes.InvalidArgument:
+		return errors.Wrap(domain.ErrWebhookInvalid, status.Convert(err).Message())
+	case codes.Unavailable:
+		return errors.Wrap(domain.ErrServiceUnavailable, err.Error())
+	}
+	return err
+}
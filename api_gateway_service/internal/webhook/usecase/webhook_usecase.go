@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	beegoContext "github.com/beego/beego/v2/server/web/context"
+	"github.com/radyatamaa/go-cqrs-microservices/api_gateway_service/internal/domain"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+)
+
+type webhookUseCase struct {
+	zapLogger               zaplogger.Logger
+	contextTimeout          time.Duration
+	writerWebhookRepository domain.WriterWebhookRepository
+}
+
+func NewWebhookUseCase(timeout time.Duration,
+	zapLogger zaplogger.Logger,
+	writerWebhookRepository domain.WriterWebhookRepository) domain.WebhookUseCase {
+	return &webhookUseCase{
+		writerWebhookRepository: writerWebhookRepository,
+		contextTimeout:          timeout,
+		zapLogger:               zapLogger,
+	}
+}
+
+func (a webhookUseCase) CreateWebhook(beegoCtx *beegoContext.Context, body domain.CreateWebhookRequest) (*domain.CreateWebhookResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	res, err := a.writerWebhookRepository.Create(c, body.ToCreateWebhookReq())
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return &domain.CreateWebhookResponse{
+		WebhookResponse: *domain.ToWebhookResponse(res.GetWebhook()),
+		Secret:          res.GetSecret(),
+	}, nil
+}
+
+func (a webhookUseCase) UpdateWebhook(beegoCtx *beegoContext.Context, id int, body domain.UpdateWebhookRequest) (*domain.WebhookResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	webhook, err := a.writerWebhookRepository.Update(c, body.ToUpdateWebhookReq(id))
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToWebhookResponse(webhook), nil
+}
+
+func (a webhookUseCase) GetWebhook(beegoCtx *beegoContext.Context, id int) (*domain.WebhookResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	webhook, err := a.writerWebhookRepository.GetById(c, id)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToWebhookResponse(webhook), nil
+}
+
+func (a webhookUseCase) ListWebhooks(beegoCtx *beegoContext.Context) ([]*domain.WebhookResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	webhooks, err := a.writerWebhookRepository.List(c)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	result := make([]*domain.WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		result = append(result, domain.ToWebhookResponse(webhook))
+	}
+	return result, nil
+}
+
+func (a webhookUseCase) DeleteWebhook(beegoCtx *beegoContext.Context, id int) (*domain.WebhookResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	webhook, err := a.writerWebhookRepository.Delete(c, id)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToWebhookResponse(webhook), nil
+}
+
+func (a webhookUseCase) ListWebhookDeliveries(beegoCtx *beegoContext.Context, id int, status string, page int, size int) (*domain.WebhookDeliveryPaginationResponse, error) {
+	c, cancel := context.WithTimeout(beegoCtx.Request.Context(), a.contextTimeout)
+	defer cancel()
+
+	list, err := a.writerWebhookRepository.ListDeliveries(c, id, status, page, size)
+	if err != nil {
+		beegoCtx.Input.SetData("stackTrace", a.zapLogger.SetMessageLog(err))
+		return nil, err
+	}
+
+	return domain.ToWebhookDeliveryPaginationResponse(list), nil
+}
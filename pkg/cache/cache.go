@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss returned by Get when the key is not cached
+var ErrMiss = errors.New("cache miss")
+
+// Entry versioned value of a key
+type Entry struct {
+	Key     string
+	Value   []byte
+	Version int64
+}
+
+// Cache store of encoded values with a lifetime
+type Cache interface {
+	// Get returns ErrMiss when the key is not cached
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetIfNewer only replace the cached value when it carries an older version, reports whether the value was stored.
+	// Values stored with Set have version 0.
+	SetIfNewer(ctx context.Context, key string, value []byte, version int64, ttl time.Duration) (bool, error)
+	// SetManyIfNewer SetIfNewer for each entry in a single round trip, reports whether each entry was stored
+	SetManyIfNewer(ctx context.Context, entries []Entry, ttl time.Duration) ([]bool, error)
+	Del(ctx context.Context, keys ...string) error
+}
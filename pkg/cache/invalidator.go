@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Invalidator broadcast the keys changed by an instance to the other instances
+type Invalidator interface {
+	Publish(ctx context.Context, keys ...string) error
+	// Subscribe call onInvalidate with the keys published by the other instances until ctx is done
+	Subscribe(ctx context.Context, onInvalidate func(keys []string)) error
+}
+
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+type redisInvalidator struct {
+	client  redis.UniversalClient
+	channel string
+	origin  string
+}
+
+// NewRedisInvalidator create new invalidator publishing on a redis pub/sub channel
+func NewRedisInvalidator(client redis.UniversalClient, channel string) Invalidator {
+	return &redisInvalidator{client: client, channel: channel, origin: uuid.New().String()}
+}
+
+func (r *redisInvalidator) Publish(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	msg, err := json.Marshal(invalidation{Origin: r.origin, Keys: keys})
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+	if err := r.client.Publish(ctx, r.channel, msg).Err(); err != nil {
+		return errors.Wrap(err, "redisClient.Publish")
+	}
+	return nil
+}
+
+func (r *redisInvalidator) Subscribe(ctx context.Context, onInvalidate func(keys []string)) error {
+	pubsub := r.client.Subscribe(ctx, r.channel)
+	defer pubsub.Close() // nolint: errcheck
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return errors.Wrap(err, "pubsub.Receive")
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil || inv.Origin == r.origin {
+				continue
+			}
+			onInvalidate(inv.Keys)
+		}
+	}
+}
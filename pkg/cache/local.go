@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+)
+
+type localEntry struct {
+	value     []byte
+	version   int64
+	expiresAt time.Time
+}
+
+type local struct {
+	// mu makes the version check and the write of SetIfNewer atomic
+	mu    sync.Mutex
+	items *lru.Cache
+}
+
+// NewLocal create new in-process cache bounded to size entries, the least recently used entries are evicted first
+func NewLocal(size int) (Cache, error) {
+	items, err := lru.New(size)
+	if err != nil {
+		return nil, errors.Wrap(err, "lru.New")
+	}
+	return &local{items: items}, nil
+}
+
+func (l *local) Get(ctx context.Context, key string) ([]byte, error) {
+	entry, ok := l.get(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return entry.value, nil
+}
+
+func (l *local) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	l.items.Add(key, localEntry{value: value, expiresAt: time.Now().Add(ttl)})
+	return nil
+}
+
+func (l *local) SetIfNewer(ctx context.Context, key string, value []byte, version int64, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if current, ok := l.get(key); ok && current.version >= version {
+		return false, nil
+	}
+	l.items.Add(key, localEntry{value: value, version: version, expiresAt: time.Now().Add(ttl)})
+	return true, nil
+}
+
+func (l *local) SetManyIfNewer(ctx context.Context, entries []Entry, ttl time.Duration) ([]bool, error) {
+	stored := make([]bool, len(entries))
+	for i, entry := range entries {
+		stored[i], _ = l.SetIfNewer(ctx, entry.Key, entry.Value, entry.Version, ttl)
+	}
+	return stored, nil
+}
+
+func (l *local) Del(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		l.items.Remove(key)
+	}
+	return nil
+}
+
+func (l *local) get(key string) (localEntry, bool) {
+	item, ok := l.items.Get(key)
+	if !ok {
+		return localEntry{}, false
+	}
+	entry := item.(localEntry)
+	if time.Now().After(entry.expiresAt) {
+		l.items.Remove(key)
+		return localEntry{}, false
+	}
+	return entry, true
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var requests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_requests_total",
+	Help: "Number of cache lookups by cache, tier and result.",
+}, []string{"cache", "tier", "result"})
+
+type instrumented struct {
+	Cache
+	hits   prometheus.Counter
+	misses prometheus.Counter
+}
+
+// NewInstrumented count the hits and misses of c under the given cache name and tier
+func NewInstrumented(name, tier string, c Cache) Cache {
+	return &instrumented{
+		Cache:  c,
+		hits:   requests.WithLabelValues(name, tier, "hit"),
+		misses: requests.WithLabelValues(name, tier, "miss"),
+	}
+}
+
+func (i *instrumented) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := i.Cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		i.hits.Inc()
+	case err == ErrMiss:
+		i.misses.Inc()
+	}
+	return value, err
+}
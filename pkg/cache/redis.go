@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// values are stored as "<version>|<value>" so the version can be compared inside redis
+const versionSeparator = '|'
+
+// setIfNewerScript KEYS[1] is the key, ARGV holds the version, the value and the ttl in ms
+var setIfNewerScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current then
+	local version = tonumber(string.match(current, '^(%-?%d+)|'))
+	if version and version >= tonumber(ARGV[1]) then
+		return 0
+	end
+end
+redis.call('SET', KEYS[1], ARGV[1] .. '|' .. ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+type redisCache struct {
+	client redis.UniversalClient
+}
+
+// NewRedis create new cache stored in redis
+func NewRedis(client redis.UniversalClient) Cache {
+	return &redisCache{client: client}
+}
+
+func (r *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	stored, err := r.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrMiss
+		}
+		return nil, errors.Wrap(err, "redisClient.Get")
+	}
+
+	i := bytes.IndexByte(stored, versionSeparator)
+	if i < 0 {
+		return nil, ErrMiss
+	}
+	return stored[i+1:], nil
+}
+
+func (r *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := r.client.Set(ctx, key, encode(0, value), ttl).Err(); err != nil {
+		return errors.Wrap(err, "redisClient.Set")
+	}
+	return nil
+}
+
+func (r *redisCache) SetIfNewer(ctx context.Context, key string, value []byte, version int64, ttl time.Duration) (bool, error) {
+	stored, err := setIfNewerScript.Run(ctx, r.client, []string{key}, version, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, errors.Wrap(err, "setIfNewerScript.Run")
+	}
+	return stored == 1, nil
+}
+
+// SetManyIfNewer the scripts are sent in one pipeline, the script is loaded first as a pipeline can not fall back
+// from EVALSHA to EVAL
+func (r *redisCache) SetManyIfNewer(ctx context.Context, entries []Entry, ttl time.Duration) ([]bool, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	if err := setIfNewerScript.Load(ctx, r.client).Err(); err != nil {
+		return nil, errors.Wrap(err, "setIfNewerScript.Load")
+	}
+
+	cmds := make([]*redis.Cmd, len(entries))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, entry := range entries {
+			cmds[i] = setIfNewerScript.EvalSha(ctx, pipe, []string{entry.Key}, entry.Version, entry.Value, ttl.Milliseconds())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "redisClient.Pipelined")
+	}
+
+	stored := make([]bool, len(entries))
+	for i, cmd := range cmds {
+		result, err := cmd.Int()
+		if err != nil {
+			return nil, errors.Wrap(err, "setIfNewerScript.EvalSha")
+		}
+		stored[i] = result == 1
+	}
+	return stored, nil
+}
+
+func (r *redisCache) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return errors.Wrap(err, "redisClient.Del")
+	}
+	return nil
+}
+
+func encode(version int64, value []byte) []byte {
+	encoded := strconv.AppendInt(nil, version, 10)
+	encoded = append(encoded, versionSeparator)
+	return append(encoded, value...)
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// TwoLevel cache reading through an in-process tier in front of a shared tier,
+// the local copies of the other instances are dropped through the invalidator on every write
+type TwoLevel struct {
+	local       Cache
+	remote      Cache
+	invalidator Invalidator
+	// localTTL upper bound of the local lifetime, bounds staleness when an invalidation is lost
+	localTTL time.Duration
+}
+
+// NewTwoLevel compose local and remote, both tiers are instrumented under name
+func NewTwoLevel(name string, local Cache, remote Cache, invalidator Invalidator, localTTL time.Duration) *TwoLevel {
+	return &TwoLevel{
+		local:       NewInstrumented(name, "local", local),
+		remote:      NewInstrumented(name, "remote", remote),
+		invalidator: invalidator,
+		localTTL:    localTTL,
+	}
+}
+
+func (t *TwoLevel) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := t.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err := t.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	_ = t.local.Set(ctx, key, value, t.localTTL)
+	return value, nil
+}
+
+func (t *TwoLevel) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := t.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	_ = t.local.Set(ctx, key, value, t.boundTTL(ttl))
+	return t.invalidator.Publish(ctx, key)
+}
+
+func (t *TwoLevel) SetIfNewer(ctx context.Context, key string, value []byte, version int64, ttl time.Duration) (bool, error) {
+	stored, err := t.remote.SetIfNewer(ctx, key, value, version, ttl)
+	if err != nil {
+		return false, err
+	}
+	if !stored {
+		// the shared tier holds a newer value, the local copy may be older
+		return false, t.local.Del(ctx, key)
+	}
+	_ = t.local.Set(ctx, key, value, t.boundTTL(ttl))
+	return true, t.invalidator.Publish(ctx, key)
+}
+
+func (t *TwoLevel) SetManyIfNewer(ctx context.Context, entries []Entry, ttl time.Duration) ([]bool, error) {
+	stored, err := t.remote.SetManyIfNewer(ctx, entries, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(entries))
+	for i, entry := range entries {
+		if !stored[i] {
+			// the shared tier holds a newer value, the local copy may be older
+			_ = t.local.Del(ctx, entry.Key)
+			continue
+		}
+		_ = t.local.Set(ctx, entry.Key, entry.Value, t.boundTTL(ttl))
+		keys = append(keys, entry.Key)
+	}
+	if len(keys) == 0 {
+		return stored, nil
+	}
+	return stored, t.invalidator.Publish(ctx, keys...)
+}
+
+func (t *TwoLevel) Del(ctx context.Context, keys ...string) error {
+	_ = t.local.Del(ctx, keys...)
+	if err := t.remote.Del(ctx, keys...); err != nil {
+		return err
+	}
+	return t.invalidator.Publish(ctx, keys...)
+}
+
+// Subscribe drop the local copies of the keys written by the other instances until ctx is done
+func (t *TwoLevel) Subscribe(ctx context.Context) error {
+	return t.invalidator.Subscribe(ctx, func(keys []string) {
+		_ = t.local.Del(context.Background(), keys...)
+	})
+}
+
+func (t *TwoLevel) boundTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > t.localTTL {
+		return t.localTTL
+	}
+	return ttl
+}
@@ -0,0 +1,333 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TableName table keeping track of the applied migrations
+const TableName = "schema_migrations"
+
+var (
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	ErrMissingMigration = errors.New("applied migration not found")
+	ErrMissingDown      = errors.New("migration has no down script")
+	ErrInvalidName      = errors.New("invalid migration name")
+
+	// migration files are named <version>_<name>.up.sql and <version>_<name>.down.sql
+	fileName      = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	migrationName = regexp.MustCompile(`^\w+$`)
+)
+
+// Migration versioned sql script with its rollback
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status state of a migration in the database
+type Status struct {
+	Migration
+	Applied          bool
+	AppliedAt        time.Time
+	ChecksumMismatch bool
+}
+
+// Migrator apply the migrations of a postgres database,
+// concurrent runs are serialized with a session advisory lock
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	lockKey    int64
+}
+
+// New create new migrator of db with the migrations found in fsys
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(TableName))
+
+	return &Migrator{db: db, migrations: migrations, lockKey: int64(h.Sum64())}, nil
+}
+
+// Load read the migration files of fsys sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "fs.ReadDir")
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.Wrap(err, "fs.ReadFile")
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d used by %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+			m.Checksum = checksum(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Up apply every pending migration, each one in its own transaction
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(statuses); err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			if s.Applied {
+				continue
+			}
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, s.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					"INSERT INTO "+TableName+" (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)",
+					s.Version, s.Name, s.Checksum, time.Now().UTC())
+				return err
+			})
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("migrate up %d_%s", s.Version, s.Name))
+			}
+			applied = append(applied, s.Migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down roll back the last steps applied migrations, newest first
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var rolledBack []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := verify(statuses); err != nil {
+			return err
+		}
+
+		for i := len(statuses) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			s := statuses[i]
+			if !s.Applied {
+				continue
+			}
+			if strings.TrimSpace(s.Down) == "" {
+				return errors.Wrap(ErrMissingDown, fmt.Sprintf("%d_%s", s.Version, s.Name))
+			}
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, s.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "DELETE FROM "+TableName+" WHERE version = $1", s.Version)
+				return err
+			})
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("migrate down %d_%s", s.Version, s.Name))
+			}
+			rolledBack = append(rolledBack, s.Migration)
+		}
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status report every known migration and whether it has been applied,
+// applied migrations missing from the files are reported without scripts
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		var err error
+		statuses, err = m.status(ctx, conn)
+		return err
+	})
+	return statuses, err
+}
+
+func (m *Migrator) status(ctx context.Context, conn *sql.Conn) ([]Status, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM "+TableName+" ORDER BY version")
+	if err != nil {
+		return nil, errors.Wrap(err, "select "+TableName)
+	}
+	defer rows.Close() // nolint: errcheck
+
+	applied := make(map[int64]Status)
+	for rows.Next() {
+		var s Status
+		if err := rows.Scan(&s.Version, &s.Name, &s.Checksum, &s.AppliedAt); err != nil {
+			return nil, errors.Wrap(err, "rows.Scan")
+		}
+		s.Applied = true
+		applied[s.Version] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows.Err")
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		s := Status{Migration: migration}
+		if a, ok := applied[migration.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			s.ChecksumMismatch = a.Checksum != migration.Checksum
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, a := range applied {
+		statuses = append(statuses, a)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// verify refuse to run when an applied migration was edited or removed
+func verify(statuses []Status) error {
+	for _, s := range statuses {
+		if s.ChecksumMismatch {
+			return errors.Wrap(ErrChecksumMismatch, fmt.Sprintf("%d_%s", s.Version, s.Name))
+		}
+		if s.Applied && s.Up == "" {
+			return errors.Wrap(ErrMissingMigration, fmt.Sprintf("%d_%s", s.Version, s.Name))
+		}
+	}
+	return nil
+}
+
+// withLock run fn on a single connection holding the migrations advisory lock,
+// the schema_migrations table is created when missing
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "db.Conn")
+	}
+	defer conn.Close() // nolint: errcheck
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.lockKey); err != nil {
+		return errors.Wrap(err, "pg_advisory_lock")
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockKey) // nolint: errcheck
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+TableName+` (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		checksum text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "create "+TableName)
+	}
+
+	return fn(conn)
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Create write the empty up and down scripts of a new migration into dir,
+// numbered after the highest version already present
+func Create(dir, name string) ([]string, error) {
+	if !migrationName.MatchString(name) {
+		return nil, errors.Wrap(ErrInvalidName, name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %04d_%s %s\n", version, name, direction)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return nil, errors.Wrap(err, "os.WriteFile")
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,306 @@
+package events
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	articleEvents "github.com/radyatamaa/go-cqrs-microservices/pkg/events/proto/article_events"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ArticleCreateType  = "article.create"
+	ArticleCreatedType = "article.created"
+	ArticleUpdateType  = "article.update"
+	ArticleUpdatedType = "article.updated"
+
+	ArticleTransitionType    = "article.transition"
+	ArticleStatusChangedType = "article.status_changed"
+	ArticlePublishedType     = "article.published"
+
+	ArticleTagType         = "article.tag"
+	ArticleTagsChangedType = "article.tags_changed"
+)
+
+// statuses of the article publishing workflow
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusInReview  = "in_review"
+	ArticleStatusPublished = "published"
+	ArticleStatusArchived  = "archived"
+)
+
+// actions of the article transition command
+const (
+	ArticleActionSubmitForReview = "submit_for_review"
+	ArticleActionPublish         = "publish"
+	ArticleActionUnpublish       = "unpublish"
+	ArticleActionArchive         = "archive"
+)
+
+// actions of the article tag command
+const (
+	ArticleTagActionAdd    = "add"
+	ArticleTagActionRemove = "remove"
+)
+
+// ArticleCreate payload of the create article command, current version 2,
+// tags and category are optional
+type ArticleCreate struct {
+	ID       int      `json:"id"`
+	Author   string   `json:"author"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Tags     []string `json:"tags,omitempty"`
+	Category string   `json:"category,omitempty"`
+}
+
+// ArticleCreated payload of the article created event, current version 4
+type ArticleCreated struct {
+	ID        int       `json:"id"`
+	Version   int       `json:"version"`
+	Status    string    `json:"status"`
+	Tags      []string  `json:"tags"`
+	Category  string    `json:"category"`
+	Author    string    `json:"author"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ArticleUpdate payload of the update article command, current version 3,
+// title, body and category are left empty when they do not change.
+// ExpectedVersion is the version of the article the command is based on, 0 applies it to any version
+type ArticleUpdate struct {
+	ID              int    `json:"id"`
+	ChangedBy       string `json:"changed_by"`
+	ExpectedVersion int    `json:"expected_version,omitempty"`
+	Title           string `json:"title,omitempty"`
+	Body            string `json:"body,omitempty"`
+	Category        string `json:"category,omitempty"`
+}
+
+// ArticleUpdated payload of the article updated event, current version 3,
+// carries the whole article state at Version along with the fields the revision changed
+type ArticleUpdated struct {
+	ID            int        `json:"id"`
+	Version       int        `json:"version"`
+	ChangedBy     string     `json:"changed_by"`
+	ChangedFields []string   `json:"changed_fields"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publish_at"`
+	PublishedAt   *time.Time `json:"published_at"`
+	Tags          []string   `json:"tags"`
+	Category      string     `json:"category"`
+	Author        string     `json:"author"`
+	Title         string     `json:"title"`
+	Body          string     `json:"body"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ArticleTransition payload of the command moving an article through the publishing workflow, current version 2,
+// a publish with PublishAt in the future schedules the publication, ExpectedVersion as in ArticleUpdate
+type ArticleTransition struct {
+	ID              int        `json:"id"`
+	Action          string     `json:"action"`
+	ChangedBy       string     `json:"changed_by"`
+	ExpectedVersion int        `json:"expected_version,omitempty"`
+	PublishAt       *time.Time `json:"publish_at,omitempty"`
+}
+
+// ArticleStatusChanged payload of the article status changed event, current version 2,
+// carries the whole article state at Version, PublishAt is the pending scheduled publication
+type ArticleStatusChanged struct {
+	ID             int        `json:"id"`
+	Version        int        `json:"version"`
+	ChangedBy      string     `json:"changed_by"`
+	ChangedFields  []string   `json:"changed_fields"`
+	PreviousStatus string     `json:"previous_status"`
+	Status         string     `json:"status"`
+	PublishAt      *time.Time `json:"publish_at"`
+	PublishedAt    *time.Time `json:"published_at"`
+	Tags           []string   `json:"tags"`
+	Category       string     `json:"category"`
+	Author         string     `json:"author"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ArticlePublished payload of the article published event, current version 2,
+// carries the whole article state at Version
+type ArticlePublished struct {
+	ID             int       `json:"id"`
+	Version        int       `json:"version"`
+	ChangedBy      string    `json:"changed_by"`
+	PreviousStatus string    `json:"previous_status"`
+	PublishedAt    time.Time `json:"published_at"`
+	Tags           []string  `json:"tags"`
+	Category       string    `json:"category"`
+	Author         string    `json:"author"`
+	Title          string    `json:"title"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ArticleTag payload of the command adding tags to an article or removing them, current version 2,
+// ExpectedVersion as in ArticleUpdate
+type ArticleTag struct {
+	ID              int      `json:"id"`
+	Action          string   `json:"action"`
+	ChangedBy       string   `json:"changed_by"`
+	ExpectedVersion int      `json:"expected_version,omitempty"`
+	Tags            []string `json:"tags"`
+}
+
+// ArticleTagsChanged payload of the article tags changed event, current version 1,
+// carries the whole article state at Version along with the tags added and removed
+type ArticleTagsChanged struct {
+	ID          int        `json:"id"`
+	Version     int        `json:"version"`
+	ChangedBy   string     `json:"changed_by"`
+	Added       []string   `json:"added"`
+	Removed     []string   `json:"removed"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at"`
+	PublishedAt *time.Time `json:"published_at"`
+	Tags        []string   `json:"tags"`
+	Category    string     `json:"category"`
+	Author      string     `json:"author"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// NormalizeTags trimmed lower case distinct tags in alphabetical order, empty tags are dropped
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// NormalizeCategory trimmed lower case category path, "news / Tech" becomes "news/tech"
+func NormalizeCategory(category string) string {
+	segments := strings.Split(category, "/")
+	for i := range segments {
+		segments[i] = strings.ToLower(strings.TrimSpace(segments[i]))
+	}
+	return strings.Trim(strings.Join(segments, "/"), "/")
+}
+
+// CategoryAncestors paths of category and of every category it is nested in, the root first
+func CategoryAncestors(category string) []string {
+	if category == "" {
+		return []string{}
+	}
+	segments := strings.Split(category, "/")
+	ancestors := make([]string, 0, len(segments))
+	for i := range segments {
+		ancestors = append(ancestors, strings.Join(segments[:i+1], "/"))
+	}
+	return ancestors
+}
+
+// upcastArticleCreatedV1 v1 events were published before articles carried a version,
+// every article of that era is on its first version
+func upcastArticleCreatedV1(payload json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	fields["version"] = 1
+	return json.Marshal(fields)
+}
+
+// upcastArticleCreatedV2 v2 events were published before the publishing workflow,
+// every article of that era was public
+func upcastArticleCreatedV2(payload json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	fields["status"] = ArticleStatusPublished
+	return json.Marshal(fields)
+}
+
+// upcastArticleUpdatedV1 v1 events were published before the publishing workflow,
+// every article of that era was public
+func upcastArticleUpdatedV1(payload json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	fields["status"] = ArticleStatusPublished
+	fields["publish_at"] = nil
+	fields["published_at"] = nil
+	return json.Marshal(fields)
+}
+
+// upcastArticleCommand the fields added by the next version of the command are optional,
+// the payloads are valid payloads of the next version as they are
+func upcastArticleCommand(payload json.RawMessage) (json.RawMessage, error) {
+	return payload, nil
+}
+
+// upcastUntaggedArticle events published before tags and categories carry an article without any
+func upcastUntaggedArticle(payload json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	fields["tags"] = []string{}
+	fields["category"] = ""
+	return json.Marshal(fields)
+}
+
+func init() {
+	Default.RegisterUpcaster(ArticleCreatedType, 1, upcastArticleCreatedV1)
+	Default.RegisterUpcaster(ArticleCreatedType, 2, upcastArticleCreatedV2)
+	Default.RegisterUpcaster(ArticleCreatedType, 3, upcastUntaggedArticle)
+	Default.RegisterUpcaster(ArticleUpdatedType, 1, upcastArticleUpdatedV1)
+	Default.RegisterUpcaster(ArticleUpdatedType, 2, upcastUntaggedArticle)
+	Default.RegisterUpcaster(ArticleStatusChangedType, 1, upcastUntaggedArticle)
+	Default.RegisterUpcaster(ArticlePublishedType, 1, upcastUntaggedArticle)
+	Default.RegisterUpcaster(ArticleCreateType, 1, upcastArticleCommand)
+	Default.RegisterUpcaster(ArticleUpdateType, 1, upcastArticleCommand)
+	Default.RegisterUpcaster(ArticleUpdateType, 2, upcastArticleCommand)
+	Default.RegisterUpcaster(ArticleTransitionType, 1, upcastArticleCommand)
+	Default.RegisterUpcaster(ArticleTagType, 1, upcastArticleCommand)
+
+	Default.RegisterProto(ArticleCreateType, 1, func() proto.Message { return new(articleEvents.ArticleCreate) })
+	Default.RegisterProto(ArticleCreateType, 2, func() proto.Message { return new(articleEvents.ArticleCreate) })
+	Default.RegisterProto(ArticleCreatedType, 2, func() proto.Message { return new(articleEvents.ArticleCreated) })
+	Default.RegisterProto(ArticleCreatedType, 3, func() proto.Message { return new(articleEvents.ArticleCreated) })
+	Default.RegisterProto(ArticleCreatedType, 4, func() proto.Message { return new(articleEvents.ArticleCreated) })
+	Default.RegisterProto(ArticleUpdateType, 1, func() proto.Message { return new(articleEvents.ArticleUpdate) })
+	Default.RegisterProto(ArticleUpdateType, 2, func() proto.Message { return new(articleEvents.ArticleUpdate) })
+	Default.RegisterProto(ArticleUpdateType, 3, func() proto.Message { return new(articleEvents.ArticleUpdate) })
+	Default.RegisterProto(ArticleUpdatedType, 1, func() proto.Message { return new(articleEvents.ArticleUpdated) })
+	Default.RegisterProto(ArticleUpdatedType, 2, func() proto.Message { return new(articleEvents.ArticleUpdated) })
+	Default.RegisterProto(ArticleUpdatedType, 3, func() proto.Message { return new(articleEvents.ArticleUpdated) })
+	Default.RegisterProto(ArticleTransitionType, 1, func() proto.Message { return new(articleEvents.ArticleTransition) })
+	Default.RegisterProto(ArticleTransitionType, 2, func() proto.Message { return new(articleEvents.ArticleTransition) })
+	Default.RegisterProto(ArticleStatusChangedType, 1, func() proto.Message { return new(articleEvents.ArticleStatusChanged) })
+	Default.RegisterProto(ArticleStatusChangedType, 2, func() proto.Message { return new(articleEvents.ArticleStatusChanged) })
+	Default.RegisterProto(ArticlePublishedType, 1, func() proto.Message { return new(articleEvents.ArticlePublished) })
+	Default.RegisterProto(ArticlePublishedType, 2, func() proto.Message { return new(articleEvents.ArticlePublished) })
+	Default.RegisterProto(ArticleTagType, 1, func() proto.Message { return new(articleEvents.ArticleTag) })
+	Default.RegisterProto(ArticleTagType, 2, func() proto.Message { return new(articleEvents.ArticleTag) })
+	Default.RegisterProto(ArticleTagsChangedType, 1, func() proto.Message { return new(articleEvents.ArticleTagsChanged) })
+}
@@ -0,0 +1,133 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	articleEvents "github.com/radyatamaa/go-cqrs-microservices/pkg/events/proto/article_events"
+	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// payloads are kept as json inside the registry, protobuf payloads are converted
+	// using the proto field names which match the json schemas
+	protoJSONMarshal   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	protoJSONUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// RegisterProto register the protobuf message carrying the payload of eventType at version
+func (r *Registry) RegisterProto(eventType string, version int, newMessage func() proto.Message) {
+	if _, ok := r.protos[eventType]; !ok {
+		r.protos[eventType] = make(map[int]func() proto.Message)
+	}
+	r.protos[eventType][version] = newMessage
+}
+
+// Encode wrap payload into a validated envelope of the current version and encode it with codec
+func (r *Registry) Encode(codec kafkaClient.Codec, eventType string, payload interface{}) ([]byte, error) {
+	envelope, err := r.NewEnvelope(eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if codec.ContentType() != kafkaClient.ContentTypeProtobuf {
+		return codec.Marshal(envelope)
+	}
+
+	msg, err := r.newProtoPayload(envelope)
+	if err != nil {
+		return nil, err
+	}
+	if err := protoJSONUnmarshal.Unmarshal(envelope.Payload, msg); err != nil {
+		return nil, errors.Wrap(err, "protojson.Unmarshal")
+	}
+	payloadBytes, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "proto.Marshal")
+	}
+
+	return codec.Marshal(&articleEvents.Envelope{
+		Id:         envelope.ID,
+		Type:       envelope.Type,
+		Version:    int32(envelope.Version),
+		OccurredAt: timestamppb.New(envelope.OccurredAt),
+		Payload:    payloadBytes,
+	})
+}
+
+// Decode decode a message of the expected event type encoded with codec,
+// validate it and upcast it to the current version
+func (r *Registry) Decode(codec kafkaClient.Codec, expectedType string, data []byte) (*Envelope, error) {
+	if codec.ContentType() != kafkaClient.ContentTypeProtobuf {
+		return r.Unmarshal(expectedType, data)
+	}
+
+	var wire articleEvents.Envelope
+	if err := codec.Unmarshal(data, &wire); err != nil {
+		return nil, errors.Wrap(err, "proto.Unmarshal")
+	}
+
+	envelope := &Envelope{
+		ID:         wire.GetId(),
+		Type:       wire.GetType(),
+		Version:    int(wire.GetVersion()),
+		OccurredAt: wire.GetOccurredAt().AsTime(),
+	}
+	if envelope.Type != expectedType {
+		return nil, errors.Wrap(ErrUnknownEventType, fmt.Sprintf("expected %s, got %s", expectedType, envelope.Type))
+	}
+
+	msg, err := r.newProtoPayload(envelope)
+	if err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(wire.GetPayload(), msg); err != nil {
+		return nil, errors.Wrap(err, "proto.Unmarshal")
+	}
+	payload, err := protoJSONMarshal.Marshal(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "protojson.Marshal")
+	}
+	envelope.Payload = json.RawMessage(payload)
+
+	if err := r.Validate(envelope); err != nil {
+		return nil, err
+	}
+
+	if err := r.Upcast(envelope); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+func (r *Registry) newProtoPayload(envelope *Envelope) (proto.Message, error) {
+	newMessage, ok := r.protos[envelope.Type][envelope.Version]
+	if !ok {
+		return nil, errors.Wrap(ErrUnsupportedVersion, fmt.Sprintf("no protobuf message for %s v%d", envelope.Type, envelope.Version))
+	}
+	return newMessage(), nil
+}
+
+// EncodeMessage encode payload with codec into a kafka message of topic carrying the content type header
+func (r *Registry) EncodeMessage(codec kafkaClient.Codec, topic string, eventType string, payload interface{}) (kafka.Message, error) {
+	value, err := r.Encode(codec, eventType, payload)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	return kafkaClient.NewMessage(topic, codec, value), nil
+}
+
+// DecodeMessage decode m with the codec selected by its content type header
+func (r *Registry) DecodeMessage(expectedType string, m kafka.Message) (*Envelope, error) {
+	codec, err := kafkaClient.MessageCodec(m)
+	if err != nil {
+		return nil, err
+	}
+	return r.Decode(codec, expectedType, m.Value)
+}
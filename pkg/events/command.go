@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	commandEvents "github.com/radyatamaa/go-cqrs-microservices/pkg/events/proto/command_events"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	CommandRejectedType = "command.rejected"
+)
+
+// CommandRejected payload of the event published instead of persisting a command breaking a business rule,
+// current version 1. CommandID is the envelope id of the rejected command, AggregateID is 0 for creations
+type CommandRejected struct {
+	CommandID   string            `json:"command_id"`
+	CommandType string            `json:"command_type"`
+	AggregateID int               `json:"aggregate_id"`
+	Reasons     []RejectionReason `json:"reasons"`
+	RejectedAt  time.Time         `json:"rejected_at"`
+}
+
+// RejectionReason rule of Field the command breaks, Messages holds the reason in every supported language
+type RejectionReason struct {
+	Field    string            `json:"field"`
+	Rule     string            `json:"rule"`
+	Messages map[string]string `json:"messages"`
+}
+
+type commandIDKey struct{}
+
+// WithCommandID copy of ctx carrying the envelope id of the command being handled
+func WithCommandID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, commandIDKey{}, id)
+}
+
+// CommandIDFromContext envelope id of the command being handled, empty when ctx carries none
+func CommandIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(commandIDKey{}).(string)
+	return id
+}
+
+func init() {
+	Default.RegisterProto(CommandRejectedType, 1, func() proto.Message { return new(commandEvents.CommandRejected) })
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"time"
+
+	commentEvents "github.com/radyatamaa/go-cqrs-microservices/pkg/events/proto/comment_events"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	CommentCreateType    = "comment.create"
+	CommentCreatedType   = "comment.created"
+	CommentEditType      = "comment.edit"
+	CommentEditedType    = "comment.edited"
+	CommentDeleteType    = "comment.delete"
+	CommentDeletedType   = "comment.deleted"
+	CommentModerateType  = "comment.moderate"
+	CommentModeratedType = "comment.moderated"
+)
+
+// statuses of a comment, only the visible comments are listed and counted on their article
+const (
+	CommentStatusVisible = "visible"
+	CommentStatusHidden  = "hidden"
+	CommentStatusDeleted = "deleted"
+)
+
+// CommentCreate payload of the create comment command, current version 1
+type CommentCreate struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+}
+
+// CommentCreated payload of the comment created event, current version 1
+type CommentCreated struct {
+	ID        int       `json:"id"`
+	ArticleID int       `json:"article_id"`
+	Version   int       `json:"version"`
+	Status    string    `json:"status"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CommentEdit payload of the edit comment command, current version 1
+type CommentEdit struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	ChangedBy string `json:"changed_by"`
+	Body      string `json:"body"`
+}
+
+// CommentEdited payload of the comment edited event, current version 1,
+// carries the whole comment state at Version
+type CommentEdited struct {
+	ID        int       `json:"id"`
+	ArticleID int       `json:"article_id"`
+	Version   int       `json:"version"`
+	ChangedBy string    `json:"changed_by"`
+	Status    string    `json:"status"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CommentDelete payload of the delete comment command, current version 1
+type CommentDelete struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	ChangedBy string `json:"changed_by"`
+}
+
+// CommentDeleted payload of the comment deleted event, current version 1,
+// carries the whole comment state at Version, the body of a deleted comment is erased
+type CommentDeleted struct {
+	ID             int       `json:"id"`
+	ArticleID      int       `json:"article_id"`
+	Version        int       `json:"version"`
+	ChangedBy      string    `json:"changed_by"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	Author         string    `json:"author"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CommentModerate payload of the command hiding a comment or making it visible again, current version 1
+type CommentModerate struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	ChangedBy string `json:"changed_by"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// CommentModerated payload of the comment moderated event, current version 1,
+// carries the whole comment state at Version
+type CommentModerated struct {
+	ID             int       `json:"id"`
+	ArticleID      int       `json:"article_id"`
+	Version        int       `json:"version"`
+	ChangedBy      string    `json:"changed_by"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason"`
+	Author         string    `json:"author"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func init() {
+	Default.RegisterProto(CommentCreateType, 1, func() proto.Message { return new(commentEvents.CommentCreate) })
+	Default.RegisterProto(CommentCreatedType, 1, func() proto.Message { return new(commentEvents.CommentCreated) })
+	Default.RegisterProto(CommentEditType, 1, func() proto.Message { return new(commentEvents.CommentEdit) })
+	Default.RegisterProto(CommentEditedType, 1, func() proto.Message { return new(commentEvents.CommentEdited) })
+	Default.RegisterProto(CommentDeleteType, 1, func() proto.Message { return new(commentEvents.CommentDelete) })
+	Default.RegisterProto(CommentDeletedType, 1, func() proto.Message { return new(commentEvents.CommentDeleted) })
+	Default.RegisterProto(CommentModerateType, 1, func() proto.Message { return new(commentEvents.CommentModerate) })
+	Default.RegisterProto(CommentModeratedType, 1, func() proto.Message { return new(commentEvents.CommentModerated) })
+}
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Envelope versioned wrapper of every message published on kafka
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope create new envelope for the given event type, version and payload
+func NewEnvelope(eventType string, version int, payload interface{}) (*Envelope, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		Version:    version,
+		OccurredAt: time.Now().UTC(),
+		Payload:    raw,
+	}, nil
+}
+
+// Decode unmarshal the envelope payload into v
+func (e *Envelope) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// isEnvelope report whether the raw message carries an envelope,
+// messages published before the envelope was introduced only hold the payload
+func isEnvelope(data []byte) bool {
+	var probe struct {
+		Type    string          `json:"type"`
+		Version int             `json:"version"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	return probe.Type != "" && probe.Version > 0 && len(probe.Payload) > 0
+}
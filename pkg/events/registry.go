@@ -0,0 +1,234 @@
+package events
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// schemaURL base url the schema resources are registered under
+const schemaURL = "mem://events/"
+
+//go:embed schemas/*.json
+var schemaFS embed.FS
+
+var (
+	ErrUnknownEventType   = errors.New("unknown event type")
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+	ErrMissingUpcaster    = errors.New("missing upcaster")
+	ErrSchemaValidation   = errors.New("event does not match schema")
+
+	// schema files are named <event type>.v<version>.json
+	schemaFileName = regexp.MustCompile(`^(.+)\.v(\d+)\.json$`)
+)
+
+// Default registry loaded from the schemas embedded in this package
+var Default = MustNewRegistry(schemaFS)
+
+// Upcaster transform a payload of version n into version n+1
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// Registry holds the schema of every known event version and the upcasters between them
+type Registry struct {
+	schemas   map[string]map[int]*jsonschema.Schema
+	current   map[string]int
+	upcasters map[string]map[int]Upcaster
+	protos    map[string]map[int]func() proto.Message
+}
+
+// NewRegistry compile every schema file found in fsys
+func NewRegistry(fsys fs.FS) (*Registry, error) {
+	r := &Registry{
+		schemas:   make(map[string]map[int]*jsonschema.Schema),
+		current:   make(map[string]int),
+		upcasters: make(map[string]map[int]Upcaster),
+		protos:    make(map[string]map[int]func() proto.Message),
+	}
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		match := schemaFileName.FindStringSubmatch(d.Name())
+		if match == nil {
+			return nil
+		}
+		version, err := strconv.Atoi(match[2])
+		if err != nil {
+			return errors.Wrap(err, path)
+		}
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return errors.Wrap(err, "fs.ReadFile")
+		}
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource(schemaURL+d.Name(), bytes.NewReader(content)); err != nil {
+			return errors.Wrap(err, "compiler.AddResource")
+		}
+		schema, err := compiler.Compile(schemaURL + d.Name())
+		if err != nil {
+			return errors.Wrap(err, "compiler.Compile")
+		}
+
+		r.addSchema(match[1], version, schema)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// MustNewRegistry like NewRegistry but panics when a schema can not be compiled
+func MustNewRegistry(fsys fs.FS) *Registry {
+	r, err := NewRegistry(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *Registry) addSchema(eventType string, version int, schema *jsonschema.Schema) {
+	if _, ok := r.schemas[eventType]; !ok {
+		r.schemas[eventType] = make(map[int]*jsonschema.Schema)
+	}
+	r.schemas[eventType][version] = schema
+	if version > r.current[eventType] {
+		r.current[eventType] = version
+	}
+}
+
+// RegisterUpcaster register the function upgrading eventType payloads from version `from` to `from+1`
+func (r *Registry) RegisterUpcaster(eventType string, from int, upcaster Upcaster) {
+	if _, ok := r.upcasters[eventType]; !ok {
+		r.upcasters[eventType] = make(map[int]Upcaster)
+	}
+	r.upcasters[eventType][from] = upcaster
+}
+
+// CurrentVersion latest known version of eventType
+func (r *Registry) CurrentVersion(eventType string) (int, error) {
+	version, ok := r.current[eventType]
+	if !ok {
+		return 0, errors.Wrap(ErrUnknownEventType, eventType)
+	}
+	return version, nil
+}
+
+// NewEnvelope wrap payload into an envelope of the current version of eventType after validating it
+func (r *Registry) NewEnvelope(eventType string, payload interface{}) (*Envelope, error) {
+	version, err := r.CurrentVersion(eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := NewEnvelope(eventType, version, payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewEnvelope")
+	}
+
+	if err := r.Validate(envelope); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+// Marshal wrap payload into a validated envelope and encode it
+func (r *Registry) Marshal(eventType string, payload interface{}) ([]byte, error) {
+	envelope, err := r.NewEnvelope(eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope)
+}
+
+// Unmarshal decode a message of the expected event type, validate it against the schema
+// of its version and upcast it to the current version.
+// Messages without an envelope are treated as version 1 payloads of expectedType.
+func (r *Registry) Unmarshal(expectedType string, data []byte) (*Envelope, error) {
+	envelope := &Envelope{Type: expectedType, Version: 1, Payload: data}
+	if isEnvelope(data) {
+		envelope = new(Envelope)
+		if err := json.Unmarshal(data, envelope); err != nil {
+			return nil, errors.Wrap(err, "json.Unmarshal")
+		}
+	}
+
+	if envelope.Type != expectedType {
+		return nil, errors.Wrap(ErrUnknownEventType, fmt.Sprintf("expected %s, got %s", expectedType, envelope.Type))
+	}
+
+	if err := r.Validate(envelope); err != nil {
+		return nil, err
+	}
+
+	if err := r.Upcast(envelope); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+// Validate check the envelope payload against the schema of its type and version
+func (r *Registry) Validate(envelope *Envelope) error {
+	versions, ok := r.schemas[envelope.Type]
+	if !ok {
+		return errors.Wrap(ErrUnknownEventType, envelope.Type)
+	}
+	schema, ok := versions[envelope.Version]
+	if !ok {
+		return errors.Wrap(ErrUnsupportedVersion, fmt.Sprintf("%s v%d", envelope.Type, envelope.Version))
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(envelope.Payload, &doc); err != nil {
+		return errors.Wrap(err, "json.Unmarshal")
+	}
+	if err := schema.Validate(doc); err != nil {
+		return errors.Wrap(ErrSchemaValidation, fmt.Sprintf("%s v%d: %v", envelope.Type, envelope.Version, err))
+	}
+
+	return nil
+}
+
+// Upcast apply the registered upcasters until the envelope reaches the current version,
+// every intermediate payload is validated against the schema of its version
+func (r *Registry) Upcast(envelope *Envelope) error {
+	current, err := r.CurrentVersion(envelope.Type)
+	if err != nil {
+		return err
+	}
+	if envelope.Version > current {
+		return errors.Wrap(ErrUnsupportedVersion, fmt.Sprintf("%s v%d", envelope.Type, envelope.Version))
+	}
+
+	for envelope.Version < current {
+		upcaster, ok := r.upcasters[envelope.Type][envelope.Version]
+		if !ok {
+			return errors.Wrap(ErrMissingUpcaster, fmt.Sprintf("%s v%d", envelope.Type, envelope.Version))
+		}
+		payload, err := upcaster(envelope.Payload)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("upcast %s v%d", envelope.Type, envelope.Version))
+		}
+		envelope.Payload = payload
+		envelope.Version++
+
+		if err := r.Validate(envelope); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
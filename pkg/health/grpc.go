@@ -0,0 +1,43 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// GrpcCheck check the grpc.health.v1 status of service on the server behind conn
+func GrpcCheck(conn *grpc.ClientConn, service string) Check {
+	client := healthpb.NewHealthClient(conn)
+	return func(ctx context.Context) error {
+		res, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if res.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("%s status %s", service, res.GetStatus())
+		}
+		return nil
+	}
+}
+
+// StopGrpcServer wait for the in-flight calls up to timeout then close the remaining connections,
+// returns false when the timeout elapsed
+func StopGrpcServer(s *grpc.Server, timeout time.Duration) bool {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return true
+	case <-time.After(timeout):
+		s.Stop()
+		return false
+	}
+}
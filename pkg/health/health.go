@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/heptiolabs/healthcheck"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	grpcHealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var ErrDraining = errors.New("draining, shutdown in progress")
+
+// Check dependency check, a nil error means the dependency is usable
+type Check func(ctx context.Context) error
+
+// Health readiness of a service served both as kubernetes http probes and as the grpc.health.v1 service,
+// every readiness check is evaluated once per interval and reported under its own name,
+// the overall status and the status of the grpc services are serving only when every check passes
+type Health struct {
+	http     healthcheck.Handler
+	grpc     *grpcHealth.Server
+	interval time.Duration
+	services []string
+	draining int32
+
+	mu      sync.RWMutex
+	checks  map[string]Check
+	results map[string]error
+}
+
+// New create new health of the grpc services, checks run every interval
+func New(interval time.Duration, services ...string) *Health {
+	h := &Health{
+		http:     healthcheck.NewHandler(),
+		grpc:     grpcHealth.NewServer(),
+		interval: interval,
+		services: services,
+		checks:   make(map[string]Check),
+		results:  make(map[string]error),
+	}
+	h.http.AddReadinessCheck("draining", func() error {
+		if h.Draining() {
+			return ErrDraining
+		}
+		return nil
+	})
+	h.setOverall(healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+// AddLivenessCheck failing liveness checks make kubernetes restart the service
+func (h *Health) AddLivenessCheck(name string, check healthcheck.Check) {
+	h.http.AddLivenessCheck(name, check)
+}
+
+// AddReadinessCheck register a dependency, it is reported not ready until its first evaluation
+func (h *Health) AddReadinessCheck(name string, check Check) {
+	h.mu.Lock()
+	h.checks[name] = check
+	h.results[name] = errors.New("not checked yet")
+	h.mu.Unlock()
+
+	h.grpc.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+	h.http.AddReadinessCheck(name, func() error {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		return h.results[name]
+	})
+}
+
+// Register serve grpc.health.v1 on the grpc server
+func (h *Health) Register(s *grpc.Server) {
+	healthpb.RegisterHealthServer(s, h.grpc)
+}
+
+// Handler kubernetes probes, /live and /ready
+func (h *Health) Handler() http.Handler {
+	return h.http
+}
+
+// Run evaluate the checks every interval until ctx is done
+func (h *Health) Run(ctx context.Context) {
+	h.check(ctx)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.check(ctx)
+		}
+	}
+}
+
+// Drain report the service not ready from now on so no new traffic is routed to it
+func (h *Health) Drain() {
+	atomic.StoreInt32(&h.draining, 1)
+	// every status is set to NOT_SERVING and later updates are ignored
+	h.grpc.Shutdown()
+}
+
+func (h *Health) Draining() bool {
+	return atomic.LoadInt32(&h.draining) == 1
+}
+
+func (h *Health) check(ctx context.Context) {
+	h.mu.RLock()
+	checks := make(map[string]Check, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	results := make(map[string]error, len(checks))
+	for name, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, h.interval)
+		results[name] = check(checkCtx)
+		cancel()
+	}
+
+	overall := healthpb.HealthCheckResponse_SERVING
+	h.mu.Lock()
+	for name, err := range results {
+		h.results[name] = err
+		h.grpc.SetServingStatus(name, servingStatus(err))
+		if err != nil {
+			overall = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	h.mu.Unlock()
+
+	h.setOverall(overall)
+}
+
+func (h *Health) setOverall(status healthpb.HealthCheckResponse_ServingStatus) {
+	h.grpc.SetServingStatus("", status)
+	for _, service := range h.services {
+		h.grpc.SetServingStatus(service, status)
+	}
+}
+
+func servingStatus(err error) healthpb.HealthCheckResponse_ServingStatus {
+	if err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// FetchBatch fetch up to size messages, waits for the first one then returns once size messages are fetched
+// or timeout elapsed since the first one. The messages fetched before ctx is done are returned along with its error
+func FetchBatch(ctx context.Context, r *kafka.Reader, size int, timeout time.Duration) ([]kafka.Message, error) {
+	m, err := r.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch := append(make([]kafka.Message, 0, size), m)
+
+	batchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for len(batch) < size {
+		m, err := r.FetchMessage(batchCtx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return batch, ctx.Err()
+			}
+			// the timeout closes the batch
+			return batch, nil
+		}
+		batch = append(batch, m)
+	}
+	return batch, nil
+}
@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ContentTypeHeader   = "content-type"
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var (
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+	ErrNotProtoMessage        = errors.New("value is not a proto.Message")
+
+	JSONCodec     Codec = jsonCodec{}
+	ProtobufCodec Codec = protobufCodec{}
+)
+
+// Codec encode and decode kafka message values of a given content type
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+	return proto.Marshal(msg)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// CodecFor return the codec of contentType, an empty content type is treated as json
+func CodecFor(contentType string) (Codec, error) {
+	switch contentType {
+	case "", ContentTypeJSON:
+		return JSONCodec, nil
+	case ContentTypeProtobuf:
+		return ProtobufCodec, nil
+	default:
+		return nil, errors.Wrap(ErrUnsupportedContentType, contentType)
+	}
+}
+
+// MessageCodec return the codec matching the content type header of m,
+// messages published without the header are json
+func MessageCodec(m kafka.Message) (Codec, error) {
+	return CodecFor(GetHeader(m, ContentTypeHeader))
+}
+
+// GetHeader return the value of the first header named key
+func GetHeader(m kafka.Message, key string) string {
+	for _, h := range m.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// NewMessage create message of topic with value encoded by codec and the matching content type header
+func NewMessage(topic string, codec Codec, value []byte) kafka.Message {
+	return kafka.Message{
+		Topic:   topic,
+		Value:   value,
+		Headers: []kafka.Header{{Key: ContentTypeHeader, Value: []byte(codec.ContentType())}},
+		Time:    time.Now().UTC(),
+	}
+}
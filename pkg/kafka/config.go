@@ -1,10 +1,29 @@
 package kafka
 
+import "time"
+
 // Config kafka config
 type Config struct {
 	Brokers    []string `mapstructure:"brokers"`
 	GroupID    string   `mapstructure:"groupID"`
 	InitTopics bool     `mapstructure:"initTopics"`
+	// ContentType encoding of the produced messages, application/json or application/x-protobuf
+	ContentType string `mapstructure:"contentType"`
+	// BatchSize and BatchTimeoutMs bound the micro-batches of the consumers projecting in bulk
+	BatchSize      int `mapstructure:"batchSize"`
+	BatchTimeoutMs int `mapstructure:"batchTimeoutMs"`
+}
+
+// Batch size and timeout of the micro-batches, the defaults when unset
+func (c *Config) Batch() (int, time.Duration) {
+	size, timeout := c.BatchSize, time.Duration(c.BatchTimeoutMs)*time.Millisecond
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	if timeout <= 0 {
+		timeout = defaultBatchTimeout
+	}
+	return size, timeout
 }
 
 // TopicConfig kafka topic config
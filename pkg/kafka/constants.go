@@ -17,4 +17,7 @@ const (
 	writerWriteTimeout = 10 * time.Second
 	writerRequiredAcks = -1
 	writerMaxAttempts  = 3
+
+	defaultBatchSize    = 100
+	defaultBatchTimeout = 200 * time.Millisecond
 )
@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/segmentio/kafka-go"
@@ -17,6 +18,20 @@ type MessageProcessor interface {
 // Worker kafka consumer worker fetch and process messages from reader
 type Worker func(ctx context.Context, r *kafka.Reader, wg *sync.WaitGroup, workerID int)
 
+// BatchProcessor processor methods must implement kafka.BatchHandler func method interface
+type BatchProcessor interface {
+	ProcessBatch(ctx context.Context, batch []kafka.Message, workerID int) error
+}
+
+// BatchHandler process every message of batch and return once they are all done, the messages that can not be
+// processed are handed to the dead letter topic. The error is the end of ctx, the batch is then left uncommitted
+type BatchHandler func(ctx context.Context, batch []kafka.Message, workerID int) error
+
+type batchJob struct {
+	messages []kafka.Message
+	done     chan error
+}
+
 type ConsumerGroup interface {
 	ConsumeTopic(ctx context.Context, cancel context.CancelFunc, groupID, topic string, poolSize int, worker Worker)
 	GetNewKafkaReader(kafkaURL []string, topic, groupID string) *kafka.Reader
@@ -88,3 +103,92 @@ func (c *consumerGroup) ConsumeTopic(ctx context.Context, groupTopics []string,
 	}
 	wg.Wait()
 }
+
+// ConsumeBatches start consumer group with a single loop fetching the micro-batches of the reader and handing each
+// whole batch to one of poolSize workers. The batches are committed in the order they were fetched, a batch once it
+// and every batch fetched before it are handled, so a commit never covers a message still being processed
+func (c *consumerGroup) ConsumeBatches(ctx context.Context, groupTopics []string, poolSize, size int, timeout time.Duration, handler BatchHandler) {
+	r := c.GetNewKafkaReader(c.Brokers, groupTopics, c.GroupID)
+
+	defer func() {
+		if err := r.Close(); err != nil {
+			c.log.Warnf("consumerGroup.r.Close: %v", err)
+		}
+	}()
+
+	c.log.Infof("Starting batch consumer groupID: %s, topic: %+v, pool size: %v", c.GroupID, groupTopics, poolSize)
+
+	jobs := make(chan *batchJob)
+	// pending holds the batches in the order they were fetched, its capacity bounds the batches in flight
+	pending := make(chan *batchJob, poolSize)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < poolSize; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for job := range jobs {
+				job.done <- handler(ctx, job.messages, workerID)
+			}
+		}(i)
+	}
+
+	committed := make(chan struct{})
+	go func() {
+		defer close(committed)
+		c.commitBatches(ctx, r, pending)
+	}()
+
+	c.fetchBatches(ctx, r, size, timeout, jobs, pending)
+	close(jobs)
+	close(pending)
+	wg.Wait()
+	<-committed
+}
+
+// fetchBatches fetch the micro-batches until ctx is done, every batch is queued for the commit before a worker gets it
+func (c *consumerGroup) fetchBatches(ctx context.Context, r *kafka.Reader, size int, timeout time.Duration, jobs, pending chan<- *batchJob) {
+	for {
+		batch, err := FetchBatch(ctx, r, size, timeout)
+		if ctx.Err() != nil {
+			// the messages of a batch cut short are not committed and get redelivered
+			return
+		}
+		if err != nil {
+			c.log.Warnf("consumerGroup.FetchBatch: %v", err)
+			continue
+		}
+
+		job := &batchJob{messages: batch, done: make(chan error, 1)}
+		select {
+		case pending <- job:
+		case <-ctx.Done():
+			return
+		}
+		jobs <- job
+	}
+}
+
+// commitBatches commit the batches in the order they were fetched, a failed batch stops the commits:
+// the batch and every batch after it are redelivered to the next consumer of the partitions
+func (c *consumerGroup) commitBatches(ctx context.Context, r *kafka.Reader, pending <-chan *batchJob) {
+	failed := false
+	for job := range pending {
+		if err := <-job.done; err != nil {
+			if !failed {
+				c.log.Warnf("consumerGroup.BatchHandler: %v", err)
+			}
+			failed = true
+		}
+		if failed {
+			continue
+		}
+
+		for _, m := range job.messages {
+			c.log.KafkaLogCommittedMessage(m.Topic, m.Partition, m.Offset)
+		}
+		if err := r.CommitMessages(ctx, job.messages...); err != nil {
+			c.log.WarnMsg("consumerGroup.CommitMessages", err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// DeadLetterTopicHeader, DeadLetterPartitionHeader and DeadLetterOffsetHeader locate the message
+	// a dead letter is a copy of, DeadLetterErrorHeader holds the error it failed with
+	DeadLetterTopicHeader     = "dead-letter-topic"
+	DeadLetterPartitionHeader = "dead-letter-partition"
+	DeadLetterOffsetHeader    = "dead-letter-offset"
+	DeadLetterErrorHeader     = "dead-letter-error"
+)
+
+// DeadLetter keep the messages a consumer gives up on, so they can be inspected and replayed
+// instead of holding back the messages after them
+type DeadLetter interface {
+	Publish(ctx context.Context, m kafka.Message, cause error) error
+}
+
+type deadLetter struct {
+	producer Producer
+	topic    string
+}
+
+// NewDeadLetter dead letter publishing to topic
+func NewDeadLetter(producer Producer, topic string) *deadLetter {
+	return &deadLetter{producer: producer, topic: topic}
+}
+
+// Publish copy m to the dead letter topic with its key, value and headers, along with its origin and cause
+func (d *deadLetter) Publish(ctx context.Context, m kafka.Message, cause error) error {
+	headers := append(make([]kafka.Header, 0, len(m.Headers)+4), m.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: DeadLetterTopicHeader, Value: []byte(m.Topic)},
+		kafka.Header{Key: DeadLetterPartitionHeader, Value: []byte(strconv.Itoa(m.Partition))},
+		kafka.Header{Key: DeadLetterOffsetHeader, Value: []byte(strconv.FormatInt(m.Offset, 10))},
+		kafka.Header{Key: DeadLetterErrorHeader, Value: []byte(cause.Error())},
+	)
+	return d.producer.PublishMessage(ctx, kafka.Message{
+		Topic:   d.topic,
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+		Time:    m.Time,
+	})
+}
@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/segmentio/kafka-go"
 )
@@ -23,7 +24,11 @@ func NewProducer(log zaplogger.Logger, brokers []string) *producer {
 	return &producer{log: log, brokers: brokers, w: NewWriter(brokers, kafka.LoggerFunc(log.Errorf))}
 }
 
+// PublishMessage write the messages, each one carries the tenant id of ctx
 func (p *producer) PublishMessage(ctx context.Context, msgs ...kafka.Message) error {
+	for i := range msgs {
+		tenant.SetMessageHeader(ctx, &msgs[i])
+	}
 	return p.w.WriteMessages(ctx, msgs...)
 }
 
@@ -25,3 +25,25 @@ func NewKafkaReader(kafkaURL []string, topic, groupID string, errLogger kafka.Lo
 		},
 	})
 }
+
+// NewTailReader create new reader of the messages published on the topics from now on, groupID must be unique
+// to the process so every instance receives every partition. The offsets are never committed
+func NewTailReader(kafkaURL []string, groupTopics []string, groupID string, errLogger kafka.Logger) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:                kafkaURL,
+		GroupID:                groupID,
+		GroupTopics:            groupTopics,
+		StartOffset:            kafka.LastOffset,
+		MinBytes:               1,
+		MaxBytes:               maxBytes,
+		QueueCapacity:          queueCapacity,
+		HeartbeatInterval:      heartbeatInterval,
+		PartitionWatchInterval: partitionWatchInterval,
+		ErrorLogger:            errLogger,
+		MaxAttempts:            maxAttempts,
+		MaxWait:                maxWait,
+		Dialer: &kafka.Dialer{
+			Timeout: dialTimeout,
+		},
+	})
+}
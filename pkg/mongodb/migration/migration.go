@@ -0,0 +1,245 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	lockID        = "lock"
+	lockTTL       = 5 * time.Minute
+	lockRetryWait = time.Second
+
+	// mongo error codes returned when an index with the same name is defined differently
+	indexOptionsConflict  = 85
+	indexKeySpecsConflict = 86
+)
+
+var ErrDuplicateVersion = errors.New("duplicate data migration version")
+
+// Collection declarative definition of a collection, its indexes and its json schema validator
+type Collection struct {
+	Name    string
+	Indexes []mongo.IndexModel
+	// Validator $jsonSchema document, no validation when nil
+	Validator bson.M
+	// ValidationAction error or warn, error when empty
+	ValidationAction string
+}
+
+// DataMigration versioned change of the stored documents, Up must be safe to run again after a failure
+type DataMigration struct {
+	Version int64
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+}
+
+// Record document of the migrations collection for an applied data migration
+type Record struct {
+	Version   int64     `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"appliedAt"`
+}
+
+// Status state of a data migration
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator bootstrap the collections of a database and apply its data migrations,
+// concurrent runs are serialized with a lock document in the migrations collection
+type Migrator struct {
+	db             *mongo.Database
+	migrationsColl string
+	collections    []Collection
+	dataMigrations []DataMigration
+}
+
+// New create new migrator of db recording the applied data migrations into migrationsColl
+func New(db *mongo.Database, migrationsColl string, collections []Collection, dataMigrations []DataMigration) (*Migrator, error) {
+	sorted := append([]DataMigration(nil), dataMigrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			return nil, errors.Wrap(ErrDuplicateVersion, fmt.Sprintf("%d", sorted[i].Version))
+		}
+	}
+
+	return &Migrator{
+		db:             db,
+		migrationsColl: migrationsColl,
+		collections:    collections,
+		dataMigrations: sorted,
+	}, nil
+}
+
+// Run bootstrap every collection then apply the pending data migrations,
+// returns the data migrations applied
+func (m *Migrator) Run(ctx context.Context) ([]DataMigration, error) {
+	var applied []DataMigration
+	err := m.withLock(ctx, func() error {
+		for _, c := range m.collections {
+			if err := m.bootstrap(ctx, c); err != nil {
+				return errors.Wrap(err, "bootstrap "+c.Name)
+			}
+		}
+
+		done, err := m.records(ctx)
+		if err != nil {
+			return err
+		}
+		for _, dm := range m.dataMigrations {
+			if _, ok := done[dm.Version]; ok {
+				continue
+			}
+			if err := dm.Up(ctx, m.db); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("data migration %04d_%s", dm.Version, dm.Name))
+			}
+			record := Record{Version: dm.Version, Name: dm.Name, AppliedAt: time.Now().UTC()}
+			if _, err := m.db.Collection(m.migrationsColl).InsertOne(ctx, record); err != nil {
+				return errors.Wrap(err, "InsertOne")
+			}
+			applied = append(applied, dm)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Status report every data migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	done, err := m.records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.dataMigrations))
+	for _, dm := range m.dataMigrations {
+		s := Status{Version: dm.Version, Name: dm.Name}
+		if r, ok := done[dm.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = r.AppliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) records(ctx context.Context) (map[int64]Record, error) {
+	cursor, err := m.db.Collection(m.migrationsColl).Find(ctx, bson.M{"_id": bson.M{"$ne": lockID}})
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	defer cursor.Close(ctx) // nolint: errcheck
+
+	records := make(map[int64]Record)
+	for cursor.Next(ctx) {
+		var r Record
+		if err := cursor.Decode(&r); err != nil {
+			return nil, errors.Wrap(err, "Decode")
+		}
+		records[r.Version] = r
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, errors.Wrap(err, "cursor.Err")
+	}
+
+	return records, nil
+}
+
+// bootstrap create the collection when missing, replace its validator and create its indexes,
+// an index whose definition changed is dropped and created again
+func (m *Migrator) bootstrap(ctx context.Context, c Collection) error {
+	names, err := m.db.ListCollectionNames(ctx, bson.M{"name": c.Name})
+	if err != nil {
+		return errors.Wrap(err, "ListCollectionNames")
+	}
+
+	action := c.ValidationAction
+	if action == "" {
+		action = "error"
+	}
+
+	if len(names) == 0 {
+		opts := options.CreateCollection()
+		if c.Validator != nil {
+			opts.SetValidator(bson.M{"$jsonSchema": c.Validator}).SetValidationAction(action)
+		}
+		if err := m.db.CreateCollection(ctx, c.Name, opts); err != nil {
+			return errors.Wrap(err, "CreateCollection")
+		}
+	} else if c.Validator != nil {
+		cmd := bson.D{
+			{Key: "collMod", Value: c.Name},
+			{Key: "validator", Value: bson.M{"$jsonSchema": c.Validator}},
+			{Key: "validationAction", Value: action},
+		}
+		if err := m.db.RunCommand(ctx, cmd).Err(); err != nil {
+			return errors.Wrap(err, "collMod")
+		}
+	}
+
+	indexes := m.db.Collection(c.Name).Indexes()
+	for _, index := range c.Indexes {
+		_, err := indexes.CreateOne(ctx, index)
+		if err == nil {
+			continue
+		}
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || (cmdErr.Code != indexOptionsConflict && cmdErr.Code != indexKeySpecsConflict) {
+			return errors.Wrap(err, "Indexes.CreateOne")
+		}
+		if index.Options == nil || index.Options.Name == nil {
+			return errors.Wrap(err, "Indexes.CreateOne, name the index to let it be replaced")
+		}
+		if _, err := indexes.DropOne(ctx, *index.Options.Name); err != nil {
+			return errors.Wrap(err, "Indexes.DropOne")
+		}
+		if _, err := indexes.CreateOne(ctx, index); err != nil {
+			return errors.Wrap(err, "Indexes.CreateOne")
+		}
+	}
+
+	return nil
+}
+
+// withLock run fn while holding the lock document, waiting for the lock to be released
+// or to expire when another migrator holds it
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	coll := m.db.Collection(m.migrationsColl)
+
+	for {
+		now := time.Now().UTC()
+		// the upsert only matches an expired lock, a held lock makes the insert fail on the duplicate _id
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": lockID, "expiresAt": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"expiresAt": now.Add(lockTTL)}},
+			options.Update().SetUpsert(true))
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return errors.Wrap(err, "acquire lock")
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "acquire lock")
+		case <-time.After(lockRetryWait):
+		}
+	}
+	defer coll.DeleteOne(context.Background(), bson.M{"_id": lockID}) // nolint: errcheck
+
+	return fn()
+}
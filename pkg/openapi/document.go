@@ -0,0 +1,345 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// documentURL base url the document is registered under, the schema pointers are resolved against it
+const documentURL = "mem://openapi/openapi.json"
+
+var methods = []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch}
+
+// Document the operations of an OpenAPI 3.1 document with their schemas compiled
+type Document struct {
+	basePath   string
+	operations []*Operation
+}
+
+// Operation an operation of the document, the values of its parameters are coerced to their schema type before validation
+type Operation struct {
+	ID        string
+	Method    string
+	Path      string
+	segments  []string
+	params    []*parameter
+	body      *requestBody
+	responses map[string]map[string]*jsonschema.Schema
+}
+
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	explode  bool
+	kind     string
+	itemKind string
+	schema   *jsonschema.Schema
+}
+
+type requestBody struct {
+	required bool
+	schemas  map[string]*jsonschema.Schema
+}
+
+type specDocument struct {
+	OpenAPI string `json:"openapi"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas       map[string]map[string]interface{} `json:"schemas"`
+		Parameters    map[string]specParameter          `json:"parameters"`
+		RequestBodies map[string]specRequestBody        `json:"requestBodies"`
+		Responses     map[string]specResponse           `json:"responses"`
+	} `json:"components"`
+}
+
+type specOperation struct {
+	OperationID string                  `json:"operationId"`
+	Parameters  []specParameter         `json:"parameters"`
+	RequestBody *specRequestBody        `json:"requestBody"`
+	Responses   map[string]specResponse `json:"responses"`
+}
+
+type specParameter struct {
+	Ref      string                 `json:"$ref"`
+	Name     string                 `json:"name"`
+	In       string                 `json:"in"`
+	Required bool                   `json:"required"`
+	Explode  *bool                  `json:"explode"`
+	Schema   map[string]interface{} `json:"schema"`
+}
+
+type specRequestBody struct {
+	Ref      string                     `json:"$ref"`
+	Required bool                       `json:"required"`
+	Content  map[string]json.RawMessage `json:"content"`
+}
+
+type specResponse struct {
+	Ref     string                                `json:"$ref"`
+	Content map[string]map[string]json.RawMessage `json:"content"`
+}
+
+// NewDocument compile the schemas of every operation of the document, the base path is the path of its first server
+func NewDocument(content []byte) (*Document, error) {
+	var spec specDocument
+	if err := json.Unmarshal(content, &spec); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.1") {
+		return nil, errors.Errorf("openapi version %q is not 3.1", spec.OpenAPI)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	compiler.AssertFormat = true
+	if err := compiler.AddResource(documentURL, bytes.NewReader(content)); err != nil {
+		return nil, errors.Wrap(err, "compiler.AddResource")
+	}
+
+	d := &Document{}
+	if len(spec.Servers) > 0 && strings.HasPrefix(spec.Servers[0].URL, "/") {
+		d.basePath = strings.TrimSuffix(spec.Servers[0].URL, "/")
+	}
+
+	for path, item := range spec.Paths {
+		var shared []specParameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, errors.Wrapf(err, "parameters of %s", path)
+			}
+		}
+		for _, method := range methods {
+			raw, ok := item[strings.ToLower(method)]
+			if !ok {
+				continue
+			}
+			var op specOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, errors.Wrapf(err, "%s %s", method, path)
+			}
+			operation, err := d.compileOperation(compiler, &spec, path, method, shared, op)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s %s", method, path)
+			}
+			d.operations = append(d.operations, operation)
+		}
+	}
+
+	// the literal segments win over the path parameters, e.g. /articles/stream over /articles/{id}
+	sort.SliceStable(d.operations, func(i, j int) bool {
+		return literalSegments(d.operations[i].segments) > literalSegments(d.operations[j].segments)
+	})
+	return d, nil
+}
+
+func (d *Document) compileOperation(compiler *jsonschema.Compiler, spec *specDocument, path string, method string, shared []specParameter, op specOperation) (*Operation, error) {
+	operation := &Operation{
+		ID:        op.OperationID,
+		Method:    method,
+		Path:      path,
+		segments:  strings.Split(strings.Trim(path, "/"), "/"),
+		responses: make(map[string]map[string]*jsonschema.Schema),
+	}
+	pointer := "#/paths/" + escape(path) + "/" + strings.ToLower(method)
+
+	byName := make(map[string]*parameter)
+	var names []string
+	for i, list := range [][]specParameter{shared, op.Parameters} {
+		listPointer := "#/paths/" + escape(path) + "/parameters/"
+		if i == 1 {
+			listPointer = pointer + "/parameters/"
+		}
+		for j, p := range list {
+			paramPointer := listPointer + strconv.Itoa(j)
+			if p.Ref != "" {
+				name := strings.TrimPrefix(p.Ref, "#/components/parameters/")
+				ref, ok := spec.Components.Parameters[name]
+				if !ok {
+					return nil, errors.Errorf("unknown parameter %s", p.Ref)
+				}
+				p, paramPointer = ref, "#/components/parameters/"+escape(name)
+			}
+			param, err := compileParameter(compiler, spec, paramPointer, p)
+			if err != nil {
+				return nil, err
+			}
+			key := param.in + ":" + param.name
+			if _, ok := byName[key]; !ok {
+				names = append(names, key)
+			}
+			// the parameters of the operation override the ones of the path
+			byName[key] = param
+		}
+	}
+	for _, key := range names {
+		operation.params = append(operation.params, byName[key])
+	}
+
+	if op.RequestBody != nil {
+		body, bodyPointer := *op.RequestBody, pointer+"/requestBody"
+		if body.Ref != "" {
+			name := strings.TrimPrefix(body.Ref, "#/components/requestBodies/")
+			ref, ok := spec.Components.RequestBodies[name]
+			if !ok {
+				return nil, errors.Errorf("unknown request body %s", body.Ref)
+			}
+			body, bodyPointer = ref, "#/components/requestBodies/"+escape(name)
+		}
+		operation.body = &requestBody{required: body.Required, schemas: make(map[string]*jsonschema.Schema)}
+		for mediaType, raw := range body.Content {
+			schema, err := compileMediaType(compiler, bodyPointer+"/content/"+escape(mediaType), raw)
+			if err != nil {
+				return nil, err
+			}
+			operation.body.schemas[mediaType] = schema
+		}
+	}
+
+	for status, res := range op.Responses {
+		resPointer := pointer + "/responses/" + escape(status)
+		if res.Ref != "" {
+			name := strings.TrimPrefix(res.Ref, "#/components/responses/")
+			ref, ok := spec.Components.Responses[name]
+			if !ok {
+				return nil, errors.Errorf("unknown response %s", res.Ref)
+			}
+			res, resPointer = ref, "#/components/responses/"+escape(name)
+		}
+		operation.responses[strings.ToUpper(status)] = make(map[string]*jsonschema.Schema)
+		for mediaType, content := range res.Content {
+			var schema *jsonschema.Schema
+			if _, ok := content["schema"]; ok {
+				var err error
+				schema, err = compiler.Compile(documentURL + resPointer + "/content/" + escape(mediaType) + "/schema")
+				if err != nil {
+					return nil, errors.Wrapf(err, "response %s", status)
+				}
+			}
+			operation.responses[strings.ToUpper(status)][mediaType] = schema
+		}
+	}
+
+	return operation, nil
+}
+
+func compileParameter(compiler *jsonschema.Compiler, spec *specDocument, pointer string, p specParameter) (*parameter, error) {
+	param := &parameter{
+		name:     p.Name,
+		in:       p.In,
+		required: p.Required || p.In == "path",
+		// form, the default style of the query parameters, explodes the arrays
+		explode: p.In == "query",
+	}
+	if p.Explode != nil {
+		param.explode = *p.Explode
+	}
+	if p.Schema == nil {
+		return param, nil
+	}
+	param.kind = schemaType(spec, p.Schema)
+	if items, ok := p.Schema["items"].(map[string]interface{}); ok {
+		param.itemKind = schemaType(spec, items)
+	}
+	schema, err := compiler.Compile(documentURL + pointer + "/schema")
+	if err != nil {
+		return nil, errors.Wrapf(err, "parameter %s", p.Name)
+	}
+	param.schema = schema
+	return param, nil
+}
+
+func compileMediaType(compiler *jsonschema.Compiler, pointer string, raw json.RawMessage) (*jsonschema.Schema, error) {
+	var content map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return nil, errors.Wrap(err, pointer)
+	}
+	if _, ok := content["schema"]; !ok {
+		return nil, nil
+	}
+	schema, err := compiler.Compile(documentURL + pointer + "/schema")
+	if err != nil {
+		return nil, errors.Wrap(err, pointer)
+	}
+	return schema, nil
+}
+
+// schemaType the first type of the schema, following a reference to the components
+func schemaType(spec *specDocument, schema map[string]interface{}) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		if target, ok := spec.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; ok {
+			return schemaType(spec, target)
+		}
+		return ""
+	}
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// Find the operation serving the request path and the values of its path parameters
+func (d *Document) Find(method string, path string) (*Operation, map[string]string, bool) {
+	if !strings.HasPrefix(path, d.basePath+"/") {
+		return nil, nil, false
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, d.basePath), "/"), "/")
+	for _, operation := range d.operations {
+		if operation.Method != method || len(operation.segments) != len(segments) {
+			continue
+		}
+		if values, ok := operation.match(segments); ok {
+			return operation, values, true
+		}
+	}
+	return nil, nil, false
+}
+
+func (o *Operation) match(segments []string) (map[string]string, bool) {
+	values := make(map[string]string)
+	for i, segment := range o.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			values[segment[1:len(segment)-1]] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+func literalSegments(segments []string) int {
+	count := 0
+	for _, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			count++
+		}
+	}
+	return count
+}
+
+// escape a key of the document as a json pointer token
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
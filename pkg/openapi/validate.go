@@ -0,0 +1,323 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/response"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InBody   = "body"
+)
+
+var (
+	ErrRequestInvalid  = errors.New("request does not match the openapi document")
+	ErrResponseInvalid = errors.New("response does not match the openapi document")
+
+	// missingProperties the properties named by the message of the required keyword
+	missingProperties = regexp.MustCompile(`'([^']+)'`)
+)
+
+// RequestError the invalid values of a request, In is where the first one is: path, query, header or body.
+// The messages are the ones of the schema validation, the same in every language
+type RequestError struct {
+	In     string
+	Fields []response.Errors
+}
+
+func (e *RequestError) Error() string {
+	return ErrRequestInvalid.Error() + ": " + joinFields(e.Fields)
+}
+
+func (e *RequestError) Unwrap() error {
+	return ErrRequestInvalid
+}
+
+// FieldErrors implements response.FieldsError
+func (e *RequestError) FieldErrors(string) []response.Errors {
+	return e.Fields
+}
+
+// ValidateRequest check the parameters and the body of the request, body is the already read request body.
+// A body of a media type the operation does not describe is checked against its application/json schema,
+// the handlers read any other body as JSON. The schema of a JSON lines media type (e.g. application/x-ndjson)
+// describes each line, the bodies of the other media types that are not JSON are not checked
+func (o *Operation) ValidateRequest(r *http.Request, pathValues map[string]string, body []byte) error {
+	result := &RequestError{}
+	add := func(in string, fields ...response.Errors) {
+		if len(fields) == 0 {
+			return
+		}
+		if result.In == "" {
+			result.In = in
+		}
+		result.Fields = append(result.Fields, fields...)
+	}
+
+	query := r.URL.Query()
+	for _, param := range o.params {
+		var values []string
+		switch param.in {
+		case InPath:
+			if value, ok := pathValues[param.name]; ok {
+				values = []string{value}
+			}
+		case InQuery:
+			values = query[param.name]
+		case InHeader:
+			values = r.Header.Values(param.name)
+		default:
+			continue
+		}
+		add(param.in, param.validate(values)...)
+	}
+
+	if o.body != nil {
+		add(InBody, o.body.validate(r.Header.Get("Content-Type"), body)...)
+	}
+
+	if len(result.Fields) > 0 {
+		return result
+	}
+	return nil
+}
+
+func (p *parameter) validate(values []string) []response.Errors {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "" && p.in != InPath) {
+		if p.required {
+			return []response.Errors{{Field: p.name, Description: "is required"}}
+		}
+		return nil
+	}
+	if p.schema == nil {
+		return nil
+	}
+
+	var value interface{}
+	if p.kind == "array" {
+		if !p.explode {
+			values = strings.Split(values[0], ",")
+		}
+		items := make([]interface{}, 0, len(values))
+		for _, item := range values {
+			coerced, err := coerce(p.itemKind, strings.TrimSpace(item))
+			if err != nil {
+				return []response.Errors{{Field: p.name, Description: err.Error()}}
+			}
+			items = append(items, coerced)
+		}
+		value = items
+	} else {
+		coerced, err := coerce(p.kind, values[0])
+		if err != nil {
+			return []response.Errors{{Field: p.name, Description: err.Error()}}
+		}
+		value = coerced
+	}
+
+	return fieldErrors(p.name, p.schema.Validate(value))
+}
+
+// coerce a parameter value to the type of its schema
+func coerce(kind string, value string) (interface{}, error) {
+	switch kind {
+	case "integer":
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value)
+		}
+		return i, nil
+	case "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return b, nil
+	}
+	return value, nil
+}
+
+func (b *requestBody) validate(contentType string, body []byte) []response.Errors {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if b.required {
+			return []response.Errors{{Field: InBody, Description: "is required"}}
+		}
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	schema, ok := b.schemas[mediaType]
+	if !ok {
+		mediaType = "application/json"
+		schema = b.schemas[mediaType]
+	}
+	if schema == nil {
+		return nil
+	}
+
+	switch {
+	case jsonLines(mediaType):
+		return validateLines(schema, body)
+	case !jsonMediaType(mediaType):
+		return nil
+	}
+
+	value, err := decode(body)
+	if err != nil {
+		return []response.Errors{{Field: "json", Description: err.Error()}}
+	}
+	fields := fieldErrors("", schema.Validate(value))
+	for i := range fields {
+		// the errors of the whole body are named after it
+		if fields[i].Field == "" {
+			fields[i].Field = InBody
+		}
+	}
+	return fields
+}
+
+// validateLines check every line of the body against schema, the lines are named after their index
+// without the blank lines. A line that is not JSON is left to the handler, which reports it on its own item
+func validateLines(schema *jsonschema.Schema, body []byte) []response.Errors {
+	var fields []response.Errors
+	index := 0
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if value, err := decode(line); err == nil {
+			fields = append(fields, fieldErrors(strconv.Itoa(index), schema.Validate(value))...)
+		}
+		index++
+	}
+	return fields
+}
+
+// jsonLines whether the media type is a sequence of JSON values, one per line
+func jsonLines(mediaType string) bool {
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return true
+	}
+	return false
+}
+
+// jsonMediaType whether the media type is JSON, e.g. application/json or application/problem+json
+func jsonMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// ValidateResponse check the JSON body answered with status against the schema of the operation,
+// the status is looked up as is, then as its range (e.g. 4XX), then default
+func (o *Operation) ValidateResponse(status int, contentType string, body []byte) error {
+	code := strconv.Itoa(status)
+	content, ok := o.responses[code]
+	if !ok {
+		content, ok = o.responses[code[:1]+"XX"]
+	}
+	if !ok {
+		content, ok = o.responses["DEFAULT"]
+	}
+	if !ok {
+		return errors.Wrapf(ErrResponseInvalid, "status %d is not documented", status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	schema, ok := content[mediaType]
+	if !ok && len(content) > 0 {
+		return errors.Wrapf(ErrResponseInvalid, "content type %q is not documented for status %d", contentType, status)
+	}
+	if schema == nil {
+		return nil
+	}
+
+	value, err := decode(body)
+	if err != nil {
+		return errors.Wrap(ErrResponseInvalid, err.Error())
+	}
+	if fields := fieldErrors("", schema.Validate(value)); len(fields) > 0 {
+		return errors.Wrap(ErrResponseInvalid, joinFields(fields))
+	}
+	return nil
+}
+
+func decode(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// fieldErrors the leaves of the validation error, named after the location of the invalid value under prefix
+func fieldErrors(prefix string, err error) []response.Errors {
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return []response.Errors{{Field: prefix, Description: err.Error()}}
+	}
+
+	var fields []response.Errors
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, cause := range e.Causes {
+				walk(cause)
+			}
+			return
+		}
+		field := fieldName(prefix, e.InstanceLocation)
+		if strings.HasSuffix(e.KeywordLocation, "/required") {
+			for _, match := range missingProperties.FindAllStringSubmatch(e.Message, -1) {
+				fields = append(fields, response.Errors{Field: fieldName(field, "/"+match[1]), Description: "is required"})
+			}
+			return
+		}
+		fields = append(fields, response.Errors{Field: field, Description: e.Message})
+	}
+	walk(validationErr)
+	return fields
+}
+
+func joinFields(fields []response.Errors) string {
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field.Field+": "+field.Description)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// fieldName a json pointer as a dotted name, e.g. /tags/0 is tags.0
+func fieldName(prefix string, pointer string) string {
+	name := strings.ReplaceAll(strings.Trim(pointer, "/"), "/", ".")
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+	switch {
+	case prefix == "":
+		return name
+	case name == "":
+		return prefix
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,137 @@
+package openapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const batchDocument = `{
+  "openapi": "3.1.0",
+  "servers": [{"url": "/api"}],
+  "paths": {
+    "/v1/articles:batch": {
+      "post": {
+        "operationId": "createArticlesBatch",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Article"}}},
+            "application/x-ndjson": {"schema": {"$ref": "#/components/schemas/Article"}},
+            "text/plain": {"schema": {"type": "string"}}
+          }
+        },
+        "responses": {"202": {"description": "accepted"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Article": {
+        "type": "object",
+        "required": ["title"],
+        "properties": {"title": {"type": "string"}, "body": {"type": "string"}}
+      }
+    }
+  }
+}`
+
+func TestValidateRequestBody(t *testing.T) {
+	document, err := NewDocument([]byte(batchDocument))
+	if err != nil {
+		t.Fatalf("NewDocument: %v", err)
+	}
+	operation, values, ok := document.Find(http.MethodPost, "/api/v1/articles:batch")
+	if !ok {
+		t.Fatal("the batch operation is not found")
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		fields      []string
+	}{
+		{
+			name:        "json array",
+			contentType: "application/json",
+			body:        `[{"title":"a"},{"title":"b","body":"c"}]`,
+		},
+		{
+			name:        "json array with an invalid item",
+			contentType: "application/json",
+			body:        `[{"title":"a"},{"body":"c"}]`,
+			fields:      []string{"1.title"},
+		},
+		{
+			name:        "json object instead of an array",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"title":"a"}`,
+			fields:      []string{InBody},
+		},
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson",
+			body:        "{\"title\":\"a\"}\n\n{\"title\":\"b\",\"body\":\"c\"}\n",
+		},
+		{
+			name:        "ndjson with an invalid line",
+			contentType: "application/x-ndjson",
+			body:        "{\"title\":\"a\"}\n\n{\"title\":5}\n{\"body\":\"c\"}",
+			fields:      []string{"1.title", "2.title"},
+		},
+		{
+			name:        "ndjson line that is not json is left to the handler",
+			contentType: "application/x-ndjson",
+			body:        "{\"title\":\"a\"}\nnot json\n",
+		},
+		{
+			name:        "media type that is not json",
+			contentType: "text/plain",
+			body:        "title",
+		},
+		{
+			name:        "undescribed media type read as json",
+			contentType: "application/octet-stream",
+			body:        `[{"body":"c"}]`,
+			fields:      []string{"0.title"},
+		},
+		{
+			name:        "missing body",
+			contentType: "application/x-ndjson",
+			body:        " \n",
+			fields:      []string{InBody},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/articles:batch", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+
+			err := operation.ValidateRequest(r, values, []byte(tt.body))
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateRequest: %v", err)
+				}
+				return
+			}
+
+			var requestErr *RequestError
+			if !errors.As(err, &requestErr) {
+				t.Fatalf("ValidateRequest: %v, want a *RequestError", err)
+			}
+			if requestErr.In != InBody {
+				t.Errorf("In = %q, want %q", requestErr.In, InBody)
+			}
+			var fields []string
+			for _, field := range requestErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
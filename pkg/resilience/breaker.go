@@ -0,0 +1,197 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultFailureThreshold    = 5
+	defaultOpenTimeout         = 30 * time.Second
+	defaultHalfOpenMaxRequests = 1
+)
+
+var (
+	ErrBreakerOpen     = errors.New("circuit breaker is open")
+	ErrTooManyRequests = errors.New("circuit breaker is half-open, too many requests")
+)
+
+// State state of a circuit breaker
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig thresholds of a circuit breaker, zero values fall back to the defaults
+type BreakerConfig struct {
+	// FailureThreshold consecutive failures opening the breaker
+	FailureThreshold int
+	// OpenTimeout time spent open before letting probe requests through
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests concurrent probe requests while half-open,
+	// as many consecutive successes close the breaker again
+	HalfOpenMaxRequests int
+}
+
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	// outcomeIgnored the permission was not used, e.g. the bulkhead rejected the call
+	outcomeIgnored
+)
+
+// Breaker circuit breaker, closed it lets every call through and counts the consecutive failures,
+// open it rejects every call, half-open it lets a few probe calls decide whether to close or open again
+type Breaker struct {
+	name string
+	cfg  BreakerConfig
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	successes int
+	probes    int
+	openedAt  time.Time
+	// generation changes on every transition so late results of a previous state are ignored
+	generation uint64
+}
+
+// NewBreaker create new closed circuit breaker registered under name
+func NewBreaker(name string, cfg BreakerConfig) *Breaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultOpenTimeout
+	}
+	if cfg.HalfOpenMaxRequests <= 0 {
+		cfg.HalfOpenMaxRequests = defaultHalfOpenMaxRequests
+	}
+
+	b := &Breaker{name: name, cfg: cfg}
+	register(b)
+	breakerState.WithLabelValues(name).Set(float64(StateClosed))
+	return b
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State current state, an open breaker whose timeout elapsed is reported half-open
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire(time.Now())
+	return b.state
+}
+
+// Execute run fn when the breaker lets the call through and record its error as a failure
+func (b *Breaker) Execute(fn func() error) error {
+	generation, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	if err != nil {
+		b.done(generation, outcomeFailure)
+	} else {
+		b.done(generation, outcomeSuccess)
+	}
+	return err
+}
+
+// allow take a permission to call the protected dependency
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire(time.Now())
+	switch b.state {
+	case StateOpen:
+		return 0, errors.Wrap(ErrBreakerOpen, b.name)
+	case StateHalfOpen:
+		if b.probes >= b.cfg.HalfOpenMaxRequests {
+			return 0, errors.Wrap(ErrTooManyRequests, b.name)
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+// done record the outcome of a call allowed during generation
+func (b *Breaker) done(generation uint64, result outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.expire(now)
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		switch result {
+		case outcomeSuccess:
+			b.failures = 0
+		case outcomeFailure:
+			b.failures++
+			if b.failures >= b.cfg.FailureThreshold {
+				b.setState(StateOpen, now)
+			}
+		}
+	case StateHalfOpen:
+		b.probes--
+		switch result {
+		case outcomeSuccess:
+			b.successes++
+			if b.successes >= b.cfg.HalfOpenMaxRequests {
+				b.setState(StateClosed, now)
+			}
+		case outcomeFailure:
+			b.setState(StateOpen, now)
+		}
+	}
+}
+
+func (b *Breaker) expire(now time.Time) {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(StateHalfOpen, now)
+	}
+}
+
+func (b *Breaker) setState(state State, now time.Time) {
+	b.state = state
+	b.failures = 0
+	b.successes = 0
+	b.probes = 0
+	b.generation++
+	if state == StateOpen {
+		b.openedAt = now
+	}
+
+	breakerState.WithLabelValues(b.name).Set(float64(state))
+	breakerTransitions.WithLabelValues(b.name, state.String()).Inc()
+}
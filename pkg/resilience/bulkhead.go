@@ -0,0 +1,69 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+// Bulkhead limit the number of concurrent calls to a dependency
+// so a slow dependency cannot hold every request goroutine
+type Bulkhead struct {
+	name string
+	sem  chan struct{}
+	// maxWait time a call waits for a free slot before being rejected
+	maxWait time.Duration
+}
+
+// NewBulkhead create new bulkhead of maxConcurrent slots
+func NewBulkhead(name string, maxConcurrent int, maxWait time.Duration) *Bulkhead {
+	return &Bulkhead{name: name, sem: make(chan struct{}, maxConcurrent), maxWait: maxWait}
+}
+
+// Acquire take a slot, release must be called once the call is over
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case b.sem <- struct{}{}:
+		return b.release(), nil
+	default:
+	}
+	if b.maxWait <= 0 {
+		return nil, errors.Wrap(ErrBulkheadFull, b.name)
+	}
+
+	timer := time.NewTimer(b.maxWait)
+	defer timer.Stop()
+	select {
+	case b.sem <- struct{}{}:
+		return b.release(), nil
+	case <-timer.C:
+		return nil, errors.Wrap(ErrBulkheadFull, b.name)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Execute run fn in a slot of the bulkhead
+func (b *Bulkhead) Execute(ctx context.Context, fn func() error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
+
+func (b *Bulkhead) InFlight() int {
+	return len(b.sem)
+}
+
+func (b *Bulkhead) release() func() {
+	bulkheadInFlight.WithLabelValues(b.name).Inc()
+	return func() {
+		<-b.sem
+		bulkheadInFlight.WithLabelValues(b.name).Dec()
+	}
+}
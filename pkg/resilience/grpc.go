@@ -0,0 +1,40 @@
+package resilience
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IsGrpcFailure only the status codes telling the server is unreachable or overloaded are failures
+func IsGrpcFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// UnaryClientInterceptor gRPC client interceptor running every call through the policy,
+// a rejected call fails with codes.Unavailable
+func UnaryClientInterceptor(p *Policy) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req interface{},
+		reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		err := p.Execute(ctx, func(ctx context.Context) error {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		})
+		if IsRejected(err) {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		return err
+	}
+}
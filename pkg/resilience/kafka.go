@@ -0,0 +1,24 @@
+package resilience
+
+import (
+	"context"
+
+	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+type producer struct {
+	kafkaClient.Producer
+	policy *Policy
+}
+
+// NewProducer wrap the kafka producer, every publish runs through the policy
+func NewProducer(p kafkaClient.Producer, policy *Policy) kafkaClient.Producer {
+	return &producer{Producer: p, policy: policy}
+}
+
+func (p *producer) PublishMessage(ctx context.Context, msgs ...kafka.Message) error {
+	return p.policy.Execute(ctx, func(ctx context.Context) error {
+		return p.Producer.PublishMessage(ctx, msgs...)
+	})
+}
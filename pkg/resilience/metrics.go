@@ -0,0 +1,37 @@
+package resilience
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "resilience_breaker_state",
+		Help: "State of the circuit breaker, 0 closed, 1 half-open, 2 open.",
+	}, []string{"breaker"})
+
+	breakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "resilience_breaker_transitions_total",
+		Help: "Number of circuit breaker transitions by breaker and new state.",
+	}, []string{"breaker", "state"})
+
+	bulkheadInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "resilience_bulkhead_in_flight",
+		Help: "Number of calls holding a bulkhead slot.",
+	}, []string{"bulkhead"})
+
+	calls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "resilience_calls_total",
+		Help: "Number of calls through a policy by policy and result.",
+	}, []string{"policy", "result"})
+)
+
+const (
+	resultSuccess         = "success"
+	resultFailure         = "failure"
+	resultTimeout         = "timeout"
+	resultBreakerOpen     = "breaker_open"
+	resultBulkheadFull    = "bulkhead_full"
+	resultNotCountedError = "error"
+)
@@ -0,0 +1,109 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Config protection of a dependency, zero values disable the timeout and the bulkhead
+type Config struct {
+	// Timeout upper bound of a single call
+	Timeout time.Duration
+	// MaxConcurrent bulkhead slots, the calls over the limit wait MaxWait then are rejected
+	MaxConcurrent int
+	MaxWait       time.Duration
+	Breaker       BreakerConfig
+	// IsFailure report whether err means the dependency is unhealthy,
+	// every error but a canceled context when nil
+	IsFailure func(err error) bool
+}
+
+// Policy circuit breaker, bulkhead and timeout applied together to the calls of a dependency
+type Policy struct {
+	name      string
+	timeout   time.Duration
+	breaker   *Breaker
+	bulkhead  *Bulkhead
+	isFailure func(err error) bool
+}
+
+// NewPolicy create new policy, its breaker and its bulkhead are registered under name
+func NewPolicy(name string, cfg Config) *Policy {
+	p := &Policy{
+		name:      name,
+		timeout:   cfg.Timeout,
+		breaker:   NewBreaker(name, cfg.Breaker),
+		isFailure: cfg.IsFailure,
+	}
+	if cfg.MaxConcurrent > 0 {
+		p.bulkhead = NewBulkhead(name, cfg.MaxConcurrent, cfg.MaxWait)
+	}
+	if p.isFailure == nil {
+		p.isFailure = isFailure
+	}
+	return p
+}
+
+func isFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+func (p *Policy) Name() string {
+	return p.name
+}
+
+func (p *Policy) Breaker() *Breaker {
+	return p.breaker
+}
+
+// Execute run fn through the breaker, the bulkhead and the timeout,
+// returns ErrBreakerOpen, ErrTooManyRequests or ErrBulkheadFull without calling fn when the call is rejected
+func (p *Policy) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	generation, err := p.breaker.allow()
+	if err != nil {
+		calls.WithLabelValues(p.name, resultBreakerOpen).Inc()
+		return err
+	}
+
+	if p.bulkhead != nil {
+		release, err := p.bulkhead.Acquire(ctx)
+		if err != nil {
+			p.breaker.done(generation, outcomeIgnored)
+			calls.WithLabelValues(p.name, resultBulkheadFull).Inc()
+			return err
+		}
+		defer release()
+	}
+
+	callCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	err = fn(callCtx)
+	switch {
+	case err == nil:
+		p.breaker.done(generation, outcomeSuccess)
+		calls.WithLabelValues(p.name, resultSuccess).Inc()
+	case callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+		p.breaker.done(generation, outcomeFailure)
+		calls.WithLabelValues(p.name, resultTimeout).Inc()
+	case p.isFailure(err):
+		p.breaker.done(generation, outcomeFailure)
+		calls.WithLabelValues(p.name, resultFailure).Inc()
+	default:
+		// the dependency answered, e.g. not found, it is healthy
+		p.breaker.done(generation, outcomeSuccess)
+		calls.WithLabelValues(p.name, resultNotCountedError).Inc()
+	}
+	return err
+}
+
+// IsRejected report whether err comes from a call rejected by a policy
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrBreakerOpen) || errors.Is(err, ErrTooManyRequests) || errors.Is(err, ErrBulkheadFull)
+}
@@ -0,0 +1,63 @@
+package resilience
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	breakers   = make(map[string]*Breaker)
+)
+
+func register(b *Breaker) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	breakers[b.name] = b
+}
+
+// States state of every registered breaker by name
+func States() map[string]string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	states := make(map[string]string, len(breakers))
+	for name, b := range breakers {
+		states[name] = b.State().String()
+	}
+	return states
+}
+
+// HealthCheck check failing while one of the named breakers is open, every registered breaker when no name is given
+func HealthCheck(names ...string) func() error {
+	return func() error {
+		registryMu.RLock()
+		defer registryMu.RUnlock()
+
+		var open []string
+		for name, b := range breakers {
+			if len(names) > 0 && !contains(names, name) {
+				continue
+			}
+			if b.State() == StateOpen {
+				open = append(open, name)
+			}
+		}
+		if len(open) > 0 {
+			sort.Strings(open)
+			return fmt.Errorf("circuit breaker open: %s", strings.Join(open, ", "))
+		}
+		return nil
+	}
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -51,6 +51,9 @@ const (
 	InvalidActiveEndDate            = "KDMU-API-025"
 	QueryParamInvalidCode           = "KDMU-API-026"
 	PathParamInvalidCode            = "KDMU-API-027"
+	TenantInvalidCode               = "KDMU-API-028"
+	TenantQuotaExceededCode         = "KDMU-API-029"
+	PreconditionFailedCode          = "KDMU-API-030"
 	ServerErrorCode                 = "KDMU-API-999"
 )
 
@@ -72,6 +75,11 @@ var (
 	ErrCustomerIDNotFound        = errors.New("customer_id not found")
 	ErrTenorIDNotFound           = errors.New("tenor id not found")
 	ErrServiceCommunicationError = errors.New("service communication error")
+	ErrTenantMissing             = errors.New("tenant is missing")
+	ErrTenantMismatch            = errors.New("tenant of the header does not match the token")
+	ErrTenantClaimMissing        = errors.New("token carries no tenant")
+	ErrTenantQuotaExceeded       = errors.New("tenant quota exceeded")
+	ErrPreconditionFailed        = errors.New("precondition failed")
 )
 
 func ErrorCodeText(code, locale string, args ...interface{}) string {
@@ -138,6 +146,14 @@ func ErrorCodeText(code, locale string, args ...interface{}) string {
 		return i18n.Tr(locale, "message.errorQueryParamInvalid", args)
 	case PathParamInvalidCode:
 		return i18n.Tr(locale, "message.errorPathParamInvalid", args)
+	case ServiceCommunicationErrorCode:
+		return i18n.Tr(locale, "message.errorServiceCommunication", args)
+	case TenantInvalidCode:
+		return i18n.Tr(locale, "message.errorTenantInvalid", args)
+	case TenantQuotaExceededCode:
+		return i18n.Tr(locale, "message.errorTenantQuotaExceeded", args)
+	case PreconditionFailedCode:
+		return i18n.Tr(locale, "message.errorPreconditionFailed", args)
 	default:
 		return ""
 	}
@@ -39,6 +39,13 @@ type Errors struct {
 	Description string `json:"message"`
 }
 
+// FieldsError error already holding the message of each invalid field in every language,
+// e.g. the rules broken on another service
+type FieldsError interface {
+	error
+	FieldErrors(lang string) []Errors
+}
+
 func (r ApiResponse) Ok(ctx *context.Context, message string, data interface{}) error {
 	ctx.Output.SetStatus(http.StatusOK)
 
@@ -74,6 +81,13 @@ func (r ApiResponse) ResponseError(ctx *context.Context, httpStatus int, errorCo
 						Description: ute.Field + ute.Msg.Translate(trans),
 					})
 				}
+			} else if fieldsErr, ok := err.(FieldsError); ok {
+				lang := "id"
+				acceptLang := ctx.Request.Header.Get("Accept-Language")
+				if i18n.IsExist(acceptLang) {
+					lang = acceptLang
+				}
+				errorValidations = fieldsErr.FieldErrors(lang)
 			} else {
 				if fields, ok := err.(validatorGo.ValidationErrors); ok {
 					lang := "id"
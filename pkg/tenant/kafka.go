@@ -0,0 +1,33 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// SetMessageHeader stamp the tenant id of the context on the message,
+// a tenant header already set is kept
+func SetMessageHeader(ctx context.Context, m *kafka.Message) {
+	id, ok := IDFromContext(ctx)
+	if !ok {
+		return
+	}
+	for _, header := range m.Headers {
+		if header.Key == KafkaHeader {
+			return
+		}
+	}
+	m.Headers = append(m.Headers, kafka.Header{Key: KafkaHeader, Value: []byte(id)})
+}
+
+// ContextFromMessage context carrying the tenant id of the message,
+// DefaultID for the messages published without one
+func ContextFromMessage(ctx context.Context, m kafka.Message) context.Context {
+	for _, header := range m.Headers {
+		if header.Key == KafkaHeader && len(header.Value) > 0 {
+			return WithID(ctx, string(header.Value))
+		}
+	}
+	return WithID(ctx, DefaultID)
+}
@@ -0,0 +1,122 @@
+package tenant
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quota requests per second granted to a tenant, Burst requests may be served at once.
+// A zero Rate is no limit
+type Quota struct {
+	Rate  float64
+	Burst int
+}
+
+// sweepInterval time between two sweeps of the idle buckets
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// refill time the empty bucket takes to be full again, a bucket idle that long is dropped
+	refill time.Duration
+}
+
+// Limiter token bucket per tenant, the buckets idle long enough to be full again are dropped
+// as a new bucket starts full anyway
+type Limiter struct {
+	mu        sync.Mutex
+	quota     Quota
+	overrides map[string]Quota
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter limiter granting quota to every tenant but the ones of overrides
+func NewLimiter(quota Quota, overrides map[string]Quota) *Limiter {
+	return &Limiter{
+		quota:     quota,
+		overrides: overrides,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow take a token of the tenant bucket, returns the wait before the next token when it is empty
+func (l *Limiter) Allow(id string) (bool, time.Duration) {
+	quota, ok := l.overrides[id]
+	if !ok {
+		quota = l.quota
+	}
+	if quota.Rate <= 0 {
+		return true, 0
+	}
+	burst := math.Max(float64(quota.Burst), 1)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{tokens: burst, last: now, refill: time.Duration(burst / quota.Rate * float64(time.Second))}
+		l.buckets[id] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*quota.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / quota.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drop the buckets full again by now, l.mu is held
+func (l *Limiter) sweep(now time.Time) {
+	for id, b := range l.buckets {
+		if now.Sub(b.last) >= b.refill {
+			delete(l.buckets, id)
+		}
+	}
+	l.lastSweep = now
+}
+
+// ParseQuotas parse the "tenant=rate:burst" comma separated list of the quota overrides
+func ParseQuotas(s string) (map[string]Quota, error) {
+	quotas := make(map[string]Quota)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("tenant quota %q: expected tenant=rate:burst", item)
+		}
+		id, err := Parse(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("tenant quota %q: %w", item, err)
+		}
+		limits := strings.SplitN(parts[1], ":", 2)
+		rate, err := strconv.ParseFloat(strings.TrimSpace(limits[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("tenant quota %q: %w", item, err)
+		}
+		quota := Quota{Rate: rate, Burst: int(math.Ceil(rate))}
+		if len(limits) == 2 {
+			if quota.Burst, err = strconv.Atoi(strings.TrimSpace(limits[1])); err != nil {
+				return nil, fmt.Errorf("tenant quota %q: %w", item, err)
+			}
+		}
+		quotas[id] = quota
+	}
+	return quotas, nil
+}
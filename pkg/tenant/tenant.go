@@ -0,0 +1,54 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultID tenant of the requests and the records written before the multi tenancy
+	DefaultID = "default"
+	// HeaderName http header carrying the tenant id
+	HeaderName = "X-Tenant-ID"
+	// MetadataKey grpc metadata key carrying the tenant id
+	MetadataKey = "x-tenant-id"
+	// KafkaHeader kafka message header carrying the tenant id
+	KafkaHeader = "tenant-id"
+)
+
+// ErrInvalidID the tenant id is not a lower case slug of at most 63 characters
+var ErrInvalidID = errors.New("invalid tenant id")
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+type contextKey struct{}
+
+// Parse normalize the tenant id, ErrInvalidID when it is not a valid one
+func Parse(id string) (string, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if !idPattern.MatchString(id) {
+		return "", ErrInvalidID
+	}
+	return id, nil
+}
+
+// WithID context carrying the tenant id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// IDFromContext tenant id carried by the context, false when none is
+func IDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// FromContext tenant id carried by the context, DefaultID when none is
+func FromContext(ctx context.Context) string {
+	if id, ok := IDFromContext(ctx); ok {
+		return id
+	}
+	return DefaultID
+}
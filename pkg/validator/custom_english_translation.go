@@ -147,4 +147,22 @@ func registerCustomEnglishTranslator(v *validatorGo.Validate, trans ut.Translato
 	}); err != nil {
 		panic(err)
 	}
+
+	if err := v.RegisterTranslation("unique_with", trans, func(ut ut.Translator) error {
+		if err := ut.Add("unique_with", "{0} already exist for the same {1}.", false); err != nil {
+			return err
+		}
+		return nil
+	}, func(ut ut.Translator, fe validatorGo.FieldError) string {
+		// the third parameter is the column the value is unique with
+		param := strings.Split(fe.Param(), `:`)
+		t, err := ut.T(fe.Tag(), fe.Field(), param[2])
+		if err != nil {
+			log.Printf("warning: error translating FieldError: %#v", fe)
+			return fe.(error).Error()
+		}
+		return t
+	}); err != nil {
+		panic(err)
+	}
 }
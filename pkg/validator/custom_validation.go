@@ -1,13 +1,16 @@
 package validator
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/helper"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"gorm.io/gorm"
 
 	validatorGo "github.com/go-playground/validator/v10"
@@ -29,7 +32,7 @@ func registerCustomValidation(db *gorm.DB, v *validatorGo.Validate) {
 	if err := v.RegisterValidation("no_space", ValidateNoSpace); err != nil {
 		panic(err)
 	}
-	if err := v.RegisterValidation("check_fk", func(fl validatorGo.FieldLevel) bool {
+	if err := v.RegisterValidationCtx("check_fk", func(ctx context.Context, fl validatorGo.FieldLevel) bool {
 		param := strings.Split(fl.Param(), `:`)
 		paramFieldValue := param[0]
 		paramTable := param[1]
@@ -40,18 +43,12 @@ func registerCustomValidation(db *gorm.DB, v *validatorGo.Validate) {
 		}
 
 		// param field reflect.Value.
-		var paramReflectValue reflect.Value
-
-		if fl.Parent().Kind() == reflect.Ptr {
-			paramReflectValue = fl.Parent().Elem().FieldByName(paramFieldValue)
-		} else {
-			paramReflectValue = fl.Parent().FieldByName(paramFieldValue)
-		}
+		paramReflectValue := parentField(fl, paramFieldValue)
 
 		// todo : check fl.Field() and paramReflectValue type data before execute to db
 		var count int64
-		if err := db.Table(paramTable).Where(paramField+"=?", paramReflectValue.Int()).Count(&count).Error; err != nil {
-			panic(err)
+		if err := ruleQuery(ctx, db, paramTable).Where(paramField+"=?", paramReflectValue.Int()).Count(&count).Error; err != nil {
+			panic(ruleQueryError{err})
 		}
 		if count <= 0 {
 			return false
@@ -60,7 +57,7 @@ func registerCustomValidation(db *gorm.DB, v *validatorGo.Validate) {
 	}); err != nil {
 		panic(err)
 	}
-	if err := v.RegisterValidation("unique_store", func(fl validatorGo.FieldLevel) bool {
+	if err := v.RegisterValidationCtx("unique_store", func(ctx context.Context, fl validatorGo.FieldLevel) bool {
 		param := strings.Split(fl.Param(), `:`)
 		paramField := param[0]
 		paramTable := param[1]
@@ -71,8 +68,8 @@ func registerCustomValidation(db *gorm.DB, v *validatorGo.Validate) {
 
 		// todo : check fl.Field() type data before execute to db
 		var count int64
-		if err := db.Table(paramTable).Where(paramField+"=?", fl.Field().Interface()).Count(&count).Error; err != nil {
-			panic(err)
+		if err := ruleQuery(ctx, db, paramTable).Where(paramField+"=?", fl.Field().Interface()).Count(&count).Error; err != nil {
+			panic(ruleQueryError{err})
 		}
 		if count > 0 {
 			return false
@@ -81,7 +78,7 @@ func registerCustomValidation(db *gorm.DB, v *validatorGo.Validate) {
 	}); err != nil {
 		panic(err)
 	}
-	if err := v.RegisterValidation("unique_update", func(fl validatorGo.FieldLevel) bool {
+	if err := v.RegisterValidationCtx("unique_update", func(ctx context.Context, fl validatorGo.FieldLevel) bool {
 		if fl.Field().String() != "" {
 			param := strings.Split(fl.Param(), `:`)
 			paramFieldValue := param[0]
@@ -94,19 +91,13 @@ func registerCustomValidation(db *gorm.DB, v *validatorGo.Validate) {
 			}
 
 			// param field reflect.Value.
-			var paramReflectValue reflect.Value
-
-			if fl.Parent().Kind() == reflect.Ptr {
-				paramReflectValue = fl.Parent().Elem().FieldByName(paramFieldValue)
-			} else {
-				paramReflectValue = fl.Parent().FieldByName(paramFieldValue)
-			}
+			paramReflectValue := parentField(fl, paramFieldValue)
 
 			// todo : check fl.Field() and paramReflectValue type data before execute to db
 			count := int64(0)
-			if err := db.Table(paramTable).Where(paramField+" =?", fl.Field().Interface()).Where(paramFieldCond+" <> ?", paramReflectValue.Int()).
+			if err := ruleQuery(ctx, db, paramTable).Where(paramField+" =?", fl.Field().Interface()).Where(paramFieldCond+" <> ?", paramReflectValue.Int()).
 				Count(&count).Error; err != nil {
-				panic(err)
+				panic(ruleQueryError{err})
 			}
 			if count > 0 {
 				return false
@@ -116,6 +107,82 @@ func registerCustomValidation(db *gorm.DB, v *validatorGo.Validate) {
 	}); err != nil {
 		panic(err)
 	}
+	// unique_with=column:table:withColumn:WithField[:exceptColumn:ExceptField] the value is unique among the rows
+	// whose withColumn equals WithField, the row whose exceptColumn equals ExceptField is left out on updates
+	if err := v.RegisterValidationCtx("unique_with", func(ctx context.Context, fl validatorGo.FieldLevel) bool {
+		param := strings.Split(fl.Param(), `:`)
+		paramField := param[0]
+		paramTable := param[1]
+		paramWithField := param[2]
+		paramWithFieldValue := param[3]
+
+		if paramField == `` {
+			return true
+		}
+
+		query := ruleQuery(ctx, db, paramTable).
+			Where(paramField+" = ?", fl.Field().Interface()).
+			Where(paramWithField+" = ?", parentField(fl, paramWithFieldValue).Interface())
+		if len(param) >= 6 {
+			query = query.Where(param[4]+" <> ?", parentField(fl, param[5]).Interface())
+		}
+
+		count := int64(0)
+		if err := query.Count(&count).Error; err != nil {
+			panic(ruleQueryError{err})
+		}
+		return count == 0
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// ruleQueryError database error of a rule, raised as panic and returned by ValidateStructCtx
+type ruleQueryError struct {
+	err error
+}
+
+// softDeleteTables whether the tables of the rules have a deleted_at column, looked up once per table
+var softDeleteTables sync.Map
+
+// ruleQuery query table within ctx, only the rows of the tenant ctx carries are looked at and,
+// like gorm does for its models, the soft deleted rows of a table having a deleted_at column are left out
+func ruleQuery(ctx context.Context, db *gorm.DB, table string) *gorm.DB {
+	query := db.WithContext(ctx).Table(table)
+	if id, ok := tenant.IDFromContext(ctx); ok {
+		query = query.Where("tenant_id = ?", id)
+	}
+	if softDelete(ctx, db, table) {
+		query = query.Where("deleted_at IS NULL")
+	}
+	return query
+}
+
+func softDelete(ctx context.Context, db *gorm.DB, table string) bool {
+	if found, ok := softDeleteTables.Load(table); ok {
+		return found.(bool)
+	}
+	columns, err := db.WithContext(ctx).Migrator().ColumnTypes(table)
+	if err != nil {
+		panic(ruleQueryError{err})
+	}
+	found := false
+	for _, column := range columns {
+		if column.Name() == "deleted_at" {
+			found = true
+			break
+		}
+	}
+	softDeleteTables.Store(table, found)
+	return found
+}
+
+// parentField field name of the struct holding the validated field
+func parentField(fl validatorGo.FieldLevel, name string) reflect.Value {
+	if fl.Parent().Kind() == reflect.Ptr {
+		return fl.Parent().Elem().FieldByName(name)
+	}
+	return fl.Parent().FieldByName(name)
 }
 
 func ValidateDateOnly(fl validatorGo.FieldLevel) bool {
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	validatorGo "github.com/go-playground/validator/v10"
 	enTranslations "github.com/go-playground/validator/v10/translations/en"
 	idTranslator "github.com/go-playground/validator/v10/translations/id"
+	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
@@ -70,20 +72,26 @@ var _ structValidator = &defaultValidator{}
 
 // ValidateStruct receives any kind of type, but only performed struct or pointer to struct type.
 func (v *defaultValidator) ValidateStruct(obj interface{}) error {
+	return v.ValidateStructCtx(context.Background(), obj)
+}
+
+// ValidateStructCtx ValidateStruct passing ctx to the rules, the database rules query within ctx
+// and only look at the rows of the tenant ctx carries.
+func (v *defaultValidator) ValidateStructCtx(ctx context.Context, obj interface{}) error {
 	if obj == nil {
 		return nil
 	}
 	value := reflect.ValueOf(obj)
 	switch value.Kind() {
 	case reflect.Ptr:
-		return v.validateStruct(value.Elem().Interface())
+		return v.validateStruct(ctx, value.Elem().Interface())
 	case reflect.Struct:
-		return v.validateStruct(obj)
+		return v.validateStruct(ctx, obj)
 	case reflect.Slice, reflect.Array:
 		count := value.Len()
 		validateRet := make(SliceValidationError, 0)
 		for i := 0; i < count; i++ {
-			if err := v.ValidateStruct(value.Index(i).Interface()); err != nil {
+			if err := v.ValidateStructCtx(ctx, value.Index(i).Interface()); err != nil {
 				validateRet = append(validateRet, err)
 			}
 		}
@@ -128,10 +136,19 @@ func (v *defaultValidator) ValidateMatchingDynamicStruct(body io.ReadCloser, exp
 	return nil
 }
 
-// validateStruct receives struct type
-func (v *defaultValidator) validateStruct(obj interface{}) error {
+// validateStruct receives struct type, a database rule failing to query is returned as the error
+func (v *defaultValidator) validateStruct(ctx context.Context, obj interface{}) (err error) {
 	v.lazyInit()
-	return v.validate.Struct(obj)
+	defer func() {
+		if r := recover(); r != nil {
+			queryErr, ok := r.(ruleQueryError)
+			if !ok {
+				panic(r)
+			}
+			err = errors.Wrap(queryErr.err, "validation rule query")
+		}
+	}()
+	return v.validate.StructCtx(ctx, obj)
 }
 
 func (v *defaultValidator) ValidateVar(val interface{}, field interface{}, tag string) error {
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"context"
 	ut "github.com/go-playground/universal-translator"
 	"gorm.io/gorm"
 	"io"
@@ -19,6 +20,10 @@ type structValidator interface {
 	// Otherwise, nil must be returned.
 	ValidateStruct(interface{}) error
 
+	// ValidateStructCtx is ValidateStruct passing ctx to the rules, the database rules query within ctx
+	// and the tenant it carries. A database rule failing to query returns its error instead of a validation error.
+	ValidateStructCtx(context.Context, interface{}) error
+
 	ValidateDynamicStruct(dynamicStruct map[string]interface{}, expectedStruct interface{}) error
 
 	ValidateMatchingDynamicStruct(body io.ReadCloser, expectedStruct interface{}) error
@@ -1,11 +1,22 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/server"
 )
 
+const usage = `usage: reader_service [command]
+
+commands:
+  (none)                 run the reader service
+  mongo-migrate          bootstrap the collections, indexes and validators and apply the pending data migrations
+  mongo-migrate status   list the data migrations and their state
+`
+
 func main() {
 
 	cfg, err := config.InitConfig()
@@ -18,6 +29,24 @@ func main() {
 	zaplog.WithName("ReaderService")
 
 	s := server.NewServer(cfg, zaplog)
-	zaplog.Fatalf("running server : %s", s.Run())
+
+	if len(os.Args) < 2 {
+		zaplog.Fatalf("running server : %s", s.Run())
+		return
+	}
+
+	switch {
+	case os.Args[1] == "mongo-migrate" && len(os.Args) == 2:
+		if err := s.MongoMigrate(); err != nil {
+			zaplog.Fatalf("mongo-migrate : %s", err)
+		}
+	case os.Args[1] == "mongo-migrate" && os.Args[2] == "status":
+		if err := s.MongoMigrateStatus(os.Stdout); err != nil {
+			zaplog.Fatalf("mongo-migrate status : %s", err)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 
 }
@@ -3,10 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/mongodb"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/redis"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
 	"github.com/spf13/viper"
 )
 
@@ -29,7 +31,10 @@ type Config struct {
 	Mongo            *mongodb.Config
 	Redis            *redis.Config
 	MongoCollections MongoCollections
+	MongoMigration   MongoMigration
 	ServiceSettings  ServiceSettings
+	Cache            Cache
+	Resilience       Resilience
 	GRPC             GRPC
 }
 
@@ -45,20 +50,107 @@ type AppConfig struct {
 	CheckIntervalSeconds int
 	LogPath              string
 	SlackWebHookUrl      string
+	// DrainDelaySeconds time between reporting not ready on shutdown and draining, lets the load balancers stop routing
+	DrainDelaySeconds int
+	// ShutdownTimeoutSeconds upper bound of the wait for the in-flight requests and messages
+	ShutdownTimeoutSeconds int
 }
 type MongoCollections struct {
-	Articles string
+	Articles         string
+	ArticleRevisions string
+	Tags             string
+	Comments         string
+	Migrations       string
+}
+
+type MongoMigration struct {
+	// MigrateOnStart bootstrap the collections and apply the pending data migrations when the server starts
+	MigrateOnStart bool
+	// ValidationAction action taken on documents failing the collection validator, error or warn
+	ValidationAction string
 }
 
 type KafkaTopics struct {
-	ArticleCreate  kafkaClient.TopicConfig
-	ArticleCreated kafkaClient.TopicConfig
+	ArticleCreate        kafkaClient.TopicConfig
+	ArticleCreated       kafkaClient.TopicConfig
+	ArticleUpdate        kafkaClient.TopicConfig
+	ArticleUpdated       kafkaClient.TopicConfig
+	ArticleTransition    kafkaClient.TopicConfig
+	ArticleStatusChanged kafkaClient.TopicConfig
+	ArticlePublished     kafkaClient.TopicConfig
+	ArticleTag           kafkaClient.TopicConfig
+	ArticleTagsChanged   kafkaClient.TopicConfig
+	CommentCreated       kafkaClient.TopicConfig
+	CommentEdited        kafkaClient.TopicConfig
+	CommentDeleted       kafkaClient.TopicConfig
+	CommentModerated     kafkaClient.TopicConfig
+	// DeadLetter messages the consumers gave up on
+	DeadLetter kafkaClient.TopicConfig
 }
 
 type ServiceSettings struct {
 	RedisArticlePrefixKey string
 }
 
+type Cache struct {
+	ArticleTTLSeconds int
+	// MissingTTLSeconds lifetime of the entries remembering unknown article ids
+	MissingTTLSeconds int
+	SearchTTLSeconds  int
+	// TTLJitterPercent random extra lifetime added to every entry so they do not expire together
+	TTLJitterPercent int
+	// SearchMaxPage only the first pages of a search are cached
+	SearchMaxPage int
+	// LocalSize number of entries kept by the in-process tier
+	LocalSize int
+	// LocalTTLSeconds upper bound of the in-process lifetime of an entry
+	LocalTTLSeconds int
+	// InvalidationChannel redis pub/sub channel dropping the in-process copies of the other instances
+	InvalidationChannel string
+}
+
+type Resilience struct {
+	Mongo ResiliencePolicy
+	Redis ResiliencePolicy
+}
+
+// ResiliencePolicy circuit breaker, bulkhead and timeout of a dependency
+type ResiliencePolicy struct {
+	TimeoutMillis int
+	// MaxConcurrent concurrent calls to the dependency, unlimited when 0
+	MaxConcurrent int
+	MaxWaitMillis int
+	// FailureThreshold consecutive failures opening the breaker
+	FailureThreshold int
+	// OpenSeconds time the breaker stays open before probing the dependency again
+	OpenSeconds         int
+	HalfOpenMaxRequests int
+}
+
+func (p ResiliencePolicy) Config() resilience.Config {
+	return resilience.Config{
+		Timeout:       time.Duration(p.TimeoutMillis) * time.Millisecond,
+		MaxConcurrent: p.MaxConcurrent,
+		MaxWait:       time.Duration(p.MaxWaitMillis) * time.Millisecond,
+		Breaker: resilience.BreakerConfig{
+			FailureThreshold:    p.FailureThreshold,
+			OpenTimeout:         time.Duration(p.OpenSeconds) * time.Second,
+			HalfOpenMaxRequests: p.HalfOpenMaxRequests,
+		},
+	}
+}
+
+func resiliencePolicy(key string) ResiliencePolicy {
+	return ResiliencePolicy{
+		TimeoutMillis:       viper.GetInt(key + ".timeoutMillis"),
+		MaxConcurrent:       viper.GetInt(key + ".maxConcurrent"),
+		MaxWaitMillis:       viper.GetInt(key + ".maxWaitMillis"),
+		FailureThreshold:    viper.GetInt(key + ".failureThreshold"),
+		OpenSeconds:         viper.GetInt(key + ".openSeconds"),
+		HalfOpenMaxRequests: viper.GetInt(key + ".halfOpenMaxRequests"),
+	}
+}
+
 func InitConfig() (*Config, error) {
 
 	// Set the file name of the configurations file
@@ -78,12 +170,14 @@ func InitConfig() (*Config, error) {
 
 	cfg := &Config{
 		App: AppConfig{
-			Port:                 viper.GetString("app.port"),
-			ServiceName:          viper.GetString("app.serviceName"),
-			ExecutionTimeout:     viper.GetInt("app.executionTimeout"),
-			CheckIntervalSeconds: viper.GetInt("app.checkIntervalSeconds"),
-			LogPath:              viper.GetString("app.logPath"),
-			SlackWebHookUrl:      viper.GetString("app.slackWebHookUrl"),
+			Port:                   viper.GetString("app.port"),
+			ServiceName:            viper.GetString("app.serviceName"),
+			ExecutionTimeout:       viper.GetInt("app.executionTimeout"),
+			CheckIntervalSeconds:   viper.GetInt("app.checkIntervalSeconds"),
+			LogPath:                viper.GetString("app.logPath"),
+			SlackWebHookUrl:        viper.GetString("app.slackWebHookUrl"),
+			DrainDelaySeconds:      viper.GetInt("app.drainDelaySeconds"),
+			ShutdownTimeoutSeconds: viper.GetInt("app.shutdownTimeoutSeconds"),
 		},
 		KafkaTopics: KafkaTopics{
 			ArticleCreate: kafkaClient.TopicConfig{
@@ -96,11 +190,74 @@ func InitConfig() (*Config, error) {
 				Partitions:        viper.GetInt("kafkaTopics.articleCreated.partitions"),
 				ReplicationFactor: viper.GetInt("kafkaTopics.articleCreated.replicationFactor"),
 			},
+			ArticleUpdate: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleUpdate.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleUpdate.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleUpdate.replicationFactor"),
+			},
+			ArticleUpdated: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleUpdated.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleUpdated.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleUpdated.replicationFactor"),
+			},
+			ArticleTransition: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleTransition.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleTransition.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleTransition.replicationFactor"),
+			},
+			ArticleStatusChanged: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleStatusChanged.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleStatusChanged.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleStatusChanged.replicationFactor"),
+			},
+			ArticlePublished: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articlePublished.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articlePublished.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articlePublished.replicationFactor"),
+			},
+			ArticleTag: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleTag.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleTag.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleTag.replicationFactor"),
+			},
+			ArticleTagsChanged: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleTagsChanged.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleTagsChanged.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleTagsChanged.replicationFactor"),
+			},
+			CommentCreated: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentCreated.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentCreated.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentCreated.replicationFactor"),
+			},
+			CommentEdited: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentEdited.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentEdited.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentEdited.replicationFactor"),
+			},
+			CommentDeleted: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentDeleted.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentDeleted.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentDeleted.replicationFactor"),
+			},
+			CommentModerated: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentModerated.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentModerated.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentModerated.replicationFactor"),
+			},
+			DeadLetter: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.deadLetter.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.deadLetter.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.deadLetter.replicationFactor"),
+			},
 		},
 		Kafka: &kafkaClient.Config{
-			Brokers:    viper.GetStringSlice("kafka.brokers"),
-			GroupID:    viper.GetString("kafka.groupID"),
-			InitTopics: viper.GetBool("kafka.initTopics"),
+			Brokers:        viper.GetStringSlice("kafka.brokers"),
+			GroupID:        viper.GetString("kafka.groupID"),
+			InitTopics:     viper.GetBool("kafka.initTopics"),
+			ContentType:    viper.GetString("kafka.contentType"),
+			BatchSize:      viper.GetInt("kafka.batchSize"),
+			BatchTimeoutMs: viper.GetInt("kafka.batchTimeoutMs"),
 		},
 		Mongo: &mongodb.Config{
 			URI:      viper.GetString("mongo.uri"),
@@ -115,11 +272,33 @@ func InitConfig() (*Config, error) {
 			PoolSize: viper.GetInt("redis.poolSize"),
 		},
 		MongoCollections: MongoCollections{
-			Articles: viper.GetString("mongoCollections.articles"),
+			Articles:         viper.GetString("mongoCollections.articles"),
+			ArticleRevisions: viper.GetString("mongoCollections.articleRevisions"),
+			Tags:             viper.GetString("mongoCollections.tags"),
+			Comments:         viper.GetString("mongoCollections.comments"),
+			Migrations:       viper.GetString("mongoCollections.migrations"),
+		},
+		MongoMigration: MongoMigration{
+			MigrateOnStart:   viper.GetBool("mongoMigration.migrateOnStart"),
+			ValidationAction: viper.GetString("mongoMigration.validationAction"),
 		},
 		ServiceSettings: ServiceSettings{
 			RedisArticlePrefixKey: viper.GetString("serviceSettings.redisArticlePrefixKey"),
 		},
+		Cache: Cache{
+			ArticleTTLSeconds:   viper.GetInt("cache.articleTTLSeconds"),
+			MissingTTLSeconds:   viper.GetInt("cache.missingTTLSeconds"),
+			SearchTTLSeconds:    viper.GetInt("cache.searchTTLSeconds"),
+			TTLJitterPercent:    viper.GetInt("cache.ttlJitterPercent"),
+			SearchMaxPage:       viper.GetInt("cache.searchMaxPage"),
+			LocalSize:           viper.GetInt("cache.localSize"),
+			LocalTTLSeconds:     viper.GetInt("cache.localTTLSeconds"),
+			InvalidationChannel: viper.GetString("cache.invalidationChannel"),
+		},
+		Resilience: Resilience{
+			Mongo: resiliencePolicy("resilience.mongo"),
+			Redis: resiliencePolicy("resilience.redis"),
+		},
 		GRPC: GRPC{
 			Port:        viper.GetString("grpc.port"),
 			Development: viper.GetBool("grpc.development"),
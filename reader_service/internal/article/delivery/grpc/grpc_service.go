@@ -2,7 +2,9 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
@@ -29,16 +31,96 @@ func NewArticleGrpcService(useCase domain.ArticleUseCase, cfg *config.Config, za
 func (s *articleGrpcService) SearchArticle(ctx context.Context, req *readerService.SearchReq) (*readerService.SearchRes, error) {
 	pq := utils.NewPaginationQuery(int(req.GetSize()), int(req.GetPage()))
 
-	query := domain.NewSearchArticleQuery(req.GetSearch(), req.Author, pq)
+	query := domain.NewSearchArticleQuery(req.GetSearch(), req.GetAuthor(), req.GetStatuses(), req.GetTags(), req.GetCategory(), pq)
 	articlesList, err := s.useCase.SearchArticle(ctx, query)
 	if err != nil {
 		s.zapLogger.WarnMsg("ArticleUseCase.SearchArticle", err)
-		return nil, s.errResponse(codes.Internal, err)
+		return nil, s.errResponse(errCode(err), err)
 	}
 
 	return domain.ArticleListToGrpc(articlesList), nil
 }
 
+func (s *articleGrpcService) ListTags(ctx context.Context, req *readerService.ListTagsReq) (*readerService.ListTagsRes, error) {
+	tags, err := s.useCase.ListTags(ctx, req.GetPrefix(), int(req.GetSize()))
+	if err != nil {
+		s.zapLogger.WarnMsg("ArticleUseCase.ListTags", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return domain.TagCountsToGrpc(tags), nil
+}
+
+func (s *articleGrpcService) GetArticleById(ctx context.Context, req *readerService.GetArticleByIdReq) (*readerService.GetArticleByIdRes, error) {
+	article, err := s.useCase.GetArticleById(ctx, int(req.GetID()))
+	if err != nil {
+		if errors.Is(err, domain.ErrArticleNotFound) {
+			return nil, s.errResponse(codes.NotFound, err)
+		}
+		s.zapLogger.WarnMsg("ArticleUseCase.GetArticleById", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return &readerService.GetArticleByIdRes{Article: domain.ArticleToGrpcMessage(article)}, nil
+}
+
+func (s *articleGrpcService) GetArticleRevisions(ctx context.Context, req *readerService.GetArticleRevisionsReq) (*readerService.GetArticleRevisionsRes, error) {
+	revisions, err := s.useCase.GetArticleRevisions(ctx, int(req.GetID()))
+	if err != nil {
+		if errors.Is(err, domain.ErrArticleNotFound) {
+			return nil, s.errResponse(codes.NotFound, err)
+		}
+		s.zapLogger.WarnMsg("ArticleUseCase.GetArticleRevisions", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	list := make([]*readerService.ArticleRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		list = append(list, domain.ArticleRevisionToGrpcMessage(revision))
+	}
+
+	return &readerService.GetArticleRevisionsRes{Revisions: list}, nil
+}
+
+func (s *articleGrpcService) GetArticleRevision(ctx context.Context, req *readerService.GetArticleRevisionReq) (*readerService.GetArticleRevisionRes, error) {
+	revision, err := s.useCase.GetArticleRevision(ctx, int(req.GetID()), int(req.GetRevision()))
+	if err != nil {
+		if errors.Is(err, domain.ErrRevisionNotFound) {
+			return nil, s.errResponse(codes.NotFound, err)
+		}
+		s.zapLogger.WarnMsg("ArticleUseCase.GetArticleRevision", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return &readerService.GetArticleRevisionRes{Revision: domain.ArticleRevisionToGrpcMessage(revision)}, nil
+}
+
+func (s *articleGrpcService) DiffArticleRevisions(ctx context.Context, req *readerService.DiffArticleRevisionsReq) (*readerService.DiffArticleRevisionsRes, error) {
+	changes, err := s.useCase.DiffArticleRevisions(ctx, int(req.GetID()), int(req.GetFrom()), int(req.GetTo()))
+	if err != nil {
+		if errors.Is(err, domain.ErrRevisionNotFound) {
+			return nil, s.errResponse(codes.NotFound, err)
+		}
+		s.zapLogger.WarnMsg("ArticleUseCase.DiffArticleRevisions", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return &readerService.DiffArticleRevisionsRes{
+		ID:      req.GetID(),
+		From:    req.GetFrom(),
+		To:      req.GetTo(),
+		Changes: domain.FieldChangesToGrpc(changes),
+	}, nil
+}
+
+// errCode calls rejected by a circuit breaker or a bulkhead are reported unavailable so clients back off
+func errCode(err error) codes.Code {
+	if resilience.IsRejected(err) {
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
 func (s *articleGrpcService) errResponse(c codes.Code, err error) error {
 	return status.Error(c, err.Error())
 }
@@ -2,11 +2,12 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
-	"sync"
 	"time"
 
 	"github.com/avast/retry-go"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
@@ -16,7 +17,11 @@ import (
 const (
 	retryAttempts = 3
 	retryDelay    = 300 * time.Millisecond
-	PoolSize      = 30
+	// retryRounds rounds of retries of a message that can not be projected yet before it is dead-lettered,
+	// retryPause pause between two rounds
+	retryRounds = 5
+	retryPause  = 5 * time.Second
+	PoolSize    = 30
 )
 
 var (
@@ -24,77 +29,204 @@ var (
 )
 
 type articleConsumer struct {
-	zapLogger zaplogger.Logger
-	useCase   domain.ArticleUseCase
-	cfg       *config.Config
+	zapLogger  zaplogger.Logger
+	useCase    domain.ArticleUseCase
+	cfg        *config.Config
+	deadLetter kafkaClient.DeadLetter
 }
 
-func NewArticleConsumer(useCase domain.ArticleUseCase, cfg *config.Config, zapLogger zaplogger.Logger) *articleConsumer {
+func NewArticleConsumer(useCase domain.ArticleUseCase, cfg *config.Config, deadLetter kafkaClient.DeadLetter, zapLogger zaplogger.Logger) *articleConsumer {
 	return &articleConsumer{
-		zapLogger: zapLogger,
-		useCase:   useCase,
-		cfg:       cfg,
+		zapLogger:  zapLogger,
+		useCase:    useCase,
+		cfg:        cfg,
+		deadLetter: deadLetter,
 	}
 }
 
-func (s *articleConsumer) ProcessMessages(ctx context.Context, r *kafka.Reader, wg *sync.WaitGroup, workerID int) {
-	defer wg.Done()
+// ProcessBatch project the consecutive article_created messages of the batch together and the other messages
+// one by one, in the order of the batch. A message that can not be projected is retried a bounded number of times
+// then dead-lettered, the batch is only committed once all of its messages are done; the error is the end of ctx
+func (s *articleConsumer) ProcessBatch(ctx context.Context, batch []kafka.Message, workerID int) error {
+	created := make([]kafka.Message, 0, len(batch))
+	for _, m := range batch {
+		s.logProcessMessage(m, workerID)
+		if m.Topic == s.cfg.KafkaTopics.ArticleCreated.TopicName {
+			created = append(created, m)
+			continue
+		}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
+		// the created articles are projected before the messages following them
+		if err := s.processCreateArticles(ctx, created); err != nil {
+			return err
+		}
+		created = created[:0]
+
+		// the message is processed within the tenant it was published for
+		msgCtx := tenant.ContextFromMessage(ctx, m)
+
+		var err error
+		switch m.Topic {
+		case s.cfg.KafkaTopics.ArticleUpdated.TopicName:
+			err = s.processUpdateArticle(msgCtx, m)
+		case s.cfg.KafkaTopics.ArticleStatusChanged.TopicName:
+			err = s.processChangeArticleStatus(msgCtx, m)
+		case s.cfg.KafkaTopics.ArticlePublished.TopicName:
+			err = s.processPublishArticle(msgCtx, m)
+		case s.cfg.KafkaTopics.ArticleTagsChanged.TopicName:
+			err = s.processChangeArticleTags(msgCtx, m)
+		}
+		if err != nil {
+			return err
 		}
+	}
+	return s.processCreateArticles(ctx, created)
+}
+
+// processCreateArticles project the created articles of each tenant with a single use case call,
+// the messages of a tenant that can not be projected are dead-lettered
+func (s *articleConsumer) processCreateArticles(ctx context.Context, msgs []kafka.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	var tenants []string
+	tenantCtx := make(map[string]context.Context)
+	tenantEvents := make(map[string][]events.ArticleCreated)
+	tenantMsgs := make(map[string][]kafka.Message)
+	for _, m := range msgs {
+		// the message is processed within the tenant it was published for
+		msgCtx := tenant.ContextFromMessage(ctx, m)
 
-		m, err := r.FetchMessage(ctx)
+		envelope, err := events.Default.DecodeMessage(events.ArticleCreatedType, m)
 		if err != nil {
-			s.zapLogger.Warnf("workerID: %v, err: %v", workerID, err)
+			if err := s.deadLetterMessage(msgCtx, "events.DecodeMessage", m, err); err != nil {
+				return err
+			}
 			continue
 		}
 
-		s.logProcessMessage(m, workerID)
+		var event events.ArticleCreated
+		if err := envelope.Decode(&event); err != nil {
+			if err := s.deadLetterMessage(msgCtx, "envelope.Decode", m, err); err != nil {
+				return err
+			}
+			continue
+		}
 
-		switch m.Topic {
-		case s.cfg.KafkaTopics.ArticleCreated.TopicName:
-			s.processCreateArticle(ctx, r, m)
+		id := tenant.FromContext(msgCtx)
+		if _, ok := tenantCtx[id]; !ok {
+			tenants = append(tenants, id)
+			tenantCtx[id] = msgCtx
+		}
+		tenantEvents[id] = append(tenantEvents[id], event)
+		tenantMsgs[id] = append(tenantMsgs[id], m)
+	}
+
+	for _, id := range tenants {
+		if err := s.retryProject(ctx, "ArticleUseCase.CreateArticles", func() error {
+			return s.useCase.CreateArticles(tenantCtx[id], tenantEvents[id])
+		}); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			for _, m := range tenantMsgs[id] {
+				if err := s.deadLetterMessage(tenantCtx[id], "ArticleUseCase.CreateArticles", m, err); err != nil {
+					return err
+				}
+			}
 		}
 	}
+	return nil
 }
 
-func (s *articleConsumer) processCreateArticle(ctx context.Context, r *kafka.Reader, m kafka.Message) {
+func (s *articleConsumer) processUpdateArticle(ctx context.Context, m kafka.Message) error {
+	var event events.ArticleUpdated
+	return s.processEvent(ctx, m, events.ArticleUpdatedType, &event, "ArticleUseCase.UpdateArticle", func() error {
+		return s.useCase.UpdateArticle(ctx, event)
+	})
+}
+
+func (s *articleConsumer) processChangeArticleStatus(ctx context.Context, m kafka.Message) error {
+	var event events.ArticleStatusChanged
+	return s.processEvent(ctx, m, events.ArticleStatusChangedType, &event, "ArticleUseCase.ChangeArticleStatus", func() error {
+		return s.useCase.ChangeArticleStatus(ctx, event)
+	})
+}
+
+func (s *articleConsumer) processPublishArticle(ctx context.Context, m kafka.Message) error {
+	var event events.ArticlePublished
+	return s.processEvent(ctx, m, events.ArticlePublishedType, &event, "ArticleUseCase.PublishArticle", func() error {
+		return s.useCase.PublishArticle(ctx, event)
+	})
+}
+
+func (s *articleConsumer) processChangeArticleTags(ctx context.Context, m kafka.Message) error {
+	var event events.ArticleTagsChanged
+	return s.processEvent(ctx, m, events.ArticleTagsChangedType, &event, "ArticleUseCase.ChangeArticleTags", func() error {
+		return s.useCase.ChangeArticleTags(ctx, event)
+	})
+}
 
-	var command domain.CreatedArticleCommand
-	if err := json.Unmarshal(m.Value, &command); err != nil {
-		s.zapLogger.WarnMsg("proto.Unmarshal", err)
-		s.commitErrMessage(ctx, r, m)
-		return
+// processEvent decode m into event then project it, undecodable messages and messages that can not be projected
+// are dead-lettered
+func (s *articleConsumer) processEvent(ctx context.Context, m kafka.Message, eventType string, event interface{}, name string, project func() error) error {
+	envelope, err := events.Default.DecodeMessage(eventType, m)
+	if err != nil {
+		return s.deadLetterMessage(ctx, "events.DecodeMessage", m, err)
 	}
 
-	if err := retry.Do(func() error {
-		return s.useCase.CreateArticle(ctx, command)
-	}, append(retryOptions, retry.Context(ctx))...); err != nil {
-		s.zapLogger.WarnMsg("ArticleUseCase.CreateArticle", err)
-		return
+	if err := envelope.Decode(event); err != nil {
+		return s.deadLetterMessage(ctx, "envelope.Decode", m, err)
 	}
 
-	s.commitMessage(ctx, r, m)
+	if err := s.retryProject(ctx, name, project); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return s.deadLetterMessage(ctx, name, m, err)
+	}
+	return nil
 }
 
-func (s *articleConsumer) logProcessMessage(m kafka.Message, workerID int) {
-	s.zapLogger.KafkaProcessMessage(m.Topic, m.Partition, string(m.Value), workerID, m.Offset, m.Time)
+// retryProject retry project for retryRounds rounds, a message that is not projected by then is given up on.
+// The end of ctx stops the retries
+func (s *articleConsumer) retryProject(ctx context.Context, name string, project func() error) error {
+	var err error
+	for round := 1; ; round++ {
+		err = retry.Do(project, append(retryOptions, retry.Context(ctx), retry.LastErrorOnly(true))...)
+		if err == nil || ctx.Err() != nil || round == retryRounds {
+			return err
+		}
+		s.zapLogger.WarnMsg(name, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryPause):
+		}
+	}
 }
 
-func (s *articleConsumer) commitMessage(ctx context.Context, r *kafka.Reader, m kafka.Message) {
-	s.zapLogger.KafkaLogCommittedMessage(m.Topic, m.Partition, m.Offset)
-	if err := r.CommitMessages(ctx, m); err != nil {
-		s.zapLogger.WarnMsg("commitMessage", err)
+// deadLetterMessage hand m to the dead letter topic, retried until it is kept since the batch is committed after it.
+// Only the end of ctx gives up, the batch is then left uncommitted
+func (s *articleConsumer) deadLetterMessage(ctx context.Context, name string, m kafka.Message, cause error) error {
+	s.zapLogger.WarnMsg(name, cause)
+	for {
+		err := retry.Do(func() error {
+			return s.deadLetter.Publish(ctx, m, cause)
+		}, append(retryOptions, retry.Context(ctx), retry.LastErrorOnly(true))...)
+		if err == nil {
+			return nil
+		}
+		s.zapLogger.WarnMsg("DeadLetter.Publish", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryPause):
+		}
 	}
 }
 
-func (s *articleConsumer) commitErrMessage(ctx context.Context, r *kafka.Reader, m kafka.Message) {
-	s.zapLogger.KafkaLogCommittedMessage(m.Topic, m.Partition, m.Offset)
-	if err := r.CommitMessages(ctx, m); err != nil {
-		s.zapLogger.WarnMsg("commitMessage", err)
-	}
+func (s *articleConsumer) logProcessMessage(m kafka.Message, workerID int) {
+	s.zapLogger.KafkaProcessMessage(m.Topic, m.Partition, string(m.Value), workerID, m.Offset, m.Time)
 }
@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"regexp"
 
 	"github.com/pkg/errors"
-	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
@@ -14,6 +16,22 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// tagDocument the tags are counted per tenant, a tag is identified by its tenant and name
+type tagDocument struct {
+	ID    tagKey `bson:"_id"`
+	Count int64  `bson:"count"`
+}
+
+type tagKey struct {
+	TenantID string `bson:"tenantId"`
+	Name     string `bson:"name"`
+}
+
+// tagID _id of the tag, the fields are ordered as stored so the documents match
+func tagID(tenantID, name string) bson.D {
+	return bson.D{{Key: "tenantId", Value: tenantID}, {Key: "name", Value: name}}
+}
+
 type mongoArticleRepository struct {
 	log zaplogger.Logger
 	cfg *config.Config
@@ -24,26 +42,30 @@ func NewMongoArticleRepository(log zaplogger.Logger, cfg *config.Config, db *mon
 	return &mongoArticleRepository{log: log, cfg: cfg, db: db}
 }
 
-func (p *mongoArticleRepository) Search(ctx context.Context, search string, author string, pagination *utils.Pagination) (*domain.ArticlesList, error) {
+func (p *mongoArticleRepository) Search(ctx context.Context, query domain.SearchArticleQuery) (*domain.ArticlesList, error) {
 	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
+	pagination := query.Pagination
 
 	filter := bson.D{
+		{Key: "tenantId", Value: tenant.FromContext(ctx)},
 		{Key: "$or", Value: bson.A{
-			bson.D{{Key: "title", Value: primitive.Regex{Pattern: search, Options: "gi"}}},
-			bson.D{{Key: "body", Value: primitive.Regex{Pattern: search, Options: "gi"}}},
+			bson.D{{Key: "title", Value: primitive.Regex{Pattern: query.Text, Options: "gi"}}},
+			bson.D{{Key: "body", Value: primitive.Regex{Pattern: query.Text, Options: "gi"}}},
 		}},
+		{Key: "status", Value: bson.D{{Key: "$in", Value: query.SearchStatuses()}}},
 	}
 
-	if author != "" {
-		filter = bson.D{
-			{Key: "$or", Value: bson.A{
-				bson.D{{Key: "title", Value: primitive.Regex{Pattern: search, Options: "gi"}}},
-				bson.D{{Key: "body", Value: primitive.Regex{Pattern: search, Options: "gi"}}},
-			}},
-			{Key: "$and", Value: bson.A{
-				bson.D{{Key: "author", Value: author}},
-			}},
-		}
+	if query.Author != "" {
+		filter = append(filter, bson.E{Key: "$and", Value: bson.A{
+			bson.D{{Key: "author", Value: query.Author}},
+		}})
+	}
+	if len(query.Tags) > 0 {
+		filter = append(filter, bson.E{Key: "tags", Value: bson.D{{Key: "$all", Value: query.Tags}}})
+	}
+	if query.Category != "" {
+		// categoryPath holds the category and its ancestors, matching it returns the subcategories too
+		filter = append(filter, bson.E{Key: "categoryPath", Value: query.Category})
 	}
 
 	count, err := collection.CountDocuments(ctx, filter)
@@ -59,7 +81,7 @@ func (p *mongoArticleRepository) Search(ctx context.Context, search string, auth
 	cursor, err := collection.Find(ctx, filter, &options.FindOptions{
 		Limit: &limit,
 		Skip:  &skip,
-		Sort:  bson.D{{"createdAt", -1}},
+		Sort:  bson.D{{Key: "createdAt", Value: -1}},
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "Find")
@@ -84,18 +106,71 @@ func (p *mongoArticleRepository) Search(ctx context.Context, search string, auth
 }
 
 func (p *mongoArticleRepository) Create(ctx context.Context, article domain.Article) (*domain.Article, error) {
+	article.TenantID = tenant.FromContext(ctx)
 
 	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
 
 	_, err := collection.InsertOne(ctx, article, &options.InsertOneOptions{})
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrStaleVersion
+		}
 		return nil, errors.Wrap(err, "InsertOne")
 	}
 
 	return &article, nil
 }
 
+// BulkUpsert store the articles the tenant does not have yet with a single BulkWrite, an article already stored
+// or conflicting with a document of another tenant is left alone like a stale Create. Returns the stored articles
+func (p *mongoArticleRepository) BulkUpsert(ctx context.Context, articles []domain.Article) ([]*domain.Article, error) {
+	if len(articles) == 0 {
+		return nil, nil
+	}
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
+
+	tenantID := tenant.FromContext(ctx)
+	models := make([]mongo.WriteModel, 0, len(articles))
+	for i := range articles {
+		articles[i].TenantID = tenantID
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": articles[i].ID, "tenantId": tenantID}).
+			SetUpdate(bson.M{"$setOnInsert": articles[i]}).
+			SetUpsert(true))
+	}
+
+	result, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil && !isOnlyDuplicateKeyErrors(err) {
+		return nil, errors.Wrap(err, "BulkWrite")
+	}
+
+	inserted := make([]*domain.Article, 0, len(result.UpsertedIDs))
+	for i := range articles {
+		if _, ok := result.UpsertedIDs[int64(i)]; ok {
+			inserted = append(inserted, &articles[i])
+		}
+	}
+
+	return inserted, nil
+}
+
+// isOnlyDuplicateKeyErrors the bulk write only failed on documents conflicting on their _id
+func isOnlyDuplicateKeyErrors(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr.WriteError) {
+			return false
+		}
+	}
+	return true
+}
+
 func (p *mongoArticleRepository) Update(ctx context.Context, article domain.Article) (*domain.Article, error) {
+	article.TenantID = tenant.FromContext(ctx)
 
 	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
 
@@ -104,18 +179,189 @@ func (p *mongoArticleRepository) Update(ctx context.Context, article domain.Arti
 	ops.SetUpsert(true)
 
 	var updated domain.Article
-	if err := collection.FindOneAndUpdate(ctx, bson.M{"_id": article.ID}, bson.M{"$set": article}, ops).Decode(&updated); err != nil {
+	if err := collection.FindOneAndUpdate(ctx, bson.M{"_id": article.ID, "tenantId": article.TenantID}, bson.M{"$set": article}, ops).Decode(&updated); err != nil {
 		return nil, errors.Wrap(err, "Decode")
 	}
 
 	return &updated, nil
 }
 
+// UpdateVersion only documents of the tenant older than article are matched, the upsert of a newer document
+// or of a document of another tenant conflicts on its _id and is reported as domain.ErrStaleVersion.
+// The replaced document is returned, nil when article is the first version stored
+func (p *mongoArticleRepository) UpdateVersion(ctx context.Context, article domain.Article) (*domain.Article, error) {
+	article.TenantID = tenant.FromContext(ctx)
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
+
+	ops := options.FindOneAndUpdate()
+	ops.SetReturnDocument(options.Before)
+	ops.SetUpsert(true)
+
+	filter := bson.M{"_id": article.ID, "tenantId": article.TenantID, "version": bson.M{"$lt": article.Version}}
+	var previous domain.Article
+	if err := collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": article}, ops).Decode(&previous); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrStaleVersion
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "Decode")
+	}
+
+	return &previous, nil
+}
+
+// RefreshTagCounts the counts are recomputed rather than incremented so a redelivered event or a failed write
+// never skews them, recounting the tags yields the same counts. A tag no article uses anymore is counted 0
+func (p *mongoArticleRepository) RefreshTagCounts(ctx context.Context, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	articles := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
+
+	tenantID := tenant.FromContext(ctx)
+	cursor, err := articles.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"tenantId": tenantID, "tags": bson.M{"$in": tags}}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$match", Value: bson.M{"tags": bson.M{"$in": tags}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "Aggregate")
+	}
+	defer cursor.Close(ctx) // nolint: errcheck
+
+	var grouped []domain.TagCount
+	if err := cursor.All(ctx, &grouped); err != nil {
+		return errors.Wrap(err, "cursor.All")
+	}
+	counts := make(map[string]int64, len(tags))
+	for _, group := range grouped {
+		counts[group.Name] = group.Count
+	}
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Tags)
+
+	models := make([]mongo.WriteModel, 0, len(tags))
+	for _, tag := range tags {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": tagID(tenantID, tag)}).
+			SetUpdate(bson.M{"$set": bson.M{"count": counts[tag]}}).
+			SetUpsert(true))
+	}
+	if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return errors.Wrap(err, "BulkWrite")
+	}
+
+	return nil
+}
+
+// ListTags tags of the tenant used by at least one article starting with prefix, the most used first
+func (p *mongoArticleRepository) ListTags(ctx context.Context, prefix string, limit int) ([]*domain.TagCount, error) {
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Tags)
+
+	filter := bson.M{"_id.tenantId": tenant.FromContext(ctx), "count": bson.M{"$gt": 0}}
+	if prefix != "" {
+		filter["_id.name"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}
+	}
+
+	ops := options.Find().
+		SetSort(bson.D{{Key: "count", Value: -1}, {Key: "_id.name", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, ops)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	defer cursor.Close(ctx) // nolint: errcheck
+
+	var documents []tagDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, errors.Wrap(err, "cursor.All")
+	}
+
+	tags := make([]*domain.TagCount, 0, len(documents))
+	for _, document := range documents {
+		tags = append(tags, &domain.TagCount{Name: document.ID.Name, Count: document.Count})
+	}
+
+	return tags, nil
+}
+
+// RefreshCommentCount the count is recomputed rather than incremented so a redelivered comment event or
+// comments projected before their article never skew it. A missing article is left alone, its creation recounts
+func (p *mongoArticleRepository) RefreshCommentCount(ctx context.Context, articleID int) (int64, error) {
+
+	comments := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Comments)
+
+	tenantID := tenant.FromContext(ctx)
+	count, err := comments.CountDocuments(ctx, bson.M{"articleId": articleID, "tenantId": tenantID, "status": events.CommentStatusVisible})
+	if err != nil {
+		return 0, errors.Wrap(err, "CountDocuments")
+	}
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
+
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": articleID, "tenantId": tenantID}, bson.M{"$set": bson.M{"commentCount": count}}); err != nil {
+		return 0, errors.Wrap(err, "UpdateOne")
+	}
+
+	return count, nil
+}
+
+// RefreshCommentCounts RefreshCommentCount of several articles with one aggregation and one BulkWrite
+func (p *mongoArticleRepository) RefreshCommentCounts(ctx context.Context, articleIDs []int) (map[int]int64, error) {
+	counts := make(map[int]int64, len(articleIDs))
+	if len(articleIDs) == 0 {
+		return counts, nil
+	}
+
+	comments := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Comments)
+
+	tenantID := tenant.FromContext(ctx)
+	cursor, err := comments.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"articleId": bson.M{"$in": articleIDs}, "tenantId": tenantID, "status": events.CommentStatusVisible}}},
+		{{Key: "$group", Value: bson.M{"_id": "$articleId", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Aggregate")
+	}
+	defer cursor.Close(ctx) // nolint: errcheck
+
+	var grouped []struct {
+		ArticleID int   `bson:"_id"`
+		Count     int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &grouped); err != nil {
+		return nil, errors.Wrap(err, "cursor.All")
+	}
+	for _, group := range grouped {
+		counts[group.ArticleID] = group.Count
+	}
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
+
+	models := make([]mongo.WriteModel, 0, len(articleIDs))
+	for _, id := range articleIDs {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id, "tenantId": tenantID}).
+			SetUpdate(bson.M{"$set": bson.M{"commentCount": counts[id]}}))
+	}
+	if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return nil, errors.Wrap(err, "BulkWrite")
+	}
+
+	return counts, nil
+}
+
 func (p *mongoArticleRepository) Delete(ctx context.Context, id int) error {
 
 	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
 
-	return collection.FindOneAndDelete(ctx, bson.M{"_id": id}).Err()
+	return collection.FindOneAndDelete(ctx, bson.M{"_id": id, "tenantId": tenant.FromContext(ctx)}).Err()
 }
 
 func (p *mongoArticleRepository) GetById(ctx context.Context, id int) (*domain.Article, error) {
@@ -123,9 +369,85 @@ func (p *mongoArticleRepository) GetById(ctx context.Context, id int) (*domain.A
 	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Articles)
 
 	var article domain.Article
-	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&article); err != nil {
+	if err := collection.FindOne(ctx, bson.M{"_id": id, "tenantId": tenant.FromContext(ctx)}).Decode(&article); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrArticleNotFound
+		}
 		return nil, errors.Wrap(err, "Decode")
 	}
 
 	return &article, nil
 }
+
+func (p *mongoArticleRepository) CreateRevision(ctx context.Context, revision domain.ArticleRevision) error {
+	revision.TenantID = tenant.FromContext(ctx)
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.ArticleRevisions)
+
+	filter := bson.M{"articleId": revision.ArticleID, "tenantId": revision.TenantID, "revision": revision.Revision}
+	_, err := collection.UpdateOne(ctx, filter, bson.M{"$setOnInsert": revision}, options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return errors.Wrap(err, "UpdateOne")
+	}
+
+	return nil
+}
+
+// CreateRevisions CreateRevision of several revisions with a single BulkWrite
+func (p *mongoArticleRepository) CreateRevisions(ctx context.Context, revisions []domain.ArticleRevision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.ArticleRevisions)
+
+	tenantID := tenant.FromContext(ctx)
+	models := make([]mongo.WriteModel, 0, len(revisions))
+	for _, revision := range revisions {
+		revision.TenantID = tenantID
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"articleId": revision.ArticleID, "tenantId": tenantID, "revision": revision.Revision}).
+			SetUpdate(bson.M{"$setOnInsert": revision}).
+			SetUpsert(true))
+	}
+
+	_, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil && !isOnlyDuplicateKeyErrors(err) {
+		return errors.Wrap(err, "BulkWrite")
+	}
+
+	return nil
+}
+
+func (p *mongoArticleRepository) GetRevisions(ctx context.Context, articleID int) ([]*domain.ArticleRevision, error) {
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.ArticleRevisions)
+
+	cursor, err := collection.Find(ctx, bson.M{"articleId": articleID, "tenantId": tenant.FromContext(ctx)}, options.Find().SetSort(bson.D{{Key: "revision", Value: 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	defer cursor.Close(ctx) // nolint: errcheck
+
+	revisions := make([]*domain.ArticleRevision, 0)
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, errors.Wrap(err, "cursor.All")
+	}
+
+	return revisions, nil
+}
+
+func (p *mongoArticleRepository) GetRevision(ctx context.Context, articleID int, revision int) (*domain.ArticleRevision, error) {
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.ArticleRevisions)
+
+	var result domain.ArticleRevision
+	if err := collection.FindOne(ctx, bson.M{"articleId": articleID, "tenantId": tenant.FromContext(ctx), "revision": revision}).Decode(&result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrRevisionNotFound
+		}
+		return nil, errors.Wrap(err, "Decode")
+	}
+
+	return &result, nil
+}
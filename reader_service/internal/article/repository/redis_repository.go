@@ -3,49 +3,107 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/cache"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
 )
 
 const (
-	redisProductPrefixKey = "reader:product"
+	redisArticlePrefixKey = "reader"
+
+	defaultArticleTTL = time.Hour
+	defaultMissingTTL = 30 * time.Second
+	defaultSearchTTL  = time.Minute
 )
 
 type redisRepository struct {
 	log         zaplogger.Logger
 	cfg         *config.Config
 	redisClient redis.UniversalClient
+	cache       cache.Cache
 }
 
-func NewRedisRepository(log zaplogger.Logger, cfg *config.Config, redisClient redis.UniversalClient) domain.RedisArticleRepository {
-	return &redisRepository{log: log, cfg: cfg, redisClient: redisClient}
+// NewRedisRepository articles and search pages are stored through articleCache,
+// redisClient keeps the index of the cached searches
+func NewRedisRepository(log zaplogger.Logger, cfg *config.Config, redisClient redis.UniversalClient, articleCache cache.Cache) domain.RedisArticleRepository {
+	return &redisRepository{log: log, cfg: cfg, redisClient: redisClient, cache: articleCache}
 }
 
-func (r *redisRepository) Put(ctx context.Context, key string, article *domain.Article) {
-	productBytes, err := json.Marshal(article)
+func (r *redisRepository) Put(ctx context.Context, article *domain.Article) {
+	articleBytes, err := json.Marshal(article)
 	if err != nil {
 		r.log.WarnMsg("json.Marshal", err)
 		return
 	}
 
-	if err := r.redisClient.HSetNX(ctx, r.getRedisArticlePrefixKey(), key, productBytes).Err(); err != nil {
-		r.log.WarnMsg("redisClient.HSetNX", err)
+	key := r.articleKey(ctx, article.ID)
+	ttl := r.ttl(r.cfg.Cache.ArticleTTLSeconds, defaultArticleTTL)
+	replaced, err := r.cache.SetIfNewer(ctx, key, articleBytes, int64(article.Version), ttl)
+	if err != nil {
+		r.log.WarnMsg("cache.SetIfNewer", err)
+		return
+	}
+	r.log.Debugf("Put key: %s, version: %d, replaced: %v", key, article.Version, replaced)
+}
+
+// PutMany the articles are stored through a single pipeline, the older versions are skipped like Put
+func (r *redisRepository) PutMany(ctx context.Context, articles []*domain.Article) {
+	if len(articles) == 0 {
+		return
+	}
+
+	entries := make([]cache.Entry, 0, len(articles))
+	for _, article := range articles {
+		articleBytes, err := json.Marshal(article)
+		if err != nil {
+			r.log.WarnMsg("json.Marshal", err)
+			continue
+		}
+		entries = append(entries, cache.Entry{Key: r.articleKey(ctx, article.ID), Value: articleBytes, Version: int64(article.Version)})
+	}
+
+	ttl := r.ttl(r.cfg.Cache.ArticleTTLSeconds, defaultArticleTTL)
+	replaced, err := r.cache.SetManyIfNewer(ctx, entries, ttl)
+	if err != nil {
+		r.log.WarnMsg("cache.SetManyIfNewer", err)
 		return
 	}
-	r.log.Debugf("HSetNX prefix: %s, key: %s", r.getRedisArticlePrefixKey(), key)
+	r.log.Debugf("PutMany keys: %d, replaced: %v", len(entries), replaced)
 }
 
-func (r *redisRepository) Get(ctx context.Context, key string) (*domain.Article, error) {
-	articleBytes, err := r.redisClient.HGet(ctx, r.getRedisArticlePrefixKey(), key).Bytes()
+// PutMissing remember for a short time that the article does not exist,
+// the marker is an empty value of version 0 so any stored article replaces it and it never replaces an article
+func (r *redisRepository) PutMissing(ctx context.Context, id int) {
+	ttl := r.ttl(r.cfg.Cache.MissingTTLSeconds, defaultMissingTTL)
+	if _, err := r.cache.SetIfNewer(ctx, r.articleKey(ctx, id), []byte{}, 0, ttl); err != nil {
+		r.log.WarnMsg("cache.SetIfNewer", err)
+		return
+	}
+	r.log.Debugf("Put missing key: %s", r.articleKey(ctx, id))
+}
+
+// Get returns domain.ErrCacheMiss when the article is not cached
+// and domain.ErrArticleNotFound when it is known to be missing
+func (r *redisRepository) Get(ctx context.Context, id int) (*domain.Article, error) {
+	articleBytes, err := r.cache.Get(ctx, r.articleKey(ctx, id))
 	if err != nil {
-		if err != redis.Nil {
-			r.log.WarnMsg("redisClient.HGet", err)
+		if err == cache.ErrMiss {
+			return nil, domain.ErrCacheMiss
 		}
-		return nil, errors.Wrap(err, "redisClient.HGet")
+		r.log.WarnMsg("cache.Get", err)
+		return nil, errors.Wrap(err, "cache.Get")
+	}
+	if len(articleBytes) == 0 {
+		return nil, domain.ErrArticleNotFound
 	}
 
 	var article domain.Article
@@ -53,30 +111,162 @@ func (r *redisRepository) Get(ctx context.Context, key string) (*domain.Article,
 		return nil, err
 	}
 
-	r.log.Debugf("HGet prefix: %s, key: %s", r.getRedisArticlePrefixKey(), key)
+	r.log.Debugf("Get key: %s", r.articleKey(ctx, id))
 	return &article, nil
 }
 
-func (r *redisRepository) Del(ctx context.Context, key string) {
-	if err := r.redisClient.HDel(ctx, r.getRedisArticlePrefixKey(), key).Err(); err != nil {
-		r.log.WarnMsg("redisClient.HDel", err)
+func (r *redisRepository) Del(ctx context.Context, id int) {
+	if err := r.cache.Del(ctx, r.articleKey(ctx, id)); err != nil {
+		r.log.WarnMsg("cache.Del", err)
 		return
 	}
-	r.log.Debugf("HDel prefix: %s, key: %s", r.getRedisArticlePrefixKey(), key)
+	r.log.Debugf("Del key: %s", r.articleKey(ctx, id))
 }
 
+// PutSearch cache the result page of query when it is one of the first pages,
+// the query is indexed by author so events can invalidate the pages they may change
+func (r *redisRepository) PutSearch(ctx context.Context, query domain.SearchArticleQuery, articles *domain.ArticlesList) {
+	if !r.isHotPage(query) {
+		return
+	}
+
+	listBytes, err := json.Marshal(articles)
+	if err != nil {
+		r.log.WarnMsg("json.Marshal", err)
+		return
+	}
+
+	ttl := r.ttl(r.cfg.Cache.SearchTTLSeconds, defaultSearchTTL)
+	key := r.searchKey(ctx, query)
+	index := r.searchIndexKey(ctx, query.Author)
+
+	if err := r.cache.Set(ctx, key, listBytes, ttl); err != nil {
+		r.log.WarnMsg("cache.Set", err)
+		return
+	}
+	_, err = r.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, index, key, query.Text)
+		// the index outlives every page it references
+		pipe.Expire(ctx, index, ttl*2)
+		return nil
+	})
+	if err != nil {
+		r.log.WarnMsg("redisClient.Pipelined", err)
+		return
+	}
+	r.log.Debugf("Set key: %s", key)
+}
+
+// GetSearch returns domain.ErrCacheMiss when the page is not cached
+func (r *redisRepository) GetSearch(ctx context.Context, query domain.SearchArticleQuery) (*domain.ArticlesList, error) {
+	if !r.isHotPage(query) {
+		return nil, domain.ErrCacheMiss
+	}
+
+	listBytes, err := r.cache.Get(ctx, r.searchKey(ctx, query))
+	if err != nil {
+		if err == cache.ErrMiss {
+			return nil, domain.ErrCacheMiss
+		}
+		r.log.WarnMsg("cache.Get", err)
+		return nil, errors.Wrap(err, "cache.Get")
+	}
+
+	var articles domain.ArticlesList
+	if err := json.Unmarshal(listBytes, &articles); err != nil {
+		return nil, err
+	}
+
+	r.log.Debugf("Get key: %s", r.searchKey(ctx, query))
+	return &articles, nil
+}
+
+// InvalidateSearch drop the cached pages of the searches the articles match,
+// searches of any author and searches of the article author are checked
+func (r *redisRepository) InvalidateSearch(ctx context.Context, articles ...*domain.Article) {
+	for _, article := range articles {
+		for _, index := range []string{r.searchIndexKey(ctx, ""), r.searchIndexKey(ctx, article.Author)} {
+			queries, err := r.redisClient.HGetAll(ctx, index).Result()
+			if err != nil {
+				r.log.WarnMsg("redisClient.HGetAll", err)
+				continue
+			}
+
+			for key, text := range queries {
+				if !matchSearch(text, article) {
+					continue
+				}
+				if err := r.cache.Del(ctx, key); err != nil {
+					r.log.WarnMsg("cache.Del", err)
+					continue
+				}
+				r.redisClient.HDel(ctx, index, key)
+				r.log.Debugf("Del key: %s", key)
+			}
+		}
+	}
+}
+
+// DelAll delete every key of the reader cache, the keys of every tenant
 func (r *redisRepository) DelAll(ctx context.Context) {
-	if err := r.redisClient.Del(ctx, r.getRedisArticlePrefixKey()).Err(); err != nil {
-		r.log.WarnMsg("redisClient.HDel", err)
+	iter := r.redisClient.Scan(ctx, 0, r.prefix()+":*", 0).Iterator()
+	for iter.Next(ctx) {
+		if err := r.cache.Del(ctx, iter.Val()); err != nil {
+			r.log.WarnMsg("cache.Del", err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		r.log.WarnMsg("redisClient.Scan", err)
 		return
 	}
-	r.log.Debugf("Del key: %s", r.getRedisArticlePrefixKey())
+	r.log.Debugf("Del keys: %s:*", r.prefix())
+}
+
+// matchSearch mirror the mongo search filter, a text that is not a valid go regexp always matches
+func matchSearch(text string, article *domain.Article) bool {
+	if text == "" {
+		return true
+	}
+	re, err := regexp.Compile("(?i)" + text)
+	if err != nil {
+		return true
+	}
+	return re.MatchString(article.Title) || re.MatchString(article.Body)
+}
+
+func (r *redisRepository) isHotPage(query domain.SearchArticleQuery) bool {
+	return query.Page() <= r.cfg.Cache.SearchMaxPage
+}
+
+// ttl lifetime of an entry with a random jitter
+func (r *redisRepository) ttl(seconds int, fallback time.Duration) time.Duration {
+	ttl := time.Duration(seconds) * time.Second
+	if ttl <= 0 {
+		ttl = fallback
+	}
+	if jitter := int64(ttl) * int64(r.cfg.Cache.TTLJitterPercent) / 100; jitter > 0 {
+		ttl += time.Duration(rand.Int63n(jitter))
+	}
+	return ttl
+}
+
+// articleKey the keys are prefixed with the tenant of ctx, a tenant never reads the entries of another one
+func (r *redisRepository) articleKey(ctx context.Context, id int) string {
+	return fmt.Sprintf("%s:%s:article:%d", r.prefix(), tenant.FromContext(ctx), id)
+}
+
+func (r *redisRepository) searchKey(ctx context.Context, query domain.SearchArticleQuery) string {
+	return fmt.Sprintf("%s:%s:search:%s", r.prefix(), tenant.FromContext(ctx), query.CacheKey())
+}
+
+func (r *redisRepository) searchIndexKey(ctx context.Context, author string) string {
+	return fmt.Sprintf("%s:%s:search:author:%s", r.prefix(), tenant.FromContext(ctx), author)
 }
 
-func (r *redisRepository) getRedisArticlePrefixKey() string {
+func (r *redisRepository) prefix() string {
 	if r.cfg.ServiceSettings.RedisArticlePrefixKey != "" {
 		return r.cfg.ServiceSettings.RedisArticlePrefixKey
 	}
 
-	return redisProductPrefixKey
+	return redisArticlePrefixKey
 }
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
+)
+
+const (
+	MongoPolicyName = "reader_mongo"
+	RedisPolicyName = "reader_redis"
+)
+
+// isFailure missing articles, cache misses and stale versions are answers of a healthy dependency
+func isFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, domain.ErrArticleNotFound) &&
+		!errors.Is(err, domain.ErrRevisionNotFound) &&
+		!errors.Is(err, domain.ErrCacheMiss) &&
+		!errors.Is(err, domain.ErrStaleVersion) &&
+		!errors.Is(err, context.Canceled)
+}
+
+type resilientMongoRepository struct {
+	next   domain.MongoArticleRepository
+	policy *resilience.Policy
+}
+
+// NewResilientMongoRepository decorate the mongo repository with the circuit breaker, bulkhead and timeout of cfg
+func NewResilientMongoRepository(next domain.MongoArticleRepository, cfg resilience.Config) domain.MongoArticleRepository {
+	cfg.IsFailure = isFailure
+	return &resilientMongoRepository{next: next, policy: resilience.NewPolicy(MongoPolicyName, cfg)}
+}
+
+func (r *resilientMongoRepository) Create(ctx context.Context, article domain.Article) (result *domain.Article, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.Create(ctx, article)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) BulkUpsert(ctx context.Context, articles []domain.Article) (result []*domain.Article, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.BulkUpsert(ctx, articles)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) Update(ctx context.Context, article domain.Article) (result *domain.Article, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.Update(ctx, article)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) UpdateVersion(ctx context.Context, article domain.Article) (result *domain.Article, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.UpdateVersion(ctx, article)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) Delete(ctx context.Context, id int) error {
+	return r.policy.Execute(ctx, func(ctx context.Context) error {
+		return r.next.Delete(ctx, id)
+	})
+}
+
+func (r *resilientMongoRepository) GetById(ctx context.Context, id int) (result *domain.Article, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.GetById(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) Search(ctx context.Context, query domain.SearchArticleQuery) (result *domain.ArticlesList, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.Search(ctx, query)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) CreateRevision(ctx context.Context, revision domain.ArticleRevision) error {
+	return r.policy.Execute(ctx, func(ctx context.Context) error {
+		return r.next.CreateRevision(ctx, revision)
+	})
+}
+
+func (r *resilientMongoRepository) CreateRevisions(ctx context.Context, revisions []domain.ArticleRevision) error {
+	return r.policy.Execute(ctx, func(ctx context.Context) error {
+		return r.next.CreateRevisions(ctx, revisions)
+	})
+}
+
+func (r *resilientMongoRepository) GetRevisions(ctx context.Context, articleID int) (result []*domain.ArticleRevision, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.GetRevisions(ctx, articleID)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) GetRevision(ctx context.Context, articleID int, revision int) (result *domain.ArticleRevision, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.GetRevision(ctx, articleID, revision)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) RefreshTagCounts(ctx context.Context, tags []string) error {
+	return r.policy.Execute(ctx, func(ctx context.Context) error {
+		return r.next.RefreshTagCounts(ctx, tags)
+	})
+}
+
+func (r *resilientMongoRepository) ListTags(ctx context.Context, prefix string, limit int) (result []*domain.TagCount, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.ListTags(ctx, prefix, limit)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) RefreshCommentCount(ctx context.Context, articleID int) (result int64, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.RefreshCommentCount(ctx, articleID)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) RefreshCommentCounts(ctx context.Context, articleIDs []int) (result map[int]int64, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.RefreshCommentCounts(ctx, articleIDs)
+		return err
+	})
+	return result, err
+}
+
+type resilientRedisRepository struct {
+	next   domain.RedisArticleRepository
+	policy *resilience.Policy
+}
+
+// NewResilientRedisRepository decorate the redis repository with the circuit breaker, bulkhead and timeout of cfg,
+// writes are skipped and reads miss while the breaker is open so the queries fall back to mongo
+func NewResilientRedisRepository(next domain.RedisArticleRepository, cfg resilience.Config) domain.RedisArticleRepository {
+	cfg.IsFailure = isFailure
+	return &resilientRedisRepository{next: next, policy: resilience.NewPolicy(RedisPolicyName, cfg)}
+}
+
+func (r *resilientRedisRepository) Put(ctx context.Context, article *domain.Article) {
+	r.run(ctx, func(ctx context.Context) { r.next.Put(ctx, article) })
+}
+
+func (r *resilientRedisRepository) PutMany(ctx context.Context, articles []*domain.Article) {
+	r.run(ctx, func(ctx context.Context) { r.next.PutMany(ctx, articles) })
+}
+
+func (r *resilientRedisRepository) PutMissing(ctx context.Context, id int) {
+	r.run(ctx, func(ctx context.Context) { r.next.PutMissing(ctx, id) })
+}
+
+func (r *resilientRedisRepository) Get(ctx context.Context, id int) (result *domain.Article, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.Get(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientRedisRepository) Del(ctx context.Context, id int) {
+	r.run(ctx, func(ctx context.Context) { r.next.Del(ctx, id) })
+}
+
+func (r *resilientRedisRepository) PutSearch(ctx context.Context, query domain.SearchArticleQuery, articles *domain.ArticlesList) {
+	r.run(ctx, func(ctx context.Context) { r.next.PutSearch(ctx, query, articles) })
+}
+
+func (r *resilientRedisRepository) GetSearch(ctx context.Context, query domain.SearchArticleQuery) (result *domain.ArticlesList, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.GetSearch(ctx, query)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientRedisRepository) InvalidateSearch(ctx context.Context, articles ...*domain.Article) {
+	r.run(ctx, func(ctx context.Context) { r.next.InvalidateSearch(ctx, articles...) })
+}
+
+func (r *resilientRedisRepository) DelAll(ctx context.Context) {
+	r.run(ctx, func(ctx context.Context) { r.next.DelAll(ctx) })
+}
+
+// run the writes log their own errors, only the timeout of the policy counts against the breaker
+func (r *resilientRedisRepository) run(ctx context.Context, fn func(ctx context.Context)) {
+	_ = r.policy.Execute(ctx, func(ctx context.Context) error {
+		fn(ctx)
+		return ctx.Err()
+	})
+}
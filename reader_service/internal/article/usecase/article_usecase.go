@@ -2,11 +2,21 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/helper"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultTagsSize = 50
+	maxTagsSize     = 100
 )
 
 type articleUseCase struct {
@@ -14,6 +24,8 @@ type articleUseCase struct {
 	contextTimeout         time.Duration
 	mongoArticleRepository domain.MongoArticleRepository
 	redisArticleRepository domain.RedisArticleRepository
+	// group coalesce the concurrent cache misses of the same key into a single mongo query
+	group *singleflight.Group
 }
 
 func NewArticleUseCase(timeout time.Duration,
@@ -25,27 +37,355 @@ func NewArticleUseCase(timeout time.Duration,
 		zapLogger:              zapLogger,
 		mongoArticleRepository: mongoArticleRepository,
 		redisArticleRepository: redisArticleRepository,
+		group:                  new(singleflight.Group),
 	}
 }
 
-func (a articleUseCase) CreateArticle(c context.Context, command domain.CreatedArticleCommand) error {
+func (a articleUseCase) CreateArticle(c context.Context, event events.ArticleCreated) error {
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
 	defer cancel()
 
-	insert, err := a.mongoArticleRepository.Create(ctx, command.ToArticle())
+	// the revision goes first so a redelivery after a failure finds it stored whatever step failed
+	if err := a.mongoArticleRepository.CreateRevision(ctx, domain.NewRevisionFromCreatedEvent(event)); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	article := domain.NewArticleFromCreatedEvent(event)
+	insert, err := a.mongoArticleRepository.Create(ctx, article)
+	if err != nil && !errors.Is(err, domain.ErrStaleVersion) {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	if insert == nil {
+		return a.refreshStale(ctx, &article)
+	}
+	if err := a.refreshTagCounts(ctx, insert); err != nil {
+		return err
+	}
+	a.refreshCommentCount(ctx, insert)
+	a.redisArticleRepository.Put(ctx, insert)
+	a.redisArticleRepository.InvalidateSearch(ctx, insert)
+
+	return nil
+}
+
+// CreateArticles CreateArticle of several events of the tenant of c, each store is written once for the whole batch
+func (a articleUseCase) CreateArticles(c context.Context, createdEvents []events.ArticleCreated) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	articles := make([]domain.Article, 0, len(createdEvents))
+	revisions := make([]domain.ArticleRevision, 0, len(createdEvents))
+	for _, event := range createdEvents {
+		articles = append(articles, domain.NewArticleFromCreatedEvent(event))
+		revisions = append(revisions, domain.NewRevisionFromCreatedEvent(event))
+	}
+
+	if err := a.mongoArticleRepository.CreateRevisions(ctx, revisions); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	inserted, err := a.mongoArticleRepository.BulkUpsert(ctx, articles)
 	if err != nil {
 		a.zapLogger.SetMessageLog(err)
 		return err
 	}
 
-	a.redisArticleRepository.Put(ctx, helper.IntToString(insert.ID), insert)
+	if len(inserted) < len(articles) {
+		stored := make(map[int]bool, len(inserted))
+		for _, article := range inserted {
+			stored[article.ID] = true
+		}
+		stale := make([]*domain.Article, 0, len(articles)-len(inserted))
+		for i := range articles {
+			if !stored[articles[i].ID] {
+				stale = append(stale, &articles[i])
+			}
+		}
+		if err := a.refreshStale(ctx, stale...); err != nil {
+			return err
+		}
+	}
+	if len(inserted) == 0 {
+		return nil
+	}
+
+	if err := a.refreshTagCounts(ctx, inserted...); err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(inserted))
+	for _, article := range inserted {
+		ids = append(ids, article.ID)
+	}
+	// a failure is only logged as the next comment event of each article recounts them
+	if counts, err := a.mongoArticleRepository.RefreshCommentCounts(ctx, ids); err != nil {
+		a.zapLogger.SetMessageLog(err)
+	} else {
+		for _, article := range inserted {
+			article.CommentCount = counts[article.ID]
+		}
+	}
+	a.redisArticleRepository.PutMany(ctx, inserted)
+	a.redisArticleRepository.InvalidateSearch(ctx, inserted...)
+
+	return nil
+}
+
+func (a articleUseCase) UpdateArticle(c context.Context, event events.ArticleUpdated) error {
+	return a.replaceArticle(c, domain.NewArticleFromUpdatedEvent(event), domain.NewRevisionFromUpdatedEvent(event))
+}
+
+func (a articleUseCase) ChangeArticleStatus(c context.Context, event events.ArticleStatusChanged) error {
+	return a.replaceArticle(c, domain.NewArticleFromStatusChangedEvent(event), domain.NewRevisionFromStatusChangedEvent(event))
+}
+
+func (a articleUseCase) PublishArticle(c context.Context, event events.ArticlePublished) error {
+	return a.replaceArticle(c, domain.NewArticleFromPublishedEvent(event), domain.NewRevisionFromPublishedEvent(event))
+}
+
+func (a articleUseCase) ChangeArticleTags(c context.Context, event events.ArticleTagsChanged) error {
+	return a.replaceArticle(c, domain.NewArticleFromTagsChangedEvent(event), domain.NewRevisionFromTagsChangedEvent(event))
+}
+
+// replaceArticle project an event carrying the whole article, events older than the stored article only record their revision
+// and refresh the cache
+func (a articleUseCase) replaceArticle(c context.Context, article domain.Article, revision domain.ArticleRevision) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	// the revision goes first so a redelivery after a failure finds it stored whatever step failed
+	if err := a.mongoArticleRepository.CreateRevision(ctx, revision); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	previous, err := a.mongoArticleRepository.UpdateVersion(ctx, article)
+	if errors.Is(err, domain.ErrStaleVersion) {
+		return a.refreshStale(ctx, &article)
+	}
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	// the searches matching the article before the change may no longer match it
+	changed := []*domain.Article{&article}
+	if previous != nil {
+		changed = append(changed, previous)
+		article.CommentCount = previous.CommentCount
+	} else {
+		a.refreshCommentCount(ctx, &article)
+	}
+	if err := a.refreshTagCounts(ctx, changed...); err != nil {
+		return err
+	}
+	a.redisArticleRepository.Put(ctx, &article)
+	a.redisArticleRepository.InvalidateSearch(ctx, changed...)
+
+	return nil
+}
+
+// refreshStale redo the cache and tag updates of events the projection already holds, the redelivery of an event whose
+// updates were cut short finds the article stored. The cached articles are dropped rather than replaced
+// as a newer event may be projected meanwhile, the searches and tags of the articles before or after the events are refreshed
+func (a articleUseCase) refreshStale(ctx context.Context, articles ...*domain.Article) error {
+	changed := make([]*domain.Article, 0, len(articles)*2)
+	var revisionErr error
+	for _, article := range articles {
+		a.redisArticleRepository.Del(ctx, article.ID)
+		changed = append(changed, article)
+		if article.Version <= 1 {
+			continue
+		}
+		previous, err := a.mongoArticleRepository.GetRevision(ctx, article.ID, article.Version-1)
+		if err != nil {
+			if !errors.Is(err, domain.ErrRevisionNotFound) {
+				a.zapLogger.SetMessageLog(err)
+				revisionErr = err
+			}
+			continue
+		}
+		changed = append(changed, domain.NewArticleFromRevision(previous))
+	}
+	a.redisArticleRepository.InvalidateSearch(ctx, changed...)
+
+	if err := a.refreshTagCounts(ctx, changed...); err != nil {
+		return err
+	}
+	return revisionErr
+}
+
+// refreshTagCounts recount the tags of the articles from the projection, recounting again yields the same
+// counts so a failure is returned for the redelivered event to recount them
+func (a articleUseCase) refreshTagCounts(ctx context.Context, articles ...*domain.Article) error {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, article := range articles {
+		for _, tag := range article.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
 
+	if err := a.mongoArticleRepository.RefreshTagCounts(ctx, tags); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
 	return nil
 }
 
+// refreshCommentCount count the comments projected before the article was stored, a failure is only logged
+// as the next comment event of the article recounts them
+func (a articleUseCase) refreshCommentCount(ctx context.Context, article *domain.Article) {
+	count, err := a.mongoArticleRepository.RefreshCommentCount(ctx, article.ID)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return
+	}
+	article.CommentCount = count
+}
+
+func (a articleUseCase) GetArticleById(c context.Context, id int) (*domain.Article, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	cached, err := a.redisArticleRepository.Get(ctx, id)
+	if err == nil || errors.Is(err, domain.ErrArticleNotFound) {
+		return cached, err
+	}
+
+	// the concurrent reads are only shared within a tenant
+	article, err := a.shared(ctx, tenant.FromContext(ctx)+":article:"+helper.IntToString(id), func(ctx context.Context) (interface{}, error) {
+		article, err := a.mongoArticleRepository.GetById(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrArticleNotFound) {
+				a.redisArticleRepository.PutMissing(ctx, id)
+			}
+			return nil, err
+		}
+
+		a.redisArticleRepository.Put(ctx, article)
+		return article, nil
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrArticleNotFound) {
+			a.zapLogger.SetMessageLog(err)
+		}
+		return nil, err
+	}
+
+	return article.(*domain.Article), nil
+}
+
 func (a articleUseCase) SearchArticle(c context.Context, query domain.SearchArticleQuery) (*domain.ArticlesList, error) {
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
 	defer cancel()
 
-	return a.mongoArticleRepository.Search(ctx, query.Text, query.Author, query.Pagination)
+	cached, err := a.redisArticleRepository.GetSearch(ctx, query)
+	if err == nil {
+		return cached, nil
+	}
+
+	articles, err := a.shared(ctx, tenant.FromContext(ctx)+":search:"+query.CacheKey(), func(ctx context.Context) (interface{}, error) {
+		articles, err := a.mongoArticleRepository.Search(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		a.redisArticleRepository.PutSearch(ctx, query, articles)
+		return articles, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return articles.(*domain.ArticlesList), nil
+}
+
+// shared run load once for the concurrent callers of key. The load runs on a context of its own carrying the tenant,
+// a caller giving up only stops its own wait and neither fails the other callers nor skips the cache fill
+func (a articleUseCase) shared(ctx context.Context, key string, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	result := a.group.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(tenant.WithID(context.Background(), tenant.FromContext(ctx)), a.contextTimeout)
+		defer cancel()
+		return load(loadCtx)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		return res.Val, res.Err
+	}
+}
+
+// ListTags tags starting with prefix used by at least one published or unpublished article, the most used first
+func (a articleUseCase) ListTags(c context.Context, prefix string, size int) ([]*domain.TagCount, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if size <= 0 {
+		size = defaultTagsSize
+	}
+	if size > maxTagsSize {
+		size = maxTagsSize
+	}
+
+	tags, err := a.mongoArticleRepository.ListTags(ctx, strings.ToLower(strings.TrimSpace(prefix)), size)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// GetArticleRevisions revisions of the article from the oldest, domain.ErrArticleNotFound when it has none
+func (a articleUseCase) GetArticleRevisions(c context.Context, id int) ([]*domain.ArticleRevision, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	revisions, err := a.mongoArticleRepository.GetRevisions(ctx, id)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, domain.ErrArticleNotFound
+	}
+
+	return revisions, nil
+}
+
+func (a articleUseCase) GetArticleRevision(c context.Context, id int, revision int) (*domain.ArticleRevision, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	result, err := a.mongoArticleRepository.GetRevision(ctx, id, revision)
+	if err != nil {
+		if !errors.Is(err, domain.ErrRevisionNotFound) {
+			a.zapLogger.SetMessageLog(err)
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DiffArticleRevisions fields changed between the revisions from and to of the article
+func (a articleUseCase) DiffArticleRevisions(c context.Context, id int, from int, to int) ([]domain.FieldChange, error) {
+	fromRevision, err := a.GetArticleRevision(c, id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := a.GetArticleRevision(c, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.DiffArticleRevisions(fromRevision, toRevision), nil
 }
@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
+	readerService "github.com/radyatamaa/go-cqrs-microservices/reader_service/proto/article_reader"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type commentGrpcService struct {
+	zapLogger zaplogger.Logger
+	useCase   domain.CommentUseCase
+	cfg       *config.Config
+}
+
+func NewCommentGrpcService(useCase domain.CommentUseCase, cfg *config.Config, zapLogger zaplogger.Logger) *commentGrpcService {
+	return &commentGrpcService{
+		zapLogger: zapLogger,
+		useCase:   useCase,
+		cfg:       cfg,
+	}
+}
+
+func (s *commentGrpcService) ListComments(ctx context.Context, req *readerService.ListCommentsReq) (*readerService.ListCommentsRes, error) {
+	pq := utils.NewPaginationQuery(int(req.GetSize()), int(req.GetPage()))
+
+	comments, err := s.useCase.ListComments(ctx, int(req.GetArticleID()), pq)
+	if err != nil {
+		s.zapLogger.WarnMsg("CommentUseCase.ListComments", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return domain.CommentListToGrpc(comments), nil
+}
+
+// errCode calls rejected by a circuit breaker or a bulkhead are reported unavailable so clients back off
+func errCode(err error) codes.Code {
+	if resilience.IsRejected(err) {
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+func (s *commentGrpcService) errResponse(c codes.Code, err error) error {
+	return status.Error(c, err.Error())
+}
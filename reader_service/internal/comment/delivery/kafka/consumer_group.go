@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	retryAttempts = 3
+	retryDelay    = 300 * time.Millisecond
+	PoolSize      = 10
+)
+
+var (
+	retryOptions = []retry.Option{retry.Attempts(retryAttempts), retry.Delay(retryDelay), retry.DelayType(retry.BackOffDelay)}
+)
+
+type commentConsumer struct {
+	zapLogger zaplogger.Logger
+	useCase   domain.CommentUseCase
+	cfg       *config.Config
+}
+
+func NewCommentConsumer(useCase domain.CommentUseCase, cfg *config.Config, zapLogger zaplogger.Logger) *commentConsumer {
+	return &commentConsumer{
+		zapLogger: zapLogger,
+		useCase:   useCase,
+		cfg:       cfg,
+	}
+}
+
+func (s *commentConsumer) ProcessMessages(ctx context.Context, r *kafka.Reader, wg *sync.WaitGroup, workerID int) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			s.zapLogger.Warnf("workerID: %v, err: %v", workerID, err)
+			continue
+		}
+
+		s.logProcessMessage(m, workerID)
+		// the message is processed within the tenant it was published for
+		msgCtx := tenant.ContextFromMessage(ctx, m)
+
+		switch m.Topic {
+		case s.cfg.KafkaTopics.CommentCreated.TopicName:
+			var event events.CommentCreated
+			s.processEvent(msgCtx, r, m, events.CommentCreatedType, &event, "CommentUseCase.CreateComment", func() error {
+				return s.useCase.CreateComment(msgCtx, event)
+			})
+		case s.cfg.KafkaTopics.CommentEdited.TopicName:
+			var event events.CommentEdited
+			s.processEvent(msgCtx, r, m, events.CommentEditedType, &event, "CommentUseCase.EditComment", func() error {
+				return s.useCase.EditComment(msgCtx, event)
+			})
+		case s.cfg.KafkaTopics.CommentDeleted.TopicName:
+			var event events.CommentDeleted
+			s.processEvent(msgCtx, r, m, events.CommentDeletedType, &event, "CommentUseCase.DeleteComment", func() error {
+				return s.useCase.DeleteComment(msgCtx, event)
+			})
+		case s.cfg.KafkaTopics.CommentModerated.TopicName:
+			var event events.CommentModerated
+			s.processEvent(msgCtx, r, m, events.CommentModeratedType, &event, "CommentUseCase.ModerateComment", func() error {
+				return s.useCase.ModerateComment(msgCtx, event)
+			})
+		}
+	}
+}
+
+// processEvent decode m of eventType into event and project it with handle
+func (s *commentConsumer) processEvent(ctx context.Context, r *kafka.Reader, m kafka.Message, eventType string, event interface{}, name string, handle func() error) {
+
+	envelope, err := events.Default.DecodeMessage(eventType, m)
+	if err != nil {
+		s.zapLogger.WarnMsg("events.DecodeMessage", err)
+		s.commitErrMessage(ctx, r, m)
+		return
+	}
+
+	if err := envelope.Decode(event); err != nil {
+		s.zapLogger.WarnMsg("envelope.Decode", err)
+		s.commitErrMessage(ctx, r, m)
+		return
+	}
+
+	if err := retry.Do(handle, append(retryOptions, retry.Context(ctx))...); err != nil {
+		s.zapLogger.WarnMsg(name, err)
+		return
+	}
+
+	s.commitMessage(ctx, r, m)
+}
+
+func (s *commentConsumer) logProcessMessage(m kafka.Message, workerID int) {
+	s.zapLogger.KafkaProcessMessage(m.Topic, m.Partition, string(m.Value), workerID, m.Offset, m.Time)
+}
+
+func (s *commentConsumer) commitMessage(ctx context.Context, r *kafka.Reader, m kafka.Message) {
+	s.zapLogger.KafkaLogCommittedMessage(m.Topic, m.Partition, m.Offset)
+	if err := r.CommitMessages(ctx, m); err != nil {
+		s.zapLogger.WarnMsg("commitMessage", err)
+	}
+}
+
+func (s *commentConsumer) commitErrMessage(ctx context.Context, r *kafka.Reader, m kafka.Message) {
+	s.zapLogger.KafkaLogCommittedMessage(m.Topic, m.Partition, m.Offset)
+	if err := r.CommitMessages(ctx, m); err != nil {
+		s.zapLogger.WarnMsg("commitMessage", err)
+	}
+}
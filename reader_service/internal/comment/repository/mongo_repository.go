@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoCommentRepository struct {
+	log zaplogger.Logger
+	cfg *config.Config
+	db  *mongo.Client
+}
+
+func NewMongoCommentRepository(log zaplogger.Logger, cfg *config.Config, db *mongo.Client) domain.MongoCommentRepository {
+	return &mongoCommentRepository{log: log, cfg: cfg, db: db}
+}
+
+// UpdateVersion only documents older than comment are matched, the upsert of a newer document
+// conflicts on its _id and is reported as domain.ErrStaleCommentVersion. The replaced document is returned,
+// nil when comment is the first version stored
+func (p *mongoCommentRepository) UpdateVersion(ctx context.Context, comment domain.Comment) (*domain.Comment, error) {
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Comments)
+
+	ops := options.FindOneAndUpdate()
+	ops.SetReturnDocument(options.Before)
+	ops.SetUpsert(true)
+
+	comment.TenantID = tenant.FromContext(ctx)
+	filter := bson.M{"_id": comment.ID, "tenantId": comment.TenantID, "version": bson.M{"$lt": comment.Version}}
+	var previous domain.Comment
+	if err := collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": comment}, ops).Decode(&previous); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, domain.ErrStaleCommentVersion
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "Decode")
+	}
+
+	return &previous, nil
+}
+
+func (p *mongoCommentRepository) List(ctx context.Context, articleID int, pagination *utils.Pagination) (*domain.CommentsList, error) {
+
+	collection := p.db.Database(p.cfg.Mongo.Db).Collection(p.cfg.MongoCollections.Comments)
+
+	filter := bson.M{"articleId": articleID, "tenantId": tenant.FromContext(ctx), "status": events.CommentStatusVisible}
+
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "CountDocuments")
+	}
+	if count == 0 {
+		return domain.NewCommentListWithPagination(make([]*domain.Comment, 0), 0, pagination), nil
+	}
+
+	ops := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(pagination.GetOffset())).
+		SetLimit(int64(pagination.GetLimit()))
+	cursor, err := collection.Find(ctx, filter, ops)
+	if err != nil {
+		return nil, errors.Wrap(err, "Find")
+	}
+	defer cursor.Close(ctx) // nolint: errcheck
+
+	comments := make([]*domain.Comment, 0, pagination.GetSize())
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, errors.Wrap(err, "cursor.All")
+	}
+
+	return domain.NewCommentListWithPagination(comments, count, pagination), nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
+)
+
+// MongoPolicyName the comments have their own breaker so a failing comments collection leaves the articles served
+const MongoPolicyName = "reader_mongo_comments"
+
+// isFailure stale versions are answers of a healthy dependency
+func isFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, domain.ErrStaleCommentVersion) &&
+		!errors.Is(err, context.Canceled)
+}
+
+type resilientMongoRepository struct {
+	next   domain.MongoCommentRepository
+	policy *resilience.Policy
+}
+
+// NewResilientMongoRepository decorate the mongo repository with the circuit breaker, bulkhead and timeout of cfg
+func NewResilientMongoRepository(next domain.MongoCommentRepository, cfg resilience.Config) domain.MongoCommentRepository {
+	cfg.IsFailure = isFailure
+	return &resilientMongoRepository{next: next, policy: resilience.NewPolicy(MongoPolicyName, cfg)}
+}
+
+func (r *resilientMongoRepository) UpdateVersion(ctx context.Context, comment domain.Comment) (result *domain.Comment, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.UpdateVersion(ctx, comment)
+		return err
+	})
+	return result, err
+}
+
+func (r *resilientMongoRepository) List(ctx context.Context, articleID int, pagination *utils.Pagination) (result *domain.CommentsList, err error) {
+	err = r.policy.Execute(ctx, func(ctx context.Context) error {
+		result, err = r.next.List(ctx, articleID, pagination)
+		return err
+	})
+	return result, err
+}
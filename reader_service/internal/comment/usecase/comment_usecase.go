@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
+)
+
+type commentUseCase struct {
+	zapLogger              zaplogger.Logger
+	contextTimeout         time.Duration
+	mongoCommentRepository domain.MongoCommentRepository
+	mongoArticleRepository domain.MongoArticleRepository
+	redisArticleRepository domain.RedisArticleRepository
+}
+
+func NewCommentUseCase(timeout time.Duration,
+	mongoCommentRepository domain.MongoCommentRepository,
+	mongoArticleRepository domain.MongoArticleRepository,
+	redisArticleRepository domain.RedisArticleRepository,
+	zapLogger zaplogger.Logger) domain.CommentUseCase {
+	return &commentUseCase{
+		contextTimeout:         timeout,
+		zapLogger:              zapLogger,
+		mongoCommentRepository: mongoCommentRepository,
+		mongoArticleRepository: mongoArticleRepository,
+		redisArticleRepository: redisArticleRepository,
+	}
+}
+
+func (a commentUseCase) CreateComment(c context.Context, event events.CommentCreated) error {
+	return a.replaceComment(c, domain.NewCommentFromCreatedEvent(event))
+}
+
+func (a commentUseCase) EditComment(c context.Context, event events.CommentEdited) error {
+	return a.replaceComment(c, domain.NewCommentFromEditedEvent(event))
+}
+
+func (a commentUseCase) DeleteComment(c context.Context, event events.CommentDeleted) error {
+	return a.replaceComment(c, domain.NewCommentFromDeletedEvent(event))
+}
+
+func (a commentUseCase) ModerateComment(c context.Context, event events.CommentModerated) error {
+	return a.replaceComment(c, domain.NewCommentFromModeratedEvent(event))
+}
+
+// replaceComment project an event carrying the whole comment, events older than the stored comment are skipped.
+// The comment count of the article is refreshed when the comment is shown or hidden by the change
+func (a commentUseCase) replaceComment(c context.Context, comment domain.Comment) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	previous, err := a.mongoCommentRepository.UpdateVersion(ctx, comment)
+	if err != nil {
+		if errors.Is(err, domain.ErrStaleCommentVersion) {
+			return nil
+		}
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	if previous.Visible() == comment.Visible() {
+		return nil
+	}
+	a.refreshCommentCount(ctx, comment.ArticleID)
+
+	return nil
+}
+
+// refreshCommentCount recount the comments of the article and refresh its cached copies, a failure is only
+// logged as the redelivered event would be stale by then and the next comment event of the article recounts
+func (a commentUseCase) refreshCommentCount(ctx context.Context, articleID int) {
+	if _, err := a.mongoArticleRepository.RefreshCommentCount(ctx, articleID); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return
+	}
+
+	article, err := a.mongoArticleRepository.GetById(ctx, articleID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrArticleNotFound) {
+			a.zapLogger.SetMessageLog(err)
+			a.redisArticleRepository.Del(ctx, articleID)
+		}
+		return
+	}
+	a.redisArticleRepository.Put(ctx, article)
+	a.redisArticleRepository.InvalidateSearch(ctx, article)
+}
+
+func (a commentUseCase) ListComments(c context.Context, articleID int, pagination *utils.Pagination) (*domain.CommentsList, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	comments, err := a.mongoCommentRepository.List(ctx, articleID, pagination)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	return comments, nil
+}
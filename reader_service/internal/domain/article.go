@@ -2,20 +2,42 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
 	readerService "github.com/radyatamaa/go-cqrs-microservices/reader_service/proto/article_reader"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+var (
+	ErrArticleNotFound  = errors.New("article not found")
+	ErrRevisionNotFound = errors.New("article revision not found")
+	ErrCacheMiss        = errors.New("cache miss")
+	// ErrStaleVersion the stored article already is at the version of the event or a newer one
+	ErrStaleVersion = errors.New("stale article version")
+)
+
 type Article struct {
-	ID        int       `json:"id" bson:"_id,omitempty"`
-	Author    string    `json:"author,omitempty" bson:"author,omitempty" validate:"required,min=3,max=250"`
-	Title     string    `json:"title,omitempty" bson:"title,omitempty" validate:"required,min=3,max=250"`
-	Body      string    `json:"body,omitempty" bson:"body,omitempty" validate:"required,min=3,max=250"`
-	CreatedAt time.Time `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
-	UpdatedAt time.Time `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+	ID       int    `json:"id" bson:"_id,omitempty"`
+	TenantID string `json:"tenantId" bson:"tenantId"`
+	Version  int    `json:"version" bson:"version"`
+	Status   string `json:"status,omitempty" bson:"status,omitempty"`
+	// PublishAt and PublishedAt are stored even when empty so a replaced article clears them
+	PublishAt   *time.Time `json:"publishAt,omitempty" bson:"publishAt"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty" bson:"publishedAt"`
+	Tags        []string   `json:"tags" bson:"tags"`
+	Category    string     `json:"category,omitempty" bson:"category"`
+	// CategoryPath category and the categories it is nested in, searching a category matches its subcategories
+	CategoryPath []string `json:"categoryPath,omitempty" bson:"categoryPath"`
+	// CommentCount visible comments of the article, kept by the comments projection and never set from an article event
+	CommentCount int64     `json:"commentCount" bson:"commentCount,omitempty"`
+	Author       string    `json:"author,omitempty" bson:"author,omitempty" validate:"required,min=3,max=250"`
+	Title        string    `json:"title,omitempty" bson:"title,omitempty" validate:"required,min=3,max=250"`
+	Body         string    `json:"body,omitempty" bson:"body,omitempty" validate:"required,min=3,max=250"`
+	CreatedAt    time.Time `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt    time.Time `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
 }
 
 // ArticlesList articles list response with pagination
@@ -35,25 +57,65 @@ func (r *Article) TableName() string {
 
 // ArticleUseCase UseCase Interface
 type ArticleUseCase interface {
-	CreateArticle(c context.Context, command CreatedArticleCommand) error
+	CreateArticle(c context.Context, event events.ArticleCreated) error
+	// CreateArticles CreateArticle of several events of the same tenant
+	CreateArticles(c context.Context, createdEvents []events.ArticleCreated) error
+	UpdateArticle(c context.Context, event events.ArticleUpdated) error
+	ChangeArticleStatus(c context.Context, event events.ArticleStatusChanged) error
+	PublishArticle(c context.Context, event events.ArticlePublished) error
+	ChangeArticleTags(c context.Context, event events.ArticleTagsChanged) error
+	GetArticleById(c context.Context, id int) (*Article, error)
 	SearchArticle(c context.Context, query SearchArticleQuery) (*ArticlesList, error)
+	ListTags(c context.Context, prefix string, size int) ([]*TagCount, error)
+	GetArticleRevisions(c context.Context, id int) ([]*ArticleRevision, error)
+	GetArticleRevision(c context.Context, id int, revision int) (*ArticleRevision, error)
+	DiffArticleRevisions(c context.Context, id int, from int, to int) ([]FieldChange, error)
 }
 
 // MongoArticleRepository Repository Interface
 type MongoArticleRepository interface {
 	Create(ctx context.Context, article Article) (*Article, error)
+	// BulkUpsert Create of several articles in one round trip, returns the articles stored,
+	// the ones already stored are skipped like a stale Create
+	BulkUpsert(ctx context.Context, articles []Article) ([]*Article, error)
 	Update(ctx context.Context, article Article) (*Article, error)
+	// UpdateVersion replace the article unless it already is at article.Version or newer, ErrStaleVersion then.
+	// Returns the replaced article, nil when article is the first version stored
+	UpdateVersion(ctx context.Context, article Article) (*Article, error)
 	Delete(ctx context.Context, id int) error
 
 	GetById(ctx context.Context, id int) (*Article, error)
-	Search(ctx context.Context, search string, author string, pagination *utils.Pagination) (*ArticlesList, error)
+	Search(ctx context.Context, query SearchArticleQuery) (*ArticlesList, error)
+
+	// CreateRevision store the revision once, storing it again is a no-op
+	CreateRevision(ctx context.Context, revision ArticleRevision) error
+	// CreateRevisions CreateRevision of several revisions in one round trip
+	CreateRevisions(ctx context.Context, revisions []ArticleRevision) error
+	GetRevisions(ctx context.Context, articleID int) ([]*ArticleRevision, error)
+	GetRevision(ctx context.Context, articleID int, revision int) (*ArticleRevision, error)
+
+	// RefreshTagCounts recount the articles of every tag and store the counts
+	RefreshTagCounts(ctx context.Context, tags []string) error
+	// ListTags tags starting with prefix used by at least one article, the most used first
+	ListTags(ctx context.Context, prefix string, limit int) ([]*TagCount, error)
+
+	// RefreshCommentCount recount the visible comments of the article and store the count on it
+	RefreshCommentCount(ctx context.Context, articleID int) (int64, error)
+	// RefreshCommentCounts RefreshCommentCount of several articles, returns the count of each
+	RefreshCommentCounts(ctx context.Context, articleIDs []int) (map[int]int64, error)
 }
 
 // RedisArticleRepository Repository Interface
 type RedisArticleRepository interface {
-	Put(ctx context.Context, key string, article *Article)
-	Get(ctx context.Context, key string) (*Article, error)
-	Del(ctx context.Context, key string)
+	Put(ctx context.Context, article *Article)
+	// PutMany Put of several articles in one round trip
+	PutMany(ctx context.Context, articles []*Article)
+	PutMissing(ctx context.Context, id int)
+	Get(ctx context.Context, id int) (*Article, error)
+	Del(ctx context.Context, id int)
+	PutSearch(ctx context.Context, query SearchArticleQuery, articles *ArticlesList)
+	GetSearch(ctx context.Context, query SearchArticleQuery) (*ArticlesList, error)
+	InvalidateSearch(ctx context.Context, articles ...*Article)
 	DelAll(ctx context.Context)
 }
 
@@ -71,13 +133,28 @@ func NewArticleListWithPagination(articles []*Article, count int64, pagination *
 
 func ArticleToGrpcMessage(article *Article) *readerService.Article {
 	return &readerService.Article{
-		ID:        int32(article.ID),
-		Author:    article.Author,
-		Title:     article.Title,
-		Body:      article.Body,
-		CreatedAt: timestamppb.New(article.CreatedAt),
-		UpdatedAt: timestamppb.New(article.UpdatedAt),
+		ID:           int32(article.ID),
+		Author:       article.Author,
+		Title:        article.Title,
+		Body:         article.Body,
+		CreatedAt:    timestamppb.New(article.CreatedAt),
+		UpdatedAt:    timestamppb.New(article.UpdatedAt),
+		Status:       article.Status,
+		Tags:         article.Tags,
+		Category:     article.Category,
+		PublishAt:    timeToGrpc(article.PublishAt),
+		PublishedAt:  timeToGrpc(article.PublishedAt),
+		CommentCount: article.CommentCount,
+		Version:      int32(article.Version),
+	}
+}
+
+// timeToGrpc an unset time is left out of the message
+func timeToGrpc(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
 	}
+	return timestamppb.New(*t)
 }
 
 func ArticleListToGrpc(articles *ArticlesList) *readerService.SearchRes {
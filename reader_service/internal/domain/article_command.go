@@ -1,23 +1,106 @@
 package domain
 
-import "time"
-
-type CreatedArticleCommand struct {
-	ID        int `json:"id"`
-	Author    string `json:"author"`
-	Title     string `json:"title"`
-	Body      string `json:"body"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+import "github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+
+// NewArticleFromCreatedEvent map the article created event into the read model
+func NewArticleFromCreatedEvent(event events.ArticleCreated) Article {
+	return Article{
+		ID:        event.ID,
+		Version:   event.Version,
+		Status:    event.Status,
+		Author:    event.Author,
+		Title:     event.Title,
+		Body:      event.Body,
+		CreatedAt: event.CreatedAt,
+		UpdatedAt: event.UpdatedAt,
+	}.withTaxonomy(event.Tags, event.Category)
+}
+
+// NewArticleFromUpdatedEvent map the article updated event into the read model
+func NewArticleFromUpdatedEvent(event events.ArticleUpdated) Article {
+	return Article{
+		ID:          event.ID,
+		Version:     event.Version,
+		Status:      event.Status,
+		PublishAt:   event.PublishAt,
+		PublishedAt: event.PublishedAt,
+		Author:      event.Author,
+		Title:       event.Title,
+		Body:        event.Body,
+		CreatedAt:   event.CreatedAt,
+		UpdatedAt:   event.UpdatedAt,
+	}.withTaxonomy(event.Tags, event.Category)
+}
+
+// NewArticleFromStatusChangedEvent map the article status changed event into the read model
+func NewArticleFromStatusChangedEvent(event events.ArticleStatusChanged) Article {
+	return Article{
+		ID:          event.ID,
+		Version:     event.Version,
+		Status:      event.Status,
+		PublishAt:   event.PublishAt,
+		PublishedAt: event.PublishedAt,
+		Author:      event.Author,
+		Title:       event.Title,
+		Body:        event.Body,
+		CreatedAt:   event.CreatedAt,
+		UpdatedAt:   event.UpdatedAt,
+	}.withTaxonomy(event.Tags, event.Category)
 }
 
-func (r CreatedArticleCommand)ToArticle() Article {
+// NewArticleFromPublishedEvent map the article published event into the read model
+func NewArticleFromPublishedEvent(event events.ArticlePublished) Article {
+	publishedAt := event.PublishedAt
 	return Article{
-		ID:        r.ID,
-		Author:    r.Author,
-		Title:     r.Title,
-		Body:      r.Body,
-		CreatedAt: r.CreatedAt,
-		UpdatedAt: r.UpdatedAt,
+		ID:          event.ID,
+		Version:     event.Version,
+		Status:      events.ArticleStatusPublished,
+		PublishedAt: &publishedAt,
+		Author:      event.Author,
+		Title:       event.Title,
+		Body:        event.Body,
+		CreatedAt:   event.CreatedAt,
+		UpdatedAt:   event.UpdatedAt,
+	}.withTaxonomy(event.Tags, event.Category)
+}
+
+// NewArticleFromTagsChangedEvent map the article tags changed event into the read model
+func NewArticleFromTagsChangedEvent(event events.ArticleTagsChanged) Article {
+	return Article{
+		ID:          event.ID,
+		Version:     event.Version,
+		Status:      event.Status,
+		PublishAt:   event.PublishAt,
+		PublishedAt: event.PublishedAt,
+		Author:      event.Author,
+		Title:       event.Title,
+		Body:        event.Body,
+		CreatedAt:   event.CreatedAt,
+		UpdatedAt:   event.UpdatedAt,
+	}.withTaxonomy(event.Tags, event.Category)
+}
+
+// withTaxonomy set the tags and the category of the article, the read model never holds null tags
+func (a Article) withTaxonomy(tags []string, category string) Article {
+	if tags == nil {
+		tags = []string{}
 	}
+	a.Tags = tags
+	a.Category = category
+	a.CategoryPath = events.CategoryAncestors(category)
+	return a
+}
+
+// NewArticleFromRevision the state of the article after the revision, without the fields the revision does not hold
+func NewArticleFromRevision(revision *ArticleRevision) *Article {
+	article := Article{
+		ID:       revision.ArticleID,
+		TenantID: revision.TenantID,
+		Version:  revision.Revision,
+		Status:   revision.Status,
+		Author:   revision.Author,
+		Title:    revision.Title,
+		Body:     revision.Body,
+	}.withTaxonomy(revision.Tags, revision.Category)
+	return &article
 }
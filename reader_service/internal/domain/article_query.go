@@ -1,13 +1,84 @@
 package domain
 
-import "github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+)
 
 type SearchArticleQuery struct {
-	Author     string            `json:"author"`
-	Text       string            `json:"text"`
+	// Author and Text are trimmed, the same values key the cache and filter the articles
+	Author string `json:"author"`
+	Text   string `json:"text"`
+	// Statuses statuses of the returned articles, only the published articles when empty
+	Statuses []string `json:"statuses"`
+	// Tags normalized tags the returned articles all carry
+	Tags []string `json:"tags"`
+	// Category normalized category path, the articles of its subcategories are returned as well
+	Category   string            `json:"category"`
 	Pagination *utils.Pagination `json:"pagination"`
 }
 
-func NewSearchArticleQuery(text string, author string, pagination *utils.Pagination) SearchArticleQuery {
-	return SearchArticleQuery{Text: text, Author: author, Pagination: pagination}
+// NewSearchArticleQuery normalize the query once, the cache and the search both use the normalized values
+func NewSearchArticleQuery(text string, author string, statuses []string, tags []string, category string, pagination *utils.Pagination) SearchArticleQuery {
+	return SearchArticleQuery{
+		Text:       strings.TrimSpace(text),
+		Author:     strings.TrimSpace(author),
+		Statuses:   statuses,
+		Tags:       events.NormalizeTags(tags),
+		Category:   events.NormalizeCategory(category),
+		Pagination: pagination,
+	}
+}
+
+// SearchStatuses sorted distinct statuses the query returns
+func (q SearchArticleQuery) SearchStatuses() []string {
+	statuses := make([]string, 0, len(q.Statuses))
+	seen := make(map[string]bool, len(q.Statuses))
+	for _, status := range q.Statuses {
+		status = strings.TrimSpace(status)
+		if status == "" || seen[status] {
+			continue
+		}
+		seen[status] = true
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		return []string{events.ArticleStatusPublished}
+	}
+	sort.Strings(statuses)
+	return statuses
+}
+
+// Page page of the query, the first page may be requested as page 0 or 1
+func (q SearchArticleQuery) Page() int {
+	if q.Pagination == nil || q.Pagination.GetPage() < 1 {
+		return 1
+	}
+	return q.Pagination.GetPage()
+}
+
+// CacheKey identifier of the query built by NewSearchArticleQuery, queries returning the same page share the same key
+func (q SearchArticleQuery) CacheKey() string {
+	size := 0
+	if q.Pagination != nil {
+		size = q.Pagination.GetSize()
+	}
+	normalized := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%d\x00%d",
+		q.Text,
+		q.Author,
+		strings.Join(q.SearchStatuses(), ","),
+		strings.Join(q.Tags, ","),
+		q.Category,
+		q.Page(),
+		size,
+	)
+
+	sum := sha1.Sum([]byte(normalized))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	readerService "github.com/radyatamaa/go-cqrs-microservices/reader_service/proto/article_reader"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ArticleRevision state of an article after one of its changes, the revision number is the article version
+type ArticleRevision struct {
+	ArticleID     int       `json:"articleId" bson:"articleId"`
+	TenantID      string    `json:"tenantId" bson:"tenantId"`
+	Revision      int       `json:"revision" bson:"revision"`
+	ChangedBy     string    `json:"changedBy,omitempty" bson:"changedBy,omitempty"`
+	ChangedFields []string  `json:"changedFields" bson:"changedFields"`
+	Status        string    `json:"status,omitempty" bson:"status,omitempty"`
+	Tags          []string  `json:"tags,omitempty" bson:"tags,omitempty"`
+	Category      string    `json:"category,omitempty" bson:"category,omitempty"`
+	Author        string    `json:"author,omitempty" bson:"author,omitempty"`
+	Title         string    `json:"title,omitempty" bson:"title,omitempty"`
+	Body          string    `json:"body,omitempty" bson:"body,omitempty"`
+	CreatedAt     time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// FieldChange value of a field in two revisions
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// NewRevisionFromCreatedEvent the first revision sets every field
+func NewRevisionFromCreatedEvent(event events.ArticleCreated) ArticleRevision {
+	return ArticleRevision{
+		ArticleID:     event.ID,
+		Revision:      event.Version,
+		ChangedBy:     event.Author,
+		ChangedFields: []string{"status", "tags", "category", "author", "title", "body"},
+		Status:        event.Status,
+		Tags:          event.Tags,
+		Category:      event.Category,
+		Author:        event.Author,
+		Title:         event.Title,
+		Body:          event.Body,
+		CreatedAt:     event.CreatedAt,
+	}
+}
+
+// NewRevisionFromUpdatedEvent map the article updated event into its revision
+func NewRevisionFromUpdatedEvent(event events.ArticleUpdated) ArticleRevision {
+	return ArticleRevision{
+		ArticleID:     event.ID,
+		Revision:      event.Version,
+		ChangedBy:     event.ChangedBy,
+		ChangedFields: event.ChangedFields,
+		Status:        event.Status,
+		Tags:          event.Tags,
+		Category:      event.Category,
+		Author:        event.Author,
+		Title:         event.Title,
+		Body:          event.Body,
+		CreatedAt:     event.UpdatedAt,
+	}
+}
+
+// NewRevisionFromStatusChangedEvent map the article status changed event into its revision
+func NewRevisionFromStatusChangedEvent(event events.ArticleStatusChanged) ArticleRevision {
+	return ArticleRevision{
+		ArticleID:     event.ID,
+		Revision:      event.Version,
+		ChangedBy:     event.ChangedBy,
+		ChangedFields: event.ChangedFields,
+		Status:        event.Status,
+		Tags:          event.Tags,
+		Category:      event.Category,
+		Author:        event.Author,
+		Title:         event.Title,
+		Body:          event.Body,
+		CreatedAt:     event.UpdatedAt,
+	}
+}
+
+// NewRevisionFromPublishedEvent map the article published event into its revision
+func NewRevisionFromPublishedEvent(event events.ArticlePublished) ArticleRevision {
+	return ArticleRevision{
+		ArticleID:     event.ID,
+		Revision:      event.Version,
+		ChangedBy:     event.ChangedBy,
+		ChangedFields: []string{"status", "published_at"},
+		Status:        events.ArticleStatusPublished,
+		Tags:          event.Tags,
+		Category:      event.Category,
+		Author:        event.Author,
+		Title:         event.Title,
+		Body:          event.Body,
+		CreatedAt:     event.UpdatedAt,
+	}
+}
+
+// NewRevisionFromTagsChangedEvent map the article tags changed event into its revision
+func NewRevisionFromTagsChangedEvent(event events.ArticleTagsChanged) ArticleRevision {
+	return ArticleRevision{
+		ArticleID:     event.ID,
+		Revision:      event.Version,
+		ChangedBy:     event.ChangedBy,
+		ChangedFields: []string{"tags"},
+		Status:        event.Status,
+		Tags:          event.Tags,
+		Category:      event.Category,
+		Author:        event.Author,
+		Title:         event.Title,
+		Body:          event.Body,
+		CreatedAt:     event.UpdatedAt,
+	}
+}
+
+// DiffArticleRevisions fields whose value differs between from and to
+func DiffArticleRevisions(from, to *ArticleRevision) []FieldChange {
+	changes := make([]FieldChange, 0, 6)
+	for _, field := range []struct {
+		name     string
+		from, to string
+	}{
+		{"status", from.Status, to.Status},
+		{"tags", strings.Join(from.Tags, ","), strings.Join(to.Tags, ",")},
+		{"category", from.Category, to.Category},
+		{"author", from.Author, to.Author},
+		{"title", from.Title, to.Title},
+		{"body", from.Body, to.Body},
+	} {
+		if field.from != field.to {
+			changes = append(changes, FieldChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+	return changes
+}
+
+func ArticleRevisionToGrpcMessage(revision *ArticleRevision) *readerService.ArticleRevision {
+	return &readerService.ArticleRevision{
+		ArticleID:     int32(revision.ArticleID),
+		Revision:      int32(revision.Revision),
+		ChangedBy:     revision.ChangedBy,
+		ChangedFields: revision.ChangedFields,
+		Status:        revision.Status,
+		Tags:          revision.Tags,
+		Category:      revision.Category,
+		Author:        revision.Author,
+		Title:         revision.Title,
+		Body:          revision.Body,
+		CreatedAt:     timestamppb.New(revision.CreatedAt),
+	}
+}
+
+func FieldChangesToGrpc(changes []FieldChange) []*readerService.FieldChange {
+	list := make([]*readerService.FieldChange, 0, len(changes))
+	for _, change := range changes {
+		list = append(list, &readerService.FieldChange{Field: change.Field, From: change.From, To: change.To})
+	}
+	return list
+}
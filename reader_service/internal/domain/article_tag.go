@@ -0,0 +1,19 @@
+package domain
+
+import (
+	readerService "github.com/radyatamaa/go-cqrs-microservices/reader_service/proto/article_reader"
+)
+
+// TagCount number of articles tagged with the tag
+type TagCount struct {
+	Name  string `json:"name" bson:"_id"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+func TagCountsToGrpc(tags []*TagCount) *readerService.ListTagsRes {
+	list := make([]*readerService.TagCount, 0, len(tags))
+	for _, tag := range tags {
+		list = append(list, &readerService.TagCount{Name: tag.Name, Count: tag.Count})
+	}
+	return &readerService.ListTagsRes{Tags: list}
+}
@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+	readerService "github.com/radyatamaa/go-cqrs-microservices/reader_service/proto/article_reader"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrStaleCommentVersion the stored comment already is at the version of the event or a newer one
+var ErrStaleCommentVersion = errors.New("stale comment version")
+
+type Comment struct {
+	ID        int       `json:"id" bson:"_id"`
+	TenantID  string    `json:"tenantId" bson:"tenantId"`
+	ArticleID int       `json:"articleId" bson:"articleId"`
+	Version   int       `json:"version" bson:"version"`
+	Status    string    `json:"status" bson:"status"`
+	Author    string    `json:"author" bson:"author"`
+	Body      string    `json:"body" bson:"body"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Visible comment is listed and counted on its article
+func (c *Comment) Visible() bool {
+	return c != nil && c.Status == events.CommentStatusVisible
+}
+
+// CommentsList comments list response with pagination
+type CommentsList struct {
+	TotalCount int64      `json:"totalCount" bson:"totalCount"`
+	TotalPages int64      `json:"totalPages" bson:"totalPages"`
+	Page       int64      `json:"page" bson:"page"`
+	Size       int64      `json:"size" bson:"size"`
+	HasMore    bool       `json:"hasMore" bson:"hasMore"`
+	Comments   []*Comment `json:"comments" bson:"comments"`
+}
+
+// CommentUseCase UseCase Interface
+type CommentUseCase interface {
+	CreateComment(c context.Context, event events.CommentCreated) error
+	EditComment(c context.Context, event events.CommentEdited) error
+	DeleteComment(c context.Context, event events.CommentDeleted) error
+	ModerateComment(c context.Context, event events.CommentModerated) error
+	// ListComments visible comments of the article from the oldest
+	ListComments(c context.Context, articleID int, pagination *utils.Pagination) (*CommentsList, error)
+}
+
+// MongoCommentRepository Repository Interface
+type MongoCommentRepository interface {
+	// UpdateVersion replace the comment unless it already is at comment.Version or newer, ErrStaleCommentVersion then.
+	// Returns the replaced comment, nil when comment is the first version stored
+	UpdateVersion(ctx context.Context, comment Comment) (*Comment, error)
+	// List visible comments of the article from the oldest
+	List(ctx context.Context, articleID int, pagination *utils.Pagination) (*CommentsList, error)
+}
+
+// Mapper
+func NewCommentFromCreatedEvent(event events.CommentCreated) Comment {
+	return Comment{
+		ID:        event.ID,
+		ArticleID: event.ArticleID,
+		Version:   event.Version,
+		Status:    event.Status,
+		Author:    event.Author,
+		Body:      event.Body,
+		CreatedAt: event.CreatedAt,
+		UpdatedAt: event.UpdatedAt,
+	}
+}
+
+func NewCommentFromEditedEvent(event events.CommentEdited) Comment {
+	return Comment{
+		ID:        event.ID,
+		ArticleID: event.ArticleID,
+		Version:   event.Version,
+		Status:    event.Status,
+		Author:    event.Author,
+		Body:      event.Body,
+		CreatedAt: event.CreatedAt,
+		UpdatedAt: event.UpdatedAt,
+	}
+}
+
+func NewCommentFromDeletedEvent(event events.CommentDeleted) Comment {
+	return Comment{
+		ID:        event.ID,
+		ArticleID: event.ArticleID,
+		Version:   event.Version,
+		Status:    event.Status,
+		Author:    event.Author,
+		Body:      event.Body,
+		CreatedAt: event.CreatedAt,
+		UpdatedAt: event.UpdatedAt,
+	}
+}
+
+func NewCommentFromModeratedEvent(event events.CommentModerated) Comment {
+	return Comment{
+		ID:        event.ID,
+		ArticleID: event.ArticleID,
+		Version:   event.Version,
+		Status:    event.Status,
+		Author:    event.Author,
+		Body:      event.Body,
+		CreatedAt: event.CreatedAt,
+		UpdatedAt: event.UpdatedAt,
+	}
+}
+
+func NewCommentListWithPagination(comments []*Comment, count int64, pagination *utils.Pagination) *CommentsList {
+	return &CommentsList{
+		TotalCount: count,
+		TotalPages: int64(pagination.GetTotalPages(int(count))),
+		Page:       int64(pagination.GetPage()),
+		Size:       int64(pagination.GetSize()),
+		HasMore:    pagination.GetHasMore(int(count)),
+		Comments:   comments,
+	}
+}
+
+func CommentToGrpcMessage(comment *Comment) *readerService.Comment {
+	return &readerService.Comment{
+		ID:        int32(comment.ID),
+		ArticleID: int32(comment.ArticleID),
+		Author:    comment.Author,
+		Body:      comment.Body,
+		CreatedAt: timestamppb.New(comment.CreatedAt),
+		UpdatedAt: timestamppb.New(comment.UpdatedAt),
+	}
+}
+
+func CommentListToGrpc(comments *CommentsList) *readerService.ListCommentsRes {
+	list := make([]*readerService.Comment, 0, len(comments.Comments))
+	for _, comment := range comments.Comments {
+		list = append(list, CommentToGrpcMessage(comment))
+	}
+
+	return &readerService.ListCommentsRes{
+		TotalCount: comments.TotalCount,
+		TotalPages: comments.TotalPages,
+		Page:       comments.Page,
+		Size:       comments.Size,
+		HasMore:    comments.HasMore,
+		Comments:   list,
+	}
+}
@@ -10,7 +10,9 @@ import (
 	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	readerGrpc "github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/article/delivery/grpc"
+	commentGrpc "github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/comment/delivery/grpc"
 	readerService "github.com/radyatamaa/go-cqrs-microservices/reader_service/proto/article_reader"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
@@ -42,6 +44,7 @@ func (s *server) newReaderGrpcServer() (func() error, *grpc.Server, error) {
 			grpc_opentracing.UnaryServerInterceptor(),
 			grpc_prometheus.UnaryServerInterceptor,
 			grpc_recovery.UnaryServerInterceptor(),
+			tenant.UnaryServerInterceptor(),
 			s.im.Logger,
 		),
 		),
@@ -49,6 +52,9 @@ func (s *server) newReaderGrpcServer() (func() error, *grpc.Server, error) {
 
 	readerGrpcService := readerGrpc.NewArticleGrpcService(s.articleUsecase, s.cfg, s.zapLog)
 	readerService.RegisterReaderServiceServer(grpcServer, readerGrpcService)
+	commentGrpcService := commentGrpc.NewCommentGrpcService(s.commentUsecase, s.cfg, s.zapLog)
+	readerService.RegisterCommentReaderServiceServer(grpcServer, commentGrpcService)
+	s.health.Register(grpcServer)
 	grpc_prometheus.Register(grpcServer)
 
 	if s.cfg.GRPC.Development {
@@ -57,7 +63,10 @@ func (s *server) newReaderGrpcServer() (func() error, *grpc.Server, error) {
 
 	go func() {
 		s.zapLog.Infof("Reader gRPC server is listening on port: %s", s.cfg.GRPC.Port)
-		s.zapLog.Fatal(grpcServer.Serve(l))
+		// Serve returns nil once the server is stopped while draining
+		if err := grpcServer.Serve(l); err != nil {
+			s.zapLog.Fatal(err)
+		}
 	}()
 
 	return l.Close, grpcServer, nil
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/mongodb"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/mongodb/migration"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/migrations"
+)
+
+// MongoMigrate bootstrap the collections, their indexes and validators, then apply the pending data migrations
+func (s *server) MongoMigrate() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	if err := s.connectMongo(ctx); err != nil {
+		return err
+	}
+	defer s.mongoClient.Disconnect(context.Background()) // nolint: errcheck
+
+	return s.migrateMongo(ctx)
+}
+
+// MongoMigrateStatus write the state of every data migration to w
+func (s *server) MongoMigrateStatus(w io.Writer) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	if err := s.connectMongo(ctx); err != nil {
+		return err
+	}
+	defer s.mongoClient.Disconnect(context.Background()) // nolint: errcheck
+
+	migrator, err := s.newMongoMigrator()
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return errors.Wrap(err, "migrator.Status")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, st := range statuses {
+		state, appliedAt := "pending", ""
+		if st.Applied {
+			state, appliedAt = "applied", st.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\t%s\n", st.Version, st.Name, state, appliedAt)
+	}
+
+	return tw.Flush()
+}
+
+func (s *server) connectMongo(ctx context.Context) error {
+	mongoDBConn, err := mongodb.NewMongoDBConn(ctx, s.cfg.Mongo)
+	if err != nil {
+		return errors.Wrap(err, "NewMongoDBConn")
+	}
+	s.mongoClient = mongoDBConn
+	return nil
+}
+
+func (s *server) migrateMongo(ctx context.Context) error {
+	migrator, err := s.newMongoMigrator()
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Run(ctx)
+	for _, m := range applied {
+		s.zapLog.Infof("applied mongo data migration %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return errors.Wrap(err, "migrator.Run")
+	}
+
+	return nil
+}
+
+func (s *server) newMongoMigrator() (*migration.Migrator, error) {
+	return migration.New(
+		s.mongoClient.Database(s.cfg.Mongo.Db),
+		s.cfg.MongoCollections.Migrations,
+		migrations.Collections(s.cfg),
+		migrations.DataMigrations(s.cfg),
+	)
+}
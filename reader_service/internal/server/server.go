@@ -5,22 +5,27 @@ import (
 
 	"github.com/go-redis/redis/v8"
 	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/health"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/interceptors"
 	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
-	"github.com/radyatamaa/go-cqrs-microservices/pkg/mongodb"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
 	articleConsumerHandler "github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/article/delivery/kafka"
+	commentConsumerHandler "github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/comment/delivery/kafka"
 	"github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/domain"
+	readerService "github.com/radyatamaa/go-cqrs-microservices/reader_service/proto/article_reader"
 
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	redisClient "github.com/radyatamaa/go-cqrs-microservices/pkg/redis"
 	articleRepository "github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/article/repository"
 	articlUsecase "github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/article/usecase"
+	commentRepository "github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/comment/repository"
+	commentUsecase "github.com/radyatamaa/go-cqrs-microservices/reader_service/internal/comment/usecase"
 	"github.com/segmentio/kafka-go"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -34,6 +39,8 @@ type server struct {
 	redisClient    redis.UniversalClient
 	im             interceptors.InterceptorManager
 	articleUsecase domain.ArticleUseCase
+	commentUsecase domain.CommentUseCase
+	health         *health.Health
 }
 
 func NewServer(cfg *config.Config, zapLog zaplogger.Logger) *server {
@@ -47,13 +54,17 @@ func (s *server) Run() error {
 	s.im = interceptors.NewInterceptorManager(s.zapLog)
 
 	// database initialization
-	mongoDBConn, err := mongodb.NewMongoDBConn(ctx, s.cfg.Mongo)
-	if err != nil {
-		return errors.Wrap(err, "NewMongoDBConn")
+	if err := s.connectMongo(ctx); err != nil {
+		return err
+	}
+	defer s.mongoClient.Disconnect(context.Background()) // nolint: errcheck
+	s.zapLog.Infof("Mongo connected: %v", s.mongoClient.NumberSessionsInProgress())
+
+	if s.cfg.MongoMigration.MigrateOnStart {
+		if err := s.migrateMongo(ctx); err != nil {
+			return errors.Wrap(err, "s.migrateMongo")
+		}
 	}
-	s.mongoClient = mongoDBConn
-	defer mongoDBConn.Disconnect(ctx) // nolint: errcheck
-	s.zapLog.Infof("Mongo connected: %v", mongoDBConn.NumberSessionsInProgress())
 
 	// cache initialization
 	s.redisClient = redisClient.NewUniversalRedisClient(s.cfg.Redis)
@@ -65,22 +76,65 @@ func (s *server) Run() error {
 
 	timeoutContext := time.Duration(s.cfg.App.ExecutionTimeout) * time.Second
 
-	mongoArticleRepo := articleRepository.NewMongoArticleRepository(s.zapLog, s.cfg, s.mongoClient)
-	redisArticleRepo := articleRepository.NewRedisRepository(s.zapLog, s.cfg, s.redisClient)
+	mongoArticleRepo := articleRepository.NewResilientMongoRepository(
+		articleRepository.NewMongoArticleRepository(s.zapLog, s.cfg, s.mongoClient), s.cfg.Resilience.Mongo.Config())
+	articleCache, err := s.newArticleCache()
+	if err != nil {
+		return errors.Wrap(err, "s.newArticleCache")
+	}
+	go func() {
+		if err := articleCache.Subscribe(ctx); err != nil {
+			s.zapLog.WarnMsg("articleCache.Subscribe", err)
+		}
+	}()
+
+	redisArticleRepo := articleRepository.NewResilientRedisRepository(
+		articleRepository.NewRedisRepository(s.zapLog, s.cfg, s.redisClient, articleCache), s.cfg.Resilience.Redis.Config())
 
 	s.articleUsecase = articlUsecase.NewArticleUseCase(timeoutContext, mongoArticleRepo, redisArticleRepo, s.zapLog)
 
-	kafkaArticleConsumerHandler := articleConsumerHandler.NewArticleConsumer(s.articleUsecase, s.cfg, s.zapLog)
+	deadLetter := kafkaClient.NewDeadLetter(kafkaProducer, s.cfg.KafkaTopics.DeadLetter.TopicName)
+	kafkaArticleConsumerHandler := articleConsumerHandler.NewArticleConsumer(s.articleUsecase, s.cfg, deadLetter, s.zapLog)
+
+	mongoCommentRepo := commentRepository.NewResilientMongoRepository(
+		commentRepository.NewMongoCommentRepository(s.zapLog, s.cfg, s.mongoClient), s.cfg.Resilience.Mongo.Config())
+
+	s.commentUsecase = commentUsecase.NewCommentUseCase(timeoutContext, mongoCommentRepo, mongoArticleRepo, redisArticleRepo, s.zapLog)
+
+	kafkaCommentConsumerHandler := commentConsumerHandler.NewCommentConsumer(s.commentUsecase, s.cfg, s.zapLog)
+
+	// consumers outlive the signal context, they are stopped while draining
+	consumerCtx, stopConsumers := context.WithCancel(context.Background())
+	defer stopConsumers()
+	consumersDone := make(chan struct{})
 
 	s.zapLog.Infof("Starting Reader Kafka consumers")
 	consumerGroup := kafkaClient.NewConsumerGroup(s.cfg.Kafka.Brokers, s.cfg.Kafka.GroupID, s.zapLog)
-	go consumerGroup.ConsumeTopic(ctx, s.getConsumerGroupTopics(), articleConsumerHandler.PoolSize, kafkaArticleConsumerHandler.ProcessMessages)
+	// every aggregate has its own consumer group so a slow aggregate does not hold the others back
+	commentConsumerGroup := kafkaClient.NewConsumerGroup(s.cfg.Kafka.Brokers, s.cfg.Kafka.GroupID+commentGroupIDSuffix, s.zapLog)
+	consumers := new(sync.WaitGroup)
+	consumers.Add(2)
+	go func() {
+		defer consumers.Done()
+		size, timeout := s.cfg.Kafka.Batch()
+		consumerGroup.ConsumeBatches(consumerCtx, s.getConsumerGroupTopics(), articleConsumerHandler.PoolSize, size, timeout, kafkaArticleConsumerHandler.ProcessBatch)
+	}()
+	go func() {
+		defer consumers.Done()
+		commentConsumerGroup.ConsumeTopic(consumerCtx, s.getCommentConsumerGroupTopics(), commentConsumerHandler.PoolSize, kafkaCommentConsumerHandler.ProcessMessages)
+	}()
+	go func() {
+		consumers.Wait()
+		close(consumersDone)
+	}()
 
 	if err := s.connectKafkaBrokers(ctx); err != nil {
 		return errors.Wrap(err, "s.connectKafkaBrokers")
 	}
 	defer s.kafkaConn.Close() // nolint: errcheck
 
+	s.health = health.New(time.Duration(s.cfg.App.CheckIntervalSeconds)*time.Second, readerService.ReaderService_ServiceDesc.ServiceName, readerService.CommentReaderService_ServiceDesc.ServiceName)
+
 	closeGrpcServer, grpcServer, err := s.newReaderGrpcServer()
 	if err != nil {
 		return errors.Wrap(err, "NewScmGrpcServer")
@@ -90,7 +144,7 @@ func (s *server) Run() error {
 	s.runHealthCheck(ctx)
 
 	<-ctx.Done()
-	grpcServer.GracefulStop()
+	s.drain(grpcServer, stopConsumers, consumersDone)
 
 	return nil
 }
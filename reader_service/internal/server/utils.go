@@ -9,14 +9,52 @@ import (
 
 	"github.com/heptiolabs/healthcheck"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/cache"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/health"
 	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/resilience"
 	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc"
 )
 
 const (
 	stackSize = 1 << 10 // 1 KB
+
+	defaultShutdownTimeout = 30 * time.Second
+
+	articleCacheName           = "reader_articles"
+	defaultLocalCacheSize      = 10000
+	defaultLocalCacheTTL       = 10 * time.Second
+	defaultInvalidationChannel = "reader:cache:invalidate"
+
+	// commentGroupIDSuffix suffix of the kafka group id consuming the comment events
+	commentGroupIDSuffix = "_comments"
 )
 
+// newArticleCache in-process lru in front of redis, the local copies are invalidated over redis pub/sub
+func (s *server) newArticleCache() (*cache.TwoLevel, error) {
+	size := s.cfg.Cache.LocalSize
+	if size <= 0 {
+		size = defaultLocalCacheSize
+	}
+	localTTL := time.Duration(s.cfg.Cache.LocalTTLSeconds) * time.Second
+	if localTTL <= 0 {
+		localTTL = defaultLocalCacheTTL
+	}
+	channel := s.cfg.Cache.InvalidationChannel
+	if channel == "" {
+		channel = defaultInvalidationChannel
+	}
+
+	local, err := cache.NewLocal(size)
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.NewTwoLevel(articleCacheName, local, cache.NewRedis(s.redisClient), cache.NewRedisInvalidator(s.redisClient, channel), localTTL), nil
+}
+
 func (s *server) connectKafkaBrokers(ctx context.Context) error {
 	kafkaConn, err := kafkaClient.NewKafkaConn(ctx, s.cfg.Kafka)
 	if err != nil {
@@ -66,50 +104,177 @@ func (s *server) initKafkaTopics(ctx context.Context) {
 		ReplicationFactor: s.cfg.KafkaTopics.ArticleCreated.ReplicationFactor,
 	}
 
+	articleUpdateTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleUpdate.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleUpdate.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleUpdate.ReplicationFactor,
+	}
+
+	articleUpdatedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleUpdated.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleUpdated.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleUpdated.ReplicationFactor,
+	}
+
+	articleTransitionTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleTransition.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleTransition.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleTransition.ReplicationFactor,
+	}
+
+	articleStatusChangedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleStatusChanged.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleStatusChanged.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleStatusChanged.ReplicationFactor,
+	}
+
+	articlePublishedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticlePublished.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticlePublished.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticlePublished.ReplicationFactor,
+	}
+
+	articleTagTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleTag.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleTag.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleTag.ReplicationFactor,
+	}
+
+	articleTagsChangedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleTagsChanged.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleTagsChanged.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleTagsChanged.ReplicationFactor,
+	}
+
+	commentCreatedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentCreated.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentCreated.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentCreated.ReplicationFactor,
+	}
+
+	commentEditedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentEdited.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentEdited.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentEdited.ReplicationFactor,
+	}
+
+	commentDeletedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentDeleted.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentDeleted.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentDeleted.ReplicationFactor,
+	}
+
+	commentModeratedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentModerated.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentModerated.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentModerated.ReplicationFactor,
+	}
+
+	deadLetterTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.DeadLetter.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.DeadLetter.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.DeadLetter.ReplicationFactor,
+	}
+
 	if err := conn.CreateTopics(
 		articleCreateTopic,
 		articleCreatedTopic,
+		articleUpdateTopic,
+		articleUpdatedTopic,
+		articleTransitionTopic,
+		articleStatusChangedTopic,
+		articlePublishedTopic,
+		articleTagTopic,
+		articleTagsChangedTopic,
+		commentCreatedTopic,
+		commentEditedTopic,
+		commentDeletedTopic,
+		commentModeratedTopic,
+		deadLetterTopic,
 	); err != nil {
 		s.zapLog.WarnMsg("kafkaConn.CreateTopics", err)
 		return
 	}
 
-	s.zapLog.Infof("kafka topics created or already exists: %+v", []kafka.TopicConfig{articleCreateTopic, articleCreatedTopic})
+	s.zapLog.Infof("kafka topics created or already exists: %+v", []kafka.TopicConfig{articleCreateTopic, articleCreatedTopic, articleUpdateTopic, articleUpdatedTopic, articleTransitionTopic, articleStatusChangedTopic, articlePublishedTopic, articleTagTopic, articleTagsChangedTopic, commentCreatedTopic, commentEditedTopic, commentDeletedTopic, commentModeratedTopic, deadLetterTopic})
 }
 
 func (s *server) runHealthCheck(ctx context.Context) {
-	health := healthcheck.NewHandler()
-
-	health.AddLivenessCheck(s.cfg.App.ServiceName, healthcheck.AsyncWithContext(ctx, func() error {
+	s.health.AddLivenessCheck(s.cfg.App.ServiceName, healthcheck.AsyncWithContext(ctx, func() error {
 		return nil
 	}, time.Duration(s.cfg.App.CheckIntervalSeconds)*time.Second))
 
-	health.AddReadinessCheck("redis", healthcheck.AsyncWithContext(ctx, func() error {
+	s.health.AddReadinessCheck("redis", func(ctx context.Context) error {
 		return s.redisClient.Ping(ctx).Err()
-	}, time.Duration(s.cfg.App.CheckIntervalSeconds)*time.Second))
+	})
 
-	health.AddReadinessCheck("mongo", healthcheck.AsyncWithContext(ctx, func() error {
+	s.health.AddReadinessCheck("mongo", func(ctx context.Context) error {
 		return s.mongoClient.Ping(ctx, nil)
-	}, time.Duration(s.cfg.App.CheckIntervalSeconds)*time.Second))
+	})
 
-	health.AddReadinessCheck("kafka", healthcheck.AsyncWithContext(ctx, func() error {
+	s.health.AddReadinessCheck("kafka", func(ctx context.Context) error {
 		_, err := s.kafkaConn.Brokers()
-		if err != nil {
-			return err
-		}
-		return nil
-	}, time.Duration(s.cfg.App.CheckIntervalSeconds)*time.Second))
+		return err
+	})
+
+	s.health.AddReadinessCheck("circuit_breakers", func(ctx context.Context) error {
+		return resilience.HealthCheck()()
+	})
+
+	go s.health.Run(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/", s.health.Handler())
 
 	go func() {
-		s.zapLog.Infof("Reader microservice Kubernetes probes listening on port: %s", s.cfg.App.Port)
-		if err := http.ListenAndServe(s.cfg.App.Port, health); err != nil {
+		s.zapLog.Infof("Reader microservice Kubernetes probes and metrics listening on port: %s", s.cfg.App.Port)
+		if err := http.ListenAndServe(s.cfg.App.Port, mux); err != nil {
 			s.zapLog.WarnMsg("ListenAndServe", err)
 		}
 	}()
 }
 
+// drain report not ready, give the load balancers the drain delay to stop routing,
+// then stop consuming and finish the in-flight messages and grpc calls before the connections are closed
+func (s *server) drain(grpcServer *grpc.Server, stopConsumers context.CancelFunc, consumersDone <-chan struct{}) {
+	timeout := time.Duration(s.cfg.App.ShutdownTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	s.zapLog.Infof("Reader microservice draining")
+	s.health.Drain()
+	time.Sleep(time.Duration(s.cfg.App.DrainDelaySeconds) * time.Second)
+
+	stopConsumers()
+	select {
+	case <-consumersDone:
+	case <-time.After(timeout):
+		s.zapLog.Warnf("kafka consumers still running after %s", timeout)
+	}
+
+	if !health.StopGrpcServer(grpcServer, timeout) {
+		s.zapLog.Warnf("grpc calls still running after %s, connections closed", timeout)
+	}
+	s.zapLog.Infof("Reader microservice drained")
+}
+
 func (s *server) getConsumerGroupTopics() []string {
 	return []string{
 		s.cfg.KafkaTopics.ArticleCreated.TopicName,
+		s.cfg.KafkaTopics.ArticleUpdated.TopicName,
+		s.cfg.KafkaTopics.ArticleStatusChanged.TopicName,
+		s.cfg.KafkaTopics.ArticlePublished.TopicName,
+		s.cfg.KafkaTopics.ArticleTagsChanged.TopicName,
+	}
+}
+
+func (s *server) getCommentConsumerGroupTopics() []string {
+	return []string{
+		s.cfg.KafkaTopics.CommentCreated.TopicName,
+		s.cfg.KafkaTopics.CommentEdited.TopicName,
+		s.cfg.KafkaTopics.CommentDeleted.TopicName,
+		s.cfg.KafkaTopics.CommentModerated.TopicName,
 	}
 }
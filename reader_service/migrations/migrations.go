@@ -0,0 +1,305 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/mongodb/migration"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/reader_service/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexNotFound error code of the mongo server dropping a missing index
+const indexNotFound = 27
+
+// Collections definition of the reader collections, their indexes and validators
+func Collections(cfg *config.Config) []migration.Collection {
+	return []migration.Collection{
+		{
+			Name: cfg.MongoCollections.Articles,
+			// every search is restricted to a tenant, the indexes lead with it
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "createdAt", Value: -1}},
+					Options: options.Index().SetName("tenantId_1_createdAt_-1"),
+				},
+				{
+					Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "author", Value: 1}, {Key: "createdAt", Value: -1}},
+					Options: options.Index().SetName("tenantId_1_author_1_createdAt_-1"),
+				},
+				{
+					// searches only return the articles of the requested statuses
+					Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: -1}},
+					Options: options.Index().SetName("tenantId_1_status_1_createdAt_-1"),
+				},
+				{
+					Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "tags", Value: 1}, {Key: "createdAt", Value: -1}},
+					Options: options.Index().SetName("tenantId_1_tags_1_createdAt_-1"),
+				},
+				{
+					// categoryPath holds the ancestors of the category so a category matches its subcategories
+					Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "categoryPath", Value: 1}, {Key: "createdAt", Value: -1}},
+					Options: options.Index().SetName("tenantId_1_categoryPath_1_createdAt_-1"),
+				},
+			},
+			Validator: bson.M{
+				"bsonType": "object",
+				// author, title and body are omitted when empty
+				// tenantId is set by every write, the earlier data migrations run before it is backfilled
+				"required": bson.A{"_id", "version"},
+				"properties": bson.M{
+					"_id":          bson.M{"bsonType": bson.A{"int", "long"}},
+					"tenantId":     bson.M{"bsonType": "string"},
+					"version":      bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1},
+					"status":       bson.M{"enum": bson.A{events.ArticleStatusDraft, events.ArticleStatusInReview, events.ArticleStatusPublished, events.ArticleStatusArchived}},
+					"publishAt":    bson.M{"bsonType": bson.A{"date", "null"}},
+					"publishedAt":  bson.M{"bsonType": bson.A{"date", "null"}},
+					"tags":         bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+					"category":     bson.M{"bsonType": "string"},
+					"categoryPath": bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+					"commentCount": bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+					"author":       bson.M{"bsonType": "string"},
+					"title":        bson.M{"bsonType": "string"},
+					"body":         bson.M{"bsonType": "string"},
+					"createdAt":    bson.M{"bsonType": "date"},
+					"updatedAt":    bson.M{"bsonType": "date"},
+				},
+			},
+			ValidationAction: cfg.MongoMigration.ValidationAction,
+		},
+		{
+			Name: cfg.MongoCollections.ArticleRevisions,
+			Indexes: []mongo.IndexModel{
+				{
+					// a redelivered event does not duplicate the revision
+					Keys:    bson.D{{Key: "articleId", Value: 1}, {Key: "revision", Value: 1}},
+					Options: options.Index().SetName("articleId_1_revision_1").SetUnique(true),
+				},
+			},
+			Validator: bson.M{
+				"bsonType": "object",
+				"required": bson.A{"articleId", "revision", "changedFields", "createdAt"},
+				"properties": bson.M{
+					"articleId":     bson.M{"bsonType": bson.A{"int", "long"}},
+					"tenantId":      bson.M{"bsonType": "string"},
+					"revision":      bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1},
+					"changedBy":     bson.M{"bsonType": "string"},
+					"changedFields": bson.M{"bsonType": "array", "items": bson.M{"bsonType": "string"}},
+					"status":        bson.M{"bsonType": "string"},
+					"tags":          bson.M{"bsonType": "array", "items": bson.M{"bsonType": "string"}},
+					"category":      bson.M{"bsonType": "string"},
+					"author":        bson.M{"bsonType": "string"},
+					"title":         bson.M{"bsonType": "string"},
+					"body":          bson.M{"bsonType": "string"},
+					"createdAt":     bson.M{"bsonType": "date"},
+				},
+			},
+			ValidationAction: cfg.MongoMigration.ValidationAction,
+		},
+		{
+			Name: cfg.MongoCollections.Tags,
+			Indexes: []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "_id.tenantId", Value: 1}, {Key: "count", Value: -1}},
+					Options: options.Index().SetName("_id.tenantId_1_count_-1"),
+				},
+			},
+			Validator: bson.M{
+				"bsonType": "object",
+				"required": bson.A{"_id", "count"},
+				"properties": bson.M{
+					// the tags are counted per tenant, _id is the tenant and the name of the tag
+					"_id": bson.M{
+						"bsonType": "object",
+						"required": bson.A{"tenantId", "name"},
+						"properties": bson.M{
+							"tenantId": bson.M{"bsonType": "string"},
+							"name":     bson.M{"bsonType": "string"},
+						},
+					},
+					"count": bson.M{"bsonType": bson.A{"int", "long"}},
+				},
+			},
+			ValidationAction: cfg.MongoMigration.ValidationAction,
+		},
+		{
+			Name: cfg.MongoCollections.Comments,
+			Indexes: []mongo.IndexModel{
+				{
+					// lists and counts the visible comments of an article from the oldest
+					Keys:    bson.D{{Key: "articleId", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+					Options: options.Index().SetName("articleId_1_status_1_createdAt_1"),
+				},
+			},
+			Validator: bson.M{
+				"bsonType": "object",
+				"required": bson.A{"_id", "articleId", "version", "status"},
+				"properties": bson.M{
+					"_id":       bson.M{"bsonType": bson.A{"int", "long"}},
+					"tenantId":  bson.M{"bsonType": "string"},
+					"articleId": bson.M{"bsonType": bson.A{"int", "long"}},
+					"version":   bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1},
+					"status":    bson.M{"enum": bson.A{events.CommentStatusVisible, events.CommentStatusHidden, events.CommentStatusDeleted}},
+					"author":    bson.M{"bsonType": "string"},
+					"body":      bson.M{"bsonType": "string"},
+					"createdAt": bson.M{"bsonType": "date"},
+					"updatedAt": bson.M{"bsonType": "date"},
+				},
+			},
+			ValidationAction: cfg.MongoMigration.ValidationAction,
+		},
+	}
+}
+
+// DataMigrations versioned changes of the reader documents, append new ones with the next version
+func DataMigrations(cfg *config.Config) []migration.DataMigration {
+	return []migration.DataMigration{
+		{
+			Version: 1,
+			Name:    "backfill_article_version",
+			// articles projected before events were versioned have no version field
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(cfg.MongoCollections.Articles).UpdateMany(ctx,
+					bson.M{"version": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"version": 1}})
+				return err
+			},
+		},
+		{
+			Version: 2,
+			Name:    "backfill_article_revisions",
+			// articles projected before revisions were recorded start their history at their current version
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				cursor, err := db.Collection(cfg.MongoCollections.Articles).Aggregate(ctx, mongo.Pipeline{
+					{{Key: "$project", Value: bson.M{
+						"_id":           0,
+						"articleId":     "$_id",
+						"revision":      "$version",
+						"changedBy":     "$author",
+						"changedFields": bson.A{"author", "title", "body"},
+						"author":        "$author",
+						"title":         "$title",
+						"body":          "$body",
+						"createdAt":     bson.M{"$ifNull": bson.A{"$updatedAt", "$$NOW"}},
+					}}},
+					{{Key: "$merge", Value: bson.M{
+						"into":           cfg.MongoCollections.ArticleRevisions,
+						"on":             bson.A{"articleId", "revision"},
+						"whenMatched":    "keepExisting",
+						"whenNotMatched": "insert",
+					}}},
+				})
+				if err != nil {
+					return err
+				}
+				return cursor.Close(ctx)
+			},
+		},
+		{
+			Version: 3,
+			Name:    "backfill_article_status",
+			// articles projected before the publishing workflow were all public
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				update := bson.M{"$set": bson.M{"status": events.ArticleStatusPublished}}
+				for _, collection := range []string{cfg.MongoCollections.Articles, cfg.MongoCollections.ArticleRevisions} {
+					if _, err := db.Collection(collection).UpdateMany(ctx, bson.M{"status": bson.M{"$exists": false}}, update); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Version: 4,
+			Name:    "rebuild_tag_counts",
+			// articles projected before tagging have no taxonomy, the tag counts are then computed from the articles
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				articles := db.Collection(cfg.MongoCollections.Articles)
+				if _, err := articles.UpdateMany(ctx,
+					bson.M{"tags": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"tags": bson.A{}, "category": "", "categoryPath": bson.A{}}}); err != nil {
+					return err
+				}
+				// the counts keyed by name only no longer match the tags validator, they are replaced by scope_documents_by_tenant
+				if _, err := db.Collection(cfg.MongoCollections.Tags).UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"count": 0}},
+					options.Update().SetBypassDocumentValidation(true)); err != nil {
+					return err
+				}
+
+				cursor, err := articles.Aggregate(ctx, mongo.Pipeline{
+					{{Key: "$unwind", Value: "$tags"}},
+					{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+					{{Key: "$merge", Value: bson.M{
+						"into":           cfg.MongoCollections.Tags,
+						"whenMatched":    "replace",
+						"whenNotMatched": "insert",
+					}}},
+				}, options.Aggregate().SetBypassDocumentValidation(true))
+				if err != nil {
+					return err
+				}
+				return cursor.Close(ctx)
+			},
+		},
+		{
+			Version: 5,
+			Name:    "scope_documents_by_tenant",
+			// documents projected before the multi tenancy belong to the default tenant,
+			// the tag counts keyed by name only are counted again per tenant
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				backfill := bson.M{"$set": bson.M{"tenantId": tenant.DefaultID}}
+				for _, collection := range []string{cfg.MongoCollections.Articles, cfg.MongoCollections.ArticleRevisions, cfg.MongoCollections.Comments} {
+					if _, err := db.Collection(collection).UpdateMany(ctx, bson.M{"tenantId": bson.M{"$exists": false}}, backfill); err != nil {
+						return err
+					}
+				}
+				if err := dropIndexes(ctx, db.Collection(cfg.MongoCollections.Articles),
+					"createdAt_-1", "author_1_createdAt_-1", "status_1_createdAt_-1", "tags_1_createdAt_-1", "categoryPath_1_createdAt_-1"); err != nil {
+					return err
+				}
+
+				tags := db.Collection(cfg.MongoCollections.Tags)
+				if _, err := tags.DeleteMany(ctx, bson.M{}); err != nil {
+					return err
+				}
+				if err := dropIndexes(ctx, tags, "count_-1"); err != nil {
+					return err
+				}
+				cursor, err := db.Collection(cfg.MongoCollections.Articles).Aggregate(ctx, mongo.Pipeline{
+					{{Key: "$unwind", Value: "$tags"}},
+					{{Key: "$group", Value: bson.M{
+						"_id":   bson.D{{Key: "tenantId", Value: "$tenantId"}, {Key: "name", Value: "$tags"}},
+						"count": bson.M{"$sum": 1},
+					}}},
+					{{Key: "$merge", Value: bson.M{
+						"into":           cfg.MongoCollections.Tags,
+						"whenMatched":    "replace",
+						"whenNotMatched": "insert",
+					}}},
+				})
+				if err != nil {
+					return err
+				}
+				return cursor.Close(ctx)
+			},
+		},
+	}
+}
+
+// dropIndexes drop the indexes superseded by a new definition, the missing ones are skipped
+func dropIndexes(ctx context.Context, collection *mongo.Collection, names ...string) error {
+	for _, name := range names {
+		if _, err := collection.Indexes().DropOne(ctx, name); err != nil {
+			var cmdErr mongo.CommandError
+			if errors.As(err, &cmdErr) && cmdErr.Code == indexNotFound {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -23,6 +23,11 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ReaderServiceClient interface {
 	SearchArticle(ctx context.Context, in *SearchReq, opts ...grpc.CallOption) (*SearchRes, error)
+	GetArticleById(ctx context.Context, in *GetArticleByIdReq, opts ...grpc.CallOption) (*GetArticleByIdRes, error)
+	GetArticleRevisions(ctx context.Context, in *GetArticleRevisionsReq, opts ...grpc.CallOption) (*GetArticleRevisionsRes, error)
+	GetArticleRevision(ctx context.Context, in *GetArticleRevisionReq, opts ...grpc.CallOption) (*GetArticleRevisionRes, error)
+	DiffArticleRevisions(ctx context.Context, in *DiffArticleRevisionsReq, opts ...grpc.CallOption) (*DiffArticleRevisionsRes, error)
+	ListTags(ctx context.Context, in *ListTagsReq, opts ...grpc.CallOption) (*ListTagsRes, error)
 }
 
 type readerServiceClient struct {
@@ -42,11 +47,61 @@ func (c *readerServiceClient) SearchArticle(ctx context.Context, in *SearchReq,
 	return out, nil
 }
 
+func (c *readerServiceClient) GetArticleById(ctx context.Context, in *GetArticleByIdReq, opts ...grpc.CallOption) (*GetArticleByIdRes, error) {
+	out := new(GetArticleByIdRes)
+	err := c.cc.Invoke(ctx, "/readerService.readerService/GetArticleById", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readerServiceClient) GetArticleRevisions(ctx context.Context, in *GetArticleRevisionsReq, opts ...grpc.CallOption) (*GetArticleRevisionsRes, error) {
+	out := new(GetArticleRevisionsRes)
+	err := c.cc.Invoke(ctx, "/readerService.readerService/GetArticleRevisions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readerServiceClient) GetArticleRevision(ctx context.Context, in *GetArticleRevisionReq, opts ...grpc.CallOption) (*GetArticleRevisionRes, error) {
+	out := new(GetArticleRevisionRes)
+	err := c.cc.Invoke(ctx, "/readerService.readerService/GetArticleRevision", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readerServiceClient) DiffArticleRevisions(ctx context.Context, in *DiffArticleRevisionsReq, opts ...grpc.CallOption) (*DiffArticleRevisionsRes, error) {
+	out := new(DiffArticleRevisionsRes)
+	err := c.cc.Invoke(ctx, "/readerService.readerService/DiffArticleRevisions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *readerServiceClient) ListTags(ctx context.Context, in *ListTagsReq, opts ...grpc.CallOption) (*ListTagsRes, error) {
+	out := new(ListTagsRes)
+	err := c.cc.Invoke(ctx, "/readerService.readerService/ListTags", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ReaderServiceServer is the server API for ReaderService service.
 // All implementations must embed UnimplementedReaderServiceServer
 // for forward compatibility
 type ReaderServiceServer interface {
 	SearchArticle(context.Context, *SearchReq) (*SearchRes, error)
+	GetArticleById(context.Context, *GetArticleByIdReq) (*GetArticleByIdRes, error)
+	GetArticleRevisions(context.Context, *GetArticleRevisionsReq) (*GetArticleRevisionsRes, error)
+	GetArticleRevision(context.Context, *GetArticleRevisionReq) (*GetArticleRevisionRes, error)
+	DiffArticleRevisions(context.Context, *DiffArticleRevisionsReq) (*DiffArticleRevisionsRes, error)
+	ListTags(context.Context, *ListTagsReq) (*ListTagsRes, error)
 }
 
 // UnimplementedReaderServiceServer must be embedded to have forward compatible implementations.
@@ -56,13 +111,27 @@ type UnimplementedReaderServiceServer struct {
 func (UnimplementedReaderServiceServer) SearchArticle(context.Context, *SearchReq) (*SearchRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SearchArticle not implemented")
 }
+func (UnimplementedReaderServiceServer) GetArticleById(context.Context, *GetArticleByIdReq) (*GetArticleByIdRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArticleById not implemented")
+}
+func (UnimplementedReaderServiceServer) GetArticleRevisions(context.Context, *GetArticleRevisionsReq) (*GetArticleRevisionsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArticleRevisions not implemented")
+}
+func (UnimplementedReaderServiceServer) GetArticleRevision(context.Context, *GetArticleRevisionReq) (*GetArticleRevisionRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArticleRevision not implemented")
+}
+func (UnimplementedReaderServiceServer) DiffArticleRevisions(context.Context, *DiffArticleRevisionsReq) (*DiffArticleRevisionsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiffArticleRevisions not implemented")
+}
+func (UnimplementedReaderServiceServer) ListTags(context.Context, *ListTagsReq) (*ListTagsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTags not implemented")
+}
 func (UnimplementedReaderServiceServer) mustEmbedUnimplementedReaderServiceServer() {}
 
 // UnsafeReaderServiceServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to ReaderServiceServer will
 // result in compilation errors.
 type UnsafeReaderServiceServer interface {
-	mustEmbedUnimplementedReaderServiceServer()
 }
 
 func RegisterReaderServiceServer(s grpc.ServiceRegistrar, srv ReaderServiceServer) {
@@ -87,6 +156,96 @@ func _ReaderService_SearchArticle_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ReaderService_GetArticleById_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArticleByIdReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReaderServiceServer).GetArticleById(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/readerService.readerService/GetArticleById",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReaderServiceServer).GetArticleById(ctx, req.(*GetArticleByIdReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReaderService_GetArticleRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArticleRevisionsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReaderServiceServer).GetArticleRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/readerService.readerService/GetArticleRevisions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReaderServiceServer).GetArticleRevisions(ctx, req.(*GetArticleRevisionsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReaderService_GetArticleRevision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetArticleRevisionReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReaderServiceServer).GetArticleRevision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/readerService.readerService/GetArticleRevision",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReaderServiceServer).GetArticleRevision(ctx, req.(*GetArticleRevisionReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReaderService_DiffArticleRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiffArticleRevisionsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReaderServiceServer).DiffArticleRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/readerService.readerService/DiffArticleRevisions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReaderServiceServer).DiffArticleRevisions(ctx, req.(*DiffArticleRevisionsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReaderService_ListTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTagsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReaderServiceServer).ListTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/readerService.readerService/ListTags",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReaderServiceServer).ListTags(ctx, req.(*ListTagsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ReaderService_ServiceDesc is the grpc.ServiceDesc for ReaderService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -98,6 +257,110 @@ var ReaderService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SearchArticle",
 			Handler:    _ReaderService_SearchArticle_Handler,
 		},
+		{
+			MethodName: "GetArticleById",
+			Handler:    _ReaderService_GetArticleById_Handler,
+		},
+		{
+			MethodName: "GetArticleRevisions",
+			Handler:    _ReaderService_GetArticleRevisions_Handler,
+		},
+		{
+			MethodName: "GetArticleRevision",
+			Handler:    _ReaderService_GetArticleRevision_Handler,
+		},
+		{
+			MethodName: "DiffArticleRevisions",
+			Handler:    _ReaderService_DiffArticleRevisions_Handler,
+		},
+		{
+			MethodName: "ListTags",
+			Handler:    _ReaderService_ListTags_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "article_reader.proto",
+}
+
+// CommentReaderServiceClient is the client API for CommentReaderService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CommentReaderServiceClient interface {
+	ListComments(ctx context.Context, in *ListCommentsReq, opts ...grpc.CallOption) (*ListCommentsRes, error)
+}
+
+type commentReaderServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCommentReaderServiceClient(cc grpc.ClientConnInterface) CommentReaderServiceClient {
+	return &commentReaderServiceClient{cc}
+}
+
+func (c *commentReaderServiceClient) ListComments(ctx context.Context, in *ListCommentsReq, opts ...grpc.CallOption) (*ListCommentsRes, error) {
+	out := new(ListCommentsRes)
+	err := c.cc.Invoke(ctx, "/readerService.commentReaderService/ListComments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CommentReaderServiceServer is the server API for CommentReaderService service.
+// All implementations must embed UnimplementedCommentReaderServiceServer
+// for forward compatibility
+type CommentReaderServiceServer interface {
+	ListComments(context.Context, *ListCommentsReq) (*ListCommentsRes, error)
+}
+
+// UnimplementedCommentReaderServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCommentReaderServiceServer struct {
+}
+
+func (UnimplementedCommentReaderServiceServer) ListComments(context.Context, *ListCommentsReq) (*ListCommentsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListComments not implemented")
+}
+func (UnimplementedCommentReaderServiceServer) mustEmbedUnimplementedCommentReaderServiceServer() {}
+
+// UnsafeCommentReaderServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CommentReaderServiceServer will
+// result in compilation errors.
+type UnsafeCommentReaderServiceServer interface {
+}
+
+func RegisterCommentReaderServiceServer(s grpc.ServiceRegistrar, srv CommentReaderServiceServer) {
+	s.RegisterService(&CommentReaderService_ServiceDesc, srv)
+}
+
+func _CommentReaderService_ListComments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCommentsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentReaderServiceServer).ListComments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/readerService.commentReaderService/ListComments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentReaderServiceServer).ListComments(ctx, req.(*ListCommentsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CommentReaderService_ServiceDesc is the grpc.ServiceDesc for CommentReaderService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CommentReaderService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "readerService.commentReaderService",
+	HandlerType: (*CommentReaderServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListComments",
+			Handler:    _CommentReaderService_ListComments_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "article_reader.proto",
@@ -27,6 +27,15 @@ type UnauthorizedResponse struct {
 	Timestamp string      `json:"timestamp" example:"2022-04-27 23:19:56"`
 }
 
+type NotFoundResponse struct {
+	Code      string      `json:"code" example:"KDMU-API-005"`
+	Message   string      `json:"message" example:"data yang anda minta tidak ditemukan."`
+	Data      interface{} `json:"data"`
+	Errors    interface{} `json:"errors"`
+	RequestId string      `json:"request_id" example:"24fa3770-628c-49de-aa17-3a338f73d99b"`
+	Timestamp string      `json:"timestamp" example:"2022-04-27 23:19:56"`
+}
+
 type BadRequestErrorValidationResponse struct {
 	Code      string      `json:"code" example:"KDMU-02-006"`
 	Message   string      `json:"message" example:"permintaan tidak valid, kesalahan muncul ketika permintaan Anda memiliki parameter yang tidak valid."`
@@ -63,7 +72,43 @@ type InternalServerErrorResponse struct {
 	Timestamp string      `json:"timestamp" example:"2022-04-27 23:19:56"`
 }
 
+type ServiceUnavailableResponse struct {
+	Code      string      `json:"code" example:"KDMU-API-006"`
+	Message   string      `json:"message" example:"layanan sedang tidak tersedia, silakan coba beberapa saat lagi."`
+	Data      interface{} `json:"data"`
+	Errors    interface{} `json:"errors"`
+	RequestId string      `json:"request_id" example:"24fa3770-628c-49de-aa17-3a338f73d99b"`
+	Timestamp string      `json:"timestamp" example:"2022-04-27 23:19:56"`
+}
+
+type PreconditionFailedResponse struct {
+	Code      string      `json:"code" example:"KDMU-API-030"`
+	Message   string      `json:"message" example:"data telah berubah sejak terakhir dibaca, silakan muat ulang lalu coba kembali."`
+	Data      interface{} `json:"data"`
+	Errors    interface{} `json:"errors"`
+	RequestId string      `json:"request_id" example:"24fa3770-628c-49de-aa17-3a338f73d99b"`
+	Timestamp string      `json:"timestamp" example:"2022-04-27 23:19:56"`
+}
+
 type ValidationErrors struct {
 	Field       string `json:"field" example:"MobilePhone wajib diisi."`
 	Description string `json:"message" example:"ActiveDate harus format yang benar yyyy-mm-dd."`
-}
\ No newline at end of file
+}
+
+type GraphqlRequest struct {
+	Query         string                 `json:"query" example:"query { article(id: 1) { id title } }"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type GraphqlError struct {
+	Message    string        `json:"message" example:"data yang anda minta tidak ditemukan."`
+	Path       []interface{} `json:"path"`
+	Extensions interface{}   `json:"extensions"`
+}
+
+type GraphqlResponse struct {
+	Data       interface{}    `json:"data"`
+	Errors     []GraphqlError `json:"errors"`
+	Extensions interface{}    `json:"extensions"`
+}
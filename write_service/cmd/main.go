@@ -1,11 +1,29 @@
 package main
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/database/migration"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
 	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/server"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/migrations"
 )
 
+const usage = `usage: write_service [command]
+
+commands:
+  (none)                         run the writer service
+  rebuild-snapshots [id ...]     rebuild the snapshots of the given articles, all articles when no id is given
+  migrate up                     apply the pending sql migrations
+  migrate down [steps]           roll back the last applied sql migrations, 1 by default
+  migrate status                 list the sql migrations and their state
+  migrate create <name>          create the up and down scripts of a new sql migration
+`
+
 func main() {
 
 	cfg, err := config.InitConfig()
@@ -18,6 +36,89 @@ func main() {
 	zaplog.WithName("WriterService")
 
 	s := server.NewServer(cfg, zaplog)
-	zaplog.Fatalf("running server : %s", s.Run())
 
+	if len(os.Args) < 2 {
+		zaplog.Fatalf("running server : %s", s.Run())
+		return
+	}
+
+	switch os.Args[1] {
+	case "rebuild-snapshots":
+		ids, err := parseIDs(os.Args[2:])
+		if err != nil {
+			fmt.Fprint(os.Stderr, usage)
+			zaplog.Fatalf("rebuild-snapshots : %s", err)
+		}
+		if err := s.RebuildSnapshots(ids); err != nil {
+			zaplog.Fatalf("rebuild-snapshots : %s", err)
+		}
+	case "migrate":
+		if err := migrate(s, os.Args[2:]); err != nil {
+			zaplog.Fatalf("migrate : %s", err)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+}
+
+// schemaMigrator server commands handling the sql migrations
+type schemaMigrator interface {
+	MigrateUp() error
+	MigrateDown(steps int) error
+	MigrateStatus(w io.Writer) error
+}
+
+func migrate(s schemaMigrator, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "up":
+		return s.MigrateUp()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+			steps = n
+		}
+		return s.MigrateDown(steps)
+	case "status":
+		return s.MigrateStatus(os.Stdout)
+	case "create":
+		if len(args) < 2 {
+			return fmt.Errorf("missing migration name")
+		}
+		files, err := migration.Create(migrations.Dir, args[1])
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			fmt.Println(f)
+		}
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	return nil
+}
+
+func parseIDs(args []string) ([]int, error) {
+	ids := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid article id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
 }
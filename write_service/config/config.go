@@ -27,6 +27,10 @@ type Config struct {
 	KafkaTopics KafkaTopics
 	Kafka       *kafkaClient.Config
 	GRPC        GRPC
+	EventStore  EventStore
+	Migration   Migration
+	Scheduler   Scheduler
+	Webhook     Webhook
 }
 
 type AppConfig struct {
@@ -36,10 +40,71 @@ type AppConfig struct {
 	CheckIntervalSeconds int
 	LogPath              string
 	SlackWebHookUrl      string
+	// DrainDelaySeconds time between reporting not ready on shutdown and draining, lets the load balancers stop routing
+	DrainDelaySeconds int
+	// ShutdownTimeoutSeconds upper bound of the wait for the in-flight requests and messages
+	ShutdownTimeoutSeconds int
 }
 type KafkaTopics struct {
-	ArticleCreate  kafkaClient.TopicConfig
-	ArticleCreated kafkaClient.TopicConfig
+	ArticleCreate        kafkaClient.TopicConfig
+	ArticleCreated       kafkaClient.TopicConfig
+	ArticleUpdate        kafkaClient.TopicConfig
+	ArticleUpdated       kafkaClient.TopicConfig
+	ArticleTransition    kafkaClient.TopicConfig
+	ArticleStatusChanged kafkaClient.TopicConfig
+	ArticlePublished     kafkaClient.TopicConfig
+	ArticleTag           kafkaClient.TopicConfig
+	ArticleTagsChanged   kafkaClient.TopicConfig
+	CommentCreate        kafkaClient.TopicConfig
+	CommentCreated       kafkaClient.TopicConfig
+	CommentEdit          kafkaClient.TopicConfig
+	CommentEdited        kafkaClient.TopicConfig
+	CommentDelete        kafkaClient.TopicConfig
+	CommentDeleted       kafkaClient.TopicConfig
+	CommentModerate      kafkaClient.TopicConfig
+	CommentModerated     kafkaClient.TopicConfig
+	CommandRejected      kafkaClient.TopicConfig
+	// DeadLetter messages the consumers gave up on
+	DeadLetter kafkaClient.TopicConfig
+}
+
+type EventStore struct {
+	// SnapshotFrequency number of events between two snapshots of an aggregate, 0 disables snapshots
+	SnapshotFrequency int
+}
+
+type Migration struct {
+	// AutoMigrate let gorm create the tables from the models instead of running the sql migrations, dev environment only
+	AutoMigrate bool
+	// MigrateOnStart apply the pending sql migrations when the server starts
+	MigrateOnStart bool
+}
+
+type Scheduler struct {
+	// PublishIntervalSeconds time between two runs of the scheduled publication, 0 disables the scheduler
+	PublishIntervalSeconds int
+	// RelayIntervalSeconds time between two runs of the outbox relay, 0 disables the relay
+	RelayIntervalSeconds int
+	// RelayGraceSeconds age an unpublished event reaches before the relay publishes it
+	RelayGraceSeconds int
+}
+
+type Webhook struct {
+	// DispatchIntervalSeconds time between two runs of the webhook dispatcher, 0 disables the deliveries
+	DispatchIntervalSeconds int
+	// BatchSize deliveries attempted per run
+	BatchSize int
+	// Concurrency deliveries attempted at once
+	Concurrency int
+	// RequestTimeoutSeconds upper bound of an attempt
+	RequestTimeoutSeconds int
+	// MaxAttempts attempts of a delivery before it is failed
+	MaxAttempts int
+	// BackoffBaseSeconds delay before the second attempt, doubled after every failed attempt up to BackoffMaxSeconds
+	BackoffBaseSeconds int
+	BackoffMaxSeconds  int
+	// DisableAfterFailures failed attempts in a row disabling a webhook, 0 never disables it
+	DisableAfterFailures int
 }
 
 type GRPC struct {
@@ -66,12 +131,14 @@ func InitConfig() (*Config, error) {
 
 	cfg := &Config{
 		App: AppConfig{
-			Port:                 viper.GetString("app.port"),
-			ServiceName:          viper.GetString("app.serviceName"),
-			ExecutionTimeout:     viper.GetInt("app.executionTimeout"),
-			CheckIntervalSeconds: viper.GetInt("app.checkIntervalSeconds"),
-			LogPath:              viper.GetString("app.logPath"),
-			SlackWebHookUrl:      viper.GetString("app.slackWebHookUrl"),
+			Port:                   viper.GetString("app.port"),
+			ServiceName:            viper.GetString("app.serviceName"),
+			ExecutionTimeout:       viper.GetInt("app.executionTimeout"),
+			CheckIntervalSeconds:   viper.GetInt("app.checkIntervalSeconds"),
+			LogPath:                viper.GetString("app.logPath"),
+			SlackWebHookUrl:        viper.GetString("app.slackWebHookUrl"),
+			DrainDelaySeconds:      viper.GetInt("app.drainDelaySeconds"),
+			ShutdownTimeoutSeconds: viper.GetInt("app.shutdownTimeoutSeconds"),
 		},
 		Database: database.Config{
 			Driver:                viper.GetString("database.driver"),
@@ -97,16 +164,126 @@ func InitConfig() (*Config, error) {
 				Partitions:        viper.GetInt("kafkaTopics.articleCreated.partitions"),
 				ReplicationFactor: viper.GetInt("kafkaTopics.articleCreated.replicationFactor"),
 			},
+			ArticleUpdate: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleUpdate.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleUpdate.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleUpdate.replicationFactor"),
+			},
+			ArticleUpdated: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleUpdated.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleUpdated.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleUpdated.replicationFactor"),
+			},
+			ArticleTransition: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleTransition.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleTransition.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleTransition.replicationFactor"),
+			},
+			ArticleStatusChanged: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleStatusChanged.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleStatusChanged.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleStatusChanged.replicationFactor"),
+			},
+			ArticlePublished: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articlePublished.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articlePublished.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articlePublished.replicationFactor"),
+			},
+			ArticleTag: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleTag.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleTag.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleTag.replicationFactor"),
+			},
+			ArticleTagsChanged: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.articleTagsChanged.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.articleTagsChanged.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.articleTagsChanged.replicationFactor"),
+			},
+			CommentCreate: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentCreate.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentCreate.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentCreate.replicationFactor"),
+			},
+			CommentCreated: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentCreated.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentCreated.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentCreated.replicationFactor"),
+			},
+			CommentEdit: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentEdit.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentEdit.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentEdit.replicationFactor"),
+			},
+			CommentEdited: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentEdited.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentEdited.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentEdited.replicationFactor"),
+			},
+			CommentDelete: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentDelete.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentDelete.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentDelete.replicationFactor"),
+			},
+			CommentDeleted: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentDeleted.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentDeleted.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentDeleted.replicationFactor"),
+			},
+			CommentModerate: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentModerate.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentModerate.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentModerate.replicationFactor"),
+			},
+			CommentModerated: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commentModerated.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commentModerated.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commentModerated.replicationFactor"),
+			},
+			CommandRejected: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.commandRejected.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.commandRejected.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.commandRejected.replicationFactor"),
+			},
+			DeadLetter: kafkaClient.TopicConfig{
+				TopicName:         viper.GetString("kafkaTopics.deadLetter.topicName"),
+				Partitions:        viper.GetInt("kafkaTopics.deadLetter.partitions"),
+				ReplicationFactor: viper.GetInt("kafkaTopics.deadLetter.replicationFactor"),
+			},
 		},
 		Kafka: &kafkaClient.Config{
-			Brokers:    viper.GetStringSlice("kafka.brokers"),
-			GroupID:    viper.GetString("kafka.groupID"),
-			InitTopics: viper.GetBool("kafka.initTopics"),
+			Brokers:        viper.GetStringSlice("kafka.brokers"),
+			GroupID:        viper.GetString("kafka.groupID"),
+			InitTopics:     viper.GetBool("kafka.initTopics"),
+			ContentType:    viper.GetString("kafka.contentType"),
+			BatchSize:      viper.GetInt("kafka.batchSize"),
+			BatchTimeoutMs: viper.GetInt("kafka.batchTimeoutMs"),
 		},
 		GRPC: GRPC{
 			Port:        viper.GetString("grpc.port"),
 			Development: viper.GetBool("grpc.development"),
 		},
+		EventStore: EventStore{
+			SnapshotFrequency: viper.GetInt("eventStore.snapshotFrequency"),
+		},
+		Migration: Migration{
+			AutoMigrate:    viper.GetBool("migration.autoMigrate"),
+			MigrateOnStart: viper.GetBool("migration.migrateOnStart"),
+		},
+		Scheduler: Scheduler{
+			PublishIntervalSeconds: viper.GetInt("scheduler.publishIntervalSeconds"),
+			RelayIntervalSeconds:   viper.GetInt("scheduler.relayIntervalSeconds"),
+			RelayGraceSeconds:      viper.GetInt("scheduler.relayGraceSeconds"),
+		},
+		Webhook: Webhook{
+			DispatchIntervalSeconds: viper.GetInt("webhook.dispatchIntervalSeconds"),
+			BatchSize:               viper.GetInt("webhook.batchSize"),
+			Concurrency:             viper.GetInt("webhook.concurrency"),
+			RequestTimeoutSeconds:   viper.GetInt("webhook.requestTimeoutSeconds"),
+			MaxAttempts:             viper.GetInt("webhook.maxAttempts"),
+			BackoffBaseSeconds:      viper.GetInt("webhook.backoffBaseSeconds"),
+			BackoffMaxSeconds:       viper.GetInt("webhook.backoffMaxSeconds"),
+			DisableAfterFailures:    viper.GetInt("webhook.disableAfterFailures"),
+		},
 	}
 
 	grpcPort := os.Getenv(GrpcPort)
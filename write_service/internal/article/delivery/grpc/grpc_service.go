@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/write_service/proto/article_writer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type articleGrpcService struct {
+	zapLogger zaplogger.Logger
+	useCase   domain.ArticleUseCase
+	cfg       *config.Config
+}
+
+func NewArticleGrpcService(useCase domain.ArticleUseCase, cfg *config.Config, zapLogger zaplogger.Logger) *articleGrpcService {
+	return &articleGrpcService{
+		zapLogger: zapLogger,
+		useCase:   useCase,
+		cfg:       cfg,
+	}
+}
+
+// ValidateCreateArticle the broken rules are answered in the response, not as an error
+func (s *articleGrpcService) ValidateCreateArticle(ctx context.Context, req *writerService.CreateArticleReq) (*writerService.ValidateCreateArticleRes, error) {
+	err := s.useCase.ValidateCreateArticle(ctx, domain.CreateArticleCommandFromGrpc(req))
+	var rejected *domain.CommandRejectedError
+	if errors.As(err, &rejected) {
+		return &writerService.ValidateCreateArticleRes{Valid: false, Reasons: domain.RejectionReasonsToGrpc(rejected.Reasons)}, nil
+	}
+	if err != nil {
+		s.zapLogger.WarnMsg("ArticleUseCase.ValidateCreateArticle", err)
+		return nil, s.errResponse(codes.Internal, err)
+	}
+
+	return &writerService.ValidateCreateArticleRes{Valid: true}, nil
+}
+
+// CreateArticleSync the broken rules are answered in the response, not as an error
+func (s *articleGrpcService) CreateArticleSync(ctx context.Context, req *writerService.CreateArticleReq) (*writerService.CreateArticleSyncRes, error) {
+	created, err := s.useCase.CreateArticleSync(ctx, domain.CreateArticleCommandFromGrpc(req))
+	var rejected *domain.CommandRejectedError
+	if errors.As(err, &rejected) {
+		return &writerService.CreateArticleSyncRes{Reasons: domain.RejectionReasonsToGrpc(rejected.Reasons)}, nil
+	}
+	if err != nil {
+		s.zapLogger.WarnMsg("ArticleUseCase.CreateArticleSync", err)
+		return nil, s.errResponse(codes.Internal, err)
+	}
+
+	return &writerService.CreateArticleSyncRes{
+		ID:        int32(created.ID),
+		Version:   int32(created.Version),
+		Status:    created.Status,
+		CreatedAt: timestamppb.New(created.CreatedAt),
+	}, nil
+}
+
+func (s *articleGrpcService) errResponse(c codes.Code, err error) error {
+	return status.Error(c, err.Error())
+}
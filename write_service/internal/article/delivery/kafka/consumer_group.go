@@ -2,21 +2,28 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
-	"sync"
+	"errors"
 	"time"
 
 	"github.com/avast/retry-go"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
 	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
 	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
 )
 
 const (
 	retryAttempts = 3
 	retryDelay    = 300 * time.Millisecond
-	PoolSize      = 30
+	// retryRounds rounds of retries of a message that can not be stored yet before it is dead-lettered,
+	// retryPause pause between two rounds
+	retryRounds = 5
+	retryPause  = 5 * time.Second
+	PoolSize    = 30
 )
 
 var (
@@ -24,77 +31,260 @@ var (
 )
 
 type articleConsumer struct {
-	zapLogger zaplogger.Logger
-	useCase   domain.ArticleUseCase
-	cfg       *config.Config
+	zapLogger  zaplogger.Logger
+	useCase    domain.ArticleUseCase
+	cfg        *config.Config
+	deadLetter kafkaClient.DeadLetter
 }
 
-func NewArticleConsumer(useCase domain.ArticleUseCase, cfg *config.Config, zapLogger zaplogger.Logger) *articleConsumer {
+func NewArticleConsumer(useCase domain.ArticleUseCase, cfg *config.Config, deadLetter kafkaClient.DeadLetter, zapLogger zaplogger.Logger) *articleConsumer {
 	return &articleConsumer{
-		zapLogger: zapLogger,
-		useCase:   useCase,
-		cfg:       cfg,
+		zapLogger:  zapLogger,
+		useCase:    useCase,
+		cfg:        cfg,
+		deadLetter: deadLetter,
 	}
 }
 
-func (s *articleConsumer) ProcessMessages(ctx context.Context, r *kafka.Reader, wg *sync.WaitGroup, workerID int) {
-	defer wg.Done()
+// ProcessBatch create the articles of the consecutive article_create messages of the batch together and
+// process the other messages one by one, in the order of the batch. A message that can not be stored is retried
+// a bounded number of times then dead-lettered, the batch is only committed once all of its messages are done;
+// the error is the end of ctx
+func (s *articleConsumer) ProcessBatch(ctx context.Context, batch []kafka.Message, workerID int) error {
+	create := make([]kafka.Message, 0, len(batch))
+	for _, m := range batch {
+		s.logProcessMessage(m, workerID)
+		if m.Topic == s.cfg.KafkaTopics.ArticleCreate.TopicName {
+			create = append(create, m)
+			continue
+		}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
+		// the articles are created before the messages following them
+		if err := s.processCreateArticles(ctx, create); err != nil {
+			return err
 		}
+		create = create[:0]
 
-		m, err := r.FetchMessage(ctx)
+		// the message is processed within the tenant it was published for
+		msgCtx := tenant.ContextFromMessage(ctx, m)
+
+		var err error
+		switch m.Topic {
+		case s.cfg.KafkaTopics.ArticleUpdate.TopicName:
+			err = s.processUpdateArticle(msgCtx, m)
+		case s.cfg.KafkaTopics.ArticleTransition.TopicName:
+			err = s.processTransitionArticle(msgCtx, m)
+		case s.cfg.KafkaTopics.ArticleTag.TopicName:
+			err = s.processTagArticle(msgCtx, m)
+		}
 		if err != nil {
-			s.zapLogger.Warnf("workerID: %v, err: %v", workerID, err)
+			return err
+		}
+	}
+	return s.processCreateArticles(ctx, create)
+}
+
+// processCreateArticles create the articles of each tenant with a single use case call. Undecodable commands and
+// the commands of a tenant that can not be stored are dead-lettered, rejected commands are dropped by the use case
+func (s *articleConsumer) processCreateArticles(ctx context.Context, msgs []kafka.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	var tenants []string
+	tenantCtx := make(map[string]context.Context)
+	tenantItems := make(map[string][]domain.CreateArticleBatchItem)
+	tenantMsgs := make(map[string][]kafka.Message)
+	for _, m := range msgs {
+		// the message is processed within the tenant it was published for
+		msgCtx := tenant.ContextFromMessage(ctx, m)
+
+		envelope, err := events.Default.DecodeMessage(events.ArticleCreateType, m)
+		if err != nil {
+			if err := s.deadLetterMessage(msgCtx, "events.DecodeMessage", m, err); err != nil {
+				return err
+			}
 			continue
 		}
 
-		s.logProcessMessage(m, workerID)
+		var command domain.CreateArticleCommand
+		if err := envelope.Decode(&command); err != nil {
+			if err := s.deadLetterMessage(msgCtx, "envelope.Decode", m, err); err != nil {
+				return err
+			}
+			continue
+		}
 
-		switch m.Topic {
-		case s.cfg.KafkaTopics.ArticleCreate.TopicName:
-			s.processCreateArticle(ctx, r, m)
+		id := tenant.FromContext(msgCtx)
+		if _, ok := tenantCtx[id]; !ok {
+			tenants = append(tenants, id)
+			tenantCtx[id] = msgCtx
+		}
+		tenantItems[id] = append(tenantItems[id], domain.CreateArticleBatchItem{CommandID: envelope.ID, Command: command})
+		tenantMsgs[id] = append(tenantMsgs[id], m)
+	}
+
+	for _, id := range tenants {
+		if err := s.retryStore(ctx, "ArticleUseCase.CreateArticles", func() error {
+			return s.useCase.CreateArticles(tenantCtx[id], tenantItems[id])
+		}, nil); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			for _, m := range tenantMsgs[id] {
+				if err := s.deadLetterMessage(tenantCtx[id], "ArticleUseCase.CreateArticles", m, err); err != nil {
+					return err
+				}
+			}
 		}
 	}
+	return nil
 }
 
-func (s *articleConsumer) processCreateArticle(ctx context.Context, r *kafka.Reader, m kafka.Message) {
+func (s *articleConsumer) processUpdateArticle(ctx context.Context, m kafka.Message) error {
 
-	var command domain.CreateArticleCommand
-	if err := json.Unmarshal(m.Value, &command); err != nil {
-		s.zapLogger.WarnMsg("proto.Unmarshal", err)
-		s.commitErrMessage(ctx, r, m)
-		return
+	envelope, err := events.Default.DecodeMessage(events.ArticleUpdateType, m)
+	if err != nil {
+		return s.deadLetterMessage(ctx, "events.DecodeMessage", m, err)
 	}
 
-	if err := retry.Do(func() error {
-		return s.useCase.CreateArticle(ctx, command)
-	}, append(retryOptions, retry.Context(ctx))...); err != nil {
-		s.zapLogger.WarnMsg("ArticleUseCase.CreateArticle", err)
-		return
+	var command domain.UpdateArticleCommand
+	if err := envelope.Decode(&command); err != nil {
+		return s.deadLetterMessage(ctx, "envelope.Decode", m, err)
 	}
 
-	s.commitMessage(ctx, r, m)
+	// rejections refer to the envelope of the command
+	commandCtx := events.WithCommandID(ctx, envelope.ID)
+	return s.processCommand(ctx, m, "ArticleUseCase.UpdateArticle", func() error {
+		return s.useCase.UpdateArticle(commandCtx, command)
+	}, func(err error) bool {
+		// updates of unknown articles and updates breaking a business rule are dropped
+		return errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrCommandRejected)
+	})
 }
 
-func (s *articleConsumer) logProcessMessage(m kafka.Message, workerID int) {
-	s.zapLogger.KafkaProcessMessage(m.Topic, m.Partition, string(m.Value), workerID, m.Offset, m.Time)
+func (s *articleConsumer) processTransitionArticle(ctx context.Context, m kafka.Message) error {
+
+	envelope, err := events.Default.DecodeMessage(events.ArticleTransitionType, m)
+	if err != nil {
+		return s.deadLetterMessage(ctx, "events.DecodeMessage", m, err)
+	}
+
+	var command domain.TransitionArticleCommand
+	if err := envelope.Decode(&command); err != nil {
+		return s.deadLetterMessage(ctx, "envelope.Decode", m, err)
+	}
+
+	transition, ok := s.transitions()[command.Action]
+	if !ok {
+		return s.deadLetterMessage(ctx, "ArticleConsumer.transitions", m, errors.New("unknown article transition action: "+command.Action))
+	}
+
+	// rejections refer to the envelope of the command
+	commandCtx := events.WithCommandID(ctx, envelope.ID)
+	return s.processCommand(ctx, m, "ArticleUseCase."+command.Action, func() error {
+		return transition(commandCtx, command)
+	}, func(err error) bool {
+		// transitions of unknown articles, transitions the workflow refuses and transitions breaking
+		// a business rule are dropped
+		return errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrInvalidTransition) ||
+			errors.Is(err, domain.ErrCommandRejected)
+	})
+}
+
+func (s *articleConsumer) transitions() map[string]func(context.Context, domain.TransitionArticleCommand) error {
+	return map[string]func(context.Context, domain.TransitionArticleCommand) error{
+		events.ArticleActionSubmitForReview: s.useCase.SubmitForReview,
+		events.ArticleActionPublish:         s.useCase.Publish,
+		events.ArticleActionUnpublish:       s.useCase.Unpublish,
+		events.ArticleActionArchive:         s.useCase.Archive,
+	}
+}
+
+func (s *articleConsumer) processTagArticle(ctx context.Context, m kafka.Message) error {
+
+	envelope, err := events.Default.DecodeMessage(events.ArticleTagType, m)
+	if err != nil {
+		return s.deadLetterMessage(ctx, "events.DecodeMessage", m, err)
+	}
+
+	var command domain.TagArticleCommand
+	if err := envelope.Decode(&command); err != nil {
+		return s.deadLetterMessage(ctx, "envelope.Decode", m, err)
+	}
+
+	tag := s.useCase.AddTags
+	if command.Action == events.ArticleTagActionRemove {
+		tag = s.useCase.RemoveTags
+	}
+
+	// rejections refer to the envelope of the command
+	commandCtx := events.WithCommandID(ctx, envelope.ID)
+	return s.processCommand(ctx, m, "ArticleUseCase.Tag", func() error {
+		return tag(commandCtx, command)
+	}, func(err error) bool {
+		// tags of unknown articles and tags breaking a business rule are dropped
+		return errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrCommandRejected)
+	})
 }
 
-func (s *articleConsumer) commitMessage(ctx context.Context, r *kafka.Reader, m kafka.Message) {
-	s.zapLogger.KafkaLogCommittedMessage(m.Topic, m.Partition, m.Offset)
-	if err := r.CommitMessages(ctx, m); err != nil {
-		s.zapLogger.WarnMsg("commitMessage", err)
+// processCommand store the command of m, the errors drop accepts end the command without a retry
+// and the command that can not be stored is dead-lettered
+func (s *articleConsumer) processCommand(ctx context.Context, m kafka.Message, name string, store func() error, drop func(error) bool) error {
+	err := s.retryStore(ctx, name, store, func(err error) bool { return !drop(err) })
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return err
+	case drop(err):
+		s.zapLogger.WarnMsg(name, err)
+		return nil
+	default:
+		return s.deadLetterMessage(ctx, name, m, err)
 	}
 }
 
-func (s *articleConsumer) commitErrMessage(ctx context.Context, r *kafka.Reader, m kafka.Message) {
-	s.zapLogger.KafkaLogCommittedMessage(m.Topic, m.Partition, m.Offset)
-	if err := r.CommitMessages(ctx, m); err != nil {
-		s.zapLogger.WarnMsg("commitMessage", err)
+// retryStore retry store for retryRounds rounds or until it fails with an error retryIf refuses (every error is
+// retried when retryIf is nil), a message that is not stored by then is given up on. The end of ctx stops the retries
+func (s *articleConsumer) retryStore(ctx context.Context, name string, store func() error, retryIf func(error) bool) error {
+	if retryIf == nil {
+		retryIf = func(error) bool { return true }
 	}
+	for round := 1; ; round++ {
+		err := retry.Do(store, append(retryOptions, retry.Context(ctx), retry.LastErrorOnly(true), retry.RetryIf(retryIf))...)
+		if err == nil || !retryIf(err) || ctx.Err() != nil || round == retryRounds {
+			return err
+		}
+		s.zapLogger.WarnMsg(name, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryPause):
+		}
+	}
+}
+
+// deadLetterMessage hand m to the dead letter topic, retried until it is kept since the batch is committed after it.
+// Only the end of ctx gives up, the batch is then left uncommitted
+func (s *articleConsumer) deadLetterMessage(ctx context.Context, name string, m kafka.Message, cause error) error {
+	s.zapLogger.WarnMsg(name, cause)
+	for {
+		err := retry.Do(func() error {
+			return s.deadLetter.Publish(ctx, m, cause)
+		}, append(retryOptions, retry.Context(ctx), retry.LastErrorOnly(true))...)
+		if err == nil {
+			return nil
+		}
+		s.zapLogger.WarnMsg("DeadLetter.Publish", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryPause):
+		}
+	}
+}
+
+func (s *articleConsumer) logProcessMessage(m kafka.Message, workerID int) {
+	s.zapLogger.KafkaProcessMessage(m.Topic, m.Partition, string(m.Value), workerID, m.Offset, m.Time)
 }
@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+)
+
+type outboxRelay struct {
+	zapLogger zaplogger.Logger
+	useCase   domain.ArticleUseCase
+	interval  time.Duration
+	grace     time.Duration
+}
+
+// NewOutboxRelay publish every interval the events left unpublished for longer than grace,
+// the grace keeps the relay away from the events their command is still publishing
+func NewOutboxRelay(useCase domain.ArticleUseCase, interval, grace time.Duration, zapLogger zaplogger.Logger) *outboxRelay {
+	return &outboxRelay{
+		zapLogger: zapLogger,
+		useCase:   useCase,
+		interval:  interval,
+		grace:     grace,
+	}
+}
+
+// Run blocks until ctx is done
+func (r *outboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		published, err := r.useCase.RelayUnpublished(ctx, time.Now().UTC().Add(-r.grace))
+		if err != nil {
+			r.zapLogger.WarnMsg("ArticleUseCase.RelayUnpublished", err)
+			continue
+		}
+		if published > 0 {
+			r.zapLogger.Infof("relayed %d unpublished article events", published)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+)
+
+type publishScheduler struct {
+	zapLogger zaplogger.Logger
+	useCase   domain.ArticleUseCase
+	interval  time.Duration
+}
+
+// NewPublishScheduler publish the articles whose publish_at is due every interval
+func NewPublishScheduler(useCase domain.ArticleUseCase, interval time.Duration, zapLogger zaplogger.Logger) *publishScheduler {
+	return &publishScheduler{
+		zapLogger: zapLogger,
+		useCase:   useCase,
+		interval:  interval,
+	}
+}
+
+// Run blocks until ctx is done
+func (s *publishScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		published, err := s.useCase.PublishDue(ctx, time.Now().UTC())
+		if err != nil {
+			s.zapLogger.WarnMsg("ArticleUseCase.PublishDue", err)
+			continue
+		}
+		if published > 0 {
+			s.zapLogger.Infof("published %d scheduled articles", published)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type eventStoreArticleRepository struct {
+	zapLogger zaplogger.Logger
+	db        *gorm.DB
+}
+
+func NewEventStoreArticleRepository(db *gorm.DB, zapLogger zaplogger.Logger) domain.EventStoreArticleRepository {
+	return &eventStoreArticleRepository{
+		db:        db,
+		zapLogger: zapLogger,
+	}
+}
+
+func (c eventStoreArticleRepository) AppendWithTx(ctx context.Context, tx *gorm.DB, articleEvents ...domain.ArticleEvent) error {
+	if len(articleEvents) == 0 {
+		return nil
+	}
+	tenantID := tenant.FromContext(ctx)
+	for i := range articleEvents {
+		articleEvents[i].TenantID = tenantID
+	}
+	// the unique (aggregate_id, aggregate_version) index rejects concurrent writers of the same version
+	return tx.WithContext(ctx).Create(&articleEvents).Error
+}
+
+func (c eventStoreArticleRepository) SaveSnapshotWithTx(ctx context.Context, tx *gorm.DB, snapshot domain.ArticleSnapshot) error {
+	snapshot.TenantID = tenant.FromContext(ctx)
+	return tx.WithContext(ctx).Create(&snapshot).Error
+}
+
+// Load restore the aggregate from its latest usable snapshot and apply only the newer events,
+// the aggregates of the other tenants are not found
+func (c eventStoreArticleRepository) Load(ctx context.Context, aggregateID int) (*domain.ArticleAggregate, error) {
+	db := c.db.WithContext(ctx).Scopes(domain.TenantScope(ctx))
+
+	aggregate := new(domain.ArticleAggregate)
+	var snapshots []domain.ArticleSnapshot
+	err := db.Where("aggregate_id = ? AND schema_version = ?", aggregateID, domain.ArticleAggregateSchemaVersion).
+		Order("version desc").Limit(1).Find(&snapshots).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "find snapshot")
+	}
+	if len(snapshots) > 0 {
+		aggregate, err = domain.NewArticleAggregateFromSnapshot(snapshots[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "domain.NewArticleAggregateFromSnapshot")
+		}
+	}
+
+	var articleEvents []domain.ArticleEvent
+	err = db.Where("aggregate_id = ? AND aggregate_version > ?", aggregateID, aggregate.Version).
+		Order("aggregate_version").Find(&articleEvents).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "find events")
+	}
+
+	if aggregate.Version == 0 && len(articleEvents) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	for _, event := range articleEvents {
+		if err := aggregate.Apply(event); err != nil {
+			return nil, errors.Wrap(err, "aggregate.Apply")
+		}
+	}
+
+	return aggregate, nil
+}
+
+// RebuildSnapshots replace the snapshots of the aggregate by replaying its whole history,
+// returns the number of snapshots written. The snapshots belong to the tenant of the events whatever the tenant of ctx
+func (c eventStoreArticleRepository) RebuildSnapshots(ctx context.Context, aggregateID int, frequency int) (int, error) {
+	written := 0
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("aggregate_id = ?", aggregateID).Delete(&domain.ArticleSnapshot{}).Error; err != nil {
+			return errors.Wrap(err, "delete snapshots")
+		}
+
+		var articleEvents []domain.ArticleEvent
+		if err := tx.Where("aggregate_id = ?", aggregateID).Order("aggregate_version").Find(&articleEvents).Error; err != nil {
+			return errors.Wrap(err, "find events")
+		}
+
+		if len(articleEvents) > 0 {
+			ctx = tenant.WithID(ctx, articleEvents[0].TenantID)
+		}
+		aggregate := new(domain.ArticleAggregate)
+		for _, event := range articleEvents {
+			if err := aggregate.Apply(event); err != nil {
+				return errors.Wrap(err, "aggregate.Apply")
+			}
+			if !domain.ShouldSnapshot(aggregate.Version, frequency) {
+				continue
+			}
+
+			snapshot, err := aggregate.Snapshot()
+			if err != nil {
+				return err
+			}
+			if err := c.SaveSnapshotWithTx(ctx, tx, snapshot); err != nil {
+				return errors.Wrap(err, "SaveSnapshotWithTx")
+			}
+			written++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return written, nil
+}
+
+// AggregateIDs aggregates of every tenant
+func (c eventStoreArticleRepository) AggregateIDs(ctx context.Context) ([]int, error) {
+	var ids []int
+	err := c.db.WithContext(ctx).Model(&domain.ArticleEvent{}).Distinct("aggregate_id").Order("aggregate_id").Pluck("aggregate_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (c eventStoreArticleRepository) MarkPublished(ctx context.Context, articleEvents ...domain.ArticleEvent) error {
+	if len(articleEvents) == 0 {
+		return nil
+	}
+	keys := make([][]interface{}, 0, len(articleEvents))
+	for _, event := range articleEvents {
+		keys = append(keys, []interface{}{event.AggregateID, event.AggregateVersion})
+	}
+	return c.db.WithContext(ctx).Model(&domain.ArticleEvent{}).
+		Where("(aggregate_id, aggregate_version) IN ? AND published_at IS NULL", keys).
+		Update("published_at", time.Now().UTC()).Error
+}
+
+// FetchUnpublished the id follows the commit order, the versions of an aggregate come out in order
+func (c eventStoreArticleRepository) FetchUnpublished(ctx context.Context, before time.Time, limit int) ([]domain.ArticleEvent, error) {
+	var articleEvents []domain.ArticleEvent
+	err := c.db.WithContext(ctx).Where("published_at IS NULL AND occurred_at < ?", before).
+		Order("id").Limit(limit).Find(&articleEvents).Error
+	if err != nil {
+		return nil, err
+	}
+	return articleEvents, nil
+}
@@ -2,8 +2,9 @@ package repository
 
 import (
 	"context"
-	"time"
 
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
 	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
@@ -25,10 +26,139 @@ func NewMessagingArticleRepository(producer kafkaClient.Producer, cfg *config.Co
 	}
 }
 
-func (m messagingArticleRepository) PushMessageInsertArticle(ctx context.Context, msg []byte) error {
-	return m.producer.PublishMessage(ctx, kafka.Message{
-		Topic: m.cfg.KafkaTopics.ArticleCreated.TopicName,
-		Value: msg,
-		Time:  time.Now().UTC(),
-	})
+func (m messagingArticleRepository) PushMessageInsertArticle(ctx context.Context, event events.ArticleCreated) error {
+	codec, err := kafkaClient.CodecFor(m.cfg.Kafka.ContentType)
+	if err != nil {
+		return err
+	}
+
+	msg, err := events.Default.EncodeMessage(codec, m.cfg.KafkaTopics.ArticleCreated.TopicName, events.ArticleCreatedType, event)
+	if err != nil {
+		return err
+	}
+
+	return m.producer.PublishMessage(ctx, msg)
+}
+
+func (m messagingArticleRepository) PushMessageInsertArticles(ctx context.Context, createdEvents []events.ArticleCreated) error {
+	if len(createdEvents) == 0 {
+		return nil
+	}
+	codec, err := kafkaClient.CodecFor(m.cfg.Kafka.ContentType)
+	if err != nil {
+		return err
+	}
+
+	msgs := make([]kafka.Message, 0, len(createdEvents))
+	for _, event := range createdEvents {
+		msg, err := events.Default.EncodeMessage(codec, m.cfg.KafkaTopics.ArticleCreated.TopicName, events.ArticleCreatedType, event)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return m.producer.PublishMessage(ctx, msgs...)
+}
+
+func (m messagingArticleRepository) PushMessageUpdateArticle(ctx context.Context, event events.ArticleUpdated) error {
+	codec, err := kafkaClient.CodecFor(m.cfg.Kafka.ContentType)
+	if err != nil {
+		return err
+	}
+
+	msg, err := events.Default.EncodeMessage(codec, m.cfg.KafkaTopics.ArticleUpdated.TopicName, events.ArticleUpdatedType, event)
+	if err != nil {
+		return err
+	}
+
+	return m.producer.PublishMessage(ctx, msg)
+}
+
+func (m messagingArticleRepository) PushMessageStatusChanged(ctx context.Context, event events.ArticleStatusChanged) error {
+	codec, err := kafkaClient.CodecFor(m.cfg.Kafka.ContentType)
+	if err != nil {
+		return err
+	}
+
+	msg, err := events.Default.EncodeMessage(codec, m.cfg.KafkaTopics.ArticleStatusChanged.TopicName, events.ArticleStatusChangedType, event)
+	if err != nil {
+		return err
+	}
+
+	return m.producer.PublishMessage(ctx, msg)
+}
+
+func (m messagingArticleRepository) PushMessagePublished(ctx context.Context, event events.ArticlePublished) error {
+	codec, err := kafkaClient.CodecFor(m.cfg.Kafka.ContentType)
+	if err != nil {
+		return err
+	}
+
+	msg, err := events.Default.EncodeMessage(codec, m.cfg.KafkaTopics.ArticlePublished.TopicName, events.ArticlePublishedType, event)
+	if err != nil {
+		return err
+	}
+
+	return m.producer.PublishMessage(ctx, msg)
+}
+
+func (m messagingArticleRepository) PushMessageTagsChanged(ctx context.Context, event events.ArticleTagsChanged) error {
+	codec, err := kafkaClient.CodecFor(m.cfg.Kafka.ContentType)
+	if err != nil {
+		return err
+	}
+
+	msg, err := events.Default.EncodeMessage(codec, m.cfg.KafkaTopics.ArticleTagsChanged.TopicName, events.ArticleTagsChangedType, event)
+	if err != nil {
+		return err
+	}
+
+	return m.producer.PublishMessage(ctx, msg)
+}
+
+func (m messagingArticleRepository) PushMessageEvent(ctx context.Context, event domain.ArticleEvent) error {
+	codec, err := kafkaClient.CodecFor(m.cfg.Kafka.ContentType)
+	if err != nil {
+		return err
+	}
+
+	envelope := &events.Envelope{
+		Type:       event.EventType,
+		Version:    event.EventVersion,
+		OccurredAt: event.OccurredAt,
+		Payload:    event.Payload,
+	}
+	if err := events.Default.Upcast(envelope); err != nil {
+		return err
+	}
+
+	topic, err := m.eventTopic(envelope.Type)
+	if err != nil {
+		return err
+	}
+
+	msg, err := events.Default.EncodeMessage(codec, topic, envelope.Type, envelope.Payload)
+	if err != nil {
+		return err
+	}
+
+	return m.producer.PublishMessage(ctx, msg)
+}
+
+// eventTopic topic the events of eventType are published to
+func (m messagingArticleRepository) eventTopic(eventType string) (string, error) {
+	switch eventType {
+	case events.ArticleCreatedType:
+		return m.cfg.KafkaTopics.ArticleCreated.TopicName, nil
+	case events.ArticleUpdatedType:
+		return m.cfg.KafkaTopics.ArticleUpdated.TopicName, nil
+	case events.ArticleStatusChangedType:
+		return m.cfg.KafkaTopics.ArticleStatusChanged.TopicName, nil
+	case events.ArticlePublishedType:
+		return m.cfg.KafkaTopics.ArticlePublished.TopicName, nil
+	case events.ArticleTagsChangedType:
+		return m.cfg.KafkaTopics.ArticleTagsChanged.TopicName, nil
+	}
+	return "", errors.Wrap(events.ErrUnknownEventType, eventType)
 }
@@ -3,6 +3,13 @@ package repository
 import (
 	"context"
 	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/pkg/errors"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 
 	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
 
@@ -11,6 +18,16 @@ import (
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// insertBatchSize rows of a multi-row insert of StoreInBatchesWithTx
+	insertBatchSize = 100
+	// uniqueViolation sql state of a unique index violation
+	uniqueViolation = "23505"
+	// uniqueTitleIndex unique index of the titles of an author, see migration 0010
+	uniqueTitleIndex = "idx_articles_tenant_author_title"
 )
 
 type pgArticleRepository struct {
@@ -30,14 +47,14 @@ func (c pgArticleRepository) DB() *gorm.DB {
 }
 
 func (c pgArticleRepository) FetchWithFilter(ctx context.Context, limit int, offset int, order string, fields, associate []string, model interface{}, args ...interface{}) error {
-	p := paginator.NewPaginator(c.db, offset, limit, model)
+	p := paginator.NewPaginator(c.db.Scopes(domain.TenantScope(ctx)), offset, limit, model)
 
 	return p.FindWithFilter(ctx, order, fields, associate, args...).Select(strings.Join(fields, ",")).Error
 }
 
 func (c pgArticleRepository) SingleWithFilter(ctx context.Context, fields, associate []string, model interface{}, args ...interface{}) error {
 
-	db := c.db.WithContext(ctx)
+	db := c.db.WithContext(ctx).Scopes(domain.TenantScope(ctx))
 
 	if len(fields) > 0 {
 		db = db.Select(strings.Join(fields, ","))
@@ -57,7 +74,7 @@ func (c pgArticleRepository) SingleWithFilter(ctx context.Context, fields, assoc
 
 func (c pgArticleRepository) Update(ctx context.Context, data domain.Article) error {
 
-	err := c.db.WithContext(ctx).Updates(&data).Error
+	err := c.db.WithContext(ctx).Scopes(domain.TenantScope(ctx)).Updates(&data).Error
 	if err != nil {
 		return err
 	}
@@ -66,10 +83,11 @@ func (c pgArticleRepository) Update(ctx context.Context, data domain.Article) er
 
 func (c pgArticleRepository) UpdateSelectedField(ctx context.Context, field []string, values map[string]interface{}, id int) error {
 
-	return c.db.WithContext(ctx).Table("customer_limit").Select(field).Where("id =?", id).Updates(values).Error
+	return c.db.WithContext(ctx).Model(&domain.Article{}).Scopes(domain.TenantScope(ctx)).Select(field).Where("id =?", id).Updates(values).Error
 }
 
 func (c pgArticleRepository) Store(ctx context.Context, data domain.Article) (domain.Article, error) {
+	data.TenantID = tenant.FromContext(ctx)
 
 	err := c.db.WithContext(ctx).Create(&data).Error
 	if err != nil {
@@ -80,7 +98,8 @@ func (c pgArticleRepository) Store(ctx context.Context, data domain.Article) (do
 
 func (c pgArticleRepository) Delete(ctx context.Context, id int) (int, error) {
 
-	err := c.db.WithContext(ctx).Exec("delete from customer_limit where id =?", id).Error
+	// Delete removes the row for good, SoftDelete only sets its deleted_at
+	err := c.db.WithContext(ctx).Unscoped().Model(&domain.Article{}).Scopes(domain.TenantScope(ctx)).Where("id = ?", id).Delete(&domain.Article{}).Error
 	if err != nil {
 		return id, err
 	}
@@ -90,19 +109,50 @@ func (c pgArticleRepository) Delete(ctx context.Context, id int) (int, error) {
 func (c pgArticleRepository) SoftDelete(ctx context.Context, id int) (int, error) {
 	var data domain.Article
 
-	err := c.db.WithContext(ctx).Where("id = ?", id).Delete(&data).Error
+	err := c.db.WithContext(ctx).Scopes(domain.TenantScope(ctx)).Where("id = ?", id).Delete(&data).Error
 	if err != nil {
 		return id, err
 	}
 	return id, nil
 }
 
+func (c pgArticleRepository) StoreInBatchesWithTx(ctx context.Context, tx *gorm.DB, data []domain.Article) ([]int, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	tenantID := tenant.FromContext(ctx)
+	for i := range data {
+		data[i].TenantID = tenantID
+	}
+
+	if err := tx.WithContext(ctx).CreateInBatches(&data, insertBatchSize).Error; err != nil {
+		return nil, mapUniqueTitle(err)
+	}
+
+	ids := make([]int, 0, len(data))
+	for _, article := range data {
+		ids = append(ids, article.ID)
+	}
+	return ids, nil
+}
+
 func (c pgArticleRepository) UpdateSelectedFieldWithTx(ctx context.Context, tx *gorm.DB, field []string, values map[string]interface{}, id int) error {
 
-	return tx.WithContext(ctx).Table("customer_limit").Select(field).Where("id =?", id).Updates(values).Error
+	err := tx.WithContext(ctx).Model(&domain.Article{}).Scopes(domain.TenantScope(ctx)).Select(field).Where("id =?", id).Updates(values).Error
+	return mapUniqueTitle(err)
+}
+
+// mapUniqueTitle report the violation of the unique title of an author as domain.ErrArticleTitleTaken
+func mapUniqueTitle(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == uniqueTitleIndex {
+		return errors.Wrap(domain.ErrArticleTitleTaken, pgErr.Message)
+	}
+	return err
 }
 
 func (c pgArticleRepository) StoreWithTx(ctx context.Context, tx *gorm.DB, data domain.Article) (int, error) {
+	data.TenantID = tenant.FromContext(ctx)
 
 	err := tx.WithContext(ctx).Create(&data).Error
 	if err != nil {
@@ -113,9 +163,97 @@ func (c pgArticleRepository) StoreWithTx(ctx context.Context, tx *gorm.DB, data
 
 func (c pgArticleRepository) FetchWithFilterAndPagination(ctx context.Context, limit int, offset int, order string, fields, associate []string, model interface{}, args ...interface{}) (*paginator.Paginator, error) {
 
-	p := paginator.NewPaginator(c.db, offset, limit, model)
+	p := paginator.NewPaginator(c.db.Scopes(domain.TenantScope(ctx)), offset, limit, model)
 	if err := p.FindWithFilter(ctx, order, fields, associate, args...).Select(strings.Join(fields, ",")).Error; err != nil {
 		return p, err
 	}
 	return p, nil
 }
+
+// FetchScheduled the scheduler publishes for every tenant, the statement is not scoped
+func (c pgArticleRepository) FetchScheduled(ctx context.Context, due time.Time, limit int) ([]domain.Article, error) {
+	var articles []domain.Article
+	err := c.db.WithContext(ctx).Model(&domain.Article{}).Select("id", "tenant_id").
+		Where("publish_at <= ? AND status IN ?", due, []string{events.ArticleStatusDraft, events.ArticleStatusInReview}).
+		Order("publish_at").Limit(limit).Find(&articles).Error
+	if err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
+
+func (c pgArticleRepository) SaveTaxonomyWithTx(ctx context.Context, tx *gorm.DB, articleID int, category string, tags []string) error {
+	db := tx.WithContext(ctx)
+	tenantID := tenant.FromContext(ctx)
+
+	var categoryID *int
+	if category != "" {
+		id, err := c.saveCategoryPathWithTx(db, tenantID, category)
+		if err != nil {
+			return err
+		}
+		categoryID = &id
+	}
+	if err := db.Model(&domain.Article{}).Scopes(domain.TenantScope(ctx)).Where("id = ?", articleID).Update("category_id", categoryID).Error; err != nil {
+		return err
+	}
+
+	tagIDs := make([]int, 0, len(tags))
+	if len(tags) > 0 {
+		now := time.Now().UTC()
+		rows := make([]domain.Tag, 0, len(tags))
+		for _, name := range tags {
+			rows = append(rows, domain.Tag{TenantID: tenantID, Name: name, CreatedAt: now})
+		}
+		if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "tenant_id"}, {Name: "name"}}, DoNothing: true}).Create(&rows).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&domain.Tag{}).Scopes(domain.TenantScope(ctx)).Where("name IN ?", tags).Pluck("id", &tagIDs).Error; err != nil {
+			return err
+		}
+	}
+
+	unlinked := db.Where("article_id = ?", articleID)
+	if len(tagIDs) > 0 {
+		unlinked = unlinked.Where("tag_id NOT IN ?", tagIDs)
+	}
+	if err := unlinked.Delete(&domain.ArticleTag{}).Error; err != nil {
+		return err
+	}
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	links := make([]domain.ArticleTag, 0, len(tagIDs))
+	for _, tagID := range tagIDs {
+		links = append(links, domain.ArticleTag{ArticleID: articleID, TagID: tagID})
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+}
+
+// saveCategoryPathWithTx id of the category of the tenant at path, the missing categories of the path are created from the root
+func (c pgArticleRepository) saveCategoryPathWithTx(db *gorm.DB, tenantID string, path string) (int, error) {
+	var parentID *int
+	for _, ancestor := range events.CategoryAncestors(path) {
+		node := domain.Category{
+			TenantID:  tenantID,
+			ParentID:  parentID,
+			Name:      ancestor[strings.LastIndex(ancestor, "/")+1:],
+			Path:      ancestor,
+			CreatedAt: time.Now().UTC(),
+		}
+		if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "tenant_id"}, {Name: "path"}}, DoNothing: true}).Create(&node).Error; err != nil {
+			return 0, err
+		}
+
+		var ids []int
+		if err := db.Model(&domain.Category{}).Where("tenant_id = ? AND path = ?", tenantID, ancestor).Pluck("id", &ids).Error; err != nil {
+			return 0, err
+		}
+		if len(ids) == 0 {
+			return 0, gorm.ErrRecordNotFound
+		}
+		parentID = &ids[0]
+	}
+	return *parentID, nil
+}
@@ -2,29 +2,50 @@ package usecase
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"time"
 
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	"gorm.io/gorm"
+)
+
+const (
+	// publishDueBatchSize upper bound of the articles published by a single PublishDue call
+	publishDueBatchSize = 100
+	// schedulerChangedBy author of the scheduled publications
+	schedulerChangedBy = "scheduler"
+	// relayBatchSize upper bound of the events published by a single RelayUnpublished call
+	relayBatchSize = 500
 )
 
 type articleUseCase struct {
-	zapLogger                  zaplogger.Logger
-	contextTimeout             time.Duration
-	pgArticleRepository        domain.PgArticleRepository
-	messagingArticleRepository domain.MessagingArticleRepository
+	zapLogger                   zaplogger.Logger
+	contextTimeout              time.Duration
+	snapshotFrequency           int
+	pgArticleRepository         domain.PgArticleRepository
+	eventStoreArticleRepository domain.EventStoreArticleRepository
+	messagingArticleRepository  domain.MessagingArticleRepository
+	commandValidator            domain.CommandValidator
 }
 
 func NewArticleUseCase(timeout time.Duration,
+	snapshotFrequency int,
 	pgArticleRepository domain.PgArticleRepository,
+	eventStoreArticleRepository domain.EventStoreArticleRepository,
 	messagingArticleRepository domain.MessagingArticleRepository,
+	commandValidator domain.CommandValidator,
 	zapLogger zaplogger.Logger) domain.ArticleUseCase {
 	return &articleUseCase{
-		contextTimeout:             timeout,
-		zapLogger:                  zapLogger,
-		pgArticleRepository:        pgArticleRepository,
-		messagingArticleRepository: messagingArticleRepository,
+		contextTimeout:              timeout,
+		snapshotFrequency:           snapshotFrequency,
+		zapLogger:                   zapLogger,
+		pgArticleRepository:         pgArticleRepository,
+		eventStoreArticleRepository: eventStoreArticleRepository,
+		messagingArticleRepository:  messagingArticleRepository,
+		commandValidator:            commandValidator,
 	}
 }
 
@@ -32,28 +53,441 @@ func (a articleUseCase) CreateArticle(c context.Context, command domain.CreateAr
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
 	defer cancel()
 
-	insert, err := a.pgArticleRepository.Store(ctx, command.ToArticle())
+	if err := a.commandValidator.Validate(ctx, events.ArticleCreateType, 0, command); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	_, err := a.createArticle(ctx, command)
+	return a.rejectTitleTaken(err, func() error {
+		return a.commandValidator.Validate(ctx, events.ArticleCreateType, 0, command)
+	})
+}
+
+// ValidateCreateArticle check command against the business rules without persisting it,
+// a CommandRejectedError holds the broken rules
+func (a articleUseCase) ValidateCreateArticle(c context.Context, command domain.CreateArticleCommand) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	return a.commandValidator.Check(ctx, command)
+}
+
+// CreateArticleSync create the article of command right away, a CommandRejectedError holds the broken rules.
+// The rejection is answered to the caller instead of being published
+func (a articleUseCase) CreateArticleSync(c context.Context, command domain.CreateArticleCommand) (events.ArticleCreated, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if err := a.commandValidator.Check(ctx, command); err != nil {
+		return events.ArticleCreated{}, err
+	}
+
+	created, err := a.createArticle(ctx, command)
+	if err != nil {
+		return events.ArticleCreated{}, a.rejectTitleTaken(err, func() error {
+			return a.commandValidator.Check(ctx, command)
+		})
+	}
+	return created, nil
+}
+
+// createArticle persist the article of the valid command and publish its creation
+func (a articleUseCase) createArticle(ctx context.Context, command domain.CreateArticleCommand) (events.ArticleCreated, error) {
+	created, err := a.createArticles(ctx, []domain.CreateArticleCommand{command})
+	if err != nil {
+		return events.ArticleCreated{}, err
+	}
+	return created[0], nil
+}
+
+// CreateArticles a command repeating the author and title of an earlier command of the batch is created after
+// the batch, on its own, so the unique rule sees the earlier article and rejects it
+func (a articleUseCase) CreateArticles(c context.Context, items []domain.CreateArticleBatchItem) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	commands := make([]domain.CreateArticleCommand, 0, len(items))
+	valid := make([]domain.CreateArticleBatchItem, 0, len(items))
+	var repeated []domain.CreateArticleBatchItem
+	seen := make(map[[2]string]bool, len(items))
+	for _, item := range items {
+		key := [2]string{item.Command.Author, item.Command.Title}
+		if seen[key] {
+			repeated = append(repeated, item)
+			continue
+		}
+		// rejections refer to the envelope of the command
+		if err := a.commandValidator.Validate(events.WithCommandID(ctx, item.CommandID), events.ArticleCreateType, 0, item.Command); err != nil {
+			if errors.Is(err, domain.ErrCommandRejected) {
+				continue
+			}
+			a.zapLogger.SetMessageLog(err)
+			return err
+		}
+		seen[key] = true
+		commands = append(commands, item.Command)
+		valid = append(valid, item)
+	}
+
+	if _, err := a.createArticles(ctx, commands); err != nil {
+		if !errors.Is(err, domain.ErrArticleTitleTaken) {
+			return err
+		}
+		// a concurrent command took the title of one of the commands, the rejected transaction is replayed
+		// command by command so only that one is rejected
+		repeated = append(valid, repeated...)
+	}
+
+	for _, item := range repeated {
+		if err := a.CreateArticle(events.WithCommandID(c, item.CommandID), item.Command); err != nil && !errors.Is(err, domain.ErrCommandRejected) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createArticles store the articles and their created events within one transaction, the rows with multi-row inserts,
+// then publish the events with a single producer call
+func (a articleUseCase) createArticles(ctx context.Context, commands []domain.CreateArticleCommand) ([]events.ArticleCreated, error) {
+	if len(commands) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	inserts := make([]domain.Article, 0, len(commands))
+	for _, command := range commands {
+		insert := command.ToArticle()
+		insert.CreatedAt = now
+		insert.UpdatedAt = now
+		inserts = append(inserts, insert)
+	}
+
+	createdEvents := make([]events.ArticleCreated, 0, len(commands))
+	articleEvents := make([]domain.ArticleEvent, 0, len(commands))
+	err := a.pgArticleRepository.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids, err := a.pgArticleRepository.StoreInBatchesWithTx(ctx, tx, inserts)
+		if err != nil {
+			return err
+		}
+
+		var snapshots []domain.ArticleSnapshot
+		for i, command := range commands {
+			id, insert := ids[i], inserts[i]
+			tags := events.NormalizeTags(command.Tags)
+			category := events.NormalizeCategory(command.Category)
+			if category != "" || len(tags) > 0 {
+				if err := a.pgArticleRepository.SaveTaxonomyWithTx(ctx, tx, id, category, tags); err != nil {
+					return err
+				}
+			}
+
+			createdEvent := events.ArticleCreated{
+				ID:        id,
+				Version:   insert.Version,
+				Status:    insert.Status,
+				Tags:      tags,
+				Category:  category,
+				Author:    insert.Author,
+				Title:     insert.Title,
+				Body:      insert.Body,
+				CreatedAt: insert.CreatedAt,
+				UpdatedAt: insert.UpdatedAt,
+			}
+			createdEvents = append(createdEvents, createdEvent)
+
+			aggregate := new(domain.ArticleAggregate)
+			event, err := domain.NewArticleEvent(id, aggregate.Version+1, events.ArticleCreatedType, createdEvent)
+			if err != nil {
+				return err
+			}
+			if err := aggregate.Apply(event); err != nil {
+				return err
+			}
+			articleEvents = append(articleEvents, event)
+
+			if domain.ShouldSnapshot(aggregate.Version, a.snapshotFrequency) {
+				snapshot, err := aggregate.Snapshot()
+				if err != nil {
+					return err
+				}
+				snapshots = append(snapshots, snapshot)
+			}
+		}
+
+		if err := a.eventStoreArticleRepository.AppendWithTx(ctx, tx, articleEvents...); err != nil {
+			return err
+		}
+		for _, snapshot := range snapshots {
+			if err := a.eventStoreArticleRepository.SaveSnapshotWithTx(ctx, tx, snapshot); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	a.publishEvents(ctx, func() error {
+		return a.messagingArticleRepository.PushMessageInsertArticles(ctx, createdEvents)
+	}, articleEvents...)
+
+	return createdEvents, nil
+}
+
+func (a articleUseCase) UpdateArticle(c context.Context, command domain.UpdateArticleCommand) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if err := a.commandValidator.Validate(ctx, events.ArticleUpdateType, command.ID, command); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	aggregate, err := a.eventStoreArticleRepository.Load(ctx, command.ID)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+	if err := a.checkVersion(ctx, events.ArticleUpdateType, aggregate, command.ExpectedVersion); err != nil {
+		return err
+	}
+
+	changedFields := command.ChangedFields(aggregate)
+	if len(changedFields) == 0 {
+		a.zapLogger.Infof("article %d update by %s changes nothing", command.ID, command.ChangedBy)
+		return nil
+	}
+	if command.Title != "" && command.Title != aggregate.Title {
+		title := domain.ArticleTitle{ID: aggregate.ID, Author: aggregate.Author, Title: command.Title}
+		if err := a.commandValidator.Validate(ctx, events.ArticleUpdateType, command.ID, title); err != nil {
+			a.zapLogger.SetMessageLog(err)
+			return err
+		}
+	}
+
+	updatedEvent := events.ArticleUpdated{
+		ID:            aggregate.ID,
+		Version:       aggregate.Version + 1,
+		ChangedBy:     command.ChangedBy,
+		ChangedFields: changedFields,
+		Status:        aggregate.Status,
+		PublishAt:     aggregate.PublishAt,
+		PublishedAt:   aggregate.PublishedAt,
+		Tags:          aggregate.Tags,
+		Category:      aggregate.Category,
+		Author:        aggregate.Author,
+		Title:         aggregate.Title,
+		Body:          aggregate.Body,
+		CreatedAt:     aggregate.CreatedAt,
+		UpdatedAt:     time.Now().UTC(),
+	}
+	if command.Title != "" {
+		updatedEvent.Title = command.Title
+	}
+	if command.Body != "" {
+		updatedEvent.Body = command.Body
+	}
+	if category := events.NormalizeCategory(command.Category); category != "" {
+		updatedEvent.Category = category
+	}
+
+	articleEvent, err := a.commitEvent(ctx, aggregate, events.ArticleUpdatedType, updatedEvent)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return a.rejectTitleTaken(err, func() error {
+			title := domain.ArticleTitle{ID: aggregate.ID, Author: aggregate.Author, Title: command.Title}
+			return a.commandValidator.Validate(ctx, events.ArticleUpdateType, command.ID, title)
+		})
+	}
+
+	a.publishEvents(ctx, func() error {
+		return a.messagingArticleRepository.PushMessageUpdateArticle(ctx, updatedEvent)
+	}, articleEvent)
+
+	return nil
+}
+
+func (a articleUseCase) SubmitForReview(c context.Context, command domain.TransitionArticleCommand) error {
+	command.Action = events.ArticleActionSubmitForReview
+	return a.transition(c, command)
+}
+
+// Publish publish the article right away, or schedule its publication when command.PublishAt is in the future
+func (a articleUseCase) Publish(c context.Context, command domain.TransitionArticleCommand) error {
+	command.Action = events.ArticleActionPublish
+	return a.transition(c, command)
+}
+
+func (a articleUseCase) Unpublish(c context.Context, command domain.TransitionArticleCommand) error {
+	command.Action = events.ArticleActionUnpublish
+	return a.transition(c, command)
+}
+
+func (a articleUseCase) Archive(c context.Context, command domain.TransitionArticleCommand) error {
+	command.Action = events.ArticleActionArchive
+	return a.transition(c, command)
+}
+
+func (a articleUseCase) AddTags(c context.Context, command domain.TagArticleCommand) error {
+	command.Action = events.ArticleTagActionAdd
+	return a.tag(c, command)
+}
+
+func (a articleUseCase) RemoveTags(c context.Context, command domain.TagArticleCommand) error {
+	command.Action = events.ArticleTagActionRemove
+	return a.tag(c, command)
+}
+
+// tag apply the tag command, commands adding tags the article already has or removing tags it does not have are ignored
+func (a articleUseCase) tag(c context.Context, command domain.TagArticleCommand) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	aggregate, err := a.eventStoreArticleRepository.Load(ctx, command.ID)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+	if err := a.checkVersion(ctx, events.ArticleTagType, aggregate, command.ExpectedVersion); err != nil {
+		return err
+	}
+
+	tags, added, removed := command.ApplyTo(aggregate.Tags)
+	if len(added) == 0 && len(removed) == 0 {
+		a.zapLogger.Infof("article %d %s tags by %s changes nothing", command.ID, command.Action, command.ChangedBy)
+		return nil
+	}
+
+	changedEvent := events.ArticleTagsChanged{
+		ID:          aggregate.ID,
+		Version:     aggregate.Version + 1,
+		ChangedBy:   command.ChangedBy,
+		Added:       added,
+		Removed:     removed,
+		Status:      aggregate.Status,
+		PublishAt:   aggregate.PublishAt,
+		PublishedAt: aggregate.PublishedAt,
+		Tags:        tags,
+		Category:    aggregate.Category,
+		Author:      aggregate.Author,
+		Title:       aggregate.Title,
+		Body:        aggregate.Body,
+		CreatedAt:   aggregate.CreatedAt,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	articleEvent, err := a.commitEvent(ctx, aggregate, events.ArticleTagsChangedType, changedEvent)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	a.publishEvents(ctx, func() error {
+		return a.messagingArticleRepository.PushMessageTagsChanged(ctx, changedEvent)
+	}, articleEvent)
+
+	return nil
+}
+
+// PublishDue publish the articles whose scheduled publication is due at now, failures are logged and retried on the next call.
+// Concurrent schedulers publishing the same article are rejected by the event store.
+// The articles of every tenant are published, each one within its tenant.
+func (a articleUseCase) PublishDue(c context.Context, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	articles, err := a.pgArticleRepository.FetchScheduled(ctx, now, publishDueBatchSize)
+	cancel()
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return 0, err
+	}
+
+	published := 0
+	for _, article := range articles {
+		if err := a.publishDue(tenant.WithID(c, article.TenantID), article.ID, now); err != nil {
+			a.zapLogger.Warnf("publish scheduled article %d of tenant %s: %v", article.ID, article.TenantID, err)
+			continue
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+func (a articleUseCase) RelayUnpublished(c context.Context, before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	articleEvents, err := a.eventStoreArticleRepository.FetchUnpublished(ctx, before, relayBatchSize)
+	cancel()
 	if err != nil {
 		a.zapLogger.SetMessageLog(err)
+		return 0, err
+	}
+
+	published := 0
+	// the later events of an aggregate wait for the next run once one of its events fails, so they are not published out of order
+	failed := make(map[int]bool)
+	for _, event := range articleEvents {
+		if failed[event.AggregateID] {
+			continue
+		}
+		if err := a.relayEvent(tenant.WithID(c, event.TenantID), event); err != nil {
+			a.zapLogger.Warnf("relay event %d of article %d of tenant %s: %v", event.AggregateVersion, event.AggregateID, event.TenantID, err)
+			failed[event.AggregateID] = true
+			continue
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+func (a articleUseCase) relayEvent(c context.Context, event domain.ArticleEvent) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if err := a.messagingArticleRepository.PushMessageEvent(ctx, event); err != nil {
 		return err
 	}
+	return a.eventStoreArticleRepository.MarkPublished(ctx, event)
+}
+
+func (a articleUseCase) publishDue(c context.Context, id int, now time.Time) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
 
-	createdCommand := domain.CreatedArticleCommand{
-		ID:        insert.ID,
-		Author:    insert.Author,
-		Title:     insert.Title,
-		Body:      insert.Body,
-		CreatedAt: insert.CreatedAt,
-		UpdatedAt: insert.UpdatedAt,
+	aggregate, err := a.eventStoreArticleRepository.Load(ctx, id)
+	if err != nil {
+		return err
 	}
+	// the schedule changed since the article was fetched
+	if aggregate.PublishAt == nil || aggregate.PublishAt.After(now) {
+		return nil
+	}
+
+	return a.publish(ctx, aggregate, schedulerChangedBy, now.UTC())
+}
 
-	msg, err := json.Marshal(createdCommand)
+func (a articleUseCase) transition(c context.Context, command domain.TransitionArticleCommand) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	aggregate, err := a.eventStoreArticleRepository.Load(ctx, command.ID)
 	if err != nil {
 		a.zapLogger.SetMessageLog(err)
 		return err
 	}
+	if err := a.checkVersion(ctx, events.ArticleTransitionType, aggregate, command.ExpectedVersion); err != nil {
+		return err
+	}
 
-	err = a.messagingArticleRepository.PushMessageInsertArticle(ctx, msg)
+	now := time.Now().UTC()
+	if command.Action == events.ArticleActionPublish && (command.PublishAt == nil || !command.PublishAt.After(now)) {
+		err = a.publish(ctx, aggregate, command.ChangedBy, now)
+	} else {
+		err = a.changeStatus(ctx, aggregate, command, now)
+	}
 	if err != nil {
 		a.zapLogger.SetMessageLog(err)
 		return err
@@ -61,3 +495,213 @@ func (a articleUseCase) CreateArticle(c context.Context, command domain.CreateAr
 
 	return nil
 }
+
+func (a articleUseCase) publish(ctx context.Context, aggregate *domain.ArticleAggregate, changedBy string, now time.Time) error {
+	if _, err := domain.NextStatus(aggregate.Status, events.ArticleActionPublish); err != nil {
+		return err
+	}
+
+	publishedEvent := events.ArticlePublished{
+		ID:             aggregate.ID,
+		Version:        aggregate.Version + 1,
+		ChangedBy:      changedBy,
+		PreviousStatus: aggregate.Status,
+		PublishedAt:    now,
+		Tags:           aggregate.Tags,
+		Category:       aggregate.Category,
+		Author:         aggregate.Author,
+		Title:          aggregate.Title,
+		Body:           aggregate.Body,
+		CreatedAt:      aggregate.CreatedAt,
+		UpdatedAt:      now,
+	}
+	articleEvent, err := a.commitEvent(ctx, aggregate, events.ArticlePublishedType, publishedEvent)
+	if err != nil {
+		return err
+	}
+
+	a.publishEvents(ctx, func() error {
+		return a.messagingArticleRepository.PushMessagePublished(ctx, publishedEvent)
+	}, articleEvent)
+	return nil
+}
+
+// changeStatus apply every transition but an immediate publication, a publish scheduled in the future keeps the status
+func (a articleUseCase) changeStatus(ctx context.Context, aggregate *domain.ArticleAggregate, command domain.TransitionArticleCommand, now time.Time) error {
+	status, err := domain.NextStatus(aggregate.Status, command.Action)
+	if err != nil {
+		return err
+	}
+
+	changedEvent := events.ArticleStatusChanged{
+		ID:             aggregate.ID,
+		Version:        aggregate.Version + 1,
+		ChangedBy:      command.ChangedBy,
+		ChangedFields:  make([]string, 0, 2),
+		PreviousStatus: aggregate.Status,
+		Status:         status,
+		PublishAt:      aggregate.PublishAt,
+		PublishedAt:    aggregate.PublishedAt,
+		Tags:           aggregate.Tags,
+		Category:       aggregate.Category,
+		Author:         aggregate.Author,
+		Title:          aggregate.Title,
+		Body:           aggregate.Body,
+		CreatedAt:      aggregate.CreatedAt,
+		UpdatedAt:      now,
+	}
+	switch command.Action {
+	case events.ArticleActionPublish:
+		publishAt := command.PublishAt.UTC()
+		changedEvent.Status = aggregate.Status
+		changedEvent.PublishAt = &publishAt
+	case events.ArticleActionUnpublish, events.ArticleActionArchive:
+		// the pending publication is cancelled
+		changedEvent.PublishAt = nil
+	}
+	if changedEvent.Status != aggregate.Status {
+		changedEvent.ChangedFields = append(changedEvent.ChangedFields, "status")
+	}
+	if !equalTime(changedEvent.PublishAt, aggregate.PublishAt) {
+		changedEvent.ChangedFields = append(changedEvent.ChangedFields, "publish_at")
+	}
+
+	articleEvent, err := a.commitEvent(ctx, aggregate, events.ArticleStatusChangedType, changedEvent)
+	if err != nil {
+		return err
+	}
+
+	a.publishEvents(ctx, func() error {
+		return a.messagingArticleRepository.PushMessageStatusChanged(ctx, changedEvent)
+	}, articleEvent)
+	return nil
+}
+
+// checkVersion reject the command of commandType once the article moved past the version it expects,
+// a concurrent change committed after the check is rejected by the event store
+func (a articleUseCase) checkVersion(ctx context.Context, commandType string, aggregate *domain.ArticleAggregate, expectedVersion int) error {
+	version := domain.ArticleVersion{Version: aggregate.Version, ExpectedVersion: expectedVersion}
+	if err := a.commandValidator.Validate(ctx, commandType, aggregate.ID, version); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+	return nil
+}
+
+// commitEvent append the event and persist the article state it leads to in the same transaction,
+// a concurrent change of the same version is rejected by the event store
+func (a articleUseCase) commitEvent(ctx context.Context, aggregate *domain.ArticleAggregate, eventType string, payload interface{}) (domain.ArticleEvent, error) {
+	category, tags := aggregate.Category, aggregate.Tags
+	var articleEvent domain.ArticleEvent
+	err := a.pgArticleRepository.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event, err := a.appendEventWithTx(ctx, tx, aggregate, aggregate.ID, eventType, payload)
+		if err != nil {
+			return err
+		}
+		articleEvent = event
+		fields, values := aggregate.ArticleFields()
+		if err := a.pgArticleRepository.UpdateSelectedFieldWithTx(ctx, tx, fields, values, aggregate.ID); err != nil {
+			return err
+		}
+		if aggregate.Category == category && equalStrings(aggregate.Tags, tags) {
+			return nil
+		}
+		return a.pgArticleRepository.SaveTaxonomyWithTx(ctx, tx, aggregate.ID, aggregate.Category, aggregate.Tags)
+	})
+	if err != nil {
+		return domain.ArticleEvent{}, err
+	}
+	return articleEvent, nil
+}
+
+// rejectTitleTaken turn the title a concurrent command took between the rule check and the write into
+// the rejection of the command, reject checks the rule again and sees the article committed first.
+// Other errors and a title released meanwhile are returned as they are
+func (a articleUseCase) rejectTitleTaken(err error, reject func() error) error {
+	if !errors.Is(err, domain.ErrArticleTitleTaken) {
+		return err
+	}
+	if rejectErr := reject(); rejectErr != nil {
+		return rejectErr
+	}
+	return err
+}
+
+// publishEvents push the committed events and mark them published in the event store,
+// the events left unpublished by a failed push are published again by the outbox relay
+func (a articleUseCase) publishEvents(ctx context.Context, push func() error, articleEvents ...domain.ArticleEvent) {
+	if err := push(); err != nil {
+		a.zapLogger.Warnf("publish events, left to the outbox relay: %v", err)
+		return
+	}
+	if err := a.eventStoreArticleRepository.MarkPublished(ctx, articleEvents...); err != nil {
+		a.zapLogger.Warnf("mark events published, the outbox relay publishes them again: %v", err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// appendEventWithTx apply the event to the aggregate, append it to the event store
+// and take a snapshot when one is due
+func (a articleUseCase) appendEventWithTx(ctx context.Context, tx *gorm.DB, aggregate *domain.ArticleAggregate, aggregateID int, eventType string, payload interface{}) (domain.ArticleEvent, error) {
+	event, err := domain.NewArticleEvent(aggregateID, aggregate.Version+1, eventType, payload)
+	if err != nil {
+		return domain.ArticleEvent{}, err
+	}
+	if err := aggregate.Apply(event); err != nil {
+		return domain.ArticleEvent{}, err
+	}
+	if err := a.eventStoreArticleRepository.AppendWithTx(ctx, tx, event); err != nil {
+		return domain.ArticleEvent{}, err
+	}
+
+	if !domain.ShouldSnapshot(aggregate.Version, a.snapshotFrequency) {
+		return event, nil
+	}
+	snapshot, err := aggregate.Snapshot()
+	if err != nil {
+		return domain.ArticleEvent{}, err
+	}
+	return event, a.eventStoreArticleRepository.SaveSnapshotWithTx(ctx, tx, snapshot)
+}
+
+// RebuildSnapshots replay the history of the given aggregates, or of every aggregate when none is given,
+// and replace their snapshots. No execution timeout is applied as it is meant to run as a maintenance task.
+func (a articleUseCase) RebuildSnapshots(c context.Context, aggregateIDs []int) error {
+	if len(aggregateIDs) == 0 {
+		ids, err := a.eventStoreArticleRepository.AggregateIDs(c)
+		if err != nil {
+			a.zapLogger.SetMessageLog(err)
+			return err
+		}
+		aggregateIDs = ids
+	}
+
+	for _, id := range aggregateIDs {
+		written, err := a.eventStoreArticleRepository.RebuildSnapshots(c, id, a.snapshotFrequency)
+		if err != nil {
+			a.zapLogger.SetMessageLog(err)
+			return err
+		}
+		a.zapLogger.Infof("rebuilt %d snapshots of article %d", written, id)
+	}
+
+	return nil
+}
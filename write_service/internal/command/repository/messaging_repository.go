@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+)
+
+type messagingCommandRepository struct {
+	zapLogger zaplogger.Logger
+	producer  kafkaClient.Producer
+	cfg       *config.Config
+}
+
+func NewMessagingCommandRepository(producer kafkaClient.Producer, cfg *config.Config, zapLogger zaplogger.Logger) domain.MessagingCommandRepository {
+	return &messagingCommandRepository{
+		producer:  producer,
+		zapLogger: zapLogger,
+		cfg:       cfg,
+	}
+}
+
+func (m messagingCommandRepository) PushMessageCommandRejected(ctx context.Context, event events.CommandRejected) error {
+	codec, err := kafkaClient.CodecFor(m.cfg.Kafka.ContentType)
+	if err != nil {
+		return err
+	}
+
+	msg, err := events.Default.EncodeMessage(codec, m.cfg.KafkaTopics.CommandRejected.TopicName, events.CommandRejectedType, event)
+	if err != nil {
+		return err
+	}
+
+	return m.producer.PublishMessage(ctx, msg)
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	validatorGo "github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/validator"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+)
+
+// rejectionLanguages languages the reasons of a rejection are given in
+var rejectionLanguages = []string{"en", "id"}
+
+type commandValidator struct {
+	zapLogger                  zaplogger.Logger
+	messagingCommandRepository domain.MessagingCommandRepository
+}
+
+func NewCommandValidator(messagingCommandRepository domain.MessagingCommandRepository, zapLogger zaplogger.Logger) domain.CommandValidator {
+	return &commandValidator{
+		zapLogger:                  zapLogger,
+		messagingCommandRepository: messagingCommandRepository,
+	}
+}
+
+// Check check the validate tags of command within the tenant of ctx, the reason of every broken rule
+// is given in every language of rejectionLanguages
+func (v commandValidator) Check(ctx context.Context, command interface{}) error {
+	err := validator.Validate.ValidateStructCtx(ctx, command)
+	if err == nil {
+		return nil
+	}
+	var fieldErrors validatorGo.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	rejected := &domain.CommandRejectedError{Reasons: make([]events.RejectionReason, 0, len(fieldErrors))}
+	for _, fieldError := range fieldErrors {
+		messages := make(map[string]string, len(rejectionLanguages))
+		for _, lang := range rejectionLanguages {
+			if trans, found := validator.Validate.GetTranslator(lang); found {
+				messages[lang] = fieldError.Translate(trans)
+			}
+		}
+		rejected.Reasons = append(rejected.Reasons, events.RejectionReason{
+			Field:    fieldError.Field(),
+			Rule:     fieldError.Tag(),
+			Messages: messages,
+		})
+	}
+	return rejected
+}
+
+// Validate the broken rules are published as a command.rejected event
+func (v commandValidator) Validate(ctx context.Context, commandType string, aggregateID int, command interface{}) error {
+	err := v.Check(ctx, command)
+	var rejected *domain.CommandRejectedError
+	if !errors.As(err, &rejected) {
+		return err
+	}
+
+	err = v.messagingCommandRepository.PushMessageCommandRejected(ctx, events.CommandRejected{
+		CommandID:   events.CommandIDFromContext(ctx),
+		CommandType: commandType,
+		AggregateID: aggregateID,
+		Reasons:     rejected.Reasons,
+		RejectedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		v.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	return rejected
+}
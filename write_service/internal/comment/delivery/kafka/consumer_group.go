@@ -0,0 +1,142 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+)
+
+const (
+	retryAttempts = 3
+	retryDelay    = 300 * time.Millisecond
+	PoolSize      = 10
+)
+
+var (
+	retryOptions = []retry.Option{retry.Attempts(retryAttempts), retry.Delay(retryDelay), retry.DelayType(retry.BackOffDelay)}
+)
+
+type commentConsumer struct {
+	zapLogger zaplogger.Logger
+	useCase   domain.CommentUseCase
+	cfg       *config.Config
+}
+
+func NewCommentConsumer(useCase domain.CommentUseCase, cfg *config.Config, zapLogger zaplogger.Logger) *commentConsumer {
+	return &commentConsumer{
+		zapLogger: zapLogger,
+		useCase:   useCase,
+		cfg:       cfg,
+	}
+}
+
+func (s *commentConsumer) ProcessMessages(ctx context.Context, r *kafka.Reader, wg *sync.WaitGroup, workerID int) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			s.zapLogger.Warnf("workerID: %v, err: %v", workerID, err)
+			continue
+		}
+
+		s.logProcessMessage(m, workerID)
+		// the message is processed within the tenant it was published for
+		msgCtx := tenant.ContextFromMessage(ctx, m)
+
+		switch m.Topic {
+		case s.cfg.KafkaTopics.CommentCreate.TopicName:
+			var command domain.CreateCommentCommand
+			s.processCommand(msgCtx, r, m, events.CommentCreateType, &command, "CommentUseCase.CreateComment", func(ctx context.Context) error {
+				return s.useCase.CreateComment(ctx, command)
+			})
+		case s.cfg.KafkaTopics.CommentEdit.TopicName:
+			var command domain.EditCommentCommand
+			s.processCommand(msgCtx, r, m, events.CommentEditType, &command, "CommentUseCase.EditComment", func(ctx context.Context) error {
+				return s.useCase.EditComment(ctx, command)
+			})
+		case s.cfg.KafkaTopics.CommentDelete.TopicName:
+			var command domain.DeleteCommentCommand
+			s.processCommand(msgCtx, r, m, events.CommentDeleteType, &command, "CommentUseCase.DeleteComment", func(ctx context.Context) error {
+				return s.useCase.DeleteComment(ctx, command)
+			})
+		case s.cfg.KafkaTopics.CommentModerate.TopicName:
+			var command domain.ModerateCommentCommand
+			s.processCommand(msgCtx, r, m, events.CommentModerateType, &command, "CommentUseCase.ModerateComment", func(ctx context.Context) error {
+				return s.useCase.ModerateComment(ctx, command)
+			})
+		}
+	}
+}
+
+// processCommand decode m of eventType into command and run handle, commands on unknown articles or comments,
+// on deleted comments and commands rejected by the validation are dropped, version conflicts are retried on the reloaded comment
+func (s *commentConsumer) processCommand(ctx context.Context, r *kafka.Reader, m kafka.Message, eventType string, command interface{}, name string, handle func(ctx context.Context) error) {
+
+	envelope, err := events.Default.DecodeMessage(eventType, m)
+	if err != nil {
+		s.zapLogger.WarnMsg("events.DecodeMessage", err)
+		s.commitErrMessage(ctx, r, m)
+		return
+	}
+
+	if err := envelope.Decode(command); err != nil {
+		s.zapLogger.WarnMsg("envelope.Decode", err)
+		s.commitErrMessage(ctx, r, m)
+		return
+	}
+
+	// rejections refer to the envelope of the command
+	commandCtx := events.WithCommandID(ctx, envelope.ID)
+	if err := retry.Do(func() error {
+		return handle(commandCtx)
+	}, append(retryOptions, retry.Context(ctx), retry.LastErrorOnly(true), retry.RetryIf(func(err error) bool {
+		return !isDropped(err)
+	}))...); err != nil {
+		s.zapLogger.WarnMsg(name, err)
+		if isDropped(err) {
+			s.commitErrMessage(ctx, r, m)
+		}
+		return
+	}
+
+	s.commitMessage(ctx, r, m)
+}
+
+func isDropped(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrCommentDeleted) || errors.Is(err, domain.ErrCommandRejected)
+}
+
+func (s *commentConsumer) logProcessMessage(m kafka.Message, workerID int) {
+	s.zapLogger.KafkaProcessMessage(m.Topic, m.Partition, string(m.Value), workerID, m.Offset, m.Time)
+}
+
+func (s *commentConsumer) commitMessage(ctx context.Context, r *kafka.Reader, m kafka.Message) {
+	s.zapLogger.KafkaLogCommittedMessage(m.Topic, m.Partition, m.Offset)
+	if err := r.CommitMessages(ctx, m); err != nil {
+		s.zapLogger.WarnMsg("commitMessage", err)
+	}
+}
+
+func (s *commentConsumer) commitErrMessage(ctx context.Context, r *kafka.Reader, m kafka.Message) {
+	s.zapLogger.KafkaLogCommittedMessage(m.Topic, m.Partition, m.Offset)
+	if err := r.CommitMessages(ctx, m); err != nil {
+		s.zapLogger.WarnMsg("commitMessage", err)
+	}
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+)
+
+type messagingCommentRepository struct {
+	zapLogger zaplogger.Logger
+	producer  kafkaClient.Producer
+	cfg       *config.Config
+}
+
+func NewMessagingCommentRepository(producer kafkaClient.Producer, cfg *config.Config, zapLogger zaplogger.Logger) domain.MessagingCommentRepository {
+	return &messagingCommentRepository{
+		producer:  producer,
+		zapLogger: zapLogger,
+		cfg:       cfg,
+	}
+}
+
+func (m messagingCommentRepository) PushMessageCommentCreated(ctx context.Context, event events.CommentCreated) error {
+	return m.push(ctx, m.cfg.KafkaTopics.CommentCreated.TopicName, events.CommentCreatedType, event)
+}
+
+func (m messagingCommentRepository) PushMessageCommentEdited(ctx context.Context, event events.CommentEdited) error {
+	return m.push(ctx, m.cfg.KafkaTopics.CommentEdited.TopicName, events.CommentEditedType, event)
+}
+
+func (m messagingCommentRepository) PushMessageCommentDeleted(ctx context.Context, event events.CommentDeleted) error {
+	return m.push(ctx, m.cfg.KafkaTopics.CommentDeleted.TopicName, events.CommentDeletedType, event)
+}
+
+func (m messagingCommentRepository) PushMessageCommentModerated(ctx context.Context, event events.CommentModerated) error {
+	return m.push(ctx, m.cfg.KafkaTopics.CommentModerated.TopicName, events.CommentModeratedType, event)
+}
+
+func (m messagingCommentRepository) push(ctx context.Context, topic string, eventType string, event interface{}) error {
+	codec, err := kafkaClient.CodecFor(m.cfg.Kafka.ContentType)
+	if err != nil {
+		return err
+	}
+
+	msg, err := events.Default.EncodeMessage(codec, topic, eventType, event)
+	if err != nil {
+		return err
+	}
+
+	return m.producer.PublishMessage(ctx, msg)
+}
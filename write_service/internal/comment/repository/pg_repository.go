@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type pgCommentRepository struct {
+	zapLogger zaplogger.Logger
+	db        *gorm.DB
+}
+
+func NewPgCommentRepository(db *gorm.DB, zapLogger zaplogger.Logger) domain.PgCommentRepository {
+	return &pgCommentRepository{
+		db:        db,
+		zapLogger: zapLogger,
+	}
+}
+
+func (c pgCommentRepository) DB() *gorm.DB {
+	return c.db
+}
+
+// Store the comment belongs to the tenant of ctx, the article must belong to it too
+func (c pgCommentRepository) Store(ctx context.Context, data domain.Comment) (domain.Comment, error) {
+	data.TenantID = tenant.FromContext(ctx)
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var articles int64
+		if err := tx.Model(&domain.Article{}).Scopes(domain.TenantScope(ctx)).Where("id = ?", data.ArticleID).Count(&articles).Error; err != nil {
+			return err
+		}
+		if articles == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(&data).Error
+	})
+	if err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+func (c pgCommentRepository) GetById(ctx context.Context, id int) (*domain.Comment, error) {
+	var comment domain.Comment
+	if err := c.db.WithContext(ctx).Scopes(domain.TenantScope(ctx)).First(&comment, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (c pgCommentRepository) UpdateVersion(ctx context.Context, comment domain.Comment, version int) error {
+	result := c.db.WithContext(ctx).Model(&domain.Comment{}).Scopes(domain.TenantScope(ctx)).
+		Where("id = ? AND version = ?", comment.ID, version).
+		Updates(map[string]interface{}{
+			"version":    comment.Version,
+			"status":     comment.Status,
+			"body":       comment.Body,
+			"updated_at": comment.UpdatedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrCommentVersionConflict
+	}
+	return nil
+}
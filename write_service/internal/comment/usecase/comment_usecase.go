@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	"gorm.io/gorm"
+)
+
+type commentUseCase struct {
+	zapLogger                  zaplogger.Logger
+	contextTimeout             time.Duration
+	pgCommentRepository        domain.PgCommentRepository
+	messagingCommentRepository domain.MessagingCommentRepository
+	commandValidator           domain.CommandValidator
+}
+
+func NewCommentUseCase(timeout time.Duration,
+	pgCommentRepository domain.PgCommentRepository,
+	messagingCommentRepository domain.MessagingCommentRepository,
+	commandValidator domain.CommandValidator,
+	zapLogger zaplogger.Logger) domain.CommentUseCase {
+	return &commentUseCase{
+		contextTimeout:             timeout,
+		zapLogger:                  zapLogger,
+		pgCommentRepository:        pgCommentRepository,
+		messagingCommentRepository: messagingCommentRepository,
+		commandValidator:           commandValidator,
+	}
+}
+
+func (a commentUseCase) CreateComment(c context.Context, command domain.CreateCommentCommand) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if err := a.commandValidator.Validate(ctx, events.CommentCreateType, 0, command); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	now := time.Now().UTC()
+	insert := command.ToComment()
+	insert.CreatedAt = now
+	insert.UpdatedAt = now
+
+	comment, err := a.pgCommentRepository.Store(ctx, insert)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	err = a.messagingCommentRepository.PushMessageCommentCreated(ctx, events.CommentCreated{
+		ID:        comment.ID,
+		ArticleID: comment.ArticleID,
+		Version:   comment.Version,
+		Status:    comment.Status,
+		Author:    comment.Author,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	})
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	return nil
+}
+
+// EditComment replace the body of the comment, edits keeping the same body are ignored
+func (a commentUseCase) EditComment(c context.Context, command domain.EditCommentCommand) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if err := a.commandValidator.Validate(ctx, events.CommentEditType, command.ID, command); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	comment, err := a.load(ctx, command.ID, command.ArticleID)
+	if err != nil {
+		return err
+	}
+	if comment.Body == command.Body {
+		a.zapLogger.Infof("comment %d edit by %s changes nothing", command.ID, command.ChangedBy)
+		return nil
+	}
+
+	version := comment.Version
+	comment.Version++
+	comment.Body = command.Body
+	comment.UpdatedAt = time.Now().UTC()
+	if err := a.pgCommentRepository.UpdateVersion(ctx, *comment, version); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	err = a.messagingCommentRepository.PushMessageCommentEdited(ctx, events.CommentEdited{
+		ID:        comment.ID,
+		ArticleID: comment.ArticleID,
+		Version:   comment.Version,
+		ChangedBy: command.ChangedBy,
+		Status:    comment.Status,
+		Author:    comment.Author,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	})
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteComment mark the comment deleted and erase its body, the comment is kept so its id is never reused
+func (a commentUseCase) DeleteComment(c context.Context, command domain.DeleteCommentCommand) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	comment, err := a.load(ctx, command.ID, command.ArticleID)
+	if err != nil {
+		return err
+	}
+
+	version, previousStatus := comment.Version, comment.Status
+	comment.Version++
+	comment.Status = events.CommentStatusDeleted
+	comment.Body = ""
+	comment.UpdatedAt = time.Now().UTC()
+	if err := a.pgCommentRepository.UpdateVersion(ctx, *comment, version); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	err = a.messagingCommentRepository.PushMessageCommentDeleted(ctx, events.CommentDeleted{
+		ID:             comment.ID,
+		ArticleID:      comment.ArticleID,
+		Version:        comment.Version,
+		ChangedBy:      command.ChangedBy,
+		PreviousStatus: previousStatus,
+		Status:         comment.Status,
+		Author:         comment.Author,
+		Body:           comment.Body,
+		CreatedAt:      comment.CreatedAt,
+		UpdatedAt:      comment.UpdatedAt,
+	})
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	return nil
+}
+
+// ModerateComment hide the comment or make it visible again, commands keeping the status are ignored
+func (a commentUseCase) ModerateComment(c context.Context, command domain.ModerateCommentCommand) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	comment, err := a.load(ctx, command.ID, command.ArticleID)
+	if err != nil {
+		return err
+	}
+	if comment.Status == command.Status {
+		a.zapLogger.Infof("comment %d moderation by %s changes nothing", command.ID, command.ChangedBy)
+		return nil
+	}
+
+	version, previousStatus := comment.Version, comment.Status
+	comment.Version++
+	comment.Status = command.Status
+	comment.UpdatedAt = time.Now().UTC()
+	if err := a.pgCommentRepository.UpdateVersion(ctx, *comment, version); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	err = a.messagingCommentRepository.PushMessageCommentModerated(ctx, events.CommentModerated{
+		ID:             comment.ID,
+		ArticleID:      comment.ArticleID,
+		Version:        comment.Version,
+		ChangedBy:      command.ChangedBy,
+		PreviousStatus: previousStatus,
+		Status:         comment.Status,
+		Reason:         command.Reason,
+		Author:         comment.Author,
+		Body:           comment.Body,
+		CreatedAt:      comment.CreatedAt,
+		UpdatedAt:      comment.UpdatedAt,
+	})
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	return nil
+}
+
+// load comment the command applies to, domain.ErrCommentDeleted when it was deleted and
+// gorm.ErrRecordNotFound when it is not a comment of the article
+func (a commentUseCase) load(ctx context.Context, id int, articleID int) (*domain.Comment, error) {
+	comment, err := a.pgCommentRepository.GetById(ctx, id)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+	if comment.ArticleID != articleID {
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "comment %d of article %d", id, articleID)
+	}
+	if comment.Status == events.CommentStatusDeleted {
+		return nil, errors.Wrapf(domain.ErrCommentDeleted, "comment %d", id)
+	}
+	return comment, nil
+}
@@ -2,20 +2,32 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/database/paginator"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
 	"gorm.io/gorm"
 )
 
+// ErrArticleTitleTaken the author already has a live article of the title within the tenant,
+// raised by the unique index when a concurrent command wrote it after the rule was checked
+var ErrArticleTitleTaken = errors.New("article title taken by the author")
+
 type Article struct {
-	ID        int            `gorm:"column:id;primarykey;autoIncrement:true"`
-	Author    string         `gorm:"type:text;column:author"`
-	Title     string         `gorm:"type:text;column:title"`
-	Body      string         `gorm:"type:text;column:body"`
-	CreatedAt time.Time      `gorm:"column:created_at"`
-	UpdatedAt time.Time      `gorm:"column:updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID          int            `gorm:"column:id;primarykey;autoIncrement:true"`
+	TenantID    string         `gorm:"type:text;column:tenant_id;not null;default:default;index:idx_articles_tenant_id;uniqueIndex:idx_articles_tenant_author_title,priority:1,where:deleted_at IS NULL"`
+	Version     int            `gorm:"column:version;not null;default:1"`
+	Status      string         `gorm:"type:text;column:status;not null;default:draft"`
+	Author      string         `gorm:"type:text;column:author;uniqueIndex:idx_articles_tenant_author_title,priority:2,where:deleted_at IS NULL"`
+	Title       string         `gorm:"type:text;column:title;uniqueIndex:idx_articles_tenant_author_title,priority:3,where:deleted_at IS NULL"`
+	Body        string         `gorm:"type:text;column:body"`
+	PublishAt   *time.Time     `gorm:"column:publish_at"`
+	PublishedAt *time.Time     `gorm:"column:published_at"`
+	CategoryID  *int           `gorm:"column:category_id;index:idx_articles_category_id"`
+	CreatedAt   time.Time      `gorm:"column:created_at"`
+	UpdatedAt   time.Time      `gorm:"column:updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
 
 // TableName name of table
@@ -26,6 +38,26 @@ func (r *Article) TableName() string {
 // ArticleUseCase UseCase Interface
 type ArticleUseCase interface {
 	CreateArticle(c context.Context, command CreateArticleCommand) error
+	// CreateArticles CreateArticle of the commands of a tenant within a single transaction and producer call,
+	// the rejected commands are skipped
+	CreateArticles(c context.Context, items []CreateArticleBatchItem) error
+	// ValidateCreateArticle check command without persisting it, a CommandRejectedError holds the broken rules
+	ValidateCreateArticle(c context.Context, command CreateArticleCommand) error
+	// CreateArticleSync create the article right away, a CommandRejectedError holds the broken rules
+	CreateArticleSync(c context.Context, command CreateArticleCommand) (events.ArticleCreated, error)
+	UpdateArticle(c context.Context, command UpdateArticleCommand) error
+	SubmitForReview(c context.Context, command TransitionArticleCommand) error
+	Publish(c context.Context, command TransitionArticleCommand) error
+	Unpublish(c context.Context, command TransitionArticleCommand) error
+	Archive(c context.Context, command TransitionArticleCommand) error
+	AddTags(c context.Context, command TagArticleCommand) error
+	RemoveTags(c context.Context, command TagArticleCommand) error
+	// PublishDue publish the articles whose scheduled publication is due at now, returns the number published
+	PublishDue(c context.Context, now time.Time) (int, error)
+	// RelayUnpublished publish again the committed events that occurred before the given time and never reached the broker,
+	// returns the number published
+	RelayUnpublished(c context.Context, before time.Time) (int, error)
+	RebuildSnapshots(c context.Context, aggregateIDs []int) error
 }
 
 // PgArticleRepository Repository Interface
@@ -37,13 +69,28 @@ type PgArticleRepository interface {
 	UpdateSelectedFieldWithTx(ctx context.Context, tx *gorm.DB, field []string, values map[string]interface{}, id int) error
 	Store(ctx context.Context, data Article) (Article, error)
 	StoreWithTx(ctx context.Context, tx *gorm.DB, data Article) (int, error)
+	// StoreInBatchesWithTx insert the articles with multi-row inserts, returns their ids in order
+	StoreInBatchesWithTx(ctx context.Context, tx *gorm.DB, data []Article) ([]int, error)
 	Delete(ctx context.Context, id int) (int, error)
 	SoftDelete(ctx context.Context, id int) (int, error)
+	// FetchScheduled id and tenant of the articles of every tenant whose scheduled publication is due at due,
+	// oldest schedule first
+	FetchScheduled(ctx context.Context, due time.Time, limit int) ([]Article, error)
+	// SaveTaxonomyWithTx file the article under category, creating the missing categories of its path, and replace its tags
+	SaveTaxonomyWithTx(ctx context.Context, tx *gorm.DB, articleID int, category string, tags []string) error
 	DB() *gorm.DB
 	FetchWithFilterAndPagination(ctx context.Context, limit int, offset int, order string, fields, associate []string, model interface{}, args ...interface{}) (*paginator.Paginator, error)
 }
 
 // MessagingArticleRepository Repository Interface
 type MessagingArticleRepository interface {
-	PushMessageInsertArticle(ctx context.Context, msg []byte) error
+	PushMessageInsertArticle(ctx context.Context, event events.ArticleCreated) error
+	// PushMessageInsertArticles publish the events with a single producer call
+	PushMessageInsertArticles(ctx context.Context, createdEvents []events.ArticleCreated) error
+	PushMessageUpdateArticle(ctx context.Context, event events.ArticleUpdated) error
+	PushMessageStatusChanged(ctx context.Context, event events.ArticleStatusChanged) error
+	PushMessagePublished(ctx context.Context, event events.ArticlePublished) error
+	PushMessageTagsChanged(ctx context.Context, event events.ArticleTagsChanged) error
+	// PushMessageEvent publish a stored event again, upcast to its current version
+	PushMessageEvent(ctx context.Context, event ArticleEvent) error
 }
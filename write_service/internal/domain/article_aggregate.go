@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"gorm.io/gorm"
+)
+
+// ArticleAggregateSchemaVersion version of the ArticleAggregate state layout,
+// bump it whenever Apply changes so stale snapshots are ignored until they are rebuilt
+const ArticleAggregateSchemaVersion = 3
+
+var ErrUnhandledEvent = errors.New("event not handled by aggregate")
+
+// ArticleEvent event appended to the history of an article aggregate
+type ArticleEvent struct {
+	ID               int             `gorm:"column:id;primarykey;autoIncrement:true"`
+	TenantID         string          `gorm:"type:text;column:tenant_id;not null;default:default;index:idx_article_events_tenant_aggregate,priority:1"`
+	AggregateID      int             `gorm:"column:aggregate_id;not null;uniqueIndex:idx_article_events_aggregate_version;index:idx_article_events_tenant_aggregate,priority:2"`
+	AggregateVersion int             `gorm:"column:aggregate_version;not null;uniqueIndex:idx_article_events_aggregate_version"`
+	EventType        string          `gorm:"type:text;column:event_type;not null"`
+	EventVersion     int             `gorm:"column:event_version;not null"`
+	Payload          json.RawMessage `gorm:"type:jsonb;column:payload;not null"`
+	OccurredAt       time.Time       `gorm:"column:occurred_at;not null"`
+	// PublishedAt nil until the event reached the broker, the outbox relay publishes the events left behind
+	PublishedAt *time.Time `gorm:"column:published_at"`
+}
+
+// TableName name of table
+func (r *ArticleEvent) TableName() string {
+	return "article_events"
+}
+
+// ArticleSnapshot state of an article aggregate at a given version
+type ArticleSnapshot struct {
+	AggregateID   int             `gorm:"column:aggregate_id;primaryKey;autoIncrement:false"`
+	Version       int             `gorm:"column:version;primaryKey;autoIncrement:false"`
+	TenantID      string          `gorm:"type:text;column:tenant_id;not null;default:default"`
+	SchemaVersion int             `gorm:"column:schema_version;not null"`
+	State         json.RawMessage `gorm:"type:jsonb;column:state;not null"`
+	CreatedAt     time.Time       `gorm:"column:created_at"`
+}
+
+// TableName name of table
+func (r *ArticleSnapshot) TableName() string {
+	return "article_snapshots"
+}
+
+// ArticleAggregate state of an article rebuilt from its events
+type ArticleAggregate struct {
+	ID          int        `json:"id"`
+	Version     int        `json:"version"`
+	Status      string     `json:"status"`
+	Author      string     `json:"author"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	PublishAt   *time.Time `json:"publish_at"`
+	PublishedAt *time.Time `json:"published_at"`
+	Tags        []string   `json:"tags"`
+	Category    string     `json:"category"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// NewArticleEvent create the event of aggregateID at aggregateVersion for the current version of eventType
+func NewArticleEvent(aggregateID, aggregateVersion int, eventType string, payload interface{}) (ArticleEvent, error) {
+	envelope, err := events.Default.NewEnvelope(eventType, payload)
+	if err != nil {
+		return ArticleEvent{}, err
+	}
+
+	return ArticleEvent{
+		AggregateID:      aggregateID,
+		AggregateVersion: aggregateVersion,
+		EventType:        envelope.Type,
+		EventVersion:     envelope.Version,
+		Payload:          envelope.Payload,
+		OccurredAt:       envelope.OccurredAt,
+	}, nil
+}
+
+// Apply upcast the event to its current version and apply it to the aggregate state
+func (a *ArticleAggregate) Apply(event ArticleEvent) error {
+	envelope := &events.Envelope{
+		Type:       event.EventType,
+		Version:    event.EventVersion,
+		OccurredAt: event.OccurredAt,
+		Payload:    event.Payload,
+	}
+	if err := events.Default.Upcast(envelope); err != nil {
+		return err
+	}
+
+	switch envelope.Type {
+	case events.ArticleCreatedType:
+		var created events.ArticleCreated
+		if err := envelope.Decode(&created); err != nil {
+			return errors.Wrap(err, "envelope.Decode")
+		}
+		a.ID = created.ID
+		a.Status = created.Status
+		a.Tags = created.Tags
+		a.Category = created.Category
+		a.Author = created.Author
+		a.Title = created.Title
+		a.Body = created.Body
+		a.CreatedAt = created.CreatedAt
+		a.UpdatedAt = created.UpdatedAt
+	case events.ArticleUpdatedType:
+		var updated events.ArticleUpdated
+		if err := envelope.Decode(&updated); err != nil {
+			return errors.Wrap(err, "envelope.Decode")
+		}
+		a.Title = updated.Title
+		a.Body = updated.Body
+		a.Category = updated.Category
+		a.UpdatedAt = updated.UpdatedAt
+	case events.ArticleStatusChangedType:
+		var changed events.ArticleStatusChanged
+		if err := envelope.Decode(&changed); err != nil {
+			return errors.Wrap(err, "envelope.Decode")
+		}
+		a.Status = changed.Status
+		a.PublishAt = changed.PublishAt
+		a.UpdatedAt = changed.UpdatedAt
+	case events.ArticlePublishedType:
+		var published events.ArticlePublished
+		if err := envelope.Decode(&published); err != nil {
+			return errors.Wrap(err, "envelope.Decode")
+		}
+		a.Status = events.ArticleStatusPublished
+		a.PublishAt = nil
+		a.PublishedAt = &published.PublishedAt
+		a.UpdatedAt = published.UpdatedAt
+	case events.ArticleTagsChangedType:
+		var changed events.ArticleTagsChanged
+		if err := envelope.Decode(&changed); err != nil {
+			return errors.Wrap(err, "envelope.Decode")
+		}
+		a.Tags = changed.Tags
+		a.UpdatedAt = changed.UpdatedAt
+	default:
+		return errors.Wrap(ErrUnhandledEvent, envelope.Type)
+	}
+
+	a.Version = event.AggregateVersion
+	return nil
+}
+
+// ArticleFields columns of the articles table holding the aggregate state, and their values
+func (a *ArticleAggregate) ArticleFields() ([]string, map[string]interface{}) {
+	values := map[string]interface{}{
+		"version":      a.Version,
+		"status":       a.Status,
+		"title":        a.Title,
+		"body":         a.Body,
+		"publish_at":   a.PublishAt,
+		"published_at": a.PublishedAt,
+		"updated_at":   a.UpdatedAt,
+	}
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		fields = append(fields, field)
+	}
+	return fields, values
+}
+
+// Snapshot capture the current state of the aggregate
+func (a *ArticleAggregate) Snapshot() (ArticleSnapshot, error) {
+	state, err := json.Marshal(a)
+	if err != nil {
+		return ArticleSnapshot{}, errors.Wrap(err, "json.Marshal")
+	}
+
+	return ArticleSnapshot{
+		AggregateID:   a.ID,
+		Version:       a.Version,
+		SchemaVersion: ArticleAggregateSchemaVersion,
+		State:         state,
+	}, nil
+}
+
+// NewArticleAggregateFromSnapshot restore the aggregate state captured by snapshot
+func NewArticleAggregateFromSnapshot(snapshot ArticleSnapshot) (*ArticleAggregate, error) {
+	if snapshot.SchemaVersion != ArticleAggregateSchemaVersion {
+		return nil, fmt.Errorf("snapshot schema version %d, expected %d", snapshot.SchemaVersion, ArticleAggregateSchemaVersion)
+	}
+
+	aggregate := new(ArticleAggregate)
+	if err := json.Unmarshal(snapshot.State, aggregate); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+	return aggregate, nil
+}
+
+// ShouldSnapshot report whether a snapshot is due at version for the given frequency,
+// a frequency lower than one disables snapshots
+func ShouldSnapshot(version, frequency int) bool {
+	return frequency > 0 && version > 0 && version%frequency == 0
+}
+
+// EventStoreArticleRepository Repository Interface
+type EventStoreArticleRepository interface {
+	AppendWithTx(ctx context.Context, tx *gorm.DB, articleEvents ...ArticleEvent) error
+	SaveSnapshotWithTx(ctx context.Context, tx *gorm.DB, snapshot ArticleSnapshot) error
+	Load(ctx context.Context, aggregateID int) (*ArticleAggregate, error)
+	RebuildSnapshots(ctx context.Context, aggregateID int, frequency int) (int, error)
+	AggregateIDs(ctx context.Context) ([]int, error)
+	// MarkPublished record that the events reached the broker
+	MarkPublished(ctx context.Context, articleEvents ...ArticleEvent) error
+	// FetchUnpublished events of every tenant that occurred before the given time and are not published yet, in commit order
+	FetchUnpublished(ctx context.Context, before time.Time, limit int) ([]ArticleEvent, error)
+}
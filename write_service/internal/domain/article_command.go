@@ -1,28 +1,96 @@
 package domain
 
-import "time"
+import (
+	"time"
 
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/write_service/proto/article_writer"
+)
+
+// CreateArticleCommand the title is unique per author within the tenant
 type CreateArticleCommand struct {
-	ID        int `json:"id"`
-	Author    string `json:"author"`
-	Title     string `json:"title"`
-	Body      string `json:"body"`
+	ID       int      `json:"id"`
+	Author   string   `json:"author" validate:"required,min=3,max=250"`
+	Title    string   `json:"title" validate:"required,min=3,max=250,unique_with=title:articles:author:Author"`
+	Body     string   `json:"body" validate:"required,min=3,max=250"`
+	Tags     []string `json:"tags"`
+	Category string   `json:"category"`
 }
 
-type CreatedArticleCommand struct {
-	ID        int `json:"id"`
-	Author    string `json:"author"`
-	Title     string `json:"title"`
-	Body      string `json:"body"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+// CreateArticleBatchItem command of a micro-batch along with the id of its envelope, its rejection refers to
+type CreateArticleBatchItem struct {
+	CommandID string
+	Command   CreateArticleCommand
 }
 
-func (r CreateArticleCommand)ToArticle() Article {
+func (r CreateArticleCommand) ToArticle() Article {
 	return Article{
-		ID:        0,
-		Author:    r.Author,
-		Title:     r.Title,
-		Body:      r.Body,
+		ID:      0,
+		Version: 1,
+		Status:  events.ArticleStatusDraft,
+		Author:  r.Author,
+		Title:   r.Title,
+		Body:    r.Body,
 	}
 }
+
+// CreateArticleCommandFromGrpc command of the synchronous creation request
+func CreateArticleCommandFromGrpc(req *writerService.CreateArticleReq) CreateArticleCommand {
+	return CreateArticleCommand{
+		ID:       0,
+		Author:   req.GetAuthor(),
+		Title:    req.GetTitle(),
+		Body:     req.GetBody(),
+		Tags:     events.NormalizeTags(req.GetTags()),
+		Category: events.NormalizeCategory(req.GetCategory()),
+	}
+}
+
+// UpdateArticleCommand ExpectedVersion is the version of the article the command is based on, 0 updates any version
+type UpdateArticleCommand struct {
+	ID              int    `json:"id"`
+	ChangedBy       string `json:"changed_by"`
+	ExpectedVersion int    `json:"expected_version"`
+	Title           string `json:"title" validate:"omitempty,min=3,max=250"`
+	Body            string `json:"body" validate:"omitempty,min=3,max=250"`
+	Category        string `json:"category"`
+}
+
+// ArticleTitle new title of the article, still unique per author within the tenant once changed
+type ArticleTitle struct {
+	ID     int
+	Author string
+	Title  string `validate:"unique_with=title:articles:author:Author:id:ID"`
+}
+
+// ArticleVersion version of the article a command is based on, the command is rejected
+// once the article moved past it. ExpectedVersion 0 accepts any version
+type ArticleVersion struct {
+	Version         int
+	ExpectedVersion int `validate:"omitempty,eqfield=Version"`
+}
+
+// ChangedFields fields of article the command changes, empty command fields are left unchanged
+func (r UpdateArticleCommand) ChangedFields(article *ArticleAggregate) []string {
+	changed := make([]string, 0, 3)
+	if r.Title != "" && r.Title != article.Title {
+		changed = append(changed, "title")
+	}
+	if r.Body != "" && r.Body != article.Body {
+		changed = append(changed, "body")
+	}
+	if category := events.NormalizeCategory(r.Category); category != "" && category != article.Category {
+		changed = append(changed, "category")
+	}
+	return changed
+}
+
+// TransitionArticleCommand move an article through the publishing workflow,
+// PublishAt in the future schedules the publication instead of publishing right away
+type TransitionArticleCommand struct {
+	ID              int        `json:"id"`
+	Action          string     `json:"action"`
+	ChangedBy       string     `json:"changed_by"`
+	ExpectedVersion int        `json:"expected_version"`
+	PublishAt       *time.Time `json:"publish_at"`
+}
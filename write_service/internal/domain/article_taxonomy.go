@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+)
+
+// Category node of the category tree, Path is the slash separated names from the root
+type Category struct {
+	ID        int       `gorm:"column:id;primarykey;autoIncrement:true"`
+	TenantID  string    `gorm:"type:text;column:tenant_id;not null;default:default;uniqueIndex:idx_categories_tenant_path,priority:1"`
+	ParentID  *int      `gorm:"column:parent_id;index:idx_categories_parent_id"`
+	Name      string    `gorm:"type:text;column:name;not null"`
+	Path      string    `gorm:"type:text;column:path;not null;uniqueIndex:idx_categories_tenant_path,priority:2"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// TableName name of table
+func (r *Category) TableName() string {
+	return "categories"
+}
+
+// Tag label shared by the articles
+type Tag struct {
+	ID        int       `gorm:"column:id;primarykey;autoIncrement:true"`
+	TenantID  string    `gorm:"type:text;column:tenant_id;not null;default:default;uniqueIndex:idx_tags_tenant_name,priority:1"`
+	Name      string    `gorm:"type:text;column:name;not null;uniqueIndex:idx_tags_tenant_name,priority:2"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+// TableName name of table
+func (r *Tag) TableName() string {
+	return "tags"
+}
+
+// ArticleTag link between an article and one of its tags
+type ArticleTag struct {
+	ArticleID int `gorm:"column:article_id;primaryKey;autoIncrement:false"`
+	TagID     int `gorm:"column:tag_id;primaryKey;autoIncrement:false;index:idx_article_tags_tag_id"`
+}
+
+// TableName name of table
+func (r *ArticleTag) TableName() string {
+	return "article_tags"
+}
+
+// TagArticleCommand add tags to an article or remove them, Action is one of the events.ArticleTagAction.
+// ExpectedVersion is the version of the article the command is based on, 0 tags any version
+type TagArticleCommand struct {
+	ID              int      `json:"id"`
+	Action          string   `json:"action"`
+	ChangedBy       string   `json:"changed_by"`
+	ExpectedVersion int      `json:"expected_version"`
+	Tags            []string `json:"tags"`
+}
+
+// ApplyTo tags of the article once the command is applied, and the tags it actually adds and removes
+func (r TagArticleCommand) ApplyTo(tags []string) (result, added, removed []string) {
+	current := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		current[tag] = true
+	}
+
+	added, removed = make([]string, 0), make([]string, 0)
+	for _, tag := range events.NormalizeTags(r.Tags) {
+		switch {
+		case r.Action == events.ArticleTagActionAdd && !current[tag]:
+			current[tag] = true
+			added = append(added, tag)
+		case r.Action == events.ArticleTagActionRemove && current[tag]:
+			delete(current, tag)
+			removed = append(removed, tag)
+		}
+	}
+
+	result = make([]string, 0, len(current))
+	for tag := range current {
+		result = append(result, tag)
+	}
+	return events.NormalizeTags(result), added, removed
+}
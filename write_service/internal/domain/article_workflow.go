@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+)
+
+var ErrInvalidTransition = errors.New("invalid article status transition")
+
+// articleTransition statuses an action may be applied from and the status it leads to
+type articleTransition struct {
+	from []string
+	to   string
+}
+
+var articleTransitions = map[string]articleTransition{
+	events.ArticleActionSubmitForReview: {
+		from: []string{events.ArticleStatusDraft},
+		to:   events.ArticleStatusInReview,
+	},
+	events.ArticleActionPublish: {
+		from: []string{events.ArticleStatusDraft, events.ArticleStatusInReview},
+		to:   events.ArticleStatusPublished,
+	},
+	events.ArticleActionUnpublish: {
+		from: []string{events.ArticleStatusPublished},
+		to:   events.ArticleStatusDraft,
+	},
+	events.ArticleActionArchive: {
+		from: []string{events.ArticleStatusDraft, events.ArticleStatusInReview, events.ArticleStatusPublished},
+		to:   events.ArticleStatusArchived,
+	},
+}
+
+// NextStatus status an article in status moves to after action, ErrInvalidTransition when action is not allowed from status
+func NextStatus(status, action string) (string, error) {
+	transition, ok := articleTransitions[action]
+	if !ok {
+		return "", errors.Wrap(ErrInvalidTransition, fmt.Sprintf("unknown action %s", action))
+	}
+	for _, from := range transition.from {
+		if from == status {
+			return transition.to, nil
+		}
+	}
+	return "", errors.Wrap(ErrInvalidTransition, fmt.Sprintf("%s an article in %s", action, status))
+}
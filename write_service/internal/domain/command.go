@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/write_service/proto/article_writer"
+)
+
+var (
+	// ErrCommandRejected the command breaks a business rule, a rejection event is published instead of persisting it
+	ErrCommandRejected = errors.New("command rejected")
+)
+
+// CommandRejectedError ErrCommandRejected carrying the rules the command breaks
+type CommandRejectedError struct {
+	Reasons []events.RejectionReason
+}
+
+func (e *CommandRejectedError) Error() string {
+	rules := make([]string, 0, len(e.Reasons))
+	for _, reason := range e.Reasons {
+		rules = append(rules, reason.Field+" "+reason.Rule)
+	}
+	return ErrCommandRejected.Error() + ": " + strings.Join(rules, ", ")
+}
+
+func (e *CommandRejectedError) Is(target error) bool {
+	return target == ErrCommandRejected
+}
+
+// CommandValidator check the commands against the business rules before they are persisted
+type CommandValidator interface {
+	// Check return a CommandRejectedError when command breaks a rule, other errors are failures of the rules themselves
+	Check(ctx context.Context, command interface{}) error
+	// Validate Check command and publish its rejection when it breaks a rule
+	Validate(ctx context.Context, commandType string, aggregateID int, command interface{}) error
+}
+
+// MessagingCommandRepository Repository Interface
+type MessagingCommandRepository interface {
+	PushMessageCommandRejected(ctx context.Context, event events.CommandRejected) error
+}
+
+// RejectionReasonsToGrpc Mapper
+func RejectionReasonsToGrpc(reasons []events.RejectionReason) []*writerService.RejectionReason {
+	list := make([]*writerService.RejectionReason, 0, len(reasons))
+	for _, reason := range reasons {
+		list = append(list, &writerService.RejectionReason{
+			Field:    reason.Field,
+			Rule:     reason.Rule,
+			Messages: reason.Messages,
+		})
+	}
+	return list
+}
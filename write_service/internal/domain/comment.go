@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCommentDeleted deleted comments are kept for their article history and can no longer change
+	ErrCommentDeleted = errors.New("comment deleted")
+	// ErrCommentVersionConflict the comment changed since it was loaded
+	ErrCommentVersionConflict = errors.New("comment version conflict")
+)
+
+// Comment comment of an article, Version is bumped by every change and guards the concurrent ones
+type Comment struct {
+	ID        int       `gorm:"column:id;primarykey;autoIncrement:true"`
+	TenantID  string    `gorm:"type:text;column:tenant_id;not null;default:default;index:idx_comments_tenant_id"`
+	ArticleID int       `gorm:"column:article_id;not null;index:idx_comments_article_id"`
+	Version   int       `gorm:"column:version;not null;default:1"`
+	Status    string    `gorm:"type:text;column:status;not null;default:visible"`
+	Author    string    `gorm:"type:text;column:author;not null"`
+	Body      string    `gorm:"type:text;column:body;not null"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+// TableName name of table
+func (r *Comment) TableName() string {
+	return "comments"
+}
+
+// CommentUseCase UseCase Interface
+type CommentUseCase interface {
+	CreateComment(c context.Context, command CreateCommentCommand) error
+	EditComment(c context.Context, command EditCommentCommand) error
+	DeleteComment(c context.Context, command DeleteCommentCommand) error
+	ModerateComment(c context.Context, command ModerateCommentCommand) error
+}
+
+// PgCommentRepository Repository Interface
+type PgCommentRepository interface {
+	// Store insert the comment of an existing article, gorm.ErrRecordNotFound when the article does not exist
+	Store(ctx context.Context, data Comment) (Comment, error)
+	GetById(ctx context.Context, id int) (*Comment, error)
+	// UpdateVersion save comment when the stored comment is still at version, ErrCommentVersionConflict otherwise
+	UpdateVersion(ctx context.Context, comment Comment, version int) error
+	DB() *gorm.DB
+}
+
+// MessagingCommentRepository Repository Interface
+type MessagingCommentRepository interface {
+	PushMessageCommentCreated(ctx context.Context, event events.CommentCreated) error
+	PushMessageCommentEdited(ctx context.Context, event events.CommentEdited) error
+	PushMessageCommentDeleted(ctx context.Context, event events.CommentDeleted) error
+	PushMessageCommentModerated(ctx context.Context, event events.CommentModerated) error
+}
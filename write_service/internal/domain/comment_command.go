@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+)
+
+type CreateCommentCommand struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id" validate:"required,check_fk=ArticleID:articles:id"`
+	Author    string `json:"author" validate:"required,min=3,max=250"`
+	Body      string `json:"body" validate:"required,max=1000"`
+}
+
+func (r CreateCommentCommand) ToComment() Comment {
+	return Comment{
+		ID:        0,
+		ArticleID: r.ArticleID,
+		Version:   1,
+		Status:    events.CommentStatusVisible,
+		Author:    r.Author,
+		Body:      r.Body,
+	}
+}
+
+type EditCommentCommand struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	ChangedBy string `json:"changed_by"`
+	Body      string `json:"body" validate:"required,max=1000"`
+}
+
+type DeleteCommentCommand struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	ChangedBy string `json:"changed_by"`
+}
+
+// ModerateCommentCommand hide a comment or make it visible again
+type ModerateCommentCommand struct {
+	ID        int    `json:"id"`
+	ArticleID int    `json:"article_id"`
+	ChangedBy string `json:"changed_by"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TenantScope restrict the statement to the rows of the tenant carried by ctx,
+// the default tenant when ctx carries none
+func TenantScope(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	id := tenant.FromContext(ctx)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: id})
+	}
+}
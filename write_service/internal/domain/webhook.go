@@ -0,0 +1,357 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/write_service/proto/article_writer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+const (
+	// WebhookDeliveryPending the delivery is attempted again at NextAttemptAt
+	WebhookDeliveryPending = "pending"
+	// WebhookDeliveryDelivered the endpoint answered a 2xx
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryFailed the attempts are exhausted
+	WebhookDeliveryFailed = "failed"
+)
+
+var (
+	// ErrWebhookInvalid the url is not an absolute http(s) url, its host is an internal address or an event is not an article event
+	ErrWebhookInvalid = errors.New("webhook invalid")
+	// ErrWebhookAddressForbidden the host of a webhook resolved to an internal address when it was dialed
+	ErrWebhookAddressForbidden = errors.New("webhook address forbidden")
+
+	// forbiddenWebhookNetworks the loopback, private, link-local (the cloud metadata endpoints among them), shared,
+	// reserved and multicast networks the webhooks must not reach
+	forbiddenWebhookNetworks = parseNetworks(
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+		"192.0.0.0/24", "192.168.0.0/16", "198.18.0.0/15", "224.0.0.0/4", "240.0.0.0/4",
+		"::/128", "::1/128", "64:ff9b::/96", "fc00::/7", "fe80::/10", "ff00::/8",
+	)
+
+	// WebhookEventTypes events a webhook is notified of
+	WebhookEventTypes = []string{
+		events.ArticleCreatedType,
+		events.ArticleUpdatedType,
+		events.ArticleStatusChangedType,
+		events.ArticlePublishedType,
+		events.ArticleTagsChangedType,
+	}
+)
+
+// WebhookAddressAllowed report whether the webhooks may be sent to ip, the internal addresses are refused
+func WebhookAddressAllowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range forbiddenWebhookNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// Webhook endpoint notified of the article events of its tenant, Events empty is every event
+type Webhook struct {
+	ID                  int        `gorm:"column:id;primarykey;autoIncrement:true"`
+	TenantID            string     `gorm:"type:text;column:tenant_id;not null;default:default;index:idx_webhooks_tenant_id"`
+	URL                 string     `gorm:"type:text;column:url;not null"`
+	Events              string     `gorm:"type:text;column:events;not null;default:''"`
+	Secret              string     `gorm:"type:text;column:secret;not null"`
+	Active              bool       `gorm:"column:active;not null;default:true"`
+	ConsecutiveFailures int        `gorm:"column:consecutive_failures;not null;default:0"`
+	DisabledReason      string     `gorm:"type:text;column:disabled_reason;not null;default:''"`
+	DisabledAt          *time.Time `gorm:"column:disabled_at"`
+	CreatedAt           time.Time  `gorm:"column:created_at"`
+	UpdatedAt           time.Time  `gorm:"column:updated_at"`
+}
+
+// TableName name of table
+func (r *Webhook) TableName() string {
+	return "webhooks"
+}
+
+// EventList events of the webhook, empty is every event
+func (r Webhook) EventList() []string {
+	if r.Events == "" {
+		return []string{}
+	}
+	return strings.Split(r.Events, ",")
+}
+
+// Subscribes report whether the webhook is notified of eventType
+func (r Webhook) Subscribes(eventType string) bool {
+	if r.Events == "" {
+		return true
+	}
+	for _, event := range r.EventList() {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery event sent to a webhook, Payload is the signed body of every attempt
+type WebhookDelivery struct {
+	ID            int                      `gorm:"column:id;primarykey;autoIncrement:true"`
+	TenantID      string                   `gorm:"type:text;column:tenant_id;not null;default:default"`
+	WebhookID     int                      `gorm:"column:webhook_id;not null;uniqueIndex:idx_webhook_deliveries_webhook_event"`
+	EventID       string                   `gorm:"type:text;column:event_id;not null;uniqueIndex:idx_webhook_deliveries_webhook_event"`
+	EventType     string                   `gorm:"type:text;column:event_type;not null"`
+	Payload       string                   `gorm:"type:text;column:payload;not null"`
+	Status        string                   `gorm:"type:text;column:status;not null;default:pending"`
+	Attempts      int                      `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt time.Time                `gorm:"column:next_attempt_at;not null"`
+	DeliveredAt   *time.Time               `gorm:"column:delivered_at"`
+	CreatedAt     time.Time                `gorm:"column:created_at"`
+	UpdatedAt     time.Time                `gorm:"column:updated_at"`
+	Webhook       *Webhook                 `gorm:"foreignKey:WebhookID"`
+	AttemptLog    []WebhookDeliveryAttempt `gorm:"foreignKey:DeliveryID"`
+}
+
+// TableName name of table
+func (r *WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeliveryAttempt attempt of a delivery, StatusCode is 0 when the endpoint could not be reached
+type WebhookDeliveryAttempt struct {
+	ID         int       `gorm:"column:id;primarykey;autoIncrement:true"`
+	DeliveryID int       `gorm:"column:delivery_id;not null;index:idx_webhook_delivery_attempts_delivery_id"`
+	Attempt    int       `gorm:"column:attempt;not null"`
+	StatusCode int       `gorm:"column:status_code;not null;default:0"`
+	Error      string    `gorm:"type:text;column:error;not null;default:''"`
+	DurationMs int64     `gorm:"column:duration_ms;not null;default:0"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+}
+
+// TableName name of table
+func (r *WebhookDeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+// WebhookEvent article event sent to the webhooks of its tenant
+type WebhookEvent struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	TenantID   string          `json:"tenant_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// WebhookEventFromEnvelope event of the envelope consumed for tenantID
+func WebhookEventFromEnvelope(envelope *events.Envelope, tenantID string) WebhookEvent {
+	return WebhookEvent{
+		ID:         envelope.ID,
+		Type:       envelope.Type,
+		TenantID:   tenantID,
+		OccurredAt: envelope.OccurredAt,
+		Data:       envelope.Payload,
+	}
+}
+
+// WebhookAttemptResult outcome of sending a delivery, Err is set unless the endpoint answered a 2xx
+type WebhookAttemptResult struct {
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+}
+
+// WebhookDeliveryList page of the deliveries of a webhook
+type WebhookDeliveryList struct {
+	TotalCount int64
+	Pagination *utils.Pagination
+	Deliveries []WebhookDelivery
+}
+
+// CreateWebhookCommand Secret signs the payloads, generated when empty
+type CreateWebhookCommand struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=256"`
+}
+
+// UpdateWebhookCommand Secret empty keeps the current one, Active true enables a disabled webhook again
+type UpdateWebhookCommand struct {
+	ID     int      `json:"id"`
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events"`
+	Active bool     `json:"active"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=256"`
+}
+
+// WebhookUseCase UseCase Interface
+type WebhookUseCase interface {
+	// CreateWebhook the webhook is answered with its secret
+	CreateWebhook(c context.Context, command CreateWebhookCommand) (*Webhook, error)
+	UpdateWebhook(c context.Context, command UpdateWebhookCommand) (*Webhook, error)
+	GetWebhook(c context.Context, id int) (*Webhook, error)
+	ListWebhooks(c context.Context) ([]Webhook, error)
+	// DeleteWebhook the deliveries of the webhook are deleted along with it
+	DeleteWebhook(c context.Context, id int) (*Webhook, error)
+	// ListDeliveries deliveries of the webhook with their attempts, the latest first, status empty is every delivery
+	ListDeliveries(c context.Context, webhookID int, status string, pq *utils.Pagination) (*WebhookDeliveryList, error)
+	// EnqueueEvent store a pending delivery of event for every webhook of its tenant notified of it
+	EnqueueEvent(c context.Context, event WebhookEvent) error
+	// DispatchDue attempt the pending deliveries due at now, returns the number of delivered ones
+	DispatchDue(c context.Context, now time.Time) (int, error)
+}
+
+// PgWebhookRepository Repository Interface
+type PgWebhookRepository interface {
+	Store(ctx context.Context, data Webhook) (Webhook, error)
+	GetById(ctx context.Context, id int) (*Webhook, error)
+	// Update save the url, events, secret and active flag of the webhook, re-enabling it clears its failures
+	Update(ctx context.Context, data Webhook) error
+	List(ctx context.Context) ([]Webhook, error)
+	Delete(ctx context.Context, id int) error
+	// ListActive active webhooks of the tenant of ctx
+	ListActive(ctx context.Context) ([]Webhook, error)
+	// StoreDeliveries the deliveries already stored for their webhook and event are skipped
+	StoreDeliveries(ctx context.Context, deliveries []WebhookDelivery) error
+	// ClaimDue pending deliveries of the active webhooks due at now, of every tenant, with their webhook;
+	// they are not due again before now+lease so the other instances skip them while they are attempted
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error)
+	// SaveAttempt log attempt and save the status of delivery, the failures of its webhook are counted
+	// and it is disabled once disableAfter attempts in a row failed, 0 never disables it
+	SaveAttempt(ctx context.Context, delivery WebhookDelivery, attempt WebhookDeliveryAttempt, disableAfter int) error
+	ListDeliveries(ctx context.Context, webhookID int, status string, offset int, limit int) ([]WebhookDelivery, int64, error)
+	DB() *gorm.DB
+}
+
+// HttpWebhookRepository Repository Interface
+type HttpWebhookRepository interface {
+	// Send post the payload of delivery signed with the secret of its webhook
+	Send(ctx context.Context, delivery WebhookDelivery) WebhookAttemptResult
+}
+
+// WebhookToGrpc Mapper
+func WebhookToGrpc(webhook *Webhook) *writerService.Webhook {
+	res := &writerService.Webhook{
+		ID:                  int32(webhook.ID),
+		URL:                 webhook.URL,
+		Events:              webhook.EventList(),
+		Active:              webhook.Active,
+		ConsecutiveFailures: int32(webhook.ConsecutiveFailures),
+		DisabledReason:      webhook.DisabledReason,
+		CreatedAt:           timestamppb.New(webhook.CreatedAt),
+		UpdatedAt:           timestamppb.New(webhook.UpdatedAt),
+	}
+	if webhook.DisabledAt != nil {
+		res.DisabledAt = timestamppb.New(*webhook.DisabledAt)
+	}
+	return res
+}
+
+func WebhookListToGrpc(webhooks []Webhook) *writerService.ListWebhooksRes {
+	list := make([]*writerService.Webhook, 0, len(webhooks))
+	for i := range webhooks {
+		list = append(list, WebhookToGrpc(&webhooks[i]))
+	}
+	return &writerService.ListWebhooksRes{Webhooks: list}
+}
+
+func WebhookDeliveryListToGrpc(deliveries *WebhookDeliveryList) *writerService.ListWebhookDeliveriesRes {
+	list := make([]*writerService.WebhookDelivery, 0, len(deliveries.Deliveries))
+	for _, delivery := range deliveries.Deliveries {
+		list = append(list, webhookDeliveryToGrpc(delivery))
+	}
+	return &writerService.ListWebhookDeliveriesRes{
+		TotalCount: deliveries.TotalCount,
+		TotalPages: int64(deliveries.Pagination.GetTotalPages(int(deliveries.TotalCount))),
+		Page:       int64(deliveries.Pagination.GetPage()),
+		Size:       int64(deliveries.Pagination.GetSize()),
+		HasMore:    deliveries.Pagination.GetHasMore(int(deliveries.TotalCount)),
+		Deliveries: list,
+	}
+}
+
+func webhookDeliveryToGrpc(delivery WebhookDelivery) *writerService.WebhookDelivery {
+	attempts := make([]*writerService.WebhookDeliveryAttempt, 0, len(delivery.AttemptLog))
+	for _, attempt := range delivery.AttemptLog {
+		attempts = append(attempts, &writerService.WebhookDeliveryAttempt{
+			Attempt:    int32(attempt.Attempt),
+			StatusCode: int32(attempt.StatusCode),
+			Error:      attempt.Error,
+			DurationMs: attempt.DurationMs,
+			CreatedAt:  timestamppb.New(attempt.CreatedAt),
+		})
+	}
+	res := &writerService.WebhookDelivery{
+		ID:         int32(delivery.ID),
+		WebhookID:  int32(delivery.WebhookID),
+		EventID:    delivery.EventID,
+		EventType:  delivery.EventType,
+		Status:     delivery.Status,
+		Attempts:   int32(delivery.Attempts),
+		CreatedAt:  timestamppb.New(delivery.CreatedAt),
+		AttemptLog: attempts,
+	}
+	if delivery.Status == WebhookDeliveryPending {
+		res.NextAttemptAt = timestamppb.New(delivery.NextAttemptAt)
+	}
+	if delivery.DeliveredAt != nil {
+		res.DeliveredAt = timestamppb.New(*delivery.DeliveredAt)
+	}
+	return res
+}
+
+// CreateWebhookCommandFromGrpc Mapper
+func CreateWebhookCommandFromGrpc(req *writerService.CreateWebhookReq) CreateWebhookCommand {
+	return CreateWebhookCommand{
+		URL:    strings.TrimSpace(req.GetURL()),
+		Events: normalizeWebhookEvents(req.GetEvents()),
+		Secret: req.GetSecret(),
+	}
+}
+
+// UpdateWebhookCommandFromGrpc Mapper
+func UpdateWebhookCommandFromGrpc(req *writerService.UpdateWebhookReq) UpdateWebhookCommand {
+	return UpdateWebhookCommand{
+		ID:     int(req.GetID()),
+		URL:    strings.TrimSpace(req.GetURL()),
+		Events: normalizeWebhookEvents(req.GetEvents()),
+		Active: req.GetActive(),
+		Secret: req.GetSecret(),
+	}
+}
+
+func normalizeWebhookEvents(list []string) []string {
+	normalized := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, event := range list {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if event == "" || seen[event] {
+			continue
+		}
+		seen[event] = true
+		normalized = append(normalized, event)
+	}
+	return normalized
+}
@@ -8,6 +8,10 @@ import (
 	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	writerGrpc "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/article/delivery/grpc"
+	webhookGrpc "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/webhook/delivery/grpc"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/write_service/proto/article_writer"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
@@ -22,7 +26,7 @@ const (
 	gRPCTime          = 10
 )
 
-func (s *server) newReaderGrpcServer() (func() error, *grpc.Server, error) {
+func (s *server) newWriterGrpcServer() (func() error, *grpc.Server, error) {
 	l, err := net.Listen("tcp", ":" + s.cfg.GRPC.Port)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "net.Listen")
@@ -40,18 +44,28 @@ func (s *server) newReaderGrpcServer() (func() error, *grpc.Server, error) {
 			grpc_opentracing.UnaryServerInterceptor(),
 			grpc_prometheus.UnaryServerInterceptor,
 			grpc_recovery.UnaryServerInterceptor(),
+			tenant.UnaryServerInterceptor(),
 		),
 		),
 	)
 
+	writerGrpcService := writerGrpc.NewArticleGrpcService(s.articleUsecase, s.cfg, s.zapLog)
+	writerService.RegisterWriterServiceServer(grpcServer, writerGrpcService)
+	webhookGrpcService := webhookGrpc.NewWebhookGrpcService(s.webhookUsecase, s.cfg, s.zapLog)
+	writerService.RegisterWebhookServiceServer(grpcServer, webhookGrpcService)
+	s.health.Register(grpcServer)
+	grpc_prometheus.Register(grpcServer)
 
 	if s.cfg.GRPC.Development {
 		reflection.Register(grpcServer)
 	}
 
 	go func() {
-		s.zapLog.Infof("Reader gRPC server is listening on port: %s", s.cfg.GRPC.Port)
-		s.zapLog.Fatal(grpcServer.Serve(l))
+		s.zapLog.Infof("Writer gRPC server is listening on port: %s", s.cfg.GRPC.Port)
+		// Serve returns nil once the server is stopped while draining
+		if err := grpcServer.Serve(l); err != nil {
+			s.zapLog.Fatal(err)
+		}
 	}()
 
 	return l.Close, grpcServer, nil
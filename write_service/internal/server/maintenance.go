@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/database/migration"
+	articleRepository "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/article/repository"
+	articlUsecase "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/article/usecase"
+)
+
+// RebuildSnapshots replace the snapshots of the given articles, or of every article when none is given,
+// by replaying their events. Run it after changing the aggregate logic.
+func (s *server) RebuildSnapshots(aggregateIDs []int) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	if err := s.connectDatabase(); err != nil {
+		return errors.Wrap(err, "s.connectDatabase")
+	}
+
+	pgArticleRepo := articleRepository.NewPgArticleRepository(s.db, s.zapLog)
+	eventStoreArticleRepo := articleRepository.NewEventStoreArticleRepository(s.db, s.zapLog)
+
+	// snapshots are rebuilt without validating or publishing anything
+	articleUcase := articlUsecase.NewArticleUseCase(0, s.cfg.EventStore.SnapshotFrequency, pgArticleRepo, eventStoreArticleRepo, nil, nil, s.zapLog)
+
+	return articleUcase.RebuildSnapshots(ctx, aggregateIDs)
+}
+
+// MigrateUp apply every pending sql migration
+func (s *server) MigrateUp() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	migrator, err := s.connectMigrator()
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		s.zapLog.Infof("applied migration %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return errors.Wrap(err, "migrator.Up")
+	}
+	if len(applied) == 0 {
+		s.zapLog.Infof("no pending migration")
+	}
+
+	return nil
+}
+
+// MigrateDown roll back the last steps applied sql migrations
+func (s *server) MigrateDown(steps int) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	migrator, err := s.connectMigrator()
+	if err != nil {
+		return err
+	}
+	rolledBack, err := migrator.Down(ctx, steps)
+	for _, m := range rolledBack {
+		s.zapLog.Infof("rolled back migration %04d_%s", m.Version, m.Name)
+	}
+	if err != nil {
+		return errors.Wrap(err, "migrator.Down")
+	}
+
+	return nil
+}
+
+// MigrateStatus write the state of every sql migration to w
+func (s *server) MigrateStatus(w io.Writer) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	migrator, err := s.connectMigrator()
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return errors.Wrap(err, "migrator.Status")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, st := range statuses {
+		state, appliedAt := "pending", ""
+		if st.Applied {
+			state, appliedAt = "applied", st.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		switch {
+		case st.ChecksumMismatch:
+			state = "checksum mismatch"
+		case st.Applied && st.Up == "":
+			state = "missing file"
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\t%s\n", st.Version, st.Name, state, appliedAt)
+	}
+
+	return tw.Flush()
+}
+
+func (s *server) connectMigrator() (*migration.Migrator, error) {
+	if err := s.connectDatabase(); err != nil {
+		return nil, errors.Wrap(err, "s.connectDatabase")
+	}
+	return s.newMigrator()
+}
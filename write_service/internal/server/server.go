@@ -4,27 +4,46 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/database"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/database/migration"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/health"
 	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/validator"
 	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
 	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
 	articleConsumerHandler "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/article/delivery/kafka"
+	articleScheduler "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/article/delivery/scheduler"
 	articleRepository "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/article/repository"
 	articlUsecase "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/article/usecase"
+	commandRepository "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/command/repository"
+	commandUsecase "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/command/usecase"
+	commentConsumerHandler "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/comment/delivery/kafka"
+	commentRepository "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/comment/repository"
+	commentUsecase "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/comment/usecase"
 	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	webhookConsumerHandler "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/webhook/delivery/kafka"
+	webhookScheduler "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/webhook/delivery/scheduler"
+	webhookRepository "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/webhook/repository"
+	webhookUsecase "github.com/radyatamaa/go-cqrs-microservices/write_service/internal/webhook/usecase"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/migrations"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/write_service/proto/article_writer"
 	"github.com/segmentio/kafka-go"
 	"gorm.io/gorm"
 )
 
 type server struct {
-	zapLog    zaplogger.Logger
-	cfg       *config.Config
-	db        *gorm.DB
-	kafkaConn *kafka.Conn
+	zapLog         zaplogger.Logger
+	cfg            *config.Config
+	db             *gorm.DB
+	kafkaConn      *kafka.Conn
+	health         *health.Health
+	articleUsecase domain.ArticleUseCase
+	webhookUsecase domain.WebhookUseCase
 }
 
 func NewServer(cfg *config.Config, zapLog zaplogger.Logger) *server {
@@ -36,56 +55,106 @@ func (s *server) Run() error {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 
-	// database initialization
-	conn, err := database.New(
-		func(config *database.Config) {
-			config.Driver = s.cfg.Database.Driver
-			config.Host = s.cfg.Database.Host
-			config.Port = s.cfg.Database.Port
-			config.Name = s.cfg.Database.Name
-			config.Username = s.cfg.Database.Username
-			config.Password = s.cfg.Database.Password
-			config.Options = s.cfg.Database.Options
-			config.MaxOpenConnection = s.cfg.Database.MaxOpenConnection
-			config.MaxIdleConnection = s.cfg.Database.MaxIdleConnection
-			config.MaxLifeTimeConnection = s.cfg.Database.MaxLifeTimeConnection
-			config.MaxIdleTimeConnection = s.cfg.Database.MaxIdleTimeConnection
-		},
-	)
-	if err != nil {
+	if err := s.connectDatabase(); err != nil {
 		panic(err)
 	}
-
-	s.db = conn[s.cfg.Database.Name]
-
-	// db auto migrate dev environment
-	if err := s.db.AutoMigrate(
-		&domain.Article{}); err != nil {
+	defer s.closeDatabase()
+	if err := s.migrateDatabase(ctx); err != nil {
 		panic(err)
 	}
+	// the database rules of the commands validation look at the write database
+	validator.Validate.SetDatabaseConnection(s.db)
 
 	kafkaProducer := kafkaClient.NewProducer(s.zapLog, s.cfg.Kafka.Brokers)
 	defer kafkaProducer.Close() // nolint: errcheck
 
 	timeoutContext := time.Duration(s.cfg.App.ExecutionTimeout) * time.Second
 
+	messagingCommandRepo := commandRepository.NewMessagingCommandRepository(kafkaProducer, s.cfg, s.zapLog)
+	commandValidator := commandUsecase.NewCommandValidator(messagingCommandRepo, s.zapLog)
+
 	messagingArticleRepo := articleRepository.NewMessagingArticleRepository(kafkaProducer, s.cfg, s.zapLog)
 	pgArticleRepo := articleRepository.NewPgArticleRepository(s.db, s.zapLog)
+	eventStoreArticleRepo := articleRepository.NewEventStoreArticleRepository(s.db, s.zapLog)
+
+	s.articleUsecase = articlUsecase.NewArticleUseCase(timeoutContext, s.cfg.EventStore.SnapshotFrequency, pgArticleRepo, eventStoreArticleRepo, messagingArticleRepo, commandValidator, s.zapLog)
+
+	deadLetter := kafkaClient.NewDeadLetter(kafkaProducer, s.cfg.KafkaTopics.DeadLetter.TopicName)
+	kafkaArticleConsumerHandler := articleConsumerHandler.NewArticleConsumer(s.articleUsecase, s.cfg, deadLetter, s.zapLog)
+
+	messagingCommentRepo := commentRepository.NewMessagingCommentRepository(kafkaProducer, s.cfg, s.zapLog)
+	pgCommentRepo := commentRepository.NewPgCommentRepository(s.db, s.zapLog)
+
+	commentUcase := commentUsecase.NewCommentUseCase(timeoutContext, pgCommentRepo, messagingCommentRepo, commandValidator, s.zapLog)
 
-	articleUcase := articlUsecase.NewArticleUseCase(timeoutContext, pgArticleRepo, messagingArticleRepo, s.zapLog)
+	kafkaCommentConsumerHandler := commentConsumerHandler.NewCommentConsumer(commentUcase, s.cfg, s.zapLog)
 
-	kafkaArticleConsumerHandler := articleConsumerHandler.NewArticleConsumer(articleUcase, s.cfg, s.zapLog)
+	pgWebhookRepo := webhookRepository.NewPgWebhookRepository(s.db, s.zapLog)
+	httpWebhookRepo := webhookRepository.NewHttpWebhookRepository(time.Duration(s.cfg.Webhook.RequestTimeoutSeconds)*time.Second, s.cfg.App.ServiceName+" webhook", s.zapLog)
 
-	s.zapLog.Infof("Starting Reader Kafka consumers")
+	s.webhookUsecase = webhookUsecase.NewWebhookUseCase(timeoutContext, s.cfg.Webhook, pgWebhookRepo, httpWebhookRepo, s.zapLog)
+
+	kafkaWebhookConsumerHandler := webhookConsumerHandler.NewWebhookConsumer(s.webhookUsecase, s.cfg, s.zapLog)
+
+	// consumers outlive the signal context, they are stopped while draining
+	consumerCtx, stopConsumers := context.WithCancel(context.Background())
+	defer stopConsumers()
+	consumersDone := make(chan struct{})
+
+	s.zapLog.Infof("Starting Writer Kafka consumers")
 	consumerGroup := kafkaClient.NewConsumerGroup(s.cfg.Kafka.Brokers, s.cfg.Kafka.GroupID, s.zapLog)
-	go consumerGroup.ConsumeTopic(ctx, s.getConsumerGroupTopics(), articleConsumerHandler.PoolSize, kafkaArticleConsumerHandler.ProcessMessages)
+	// every aggregate has its own consumer group so a slow aggregate does not hold the others back
+	commentConsumerGroup := kafkaClient.NewConsumerGroup(s.cfg.Kafka.Brokers, s.cfg.Kafka.GroupID+commentGroupIDSuffix, s.zapLog)
+	webhookConsumerGroup := kafkaClient.NewConsumerGroup(s.cfg.Kafka.Brokers, s.cfg.Kafka.GroupID+webhookGroupIDSuffix, s.zapLog)
+	consumers := new(sync.WaitGroup)
+	consumers.Add(3)
+	go func() {
+		defer consumers.Done()
+		size, timeout := s.cfg.Kafka.Batch()
+		consumerGroup.ConsumeBatches(consumerCtx, s.getConsumerGroupTopics(), articleConsumerHandler.PoolSize, size, timeout, kafkaArticleConsumerHandler.ProcessBatch)
+	}()
+	go func() {
+		defer consumers.Done()
+		commentConsumerGroup.ConsumeTopic(consumerCtx, s.getCommentConsumerGroupTopics(), commentConsumerHandler.PoolSize, kafkaCommentConsumerHandler.ProcessMessages)
+	}()
+	go func() {
+		defer consumers.Done()
+		size, timeout := s.cfg.Kafka.Batch()
+		webhookConsumerGroup.ConsumeBatches(consumerCtx, s.getWebhookConsumerGroupTopics(), webhookConsumerHandler.PoolSize, size, timeout, kafkaWebhookConsumerHandler.ProcessBatch)
+	}()
+	go func() {
+		consumers.Wait()
+		close(consumersDone)
+	}()
+
+	if s.cfg.Scheduler.PublishIntervalSeconds > 0 {
+		s.zapLog.Infof("Starting Writer publish scheduler")
+		publishScheduler := articleScheduler.NewPublishScheduler(s.articleUsecase, time.Duration(s.cfg.Scheduler.PublishIntervalSeconds)*time.Second, s.zapLog)
+		go publishScheduler.Run(consumerCtx)
+	}
+
+	if s.cfg.Scheduler.RelayIntervalSeconds > 0 {
+		s.zapLog.Infof("Starting Writer outbox relay")
+		outboxRelay := articleScheduler.NewOutboxRelay(s.articleUsecase,
+			time.Duration(s.cfg.Scheduler.RelayIntervalSeconds)*time.Second,
+			time.Duration(s.cfg.Scheduler.RelayGraceSeconds)*time.Second, s.zapLog)
+		go outboxRelay.Run(consumerCtx)
+	}
+
+	if s.cfg.Webhook.DispatchIntervalSeconds > 0 {
+		s.zapLog.Infof("Starting Writer webhook dispatcher")
+		dispatchScheduler := webhookScheduler.NewDispatchScheduler(s.webhookUsecase, time.Duration(s.cfg.Webhook.DispatchIntervalSeconds)*time.Second, s.zapLog)
+		go dispatchScheduler.Run(consumerCtx)
+	}
 
 	if err := s.connectKafkaBrokers(ctx); err != nil {
 		return errors.Wrap(err, "s.connectKafkaBrokers")
 	}
 	defer s.kafkaConn.Close() // nolint: errcheck
 
-	closeGrpcServer, grpcServer, err := s.newReaderGrpcServer()
+	s.health = health.New(time.Duration(s.cfg.App.CheckIntervalSeconds)*time.Second, writerService.WriterService_ServiceDesc.ServiceName)
+
+	closeGrpcServer, grpcServer, err := s.newWriterGrpcServer()
 	if err != nil {
 		return errors.Wrap(err, "NewScmGrpcServer")
 	}
@@ -98,7 +167,89 @@ func (s *server) Run() error {
 	s.runHealthCheck(ctx)
 
 	<-ctx.Done()
-	grpcServer.GracefulStop()
+	s.drain(grpcServer, stopConsumers, consumersDone)
+
+	return nil
+}
+
+func (s *server) connectDatabase() error {
+	// database initialization
+	conn, err := database.New(
+		func(config *database.Config) {
+			config.Driver = s.cfg.Database.Driver
+			config.Host = s.cfg.Database.Host
+			config.Port = s.cfg.Database.Port
+			config.Name = s.cfg.Database.Name
+			config.Username = s.cfg.Database.Username
+			config.Password = s.cfg.Database.Password
+			config.Options = s.cfg.Database.Options
+			config.MaxOpenConnection = s.cfg.Database.MaxOpenConnection
+			config.MaxIdleConnection = s.cfg.Database.MaxIdleConnection
+			config.MaxLifeTimeConnection = s.cfg.Database.MaxLifeTimeConnection
+			config.MaxIdleTimeConnection = s.cfg.Database.MaxIdleTimeConnection
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "database.New")
+	}
+
+	s.db = conn[s.cfg.Database.Name]
 
 	return nil
 }
+
+// closeDatabase close the connection pool once the consumers and the grpc server are drained
+func (s *server) closeDatabase() {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		s.zapLog.WarnMsg("db.DB", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		s.zapLog.WarnMsg("sqlDB.Close", err)
+	}
+}
+
+func (s *server) migrateDatabase(ctx context.Context) error {
+	// db auto migrate dev environment
+	if s.cfg.Migration.AutoMigrate {
+		s.zapLog.Warnf("migration.autoMigrate is enabled, sql migrations are skipped")
+		return s.db.AutoMigrate(
+			&domain.Article{},
+			&domain.ArticleEvent{},
+			&domain.ArticleSnapshot{},
+			&domain.Category{},
+			&domain.Tag{},
+			&domain.ArticleTag{},
+			&domain.Comment{},
+			&domain.Webhook{},
+			&domain.WebhookDelivery{},
+			&domain.WebhookDeliveryAttempt{})
+	}
+
+	if !s.cfg.Migration.MigrateOnStart {
+		return nil
+	}
+
+	migrator, err := s.newMigrator()
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return errors.Wrap(err, "migrator.Up")
+	}
+	for _, m := range applied {
+		s.zapLog.Infof("applied migration %04d_%s", m.Version, m.Name)
+	}
+
+	return nil
+}
+
+func (s *server) newMigrator() (*migration.Migrator, error) {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return nil, errors.Wrap(err, "db.DB")
+	}
+	return migration.New(sqlDB, migrations.FS)
+}
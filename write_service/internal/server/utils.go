@@ -9,12 +9,21 @@ import (
 
 	"github.com/heptiolabs/healthcheck"
 	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/health"
 	kafkaClient "github.com/radyatamaa/go-cqrs-microservices/pkg/kafka"
 	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc"
 )
 
 const (
 	stackSize = 1 << 10 // 1 KB
+
+	defaultShutdownTimeout = 30 * time.Second
+
+	// commentGroupIDSuffix suffix of the kafka group id consuming the comment commands
+	commentGroupIDSuffix = "_comments"
+	// webhookGroupIDSuffix suffix of the kafka group id queuing the webhook deliveries of the article events
+	webhookGroupIDSuffix = "_webhooks"
 )
 
 func (s *server) connectKafkaBrokers(ctx context.Context) error {
@@ -66,50 +75,215 @@ func (s *server) initKafkaTopics(ctx context.Context) {
 		ReplicationFactor: s.cfg.KafkaTopics.ArticleCreated.ReplicationFactor,
 	}
 
+	articleUpdateTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleUpdate.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleUpdate.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleUpdate.ReplicationFactor,
+	}
+
+	articleUpdatedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleUpdated.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleUpdated.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleUpdated.ReplicationFactor,
+	}
+
+	articleTransitionTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleTransition.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleTransition.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleTransition.ReplicationFactor,
+	}
+
+	articleStatusChangedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleStatusChanged.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleStatusChanged.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleStatusChanged.ReplicationFactor,
+	}
+
+	articlePublishedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticlePublished.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticlePublished.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticlePublished.ReplicationFactor,
+	}
+
+	articleTagTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleTag.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleTag.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleTag.ReplicationFactor,
+	}
+
+	articleTagsChangedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.ArticleTagsChanged.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.ArticleTagsChanged.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.ArticleTagsChanged.ReplicationFactor,
+	}
+
+	commentCreateTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentCreate.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentCreate.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentCreate.ReplicationFactor,
+	}
+
+	commentCreatedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentCreated.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentCreated.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentCreated.ReplicationFactor,
+	}
+
+	commentEditTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentEdit.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentEdit.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentEdit.ReplicationFactor,
+	}
+
+	commentEditedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentEdited.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentEdited.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentEdited.ReplicationFactor,
+	}
+
+	commentDeleteTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentDelete.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentDelete.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentDelete.ReplicationFactor,
+	}
+
+	commentDeletedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentDeleted.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentDeleted.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentDeleted.ReplicationFactor,
+	}
+
+	commentModerateTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentModerate.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentModerate.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentModerate.ReplicationFactor,
+	}
+
+	commentModeratedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommentModerated.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommentModerated.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommentModerated.ReplicationFactor,
+	}
+
+	commandRejectedTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.CommandRejected.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.CommandRejected.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.CommandRejected.ReplicationFactor,
+	}
+
+	deadLetterTopic := kafka.TopicConfig{
+		Topic:             s.cfg.KafkaTopics.DeadLetter.TopicName,
+		NumPartitions:     s.cfg.KafkaTopics.DeadLetter.Partitions,
+		ReplicationFactor: s.cfg.KafkaTopics.DeadLetter.ReplicationFactor,
+	}
+
 	if err := conn.CreateTopics(
 		articleCreateTopic,
 		articleCreatedTopic,
+		articleUpdateTopic,
+		articleUpdatedTopic,
+		articleTransitionTopic,
+		articleStatusChangedTopic,
+		articlePublishedTopic,
+		articleTagTopic,
+		articleTagsChangedTopic,
+		commentCreateTopic,
+		commentCreatedTopic,
+		commentEditTopic,
+		commentEditedTopic,
+		commentDeleteTopic,
+		commentDeletedTopic,
+		commentModerateTopic,
+		commentModeratedTopic,
+		commandRejectedTopic,
+		deadLetterTopic,
 	); err != nil {
 		s.zapLog.WarnMsg("kafkaConn.CreateTopics", err)
 		return
 	}
 
-	s.zapLog.Infof("kafka topics created or already exists: %+v", []kafka.TopicConfig{articleCreateTopic, articleCreatedTopic})
+	s.zapLog.Infof("kafka topics created or already exists: %+v", []kafka.TopicConfig{articleCreateTopic, articleCreatedTopic, articleUpdateTopic, articleUpdatedTopic, articleTransitionTopic, articleStatusChangedTopic, articlePublishedTopic, articleTagTopic, articleTagsChangedTopic, commentCreateTopic, commentCreatedTopic, commentEditTopic, commentEditedTopic, commentDeleteTopic, commentDeletedTopic, commentModerateTopic, commentModeratedTopic, commandRejectedTopic, deadLetterTopic})
 }
 
 func (s *server) runHealthCheck(ctx context.Context) {
-	health := healthcheck.NewHandler()
-
-	health.AddLivenessCheck(s.cfg.App.ServiceName, healthcheck.AsyncWithContext(ctx, func() error {
+	s.health.AddLivenessCheck(s.cfg.App.ServiceName, healthcheck.AsyncWithContext(ctx, func() error {
 		return nil
 	}, time.Duration(s.cfg.App.CheckIntervalSeconds)*time.Second))
 
-	health.AddReadinessCheck(s.cfg.Database.Driver, healthcheck.AsyncWithContext(ctx, func() error {
+	s.health.AddReadinessCheck(s.cfg.Database.Driver, func(ctx context.Context) error {
 		db, err := s.db.DB()
 		if err != nil {
 			return err
 		}
-		return db.Ping()
-	}, time.Duration(s.cfg.App.CheckIntervalSeconds)*time.Second))
+		return db.PingContext(ctx)
+	})
 
-	health.AddReadinessCheck("kafka", healthcheck.AsyncWithContext(ctx, func() error {
+	s.health.AddReadinessCheck("kafka", func(ctx context.Context) error {
 		_, err := s.kafkaConn.Brokers()
-		if err != nil {
-			return err
-		}
-		return nil
-	}, time.Duration(s.cfg.App.CheckIntervalSeconds)*time.Second))
+		return err
+	})
+
+	go s.health.Run(ctx)
 
 	go func() {
 		s.zapLog.Infof("Writer microservice Kubernetes probes listening on port: %s", s.cfg.App.Port)
-		if err := http.ListenAndServe(s.cfg.App.Port, health); err != nil {
+		if err := http.ListenAndServe(s.cfg.App.Port, s.health.Handler()); err != nil {
 			s.zapLog.WarnMsg("ListenAndServe", err)
 		}
 	}()
 }
 
+// drain report not ready, give the load balancers the drain delay to stop routing,
+// then stop consuming and finish the in-flight messages and grpc calls before the connections are closed
+func (s *server) drain(grpcServer *grpc.Server, stopConsumers context.CancelFunc, consumersDone <-chan struct{}) {
+	timeout := time.Duration(s.cfg.App.ShutdownTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	s.zapLog.Infof("Writer microservice draining")
+	s.health.Drain()
+	time.Sleep(time.Duration(s.cfg.App.DrainDelaySeconds) * time.Second)
+
+	stopConsumers()
+	select {
+	case <-consumersDone:
+	case <-time.After(timeout):
+		s.zapLog.Warnf("kafka consumers still running after %s", timeout)
+	}
+
+	if !health.StopGrpcServer(grpcServer, timeout) {
+		s.zapLog.Warnf("grpc calls still running after %s, connections closed", timeout)
+	}
+	s.zapLog.Infof("Writer microservice drained")
+}
+
 func (s *server) getConsumerGroupTopics() []string {
 	return []string{
 		s.cfg.KafkaTopics.ArticleCreate.TopicName,
+		s.cfg.KafkaTopics.ArticleUpdate.TopicName,
+		s.cfg.KafkaTopics.ArticleTransition.TopicName,
+		s.cfg.KafkaTopics.ArticleTag.TopicName,
+	}
+}
+
+// getCommentConsumerGroupTopics command topics of the comment aggregate, consumed by their own consumer group
+func (s *server) getCommentConsumerGroupTopics() []string {
+	return []string{
+		s.cfg.KafkaTopics.CommentCreate.TopicName,
+		s.cfg.KafkaTopics.CommentEdit.TopicName,
+		s.cfg.KafkaTopics.CommentDelete.TopicName,
+		s.cfg.KafkaTopics.CommentModerate.TopicName,
+	}
+}
+
+// getWebhookConsumerGroupTopics event topics of the article aggregate, their deliveries are queued for the webhooks
+func (s *server) getWebhookConsumerGroupTopics() []string {
+	return []string{
+		s.cfg.KafkaTopics.ArticleCreated.TopicName,
+		s.cfg.KafkaTopics.ArticleUpdated.TopicName,
+		s.cfg.KafkaTopics.ArticleStatusChanged.TopicName,
+		s.cfg.KafkaTopics.ArticlePublished.TopicName,
+		s.cfg.KafkaTopics.ArticleTagsChanged.TopicName,
 	}
 }
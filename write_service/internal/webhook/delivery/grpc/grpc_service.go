@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	writerService "github.com/radyatamaa/go-cqrs-microservices/write_service/proto/article_writer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type webhookGrpcService struct {
+	zapLogger zaplogger.Logger
+	useCase   domain.WebhookUseCase
+	cfg       *config.Config
+}
+
+func NewWebhookGrpcService(useCase domain.WebhookUseCase, cfg *config.Config, zapLogger zaplogger.Logger) *webhookGrpcService {
+	return &webhookGrpcService{
+		zapLogger: zapLogger,
+		useCase:   useCase,
+		cfg:       cfg,
+	}
+}
+
+func (s *webhookGrpcService) CreateWebhook(ctx context.Context, req *writerService.CreateWebhookReq) (*writerService.CreateWebhookRes, error) {
+	webhook, err := s.useCase.CreateWebhook(ctx, domain.CreateWebhookCommandFromGrpc(req))
+	if err != nil {
+		s.zapLogger.WarnMsg("WebhookUseCase.CreateWebhook", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return &writerService.CreateWebhookRes{Webhook: domain.WebhookToGrpc(webhook), Secret: webhook.Secret}, nil
+}
+
+func (s *webhookGrpcService) UpdateWebhook(ctx context.Context, req *writerService.UpdateWebhookReq) (*writerService.WebhookRes, error) {
+	webhook, err := s.useCase.UpdateWebhook(ctx, domain.UpdateWebhookCommandFromGrpc(req))
+	if err != nil {
+		s.zapLogger.WarnMsg("WebhookUseCase.UpdateWebhook", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return &writerService.WebhookRes{Webhook: domain.WebhookToGrpc(webhook)}, nil
+}
+
+func (s *webhookGrpcService) GetWebhook(ctx context.Context, req *writerService.GetWebhookReq) (*writerService.WebhookRes, error) {
+	webhook, err := s.useCase.GetWebhook(ctx, int(req.GetID()))
+	if err != nil {
+		s.zapLogger.WarnMsg("WebhookUseCase.GetWebhook", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return &writerService.WebhookRes{Webhook: domain.WebhookToGrpc(webhook)}, nil
+}
+
+func (s *webhookGrpcService) ListWebhooks(ctx context.Context, req *writerService.ListWebhooksReq) (*writerService.ListWebhooksRes, error) {
+	webhooks, err := s.useCase.ListWebhooks(ctx)
+	if err != nil {
+		s.zapLogger.WarnMsg("WebhookUseCase.ListWebhooks", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return domain.WebhookListToGrpc(webhooks), nil
+}
+
+func (s *webhookGrpcService) DeleteWebhook(ctx context.Context, req *writerService.DeleteWebhookReq) (*writerService.WebhookRes, error) {
+	webhook, err := s.useCase.DeleteWebhook(ctx, int(req.GetID()))
+	if err != nil {
+		s.zapLogger.WarnMsg("WebhookUseCase.DeleteWebhook", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return &writerService.WebhookRes{Webhook: domain.WebhookToGrpc(webhook)}, nil
+}
+
+func (s *webhookGrpcService) ListWebhookDeliveries(ctx context.Context, req *writerService.ListWebhookDeliveriesReq) (*writerService.ListWebhookDeliveriesRes, error) {
+	pq := utils.NewPaginationQuery(int(req.GetSize()), int(req.GetPage()))
+
+	deliveries, err := s.useCase.ListDeliveries(ctx, int(req.GetWebhookID()), req.GetStatus(), pq)
+	if err != nil {
+		s.zapLogger.WarnMsg("WebhookUseCase.ListDeliveries", err)
+		return nil, s.errResponse(errCode(err), err)
+	}
+
+	return domain.WebhookDeliveryListToGrpc(deliveries), nil
+}
+
+func errCode(err error) codes.Code {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return codes.NotFound
+	}
+	if errors.Is(err, domain.ErrWebhookInvalid) {
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
+
+func (s *webhookGrpcService) errResponse(c codes.Code, err error) error {
+	return status.Error(c, err.Error())
+}
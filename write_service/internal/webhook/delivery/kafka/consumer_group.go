@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/events"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	retryAttempts = 3
+	retryDelay    = 300 * time.Millisecond
+	// retryPause pause between the rounds of retries of an event that can not be queued yet
+	retryPause = 5 * time.Second
+	PoolSize   = 10
+)
+
+var (
+	retryOptions = []retry.Option{retry.Attempts(retryAttempts), retry.Delay(retryDelay), retry.DelayType(retry.BackOffDelay)}
+)
+
+type webhookConsumer struct {
+	zapLogger  zaplogger.Logger
+	useCase    domain.WebhookUseCase
+	cfg        *config.Config
+	eventTypes map[string]string
+}
+
+// NewWebhookConsumer queue the deliveries of the article events, the webhooks are called by the dispatcher
+func NewWebhookConsumer(useCase domain.WebhookUseCase, cfg *config.Config, zapLogger zaplogger.Logger) *webhookConsumer {
+	return &webhookConsumer{
+		zapLogger: zapLogger,
+		useCase:   useCase,
+		cfg:       cfg,
+		eventTypes: map[string]string{
+			cfg.KafkaTopics.ArticleCreated.TopicName:       events.ArticleCreatedType,
+			cfg.KafkaTopics.ArticleUpdated.TopicName:       events.ArticleUpdatedType,
+			cfg.KafkaTopics.ArticleStatusChanged.TopicName: events.ArticleStatusChangedType,
+			cfg.KafkaTopics.ArticlePublished.TopicName:     events.ArticlePublishedType,
+			cfg.KafkaTopics.ArticleTagsChanged.TopicName:   events.ArticleTagsChangedType,
+		},
+	}
+}
+
+// ProcessBatch queue the deliveries of the events of the batch, in the order of the batch. The batch is only
+// committed once every event of it is queued; the error is the end of ctx
+func (s *webhookConsumer) ProcessBatch(ctx context.Context, batch []kafka.Message, workerID int) error {
+	for _, m := range batch {
+		s.logProcessMessage(m, workerID)
+
+		eventType, ok := s.eventTypes[m.Topic]
+		if !ok {
+			continue
+		}
+		// the deliveries belong to the tenant the event was published for
+		if err := s.processEvent(tenant.ContextFromMessage(ctx, m), m, eventType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processEvent queue the deliveries of the event of m, retried until they are queued since the batch
+// is committed after it. Undecodable events are dropped
+func (s *webhookConsumer) processEvent(ctx context.Context, m kafka.Message, eventType string) error {
+	envelope, err := events.Default.DecodeMessage(eventType, m)
+	if err != nil {
+		s.zapLogger.WarnMsg("events.DecodeMessage", err)
+		return nil
+	}
+
+	event := domain.WebhookEventFromEnvelope(envelope, tenant.FromContext(ctx))
+	for {
+		err := retry.Do(func() error {
+			return s.useCase.EnqueueEvent(ctx, event)
+		}, append(retryOptions, retry.Context(ctx), retry.LastErrorOnly(true))...)
+		if err == nil {
+			return nil
+		}
+		s.zapLogger.WarnMsg("WebhookUseCase.EnqueueEvent", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryPause):
+		}
+	}
+}
+
+func (s *webhookConsumer) logProcessMessage(m kafka.Message, workerID int) {
+	s.zapLogger.KafkaProcessMessage(m.Topic, m.Partition, string(m.Value), workerID, m.Offset, m.Time)
+}
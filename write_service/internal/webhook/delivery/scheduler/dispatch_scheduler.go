@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+)
+
+type dispatchScheduler struct {
+	zapLogger zaplogger.Logger
+	useCase   domain.WebhookUseCase
+	interval  time.Duration
+}
+
+// NewDispatchScheduler attempt the due webhook deliveries every interval
+func NewDispatchScheduler(useCase domain.WebhookUseCase, interval time.Duration, zapLogger zaplogger.Logger) *dispatchScheduler {
+	return &dispatchScheduler{
+		zapLogger: zapLogger,
+		useCase:   useCase,
+		interval:  interval,
+	}
+}
+
+// Run blocks until ctx is done
+func (s *dispatchScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		delivered, err := s.useCase.DispatchDue(ctx, time.Now().UTC())
+		if err != nil {
+			s.zapLogger.WarnMsg("WebhookUseCase.DispatchDue", err)
+			continue
+		}
+		if delivered > 0 {
+			s.zapLogger.Infof("delivered %d webhook events", delivered)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+)
+
+const (
+	// SignatureHeader hex hmac-sha256 of "<timestamp>.<body>" keyed with the secret of the webhook, prefixed with sha256=
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader unix time of the attempt, part of the signed content so a captured request can not be replayed later
+	TimestampHeader = "X-Webhook-Timestamp"
+	// EventIDHeader id of the event, the same on every attempt so the endpoints can skip the duplicates
+	EventIDHeader = "X-Webhook-Event-Id"
+	// EventTypeHeader type of the event
+	EventTypeHeader = "X-Webhook-Event"
+
+	// maxErrorBodySize part of the response body of a failed attempt kept in its error
+	maxErrorBodySize = 256
+)
+
+type httpWebhookRepository struct {
+	zapLogger zaplogger.Logger
+	client    *http.Client
+	userAgent string
+}
+
+// NewHttpWebhookRepository the redirects are not followed, they fail the attempt like any non 2xx response.
+// The address dialed is checked after the resolution so a host resolving to an internal address since
+// the webhook was validated is not reached, no proxy is used as it would be the address dialed
+func NewHttpWebhookRepository(timeout time.Duration, userAgent string, zapLogger zaplogger.Logger) domain.HttpWebhookRepository {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   controlWebhookAddress,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &httpWebhookRepository{
+		zapLogger: zapLogger,
+		userAgent: userAgent,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (h httpWebhookRepository) Send(ctx context.Context, delivery domain.WebhookDelivery) domain.WebhookAttemptResult {
+	start := time.Now()
+	statusCode, err := h.post(ctx, delivery, start)
+	return domain.WebhookAttemptResult{StatusCode: statusCode, Err: err, Duration: time.Since(start)}
+}
+
+func (h httpWebhookRepository) post(ctx context.Context, delivery domain.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.Wrap(err, "http.NewRequest")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", h.userAgent)
+	req.Header.Set(EventIDHeader, delivery.EventID)
+	req.Header.Set(EventTypeHeader, delivery.EventType)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(delivery.Webhook.Secret, timestamp, body))
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close() // nolint: errcheck
+
+	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+		// the body is drained so the connection is reused
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, 64<<10))
+		return res.StatusCode, nil
+	}
+
+	excerpt, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	if excerpt = bytes.TrimSpace(excerpt); len(excerpt) > 0 {
+		return res.StatusCode, fmt.Errorf("unexpected status %d: %s", res.StatusCode, excerpt)
+	}
+	return res.StatusCode, fmt.Errorf("unexpected status %d", res.StatusCode)
+}
+
+// controlWebhookAddress refuse the connections to the internal addresses, address is the resolved ip and port
+func controlWebhookAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.Wrap(domain.ErrWebhookAddressForbidden, err.Error())
+	}
+	if !domain.WebhookAddressAllowed(net.ParseIP(host)) {
+		return errors.Wrapf(domain.ErrWebhookAddressForbidden, "%s %s", network, host)
+	}
+	return nil
+}
+
+// Sign hex hmac-sha256 of "<timestamp>.<body>" keyed with secret
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type pgWebhookRepository struct {
+	zapLogger zaplogger.Logger
+	db        *gorm.DB
+}
+
+func NewPgWebhookRepository(db *gorm.DB, zapLogger zaplogger.Logger) domain.PgWebhookRepository {
+	return &pgWebhookRepository{
+		db:        db,
+		zapLogger: zapLogger,
+	}
+}
+
+func (c pgWebhookRepository) DB() *gorm.DB {
+	return c.db
+}
+
+// Store the webhook belongs to the tenant of ctx
+func (c pgWebhookRepository) Store(ctx context.Context, data domain.Webhook) (domain.Webhook, error) {
+	data.TenantID = tenant.FromContext(ctx)
+	if err := c.db.WithContext(ctx).Create(&data).Error; err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+func (c pgWebhookRepository) GetById(ctx context.Context, id int) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	if err := c.db.WithContext(ctx).Scopes(domain.TenantScope(ctx)).First(&webhook, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (c pgWebhookRepository) Update(ctx context.Context, data domain.Webhook) error {
+	result := c.db.WithContext(ctx).Model(&domain.Webhook{}).Scopes(domain.TenantScope(ctx)).
+		Where("id = ?", data.ID).
+		Updates(map[string]interface{}{
+			"url":                  data.URL,
+			"events":               data.Events,
+			"secret":               data.Secret,
+			"active":               data.Active,
+			"consecutive_failures": data.ConsecutiveFailures,
+			"disabled_reason":      data.DisabledReason,
+			"disabled_at":          data.DisabledAt,
+			"updated_at":           data.UpdatedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (c pgWebhookRepository) List(ctx context.Context) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	if err := c.db.WithContext(ctx).Scopes(domain.TenantScope(ctx)).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (c pgWebhookRepository) Delete(ctx context.Context, id int) error {
+	result := c.db.WithContext(ctx).Scopes(domain.TenantScope(ctx)).Where("id = ?", id).Delete(&domain.Webhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (c pgWebhookRepository) ListActive(ctx context.Context) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	if err := c.db.WithContext(ctx).Scopes(domain.TenantScope(ctx)).Where("active").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (c pgWebhookRepository) StoreDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return c.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "webhook_id"}, {Name: "event_id"}}, DoNothing: true}).
+		Omit("Webhook", "AttemptLog").
+		Create(&deliveries).Error
+}
+
+func (c pgWebhookRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []int
+		// the deliveries locked by another instance are skipped instead of waited for
+		err := tx.Model(&domain.WebhookDelivery{}).
+			Joins("JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id AND webhooks.active").
+			Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", domain.WebhookDeliveryPending, now).
+			Order("webhook_deliveries.next_attempt_at").
+			Limit(limit).
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "webhook_deliveries"}, Options: "SKIP LOCKED"}).
+			Pluck("webhook_deliveries.id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if err := tx.Model(&domain.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error; err != nil {
+			return err
+		}
+		return tx.Preload("Webhook").Where("id IN ?", ids).Order("next_attempt_at, id").Find(&deliveries).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (c pgWebhookRepository) SaveAttempt(ctx context.Context, delivery domain.WebhookDelivery, attempt domain.WebhookDeliveryAttempt, disableAfter int) error {
+	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&domain.WebhookDelivery{}).Where("id = ?", delivery.ID).
+			Updates(map[string]interface{}{
+				"status":          delivery.Status,
+				"attempts":        delivery.Attempts,
+				"next_attempt_at": delivery.NextAttemptAt,
+				"delivered_at":    delivery.DeliveredAt,
+				"updated_at":      attempt.CreatedAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		webhook := tx.Model(&domain.Webhook{}).Where("id = ?", delivery.WebhookID)
+		if attempt.Error == "" {
+			return webhook.Update("consecutive_failures", 0).Error
+		}
+		if err := webhook.Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error; err != nil {
+			return err
+		}
+		if disableAfter <= 0 {
+			return nil
+		}
+		return tx.Model(&domain.Webhook{}).
+			Where("id = ? AND active AND consecutive_failures >= ?", delivery.WebhookID, disableAfter).
+			Updates(map[string]interface{}{
+				"active":          false,
+				"disabled_at":     attempt.CreatedAt,
+				"disabled_reason": fmt.Sprintf("%d failed deliveries in a row, last: %s", disableAfter, attempt.Error),
+				"updated_at":      attempt.CreatedAt,
+			}).Error
+	})
+}
+
+func (c pgWebhookRepository) ListDeliveries(ctx context.Context, webhookID int, status string, offset int, limit int) ([]domain.WebhookDelivery, int64, error) {
+	query := c.db.WithContext(ctx).Model(&domain.WebhookDelivery{}).Scopes(domain.TenantScope(ctx)).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []domain.WebhookDelivery
+	err := query.Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attempt")
+	}).Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
@@ -0,0 +1,373 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/tenant"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/utils"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/validator"
+	"github.com/radyatamaa/go-cqrs-microservices/pkg/zaplogger"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/config"
+	"github.com/radyatamaa/go-cqrs-microservices/write_service/internal/domain"
+)
+
+// secretSize bytes of the generated secrets
+const secretSize = 32
+
+type webhookUseCase struct {
+	zapLogger             zaplogger.Logger
+	contextTimeout        time.Duration
+	cfg                   config.Webhook
+	pgWebhookRepository   domain.PgWebhookRepository
+	httpWebhookRepository domain.HttpWebhookRepository
+}
+
+func NewWebhookUseCase(timeout time.Duration,
+	cfg config.Webhook,
+	pgWebhookRepository domain.PgWebhookRepository,
+	httpWebhookRepository domain.HttpWebhookRepository,
+	zapLogger zaplogger.Logger) domain.WebhookUseCase {
+	return &webhookUseCase{
+		contextTimeout:        timeout,
+		cfg:                   cfg,
+		zapLogger:             zapLogger,
+		pgWebhookRepository:   pgWebhookRepository,
+		httpWebhookRepository: httpWebhookRepository,
+	}
+}
+
+func (a webhookUseCase) CreateWebhook(c context.Context, command domain.CreateWebhookCommand) (*domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if err := validateWebhook(ctx, command.URL, command.Events, command); err != nil {
+		return nil, err
+	}
+
+	secret := command.Secret
+	if secret == "" {
+		generated, err := newSecret()
+		if err != nil {
+			a.zapLogger.SetMessageLog(err)
+			return nil, err
+		}
+		secret = generated
+	}
+
+	now := time.Now().UTC()
+	webhook, err := a.pgWebhookRepository.Store(ctx, domain.Webhook{
+		URL:       command.URL,
+		Events:    strings.Join(command.Events, ","),
+		Secret:    secret,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// UpdateWebhook enabling a disabled webhook clears its failures, its pending deliveries are attempted again
+func (a webhookUseCase) UpdateWebhook(c context.Context, command domain.UpdateWebhookCommand) (*domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if err := validateWebhook(ctx, command.URL, command.Events, command); err != nil {
+		return nil, err
+	}
+
+	webhook, err := a.pgWebhookRepository.GetById(ctx, command.ID)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	webhook.URL = command.URL
+	webhook.Events = strings.Join(command.Events, ",")
+	if command.Secret != "" {
+		webhook.Secret = command.Secret
+	}
+	if command.Active && !webhook.Active {
+		webhook.ConsecutiveFailures = 0
+		webhook.DisabledReason = ""
+		webhook.DisabledAt = nil
+	}
+	if !command.Active && webhook.Active {
+		now := time.Now().UTC()
+		webhook.DisabledReason = "disabled by the tenant"
+		webhook.DisabledAt = &now
+	}
+	webhook.Active = command.Active
+	webhook.UpdatedAt = time.Now().UTC()
+
+	if err := a.pgWebhookRepository.Update(ctx, *webhook); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (a webhookUseCase) GetWebhook(c context.Context, id int) (*domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	webhook, err := a.pgWebhookRepository.GetById(ctx, id)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (a webhookUseCase) ListWebhooks(c context.Context) ([]domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	webhooks, err := a.pgWebhookRepository.List(ctx)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (a webhookUseCase) DeleteWebhook(c context.Context, id int) (*domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	webhook, err := a.pgWebhookRepository.GetById(ctx, id)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	if err := a.pgWebhookRepository.Delete(ctx, id); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (a webhookUseCase) ListDeliveries(c context.Context, webhookID int, status string, pq *utils.Pagination) (*domain.WebhookDeliveryList, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	// the deliveries of the webhooks of another tenant are not found
+	if _, err := a.pgWebhookRepository.GetById(ctx, webhookID); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	deliveries, total, err := a.pgWebhookRepository.ListDeliveries(ctx, webhookID, status, pq.GetOffset(), pq.GetLimit())
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return nil, err
+	}
+
+	return &domain.WebhookDeliveryList{TotalCount: total, Pagination: pq, Deliveries: deliveries}, nil
+}
+
+func (a webhookUseCase) EnqueueEvent(c context.Context, event domain.WebhookEvent) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	webhooks, err := a.pgWebhookRepository.ListActive(ctx)
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+
+	now := time.Now().UTC()
+	deliveries := make([]domain.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			TenantID:      webhook.TenantID,
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        domain.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+
+	if err := a.pgWebhookRepository.StoreDeliveries(ctx, deliveries); err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return err
+	}
+
+	return nil
+}
+
+// DispatchDue the deliveries are attempted cfg.Concurrency at a time, a failed attempt is retried after an exponential
+// backoff until cfg.MaxAttempts
+func (a webhookUseCase) DispatchDue(c context.Context, now time.Time) (int, error) {
+	requestTimeout := time.Duration(a.cfg.RequestTimeoutSeconds) * time.Second
+	concurrency := a.cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	// the claimed deliveries are not due again before every one of them had the time to be attempted
+	lease := requestTimeout * time.Duration((a.cfg.BatchSize+concurrency-1)/concurrency+1)
+
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	deliveries, err := a.pgWebhookRepository.ClaimDue(ctx, now, lease, a.cfg.BatchSize)
+	cancel()
+	if err != nil {
+		a.zapLogger.SetMessageLog(err)
+		return 0, err
+	}
+
+	var (
+		mu        sync.Mutex
+		delivered int
+		wg        sync.WaitGroup
+		slots     = make(chan struct{}, concurrency)
+	)
+	for _, delivery := range deliveries {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(delivery domain.WebhookDelivery) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			ok, err := a.attempt(tenant.WithID(c, delivery.TenantID), delivery, requestTimeout)
+			if err != nil {
+				a.zapLogger.Warnf("save attempt of webhook delivery %d of tenant %s: %v", delivery.ID, delivery.TenantID, err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				delivered++
+				mu.Unlock()
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	return delivered, nil
+}
+
+// attempt send delivery and save the outcome, reports whether the endpoint accepted it
+func (a webhookUseCase) attempt(c context.Context, delivery domain.WebhookDelivery, requestTimeout time.Duration) (bool, error) {
+	sendCtx, cancel := context.WithTimeout(c, requestTimeout)
+	result := a.httpWebhookRepository.Send(sendCtx, delivery)
+	cancel()
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	attempt := domain.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		StatusCode: result.StatusCode,
+		DurationMs: result.Duration.Milliseconds(),
+		CreatedAt:  now,
+	}
+
+	switch {
+	case result.Err == nil:
+		delivery.Status = domain.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= a.cfg.MaxAttempts:
+		attempt.Error = result.Err.Error()
+		delivery.Status = domain.WebhookDeliveryFailed
+	default:
+		attempt.Error = result.Err.Error()
+		delivery.NextAttemptAt = now.Add(a.backoff(delivery.Attempts))
+	}
+
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+	if err := a.pgWebhookRepository.SaveAttempt(ctx, delivery, attempt, a.cfg.DisableAfterFailures); err != nil {
+		return false, err
+	}
+	return result.Err == nil, nil
+}
+
+// backoff delay following the attempts-th failed attempt, doubled after every attempt up to cfg.BackoffMaxSeconds
+func (a webhookUseCase) backoff(attempts int) time.Duration {
+	delay := time.Duration(a.cfg.BackoffBaseSeconds) * time.Second
+	max := time.Duration(a.cfg.BackoffMaxSeconds) * time.Second
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// validateWebhook the url is an absolute http(s) url whose host resolves to public addresses only
+// and the events are article events. The address is checked again on every attempt, the host may resolve differently then
+func validateWebhook(ctx context.Context, rawURL string, events []string, command interface{}) error {
+	if err := validator.Validate.ValidateStruct(command); err != nil {
+		return errors.Wrap(domain.ErrWebhookInvalid, err.Error())
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Wrap(domain.ErrWebhookInvalid, "url must be an absolute http or https url")
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return errors.Wrapf(domain.ErrWebhookInvalid, "host %s can not be resolved", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !domain.WebhookAddressAllowed(addr.IP) {
+			return errors.Wrapf(domain.ErrWebhookInvalid, "host %s resolves to the internal address %s", u.Hostname(), addr.IP)
+		}
+	}
+
+	for _, event := range events {
+		if !isWebhookEventType(event) {
+			return errors.Wrapf(domain.ErrWebhookInvalid, "event %s is not one of %s", event, strings.Join(domain.WebhookEventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, known := range domain.WebhookEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "rand.Read")
+	}
+	return hex.EncodeToString(b), nil
+}
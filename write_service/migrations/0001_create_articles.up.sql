@@ -0,0 +1,12 @@
+-- databases created by AutoMigrate already hold this table
+CREATE TABLE IF NOT EXISTS articles (
+    id bigserial PRIMARY KEY,
+    author text,
+    title text,
+    body text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_articles_deleted_at ON articles (deleted_at);
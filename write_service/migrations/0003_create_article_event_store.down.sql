@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS article_snapshots;
+DROP TABLE IF EXISTS article_events;
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS article_events (
+    id bigserial PRIMARY KEY,
+    aggregate_id bigint NOT NULL,
+    aggregate_version bigint NOT NULL,
+    event_type text NOT NULL,
+    event_version bigint NOT NULL,
+    payload jsonb NOT NULL,
+    occurred_at timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_article_events_aggregate_version ON article_events (aggregate_id, aggregate_version);
+
+CREATE TABLE IF NOT EXISTS article_snapshots (
+    aggregate_id bigint NOT NULL,
+    version bigint NOT NULL,
+    schema_version bigint NOT NULL,
+    state jsonb NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (aggregate_id, version)
+);
+
+-- backfill the history of the articles written before the event store existed
+INSERT INTO article_events (aggregate_id, aggregate_version, event_type, event_version, payload, occurred_at)
+SELECT a.id, 1, 'article.created', 2,
+       jsonb_build_object(
+           'id', a.id,
+           'version', 1,
+           'author', COALESCE(a.author, ''),
+           'title', COALESCE(a.title, ''),
+           'body', COALESCE(a.body, ''),
+           'created_at', COALESCE(a.created_at, now()),
+           'updated_at', COALESCE(a.updated_at, a.created_at, now())
+       ),
+       COALESCE(a.created_at, now())
+FROM articles a
+WHERE NOT EXISTS (SELECT 1 FROM article_events e WHERE e.aggregate_id = a.id);
@@ -0,0 +1,9 @@
+-- articles written before the workflow existed were public, new articles start as drafts
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'published';
+ALTER TABLE articles ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS publish_at timestamptz;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS published_at timestamptz;
+
+UPDATE articles SET published_at = created_at WHERE status = 'published' AND published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_articles_publish_at ON articles (publish_at) WHERE publish_at IS NOT NULL;
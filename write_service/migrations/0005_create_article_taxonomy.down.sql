@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_articles_category_id;
+ALTER TABLE articles DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS article_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,31 @@
+-- categories form a tree, path is the slash separated names from the root, e.g. news/tech
+CREATE TABLE IF NOT EXISTS categories (
+    id bigserial PRIMARY KEY,
+    parent_id bigint REFERENCES categories (id),
+    name text NOT NULL,
+    path text NOT NULL,
+    created_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_path ON categories (path);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    created_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+
+CREATE TABLE IF NOT EXISTS article_tags (
+    article_id bigint NOT NULL REFERENCES articles (id),
+    tag_id bigint NOT NULL REFERENCES tags (id),
+    PRIMARY KEY (article_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_tags_tag_id ON article_tags (tag_id);
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS category_id bigint REFERENCES categories (id);
+
+CREATE INDEX IF NOT EXISTS idx_articles_category_id ON articles (category_id);
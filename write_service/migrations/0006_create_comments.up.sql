@@ -0,0 +1,13 @@
+-- deleted comments are kept with an empty body, only the visible ones are listed
+CREATE TABLE IF NOT EXISTS comments (
+    id bigserial PRIMARY KEY,
+    article_id bigint NOT NULL REFERENCES articles (id),
+    version bigint NOT NULL DEFAULT 1,
+    status text NOT NULL DEFAULT 'visible',
+    author text NOT NULL,
+    body text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_article_id ON comments (article_id);
@@ -0,0 +1,16 @@
+-- fails when two tenants share a tag name or a category path
+DROP INDEX IF EXISTS idx_tags_tenant_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+DROP INDEX IF EXISTS idx_categories_tenant_path;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_path ON categories (path);
+
+DROP INDEX IF EXISTS idx_comments_tenant_id;
+DROP INDEX IF EXISTS idx_article_events_tenant_aggregate;
+DROP INDEX IF EXISTS idx_articles_tenant_id;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE tags DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE article_snapshots DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE article_events DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE articles DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,17 @@
+-- every row belongs to a tenant, the rows written before the multi tenancy belong to the default one
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS tenant_id text NOT NULL DEFAULT 'default';
+ALTER TABLE article_events ADD COLUMN IF NOT EXISTS tenant_id text NOT NULL DEFAULT 'default';
+ALTER TABLE article_snapshots ADD COLUMN IF NOT EXISTS tenant_id text NOT NULL DEFAULT 'default';
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tenant_id text NOT NULL DEFAULT 'default';
+ALTER TABLE tags ADD COLUMN IF NOT EXISTS tenant_id text NOT NULL DEFAULT 'default';
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS tenant_id text NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_articles_tenant_id ON articles (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_article_events_tenant_aggregate ON article_events (tenant_id, aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_comments_tenant_id ON comments (tenant_id);
+
+-- the names and paths are unique within a tenant
+DROP INDEX IF EXISTS idx_categories_path;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_tenant_path ON categories (tenant_id, path);
+DROP INDEX IF EXISTS idx_tags_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_tenant_name ON tags (tenant_id, name);
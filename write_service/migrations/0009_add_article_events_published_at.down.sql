@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_article_events_unpublished;
+ALTER TABLE article_events DROP COLUMN IF EXISTS published_at;
@@ -0,0 +1,5 @@
+-- the event store doubles as the outbox, the events written before it existed were already published
+ALTER TABLE article_events ADD COLUMN IF NOT EXISTS published_at timestamptz;
+UPDATE article_events SET published_at = occurred_at WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_article_events_unpublished ON article_events (id) WHERE published_at IS NULL;
@@ -0,0 +1,4 @@
+-- a title is unique per author within a tenant among the live articles, the rule of the commands
+-- cannot see a concurrent command writing the same title, the index rejects the second one.
+-- Duplicates written before have to be renamed or deleted for the index to be created
+CREATE UNIQUE INDEX IF NOT EXISTS idx_articles_tenant_author_title ON articles (tenant_id, author, title) WHERE deleted_at IS NULL;
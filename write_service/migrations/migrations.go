@@ -0,0 +1,11 @@
+package migrations
+
+import "embed"
+
+// Dir path of the migration files, relative to the repository root like the config path
+const Dir = "./write_service/migrations"
+
+// FS sql migrations of the writer database
+//
+//go:embed *.sql
+var FS embed.FS